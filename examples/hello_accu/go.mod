@@ -2,7 +2,12 @@ module hello_accu
 
 go 1.25.0
 
-require gitlab.com/accumulatenetwork/accumulate v1.4.2
+replace github.com/opendlt/accu-did/sdks/go/accdid => ../../sdks/go/accdid
+
+require (
+	github.com/opendlt/accu-did/sdks/go/accdid v0.0.0-00010101000000-000000000000
+	gitlab.com/accumulatenetwork/accumulate v1.4.2
+)
 
 require (
 	github.com/AccumulateNetwork/jsonrpc2/v15 v15.0.0-20220517212445-953ad957e040 // indirect
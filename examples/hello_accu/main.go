@@ -12,6 +12,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/opendlt/accu-did/sdks/go/accdid"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3/jsonrpc"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/build"
@@ -133,23 +134,11 @@ func main() {
 	fmt.Printf("\n5c. Writing DID document to: %s\n", dataAccountURL)
 
 	// Create minimal DID document
-	didDocument := map[string]interface{}{
-		"@context": []string{
-			"https://www.w3.org/ns/did/v1",
-			"https://w3id.org/security/suites/ed25519-2020/v1",
-		},
-		"id": fmt.Sprintf("did:acc:%s", adiLabel),
-		"verificationMethod": []map[string]interface{}{
-			{
-				"id":           fmt.Sprintf("did:acc:%s#key1", adiLabel),
-				"type":         "Ed25519VerificationKey2020",
-				"controller":   fmt.Sprintf("did:acc:%s", adiLabel),
-				"publicKeyMultibase": fmt.Sprintf("z%x", publicKey), // Simplified multibase encoding
-			},
-		},
-		"authentication": []string{
-			fmt.Sprintf("did:acc:%s#key1", adiLabel),
-		},
+	didDocument, err := accdid.NewDocument(fmt.Sprintf("did:acc:%s", adiLabel)).
+		AddEd25519Key("key1", publicKey, accdid.Authentication).
+		Build()
+	if err != nil {
+		log.Fatalf("Failed to build DID document: %v", err)
 	}
 
 	didDocData, err := json.Marshal(didDocument)
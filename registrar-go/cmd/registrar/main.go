@@ -3,100 +3,255 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
 	"github.com/opendlt/accu-did/registrar-go/handlers"
 	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/registrar-go/internal/config"
+	"github.com/opendlt/accu-did/registrar-go/internal/didauth"
+	"github.com/opendlt/accu-did/registrar-go/internal/idempotency"
 	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/registrar-go/internal/readiness"
 	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/registrar-go/internal/telemetry"
+	"github.com/opendlt/accu-did/shared/health"
+	"github.com/opendlt/accu-did/shared/ledger"
+	"github.com/opendlt/accu-did/shared/logging"
+	"github.com/opendlt/accu-did/shared/nodepool"
+	"github.com/opendlt/accu-did/shared/tlsutil"
 )
 
 func main() {
-	// Parse command line flags
+	// Parse command line flags. Flags that are set explicitly override the
+	// config file and environment.
 	var (
-		addr        = flag.String("addr", ":8081", "listen address")
-		bind        = flag.String("bind", "127.0.0.1", "bind address (security: 127.0.0.1 for localhost only)")
-		real        = flag.Bool("real", false, "enable real mode (connect to Accumulate network)")
-		authAPIKey  = flag.String("auth-api-key", "", "API key for authentication (env: REGISTRAR_API_KEY)")
-		allowlist   = flag.String("allowlist", "", "comma-separated CIDR/IP allowlist (env: REGISTRAR_ALLOWLIST)")
-		rateRPS     = flag.Int("rate-rps", 50, "rate limit requests per second")
-		rateBurst   = flag.Int("rate-burst", 100, "rate limit burst capacity")
+		configPath     = flag.String("config", os.Getenv("REGISTRAR_CONFIG"), "path to a YAML or TOML config file (env: REGISTRAR_CONFIG)")
+		addr           = flag.String("addr", ":8081", "listen address")
+		bind           = flag.String("bind", "127.0.0.1", "bind address (security: 127.0.0.1 for localhost only)")
+		real           = flag.Bool("real", false, "enable real mode (connect to Accumulate network)")
+		tlsCert        = flag.String("tls-cert", "", "TLS certificate file; enables HTTPS (env: REGISTRAR_TLS_CERT_FILE)")
+		tlsKey         = flag.String("tls-key", "", "TLS private key file (env: REGISTRAR_TLS_KEY_FILE)")
+		tlsClientCA    = flag.String("tls-client-ca", "", "PEM bundle of CAs for client certificates (mTLS)")
+		tlsClientAuth  = flag.String("tls-client-auth", "", "client certificates: none, optional or require (default require with a CA bundle)")
+		authAPIKey     = flag.String("auth-api-key", "", "API key for authentication (env: REGISTRAR_API_KEY)")
+		allowlist      = flag.String("allowlist", "", "comma-separated CIDR/IP allowlist (env: REGISTRAR_ALLOWLIST)")
+		trustedProxies = flag.String("trusted-proxies", "", "comma-separated CIDR/IP list of proxies whose forwarding headers are honored (env: REGISTRAR_TRUSTED_PROXIES)")
+		rateRPS        = flag.Int("rate-rps", 50, "rate limit requests per second")
+		rateBurst      = flag.Int("rate-burst", 100, "rate limit burst capacity")
+		otlpEndpoint   = flag.String("otlp-endpoint", "", "OTLP/HTTP trace collector endpoint, host:port (empty=tracing disabled)")
+		otlpInsecure   = flag.Bool("otlp-insecure", false, "use plain HTTP for the OTLP trace collector")
+		traceSample    = flag.Float64("trace-sample-ratio", 1.0, "fraction of new traces to sample (0.0-1.0)")
+		metricsEnabled = flag.Bool("metrics", true, "expose Prometheus metrics on /metrics")
+		logLevel       = flag.String("log-level", "info", "log level: debug, info, warn or error (env: LOG_LEVEL)")
+		logFormat      = flag.String("log-format", "json", "log format: json or text")
+		logRedactDIDs  = flag.Bool("log-redact-dids", false, "replace DIDs in logs with a stable hash")
+		logRedactIPs   = flag.Bool("log-redact-ips", false, "truncate client IPs in logs to their network prefix")
 	)
 	flag.Parse()
 
-	// Override from environment variables
-	if envAPIKey := os.Getenv("REGISTRAR_API_KEY"); envAPIKey != "" {
-		*authAPIKey = envAPIKey
-	}
-	if envAllowlist := os.Getenv("REGISTRAR_ALLOWLIST"); envAllowlist != "" {
-		*allowlist = envAllowlist
+	applyFlags := func(cfg *config.Config) {
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "addr":
+				cfg.Server.Addr = *addr
+			case "bind":
+				cfg.Server.Bind = *bind
+			case "real":
+				cfg.Accumulate.Real = *real
+			case "tls-cert":
+				cfg.TLS.CertFile = *tlsCert
+			case "tls-key":
+				cfg.TLS.KeyFile = *tlsKey
+			case "tls-client-ca":
+				cfg.TLS.ClientCAFile = *tlsClientCA
+			case "tls-client-auth":
+				cfg.TLS.ClientAuth = *tlsClientAuth
+			case "auth-api-key":
+				cfg.Security.APIKey = *authAPIKey
+			case "allowlist":
+				cfg.Security.AllowList = config.SplitList(*allowlist)
+			case "trusted-proxies":
+				cfg.Security.TrustedProxies = config.SplitList(*trustedProxies)
+			case "rate-rps":
+				cfg.Security.RateLimit.RPS = *rateRPS
+			case "rate-burst":
+				cfg.Security.RateLimit.Burst = *rateBurst
+			case "otlp-endpoint":
+				cfg.Telemetry.OTLPEndpoint = *otlpEndpoint
+			case "otlp-insecure":
+				cfg.Telemetry.OTLPInsecure = *otlpInsecure
+			case "trace-sample-ratio":
+				cfg.Telemetry.TraceSampleRatio = *traceSample
+			case "metrics":
+				cfg.Telemetry.Metrics = *metricsEnabled
+			case "log-level":
+				cfg.Logging.Level = *logLevel
+			case "log-format":
+				cfg.Logging.Format = *logFormat
+			case "log-redact-dids":
+				cfg.Logging.RedactDIDs = *logRedactDIDs
+			case "log-redact-ips":
+				cfg.Logging.RedactIPs = *logRedactIPs
+			}
+		})
 	}
 
-	// Get Accumulate node URL from environment if in real mode
-	var nodeURL string
-	if *real {
-		nodeURL = os.Getenv("ACC_NODE_URL")
-		if nodeURL == "" {
-			log.Fatal("ACC_NODE_URL environment variable is required when using --real mode")
+	// loadConfig layers defaults, config file, environment and flags
+	loadConfig := func() (*config.Config, error) {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return nil, err
+		}
+		applyFlags(cfg)
+		if err := cfg.Validate(); err != nil {
+			return nil, err
 		}
+		return cfg, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
 	}
 
+	// Setup structured logging. The level can be changed on reload.
+	logLevelVar := new(slog.LevelVar)
+	logger, err := logging.NewWithLevelVar(os.Stdout, logging.Config{
+		Level:      cfg.Logging.Level,
+		Format:     cfg.Logging.Format,
+		RedactDIDs: cfg.Logging.RedactDIDs,
+		RedactIPs:  cfg.Logging.RedactIPs,
+	}, logLevelVar)
+	if err != nil {
+		fatal("Invalid logging configuration", "error", err)
+	}
+	slog.SetDefault(logger)
+
 	// Determine mode for logging
 	mode := "FAKE"
-	if *real {
+	if cfg.Accumulate.Real {
 		mode = "REAL"
 	}
 
-	// Parse allowlist
-	var allowList []string
-	if *allowlist != "" {
-		allowList = strings.Split(*allowlist, ",")
-		for i, item := range allowList {
-			allowList[i] = strings.TrimSpace(item)
-		}
-	}
-
 	// Build full bind address
-	fullAddr := *bind + *addr
+	fullAddr := cfg.Server.ListenAddr()
 
-	// Security configuration logged above
-	_ = &security.SecurityConfig{
-		APIKey:    *authAPIKey,
-		AllowList: allowList,
-		RateRPS:   *rateRPS,
-		RateBurst: *rateBurst,
-		BindAddr:  fullAddr,
+	// Log startup configuration
+	logger.Info("Starting DID Registrar",
+		"mode", mode,
+		"config_file", *configPath,
+		"bind", fullAddr,
+		"tls", cfg.TLS.Enabled(),
+		"api_key", func() string {
+			if cfg.Security.APIKey != "" {
+				return "configured"
+			}
+			return "disabled"
+		}(),
+		"ip_allowlist", cfg.Security.AllowList,
+		"trusted_proxies", cfg.Security.TrustedProxies,
+		"rate_rps", cfg.Security.RateLimit.RPS,
+		"rate_burst", cfg.Security.RateLimit.Burst,
+		"did_auth", cfg.Security.DIDAuth.Enabled,
+		"accumulate_nodes", cfg.Accumulate.NodeURLs(),
+		"policy", cfg.Policy.Version,
+		"key_store", cfg.KeyStore.Type,
+		"tracing", func() string {
+			if cfg.Telemetry.OTLPEndpoint != "" {
+				return cfg.Telemetry.OTLPEndpoint
+			}
+			return "disabled"
+		}(),
+		"metrics", cfg.Telemetry.Metrics,
+		"log_level", cfg.Logging.Level,
+	)
+
+	// Setup tracing and metrics
+	telCfg := telemetry.DefaultConfig()
+	telCfg.OTLPEndpoint = cfg.Telemetry.OTLPEndpoint
+	telCfg.OTLPInsecure = cfg.Telemetry.OTLPInsecure
+	telCfg.SampleRatio = cfg.Telemetry.TraceSampleRatio
+	tel, err := telemetry.New(context.Background(), telCfg)
+	if err != nil {
+		fatal("Failed to initialize telemetry", "error", err)
 	}
 
-	// Log startup configuration
-	log.Printf("Starting DID Registrar")
-	log.Printf("  Mode: %s", mode)
-	log.Printf("  Bind: %s", fullAddr)
-	log.Printf("  API Key: %s", func() string {
-		if *authAPIKey != "" {
-			return "configured"
+	// Create Accumulate submitter. In REAL mode calls are spread over a pool
+	// of health-checked nodes; each transaction goes to exactly one node.
+	var baseSubmitter acc.Submitter
+	healthHandler := health.NewHealthHandler(nil)
+	checker := health.NewChecker(cfg.Readiness.Timeout.Std())
+	if cfg.Accumulate.Real {
+		pool, err := nodepool.New(cfg.Accumulate.NodeURLs(), nodepool.Config{
+			ProbeInterval:    cfg.Accumulate.Pool.ProbeInterval.Std(),
+			ProbeTimeout:     cfg.Accumulate.Pool.ProbeTimeout.Std(),
+			FailureThreshold: cfg.Accumulate.Pool.FailureThreshold,
+			Cooldown:         cfg.Accumulate.Pool.Cooldown.Std(),
+			Retries:          cfg.Accumulate.Pool.Retries,
+		}, func(nodeURL string) http.RoundTripper {
+			return tel.Transport(nodeURL, nil)
+		})
+		if err != nil {
+			fatal("Failed to create Accumulate node pool", "error", err)
+		}
+		pool.Start()
+		defer pool.Close()
+		healthHandler = health.NewHealthHandler(pool)
+		realSubmitter := acc.NewRealSubmitterWithPool(pool).
+			WithTimeouts(cfg.Accumulate.SubmitTimeout.Std(), cfg.Accumulate.QueryTimeout.Std())
+		if cfg.KeyStore.Type == "file" {
+			signer, err := acc.LoadKeyStoreFile(cfg.KeyStore.Path)
+			if err != nil {
+				fatal("Failed to load key store", "path", cfg.KeyStore.Path, "error", err)
+			}
+			realSubmitter = realSubmitter.WithSignerHook(signer)
+			checker.Add(readiness.KeyStoreCheck(cfg.KeyStore.Path))
 		}
-		return "disabled"
-	}())
-	log.Printf("  IP Allowlist: %v", allowList)
-	log.Printf("  Rate Limit: %d RPS, %d burst", *rateRPS, *rateBurst)
-	if *real && nodeURL != "" {
-		log.Printf("  Accumulate Node: %s", nodeURL)
+		// The background probes keep the pool status fresh; allow one
+		// missed round before /readyz probes itself
+		checker.Add(health.NodesCheck(pool, 2*cfg.Accumulate.Pool.ProbeInterval.Std()))
+		checker.Add(health.NetworkCheck(realSubmitter.NetworkID, cfg.Accumulate.Network))
+		if len(cfg.KeyStore.KeyPages) > 0 {
+			checker.Add(readiness.SignerKeysCheck(realSubmitter.Signer(), cfg.KeyStore.KeyPages))
+			if cfg.Readiness.MinCredits > 0 {
+				checker.Add(readiness.CreditsCheck(realSubmitter.GetKeyPageState, cfg.KeyStore.KeyPages, cfg.Readiness.MinCredits))
+			}
+		}
+		if cfg.Credits.LiteTokenAccount != "" {
+			realSubmitter = realSubmitter.WithCreditSource(cfg.Credits.LiteTokenAccount)
+		}
+		baseSubmitter = realSubmitter
+	} else {
+		fakeLedger, err := openFakeLedger(cfg.Accumulate.Fake)
+		if err != nil {
+			fatal("Failed to open fake ledger", "error", err)
+		}
+		defer fakeLedger.Close()
+		baseSubmitter = acc.NewFakeSubmitterWithLedger(fakeLedger)
+		logger.Info("Using fake ledger", "state_dir", cfg.Accumulate.Fake.StateDir, "height", fakeLedger.Height())
 	}
 
-	// Create Accumulate submitter
-	accSubmitter := acc.NewSubmitter(*real, nodeURL)
+	// Check the signer can pay before submitting, buying credits when
+	// top-ups are configured and waiting for them like any other write
+	if cfg.Credits.Check {
+		guard := acc.NewCreditGuard(baseSubmitter).
+			WithConfirmer(acc.NewConfirmer(baseSubmitter).WithTimeout(cfg.Accumulate.ConfirmTimeout.Std(), acc.DefaultConfirmInterval))
+		if purchaser, ok := baseSubmitter.(acc.CreditPurchaser); ok && cfg.Credits.TopUpAmount > 0 {
+			guard = guard.WithTopUp(purchaser, cfg.Credits.TopUpThreshold, cfg.Credits.TopUpAmount)
+		}
+		baseSubmitter = guard
+	}
+	accSubmitter := acc.NewInstrumentedSubmitter(baseSubmitter, tel.Metrics)
 
-	// Create authorization policy
+	// Create authorization policy (v1 is the only version; checked by Validate)
 	authPolicy := policy.NewPolicyV1()
 
 	// Setup router
@@ -104,53 +259,196 @@ func main() {
 
 	// Security middleware (applied to all routes)
 	r.Use(security.RequestIDMiddleware())
-	r.Use(security.IPAllowListMiddleware(allowList))
-	r.Use(security.RateLimitMiddleware(*rateRPS, *rateBurst))
-	r.Use(security.APIKeyMiddleware(*authAPIKey))
 
-	// Standard middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
+	// Tracing and HTTP metrics (ahead of the allowlist and rate limiter so
+	// rejections are counted too)
+	r.Use(tel.Middleware())
+
+	// Issued API keys; without a file they live until restart
+	apiKeys := security.NewAPIKeyStore()
+	if cfg.Security.APIKeysFile != "" {
+		apiKeys, err = security.LoadAPIKeyStore(cfg.Security.APIKeysFile)
+		if err != nil {
+			fatal("Failed to load API keys", "path", cfg.Security.APIKeysFile, "error", err)
+		}
+	}
+
+	// Reloadable security checks. Rate limits apply per API key, so the
+	// limiter runs after authentication.
+	proxies, err := security.NewTrustedProxies(cfg.Security.TrustedProxies)
+	if err != nil {
+		fatal("Invalid trusted proxies", "error", err)
+	}
+	ipAllowList, err := security.NewIPAllowList(cfg.Security.AllowList)
+	if err != nil {
+		fatal("Invalid IP allowlist", "error", err)
+	}
+	rateLimiter := security.NewRateLimiter(cfg.Security.RateLimit.RPS, cfg.Security.RateLimit.Burst).
+		WithMetrics(tel.HTTP)
+	apiKeyAuth := security.NewAPIKeyAuth(cfg.Security.APIKey).WithStore(apiKeys)
+	quotaLimiter := security.NewQuotaLimiter(cfg.Security.DailyQuota).WithMetrics(tel.Metrics)
+
+	// Liveness (cheap) and readiness (deep) checks and Prometheus metrics are
+	// polled by orchestrators and scrapers, so they sit outside the
+	// allowlist, authentication and rate limits
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Recoverer)
+		r.Get("/healthz", healthHandler.Healthz)
+		r.Get("/readyz", health.NewReadyHandler(checker).Readyz)
+		if cfg.Telemetry.Metrics {
+			r.Handle("/metrics", tel.MetricsHandler())
+		}
+	})
+
+	// Updates and deactivations signed by a key of the DID's current
+	// document or key page need no API key (DID-Auth)
+	var didAuth func(http.Handler) http.Handler
+	if cfg.Security.DIDAuth.Enabled {
+		verifier := didauth.NewVerifier(didauth.NewSubmitterResolver(accSubmitter)).
+			WithMaxSkew(cfg.Security.DIDAuth.MaxSkew.Std())
+		didAuth = verifier.Middleware()
+	}
+
+	// Every other route goes through the security checks and the standard
+	// middleware
+	api, signed := securedRouters(r.With(proxies.Middleware(), ipAllowList.Middleware()), didAuth,
+		apiKeyAuth.Middleware(),
+		rateLimiter.Middleware(),
+		logging.Middleware(logger),
+		middleware.Recoverer,
+		middleware.Timeout(cfg.Server.RequestTimeout.Std()),
+	)
+
+	// Writes wait up to the confirm timeout for delivery, then report "wait";
+	// clients poll /transaction for the outcome
+	confirmer := acc.NewConfirmer(accSubmitter).
+		WithTimeout(cfg.Accumulate.ConfirmTimeout.Std(), acc.DefaultConfirmInterval)
+	api.Get("/transaction", handlers.NewTransactionHandler(accSubmitter).Status)
 
-	// Health check
-	r.Get("/healthz", handlers.Healthz)
+	// API key administration
+	adminHandler := handlers.NewAdminHandler(apiKeys)
+	api.Route("/admin/keys", func(r chi.Router) {
+		r.Use(security.RequireAdmin())
+		r.Post("/", adminHandler.IssueKey)
+		r.Get("/", adminHandler.ListKeys)
+		r.Delete("/{id}", adminHandler.RevokeKey)
+	})
+
+	// Writes retried with the same Idempotency-Key get the stored response
+	// instead of submitting again; replays do not count against the quota
+	writeChecks := []func(http.Handler) http.Handler{quotaLimiter.Middleware()}
+	if cfg.Idempotency.TTL > 0 {
+		idempotencyStore := idempotency.NewStore(cfg.Idempotency.TTL.Std(), cfg.Idempotency.MaxEntries)
+		writeChecks = append([]func(http.Handler) http.Handler{idempotencyStore.Middleware()}, writeChecks...)
+	}
+	writes := api.With(writeChecks...)
+	signedWrites := signed.With(writeChecks...)
 
 	// Legacy DID registration endpoints (Universal Registrar v0.x compatibility)
-	createHandler := handlers.NewCreateHandler(accSubmitter, authPolicy)
-	updateHandler := handlers.NewUpdateHandler(accSubmitter, authPolicy)
-	deactivateHandler := handlers.NewDeactivateHandler(accSubmitter, authPolicy)
+	createHandler := handlers.NewCreateHandler(accSubmitter, authPolicy).WithConfirmer(confirmer)
+	updateHandler := handlers.NewUpdateHandler(accSubmitter, authPolicy).WithConfirmer(confirmer)
+	deactivateHandler := handlers.NewDeactivateHandler(accSubmitter, authPolicy).WithConfirmer(confirmer)
 
-	r.Post("/create", createHandler.Create)
-	r.Post("/update", updateHandler.Update)
-	r.Post("/deactivate", deactivateHandler.Deactivate)
+	writes.Post("/create", createHandler.Create)
+	signedWrites.Post("/update", updateHandler.Update)
+	signedWrites.Post("/deactivate", deactivateHandler.Deactivate)
 
 	// Native DID registration endpoints (clean internal API)
-	nativeHandler := handlers.NewNativeHandler(accSubmitter)
-	r.Post("/register", nativeHandler.Register)
-	r.Post("/native/update", nativeHandler.Update)
-	r.Post("/native/deactivate", nativeHandler.Deactivate)
+	nativeHandler := handlers.NewNativeHandler(accSubmitter).WithConfirmer(confirmer)
+	writes.Post("/register", nativeHandler.Register)
+	signedWrites.Post("/native/update", nativeHandler.Update)
+	signedWrites.Post("/native/deactivate", nativeHandler.Deactivate)
 
 	// Universal Registrar v1.0 compatibility endpoints
-	universalHandler := handlers.NewUniversalHandler(accSubmitter, authPolicy)
-	r.Post("/1.0/create", universalHandler.UniversalCreate)
-	r.Post("/1.0/update", universalHandler.UniversalUpdate)
-	r.Post("/1.0/deactivate", universalHandler.UniversalDeactivate)
+	universalHandler := handlers.NewUniversalHandler(accSubmitter, authPolicy).WithConfirmer(confirmer)
+	writes.Post("/1.0/create", universalHandler.UniversalCreate)
+	signedWrites.Post("/1.0/update", universalHandler.UniversalUpdate)
+	signedWrites.Post("/1.0/deactivate", universalHandler.UniversalDeactivate)
 
 	// Create server
 	srv := &http.Server{
 		Addr:         fullAddr,
 		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout.Std(),
+		WriteTimeout: cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Std(),
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	// Serve TLS from the configured files, re-read when they are rotated,
+	// verifying client certificates when a CA bundle is set
+	var tlsReloader *tlsutil.Reloader
+	if cfg.TLS.Enabled() {
+		tlsReloader, err = tlsutil.New(tlsutil.Options{
+			CertFile:     cfg.TLS.CertFile,
+			KeyFile:      cfg.TLS.KeyFile,
+			ClientCAFile: cfg.TLS.ClientCAFile,
+			ClientAuth:   cfg.TLS.ClientAuth,
+		})
+		if err != nil {
+			fatal("Failed to load TLS certificate", "error", err)
+		}
+		srv.TLSConfig = tlsReloader.TLSConfig()
 	}
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Server listening on %s", fullAddr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+		logger.Info("Server listening", "addr", fullAddr, "tls", cfg.TLS.Enabled(), "mtls", cfg.TLS.ClientCAFile != "")
+		var err error
+		if cfg.TLS.Enabled() {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed to start", "error", err)
+		}
+	}()
+
+	// Reload the configuration on SIGHUP. Only the log level, API key,
+	// allowlist, trusted proxies, rate limits and quota are applied live, and
+	// the API key file and TLS certificate are re-read; other changes are
+	// reported and need a restart.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			next, err := loadConfig()
+			if err != nil {
+				logger.Error("Config reload failed, keeping current configuration", "error", err)
+				continue
+			}
+			for _, setting := range cfg.RestartRequired(next) {
+				logger.Warn("Config change requires a restart to take effect", "setting", setting)
+			}
+
+			level, _ := logging.ParseLevel(next.Logging.Level)
+			logLevelVar.Set(level)
+			if tlsReloader != nil {
+				if err := tlsReloader.Reload(); err != nil {
+					logger.Error("TLS reload failed, keeping current certificate", "error", err)
+				}
+			}
+			apiKeyAuth.Update(next.Security.APIKey)
+			if err := proxies.Update(next.Security.TrustedProxies); err != nil {
+				logger.Error("Trusted proxy reload failed, keeping current list", "error", err)
+			}
+			if err := ipAllowList.Update(next.Security.AllowList); err != nil {
+				logger.Error("IP allowlist reload failed, keeping current list", "error", err)
+			}
+			rateLimiter.Update(next.Security.RateLimit.RPS, next.Security.RateLimit.Burst)
+			quotaLimiter.Update(next.Security.DailyQuota)
+			if err := apiKeys.Reload(); err != nil {
+				logger.Error("API key reload failed, keeping current keys", "error", err)
+			}
+			logger.Info("Configuration reloaded",
+				"log_level", next.Logging.Level,
+				"ip_allowlist", next.Security.AllowList,
+				"trusted_proxies", next.Security.TrustedProxies,
+				"rate_rps", next.Security.RateLimit.RPS,
+				"rate_burst", next.Security.RateLimit.Burst,
+				"daily_quota", next.Security.DailyQuota,
+			)
 		}
 	}()
 
@@ -159,15 +457,58 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
 
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", "error", err)
+	}
+
+	if err := tel.Shutdown(ctx); err != nil {
+		logger.Error("Telemetry shutdown error", "error", err)
+	}
+
+	logger.Info("Server exited")
+}
+
+// fatal logs at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// openFakeLedger opens the ledger simulated in FAKE mode: persistent when a
+// state directory is configured, in memory otherwise. A fixture is imported
+// only into an empty ledger, so restarts keep the state written since.
+func openFakeLedger(cfg config.FakeConfig) (*ledger.Ledger, error) {
+	l := ledger.New()
+	if cfg.StateDir != "" {
+		var err error
+		if l, err = ledger.Open(cfg.StateDir); err != nil {
+			return nil, err
+		}
 	}
+	if cfg.Fixture != "" && l.Height() == 0 {
+		if err := l.ImportFile(cfg.Fixture); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("failed to import fixture %s: %w", cfg.Fixture, err)
+		}
+	}
+	return l, nil
+}
 
-	log.Println("Server exited")
+// securedRouters applies checks to r and returns the router for routes that
+// need an API key, and the one for updates and deactivations, where a DID-Auth
+// signature stands in for the key when didAuth is set. DID-Auth runs on those
+// routes only: the principal it authenticates passes the API key check of any
+// route it reaches.
+func securedRouters(r chi.Router, didAuth func(http.Handler) http.Handler, checks ...func(http.Handler) http.Handler) (api, signed chi.Router) {
+	api = r.With(checks...)
+	if didAuth == nil {
+		return api, api
+	}
+	return api, r.With(didAuth).With(checks...)
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/registrar-go/internal/didauth"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+func TestSecuredRouters_DIDAuthOnSignedRoutesOnly(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	const did = "did:acc:alice.acme"
+	doc, err := json.Marshal(map[string]interface{}{
+		"id": did,
+		"verificationMethod": []interface{}{
+			map[string]interface{}{"id": "#key-1", "type": "Ed25519VerificationKey2018", "publicKeyHex": hex.EncodeToString(pub)},
+		},
+		"authentication": []interface{}{"#key-1"},
+	})
+	require.NoError(t, err)
+	fake := acc.NewFakeSubmitterWithLedger(ledger.New())
+	_, err = fake.WriteDataEntry(context.Background(), "acc://alice.acme/did", doc)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	verifier := didauth.NewVerifier(didauth.NewSubmitterResolver(fake))
+	api, signed := securedRouters(r, verifier.Middleware(), security.NewAPIKeyAuth("secret").Middleware())
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	api.Get("/transaction", ok)
+	api.Post("/create", ok)
+	signed.Post("/update", ok)
+
+	send := func(method, path string) int {
+		credential, err := didauth.Sign(priv, didauth.Header{Kid: did + "#key-1", Iat: time.Now().Unix(), Htm: method, Htu: path}, nil)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(nil))
+		req.Header.Set("Authorization", didauth.Scheme+" "+credential)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/update"))
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/transaction"), "other routes still need an API key")
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "/create"))
+}
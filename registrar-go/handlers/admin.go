@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/api"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
+)
+
+// AdminHandler lets operators issue, list and revoke API keys. Its routes
+// must sit behind security.RequireAdmin.
+type AdminHandler struct {
+	keys *security.APIKeyStore
+}
+
+// NewAdminHandler creates an API key admin handler
+func NewAdminHandler(keys *security.APIKeyStore) *AdminHandler {
+	return &AdminHandler{keys: keys}
+}
+
+// IssueKeyRequest is the scope and limits of a new API key. Empty ADIs and
+// DID prefixes allow every DID; empty operations allow every operation.
+type IssueKeyRequest struct {
+	Name           string              `json:"name"`
+	ADIs           []string            `json:"adis,omitempty"`
+	DIDPrefixes    []string            `json:"didPrefixes,omitempty"`
+	Operations     []string            `json:"operations,omitempty"`
+	Admin          bool                `json:"admin,omitempty"`
+	RateLimit      *security.RateLimit `json:"rateLimit,omitempty"`
+	DailyQuota     int                 `json:"dailyQuota,omitempty"`
+	ClientSubjects []string            `json:"clientSubjects,omitempty"` // TLS client certificate subjects
+}
+
+// IssueKeyResponse carries the secret of a new key. It is shown once.
+type IssueKeyResponse struct {
+	security.APIKey
+	Key string `json:"key"`
+}
+
+// KeyListResponse lists issued keys
+type KeyListResponse struct {
+	Keys []security.APIKey `json:"keys"`
+}
+
+// IssueKey handles POST /admin/keys requests
+func (h *AdminHandler) IssueKey(w http.ResponseWriter, r *http.Request) {
+	var req IssueKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		h.writeError(w, r, "invalidRequest", "name is required", http.StatusBadRequest)
+		return
+	}
+
+	key, secret, err := h.keys.Issue(security.APIKey{
+		Name:           req.Name,
+		ADIs:           req.ADIs,
+		DIDPrefixes:    req.DIDPrefixes,
+		Operations:     req.Operations,
+		Admin:          req.Admin,
+		RateLimit:      req.RateLimit,
+		DailyQuota:     req.DailyQuota,
+		ClientSubjects: req.ClientSubjects,
+	})
+	if err != nil {
+		if errors.Is(err, security.ErrInvalidKey) {
+			h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to issue API key", "error", err)
+		h.writeError(w, r, "internalError", "Failed to issue API key", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key issued", "key_id", key.ID, "name", key.Name, "issued_by", callerID(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(IssueKeyResponse{APIKey: publicKey(*key), Key: secret})
+}
+
+// ListKeys handles GET /admin/keys requests
+func (h *AdminHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys := h.keys.List()
+	for i := range keys {
+		keys[i] = publicKey(keys[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(KeyListResponse{Keys: keys})
+}
+
+// RevokeKey handles DELETE /admin/keys/{id} requests
+func (h *AdminHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	key, err := h.keys.Revoke(id)
+	if err != nil {
+		if errors.Is(err, security.ErrKeyNotFound) {
+			h.writeError(w, r, "notFound", err.Error(), http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to revoke API key", "key_id", id, "error", err)
+		h.writeError(w, r, "internalError", "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+	slog.InfoContext(r.Context(), "API key revoked", "key_id", id, "revoked_by", callerID(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(publicKey(*key))
+}
+
+// publicKey drops the secret hash from a key before it is returned
+func publicKey(key security.APIKey) security.APIKey {
+	key.Hash = ""
+	return key
+}
+
+// callerID returns the ID of the admin key making the request
+func callerID(r *http.Request) string {
+	if key := security.APIKeyFromContext(r.Context()); key != nil {
+		return key.ID
+	}
+	return ""
+}
+
+// writeError writes an error response
+func (h *AdminHandler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int) {
+	response := api.ErrorResponse{
+		Error:     errorCode,
+		Message:   message,
+		RequestID: logging.RequestIDFromContext(r.Context()),
+		Timestamp: time.Now().UTC(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
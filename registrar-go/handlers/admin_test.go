@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+)
+
+func TestAdminHandler_Keys(t *testing.T) {
+	store := security.NewAPIKeyStore()
+	handler := NewAdminHandler(store)
+	r := chi.NewRouter()
+	r.Post("/admin/keys", handler.IssueKey)
+	r.Get("/admin/keys", handler.ListKeys)
+	r.Delete("/admin/keys/{id}", handler.RevokeKey)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/keys",
+		strings.NewReader(`{"name":"acme","adis":["acme.acme"],"operations":["create","update"],"dailyQuota":100}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var issued IssueKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &issued))
+	assert.NotEmpty(t, issued.Key)
+	assert.Empty(t, issued.Hash, "the hash is not returned")
+	assert.Equal(t, 100, issued.DailyQuota)
+	key, ok := store.Lookup(issued.Key)
+	require.True(t, ok)
+	assert.Equal(t, issued.ID, key.ID)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/keys", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), key.Hash)
+	var list KeyListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Keys, 1)
+	assert.Equal(t, "acme", list.Keys[0].Name)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/keys/"+issued.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	_, ok = store.Lookup(issued.Key)
+	assert.False(t, ok, "a revoked key is rejected")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/keys/key_missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/admin/keys", strings.NewReader(`{"name":"bad","operations":["delete"]}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
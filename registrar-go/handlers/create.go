@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -10,12 +11,16 @@ import (
 	"github.com/opendlt/accu-did/registrar-go/internal/api"
 	"github.com/opendlt/accu-did/registrar-go/internal/ops"
 	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // CreateHandler handles DID creation requests
 type CreateHandler struct {
-	accClient  acc.Submitter
-	authPolicy policy.AuthPolicy
+	accClient   acc.Submitter
+	provisioner *acc.Provisioner
+	authPolicy  policy.AuthPolicy
+	confirmer   *acc.Confirmer
 }
 
 // CreateRequest represents a DID creation request
@@ -47,6 +52,16 @@ type DIDRegistrationMetadata struct {
 	VersionID   string `json:"versionId"`
 	ContentHash string `json:"contentHash"`
 	TxID        string `json:"txid"`
+
+	// Hashes of the signatures submitted with the transaction
+	SignatureHashes []string `json:"signatureHashes,omitempty"`
+
+	// Set by create: the accounts behind the DID and the transactions that
+	// created them, empty for accounts that already existed
+	ADI             string `json:"adi,omitempty"`
+	ADITxID         string `json:"adiTxId,omitempty"`
+	DataAccount     string `json:"dataAccount,omitempty"`
+	DataAccountTxID string `json:"dataAccountTxId,omitempty"`
 }
 
 // DIDDocumentMetadata represents document metadata
@@ -58,66 +73,143 @@ type DIDDocumentMetadata struct {
 // NewCreateHandler creates a new create handler
 func NewCreateHandler(accClient acc.Submitter, authPolicy policy.AuthPolicy) *CreateHandler {
 	return &CreateHandler{
-		accClient:  accClient,
-		authPolicy: authPolicy,
+		accClient:   accClient,
+		provisioner: acc.NewProvisioner(accClient),
+		authPolicy:  authPolicy,
+		confirmer:   acc.NewConfirmer(accClient),
 	}
 }
 
+// WithConfirmer replaces the confirmer that waits for delivery, of the
+// document and of the accounts provisioned before it
+func (h *CreateHandler) WithConfirmer(confirmer *acc.Confirmer) *CreateHandler {
+	h.confirmer = confirmer
+	h.provisioner.WithConfirmer(confirmer)
+	return h
+}
+
+// WithProvisioner replaces the provisioner, e.g. to change how long it waits
+// for new accounts
+func (h *CreateHandler) WithProvisioner(provisioner *acc.Provisioner) *CreateHandler {
+	h.provisioner = provisioner
+	return h
+}
+
 // Create handles POST /create requests
 func (h *CreateHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	var req CreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateCreateRequest(&req); err != nil {
-		h.writeError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The caller's API key must cover the DID and operation
+	if err := security.Authorize(r.Context(), security.OpCreate, req.DID); err != nil {
+		h.writeError(w, r, "forbidden", err.Error(), http.StatusForbidden, nil)
 		return
 	}
 
 	// Get required key page for authorization
 	requiredKeyPage, err := h.authPolicy.GetRequiredKeyPage(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
 	// Build envelope
 	envelope, err := ops.BuildEnvelope(req.DIDDocument, requiredKeyPage, "")
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to build envelope", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to build envelope", http.StatusInternalServerError, nil)
 		return
 	}
 
 	// Get data account URL using safe helper
 	dataAccountURL, err := policy.DIDToDataAccountURL(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// Create the ADI and data account if missing. Whatever a failed attempt
+	// created is skipped when the request is repeated. The accounts and the
+	// document share one confirmation deadline.
+	r = r.WithContext(h.confirmer.Begin(r.Context()))
+	provisioning, err := h.provisioner.Provision(r.Context(), dataAccountURL, requiredKeyPage)
+	if pending, ok := pendingProvisioning(r.Context(), err); ok {
+		slog.InfoContext(r.Context(), "Accounts pending", logging.DID(req.DID), "operation", "create", "error", err)
+		response := CreateResponse{
+			JobID: h.generateJobID(),
+			DIDState: DIDState{
+				DID:    req.DID,
+				State:  "wait",
+				Action: "create",
+				Reason: err.Error(),
+			},
+			DIDRegistrationMetadata: DIDRegistrationMetadata{
+				ADI:             pending.ADI,
+				ADITxID:         pending.ADITxID,
+				DataAccount:     pending.DataAccount,
+				DataAccountTxID: pending.DataAccountTxID,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to provision accounts", logging.DID(req.DID), "operation", "create", "error", err)
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
 		return
 	}
 
 	// Submit to Accumulate
-	txID, err := h.accClient.SubmitWriteData(dataAccountURL, envelope)
+	txID, err := h.accClient.SubmitWriteData(r.Context(), dataAccountURL, envelope)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to submit transaction", logging.DID(req.DID), "operation", "create", "error", err)
+		if details, ok := insufficientCreditsDetails(err); ok {
+			h.writeError(w, r, "insufficientCredits", err.Error(), http.StatusPaymentRequired, details)
+			return
+		}
+		h.writeError(w, r, "internalError", "Failed to submit transaction", http.StatusInternalServerError, nil)
+		return
+	}
+	slog.InfoContext(r.Context(), "DID document submitted", logging.DID(req.DID), "operation", "create", "tx_id", txID)
+
+	// Wait for delivery; a transaction still pending is reported as "wait"
+	tx, err := confirmWrite(r.Context(), h.confirmer, txID)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to submit transaction", http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
 		return
 	}
+	state := didStateOf(tx)
 
 	// Build response
 	response := CreateResponse{
 		JobID: h.generateJobID(),
 		DIDState: DIDState{
 			DID:    req.DID,
-			State:  "finished",
+			State:  state,
 			Action: "create",
 		},
 		DIDRegistrationMetadata: DIDRegistrationMetadata{
-			VersionID:   envelope.Meta.VersionID,
-			ContentHash: envelope.GetContentHash(),
-			TxID:        txID,
+			VersionID:       envelope.Meta.VersionID,
+			ContentHash:     envelope.GetContentHash(),
+			TxID:            txID,
+			SignatureHashes: tx.SignatureHashes,
+			ADI:             provisioning.ADI,
+			ADITxID:         provisioning.ADITxID,
+			DataAccount:     provisioning.DataAccount,
+			DataAccountTxID: provisioning.DataAccountTxID,
 		},
 		DIDDocumentMetadata: DIDDocumentMetadata{
 			Created:   envelope.Meta.Timestamp,
@@ -127,10 +219,10 @@ func (h *CreateHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Return response
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(state))
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.writeError(w, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
 		return
 	}
 }
@@ -172,11 +264,12 @@ func (h *CreateHandler) generateJobID() string {
 }
 
 // writeError writes an error response
-func (h *CreateHandler) writeError(w http.ResponseWriter, errorCode, message string, status int, details map[string]string) {
+func (h *CreateHandler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int, details map[string]string) {
 	response := api.ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		Details:   details,
+		RequestID: logging.RequestIDFromContext(r.Context()),
 		Timestamp: time.Now().UTC(),
 	}
 
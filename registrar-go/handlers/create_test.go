@@ -6,13 +6,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/opendlt/accu-did/registrar-go/internal/acc"
 	"github.com/opendlt/accu-did/registrar-go/internal/api"
+	"github.com/opendlt/accu-did/registrar-go/internal/idempotency"
 	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 func TestCreateHandler_Create(t *testing.T) {
@@ -136,4 +140,186 @@ func TestCreateHandler_Create(t *testing.T) {
 		assert.Equal(t, "invalidRequest", response.Error)
 		assert.Contains(t, response.Message, "Invalid JSON")
 	})
+
+	t.Run("error carries request ID", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/create", bytes.NewReader([]byte("invalid json")))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(logging.WithRequestID(req.Context(), "req_test"))
+		w := httptest.NewRecorder()
+
+		handler.Create(w, req)
+
+		var response api.ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "req_test", response.RequestID)
+	})
+}
+
+func TestCreateHandler_Provisioning(t *testing.T) {
+	accClient := acc.NewMockClient()
+	accClient.CreateDataAccountFn = func(adiURL, dataAccountLabel string) (string, error) {
+		return "", assert.AnError
+	}
+	handler := NewCreateHandler(accClient, policy.NewPolicyV1())
+
+	requestBody, err := json.Marshal(CreateRequest{
+		DID: "did:acc:dave",
+		DIDDocument: map[string]interface{}{
+			"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+			"id":       "did:acc:dave",
+		},
+	})
+	require.NoError(t, err)
+
+	// The data account cannot be created; the ADI is reported for the retry
+	w := httptest.NewRecorder()
+	handler.Create(w, httptest.NewRequest("POST", "/create", bytes.NewReader(requestBody)))
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var errResponse api.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Equal(t, "createDataAccount", errResponse.Details["step"])
+	assert.Equal(t, "txid-create-identity-mock", errResponse.Details["adiTxId"])
+	assert.Nil(t, accClient.LastEnvelope)
+
+	// The retry creates only the data account, then writes the document
+	accClient.CreateDataAccountFn = nil
+	w = httptest.NewRecorder()
+	handler.Create(w, httptest.NewRequest("POST", "/create", bytes.NewReader(requestBody)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response CreateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	metadata := response.DIDRegistrationMetadata
+	assert.Equal(t, "acc://dave", metadata.ADI)
+	assert.Empty(t, metadata.ADITxID)
+	assert.Equal(t, "acc://dave/data/did", metadata.DataAccount)
+	assert.Equal(t, "txid-create-data-account-mock", metadata.DataAccountTxID)
+	assert.Equal(t, "acc://dave/data/did", accClient.LastAccountURL)
+}
+
+func TestCreateHandler_ProvisioningPending(t *testing.T) {
+	accClient := acc.NewMockClient()
+	delivered := false
+	accClient.AccountExistsFn = func(accountURL string) (bool, error) {
+		return delivered, nil
+	}
+	accClient.TransactionStatusFn = func(txID string) (*acc.TxStatus, error) {
+		if delivered {
+			return &acc.TxStatus{TxID: txID, State: acc.TxDelivered}, nil
+		}
+		return &acc.TxStatus{TxID: txID, State: acc.TxPending}, nil
+	}
+	confirmer := acc.NewConfirmer(accClient).WithTimeout(0, 0)
+	handler := NewCreateHandler(accClient, policy.NewPolicyV1()).WithConfirmer(confirmer)
+	h := idempotency.NewStore(time.Hour, 100).Middleware()(http.HandlerFunc(handler.Create))
+
+	requestBody, err := json.Marshal(CreateRequest{
+		DID: "did:acc:frank",
+		DIDDocument: map[string]interface{}{
+			"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+			"id":       "did:acc:frank",
+		},
+	})
+	require.NoError(t, err)
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/create", bytes.NewReader(requestBody))
+		req.Header.Set(idempotency.HeaderKey, "create-frank")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// The ADI is not delivered yet: wait, with the transaction to watch
+	w := create()
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var response CreateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "wait", response.DIDState.State)
+	assert.Equal(t, "txid-create-identity-mock", response.DIDRegistrationMetadata.ADITxID)
+	assert.Empty(t, response.DIDRegistrationMetadata.TxID)
+	assert.Nil(t, accClient.LastEnvelope)
+
+	// The retry with the same key is not replayed and does not pay again
+	delivered = true
+	w = create()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(idempotency.HeaderReplayed))
+	assert.Equal(t, []string{"acc://frank"}, accClient.Created)
+	assert.NotNil(t, accClient.LastEnvelope)
+}
+
+func TestCreateHandler_Confirmation(t *testing.T) {
+	requestBody, err := json.Marshal(CreateRequest{
+		DID: "did:acc:erin",
+		DIDDocument: map[string]interface{}{
+			"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+			"id":       "did:acc:erin",
+		},
+	})
+	require.NoError(t, err)
+
+	create := func(state acc.TxState) *httptest.ResponseRecorder {
+		accClient := acc.NewMockClient()
+		accClient.AccountExistsFn = func(string) (bool, error) { return true, nil }
+		accClient.TransactionStatusFn = func(txID string) (*acc.TxStatus, error) {
+			return &acc.TxStatus{TxID: txID, State: state, Error: "rejected by the network"}, nil
+		}
+		confirmer := acc.NewConfirmer(accClient).WithTimeout(0, 0)
+		handler := NewCreateHandler(accClient, policy.NewPolicyV1()).WithConfirmer(confirmer)
+
+		w := httptest.NewRecorder()
+		handler.Create(w, httptest.NewRequest("POST", "/create", bytes.NewReader(requestBody)))
+		return w
+	}
+
+	t.Run("pending", func(t *testing.T) {
+		w := create(acc.TxPending)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		var response CreateResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "wait", response.DIDState.State)
+		assert.Equal(t, "txid-submit-write-mock", response.DIDRegistrationMetadata.TxID)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		w := create(acc.TxFailed)
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+		var response api.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "transactionFailed", response.Error)
+		assert.Equal(t, "txid-submit-write-mock", response.Details["txid"])
+		assert.Equal(t, "rejected by the network", response.Details["error"])
+	})
+}
+
+func TestCreateHandler_APIKeyScope(t *testing.T) {
+	requestBody, err := json.Marshal(CreateRequest{
+		DID: "did:acc:mallory",
+		DIDDocument: map[string]interface{}{
+			"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+			"id":       "did:acc:mallory",
+		},
+	})
+	require.NoError(t, err)
+
+	accClient := acc.NewMockClient()
+	handler := NewCreateHandler(accClient, policy.NewPolicyV1())
+
+	key := &security.APIKey{ID: "key_tenant", ADIs: []string{"alice.acme"}}
+	req := httptest.NewRequest("POST", "/create", bytes.NewReader(requestBody))
+	req = req.WithContext(security.WithAPIKey(req.Context(), key))
+	w := httptest.NewRecorder()
+	handler.Create(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	var response api.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "forbidden", response.Error)
+	assert.Empty(t, accClient.Created, "nothing is submitted outside the key's scope")
 }
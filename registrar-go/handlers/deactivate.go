@@ -5,18 +5,22 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/opendlt/accu-did/registrar-go/internal/acc"
 	"github.com/opendlt/accu-did/registrar-go/internal/api"
 	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // DeactivateHandler handles DID deactivation requests
 type DeactivateHandler struct {
 	accClient  acc.Submitter
 	authPolicy policy.AuthPolicy
+	confirmer  *acc.Confirmer
 }
 
 // DeactivateResponse represents a DID deactivation response
@@ -32,28 +36,41 @@ func NewDeactivateHandler(accClient acc.Submitter, authPolicy policy.AuthPolicy)
 	return &DeactivateHandler{
 		accClient:  accClient,
 		authPolicy: authPolicy,
+		confirmer:  acc.NewConfirmer(accClient),
 	}
 }
 
+// WithConfirmer replaces the confirmer that waits for delivery
+func (h *DeactivateHandler) WithConfirmer(confirmer *acc.Confirmer) *DeactivateHandler {
+	h.confirmer = confirmer
+	return h
+}
+
 // Deactivate handles POST /deactivate requests
 func (h *DeactivateHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	var req api.DeactivateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateDeactivateRequest(&req); err != nil {
-		h.writeError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The caller's API key must cover the DID and operation
+	if err := security.Authorize(r.Context(), security.OpDeactivate, req.DID); err != nil {
+		h.writeError(w, r, "forbidden", err.Error(), http.StatusForbidden, nil)
 		return
 	}
 
 	// Get required key page for authorization
 	_, err := h.authPolicy.GetRequiredKeyPage(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
@@ -75,23 +92,38 @@ func (h *DeactivateHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 	// Convert to JSON for writing
 	deactivationData, err := json.Marshal(deactivationDoc)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to marshal deactivation document", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to marshal deactivation document", http.StatusInternalServerError, nil)
 		return
 	}
 
 	// Get data account URL using safe helper
 	dataAccountURL, err := policy.DIDToDataAccountURL(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
 	// Submit deactivation tombstone to Accumulate
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL, deactivationData)
+	txID, err := h.accClient.WriteDataEntry(r.Context(), dataAccountURL, deactivationData)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to submit deactivation transaction", logging.DID(req.DID), "operation", "deactivate", "error", err)
+		if details, ok := insufficientCreditsDetails(err); ok {
+			h.writeError(w, r, "insufficientCredits", err.Error(), http.StatusPaymentRequired, details)
+			return
+		}
+		h.writeError(w, r, "internalError", "Failed to submit deactivation transaction", http.StatusInternalServerError, nil)
+		return
+	}
+	slog.InfoContext(r.Context(), "DID document submitted", logging.DID(req.DID), "operation", "deactivate", "tx_id", txID)
+
+	// Wait for delivery; a transaction still pending is reported as "wait"
+	tx, err := confirmWrite(r.Context(), h.confirmer, txID)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to submit deactivation transaction", http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
 		return
 	}
+	state := didStateOf(tx)
 
 	// Generate version ID for this deactivation
 	versionID := fmt.Sprintf("%d-deactivated", time.Now().Unix())
@@ -101,13 +133,14 @@ func (h *DeactivateHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 		JobID: h.generateJobID(),
 		DIDState: DIDState{
 			DID:    req.DID,
-			State:  "finished",
+			State:  state,
 			Action: "deactivate",
 		},
 		DIDRegistrationMetadata: DIDRegistrationMetadata{
-			VersionID:   versionID,
-			ContentHash: h.calculateContentHash(deactivationData),
-			TxID:        txID,
+			VersionID:       versionID,
+			ContentHash:     h.calculateContentHash(deactivationData),
+			TxID:            txID,
+			SignatureHashes: tx.SignatureHashes,
 		},
 		DIDDocumentMetadata: DIDDocumentMetadata{
 			Created:   time.Now().UTC(), // Use current time for deactivation
@@ -117,10 +150,10 @@ func (h *DeactivateHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 
 	// Return response
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(state))
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.writeError(w, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
 		return
 	}
 }
@@ -157,11 +190,12 @@ func (h *DeactivateHandler) calculateContentHash(data []byte) string {
 }
 
 // writeError writes an error response
-func (h *DeactivateHandler) writeError(w http.ResponseWriter, errorCode, message string, status int, details map[string]string) {
+func (h *DeactivateHandler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int, details map[string]string) {
 	response := api.ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		Details:   details,
+		RequestID: logging.RequestIDFromContext(r.Context()),
 		Timestamp: time.Now().UTC(),
 	}
 
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/registrar-go/internal/idempotency"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+)
+
+// txFailedError reports a transaction the network accepted but did not apply
+type txFailedError struct {
+	status *acc.TxStatus
+}
+
+func (e *txFailedError) Error() string {
+	return fmt.Sprintf("transaction %s failed: %s", e.status.TxID, e.status.Error)
+}
+
+// confirmWrite waits for the transaction of a write. It returns the last
+// status, delivered or still pending, or a *txFailedError.
+func confirmWrite(ctx context.Context, confirmer *acc.Confirmer, txID string) (*acc.TxStatus, error) {
+	status := confirmer.Wait(ctx, txID)
+	switch status.State {
+	case acc.TxFailed:
+		return nil, &txFailedError{status: status}
+	case acc.TxPending:
+		slog.InfoContext(ctx, "Transaction pending", "tx_id", txID)
+	}
+	return status, nil
+}
+
+// didStateOf maps a confirmed write to the didState state: finished once
+// delivered, wait while pending
+func didStateOf(status *acc.TxStatus) string {
+	if status.State == acc.TxDelivered {
+		return "finished"
+	}
+	return "wait"
+}
+
+// httpStatusFor returns the HTTP status of a response in didState state:
+// 202 while the operation is still pending, 200 otherwise
+func httpStatusFor(state string) int {
+	if state == "wait" {
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// pendingProvisioning returns what a create provisioned when err reports an
+// account whose transaction is not delivered yet, and false for any other
+// error. The create is reported as "wait" and not stored by the idempotency
+// middleware, so repeating it with the same key waits for that transaction
+// instead of paying for another one.
+func pendingProvisioning(ctx context.Context, err error) (*acc.Provisioning, bool) {
+	var provision *acc.ProvisionError
+	if !errors.As(err, &provision) || !provision.Pending() {
+		return nil, false
+	}
+	idempotency.MarkRetryable(ctx)
+	return &provision.Provisioning, true
+}
+
+// failureFor maps an error from a write to an error code, HTTP status and
+// details. The details of a failed create name the failed step and every
+// account and transaction provisioned before it, so the caller knows what a
+// retry will skip.
+func failureFor(err error) (string, int, map[string]string) {
+	var scope *security.ScopeError
+	if errors.As(err, &scope) {
+		return "forbidden", http.StatusForbidden, map[string]string{
+			"keyId":     scope.KeyID,
+			"operation": scope.Operation,
+		}
+	}
+
+	var failed *txFailedError
+	if errors.As(err, &failed) {
+		return "transactionFailed", http.StatusUnprocessableEntity, map[string]string{
+			"txid":  failed.status.TxID,
+			"error": failed.status.Error,
+		}
+	}
+
+	code, status := "internalError", http.StatusInternalServerError
+	details, ok := insufficientCreditsDetails(err)
+	if ok {
+		code, status = "insufficientCredits", http.StatusPaymentRequired
+	} else {
+		details = map[string]string{}
+	}
+
+	var provision *acc.ProvisionError
+	if errors.As(err, &provision) {
+		details["step"] = provision.Step
+		details["adi"] = provision.Provisioning.ADI
+		details["dataAccount"] = provision.Provisioning.DataAccount
+		if provision.Provisioning.ADITxID != "" {
+			details["adiTxId"] = provision.Provisioning.ADITxID
+		}
+		if provision.Provisioning.DataAccountTxID != "" {
+			details["dataAccountTxId"] = provision.Provisioning.DataAccountTxID
+		}
+	}
+	return code, status, details
+}
+
+// insufficientCreditsDetails returns the error details for a submission
+// rejected because the signing key page cannot pay, with amounts in whole
+// credits, and false for any other error
+func insufficientCreditsDetails(err error) (map[string]string, bool) {
+	var credits *acc.InsufficientCreditsError
+	if !errors.As(err, &credits) {
+		return nil, false
+	}
+	return map[string]string{
+		"keyPage":   credits.KeyPage,
+		"operation": credits.Operation,
+		"balance":   acc.FormatCredits(credits.Balance),
+		"required":  acc.FormatCredits(credits.Required),
+		"shortfall": acc.FormatCredits(credits.Shortfall()),
+	}, true
+}
@@ -3,17 +3,22 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/opendlt/accu-did/registrar-go/internal/acc"
 	"github.com/opendlt/accu-did/registrar-go/internal/api"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
 	"github.com/opendlt/accu-did/shared/did"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // NativeHandler handles native DID registration endpoints
 type NativeHandler struct {
-	accClient acc.Submitter
+	accClient   acc.Submitter
+	provisioner *acc.Provisioner
+	confirmer   *acc.Confirmer
 }
 
 // RegisterRequest represents a native DID registration request
@@ -33,94 +38,142 @@ type NativeUpdateRequest struct {
 type NativeResponse struct {
 	Success   bool                   `json:"success"`
 	TxID      string                 `json:"txid,omitempty"`
+	State     string                 `json:"state,omitempty"` // "finished" once delivered, "wait" while pending
 	DID       string                 `json:"did"`
 	JobID     string                 `json:"jobId,omitempty"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
 	Error     string                 `json:"error,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
+
+	// Hashes of the signatures submitted with the transaction
+	SignatureHashes []string `json:"signatureHashes,omitempty"`
 }
 
 // NewNativeHandler creates a new native handler
 func NewNativeHandler(accClient acc.Submitter) *NativeHandler {
 	return &NativeHandler{
-		accClient: accClient,
+		accClient:   accClient,
+		provisioner: acc.NewProvisioner(accClient),
+		confirmer:   acc.NewConfirmer(accClient),
 	}
 }
 
+// WithConfirmer replaces the confirmer that waits for delivery, of the
+// document and of the accounts provisioned before it
+func (h *NativeHandler) WithConfirmer(confirmer *acc.Confirmer) *NativeHandler {
+	h.confirmer = confirmer
+	h.provisioner.WithConfirmer(confirmer)
+	return h
+}
+
+// WithProvisioner replaces the provisioner, e.g. to change how long it waits
+// for new accounts
+func (h *NativeHandler) WithProvisioner(provisioner *acc.Provisioner) *NativeHandler {
+	h.provisioner = provisioner
+	return h
+}
+
 // Register handles POST /register requests
 func (h *NativeHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateRegisterRequest(&req); err != nil {
-		h.writeError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The caller's API key must cover the DID and operation
+	if err := security.Authorize(r.Context(), security.OpCreate, req.DID); err != nil {
+		h.writeError(w, r, "forbidden", err.Error(), http.StatusForbidden, nil)
 		return
 	}
 
 	// Parse DID to get ADI components
 	adiURL, dataAccountURL, err := did.ParseDID(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	// Step 1: Create ADI if it doesn't exist
+	// Steps 1 and 2: create the ADI and data account if missing. Whatever a
+	// failed attempt created is skipped when the request is repeated.
 	adiLabel := adiURL.Authority
 	keyPageURL := req.KeyPageURL
 	if keyPageURL == "" {
 		keyPageURL = fmt.Sprintf("acc://%s/book/1", adiLabel)
 	}
 
-	adiTxID, err := h.accClient.CreateIdentity(adiLabel, keyPageURL)
-	if err != nil {
-		// ADI might already exist, continue with data account creation
-		// In a real implementation, you'd check if the error is "already exists"
+	// The accounts and the document share one confirmation deadline
+	r = r.WithContext(h.confirmer.Begin(r.Context()))
+	provisioning, err := h.provisioner.Provision(r.Context(), dataAccountURL.String(), keyPageURL)
+	if pending, ok := pendingProvisioning(r.Context(), err); ok {
+		slog.InfoContext(r.Context(), "Accounts pending", logging.DID(req.DID), "operation", "register", "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(NativeResponse{
+			Success:   true,
+			State:     "wait",
+			DID:       req.DID,
+			JobID:     h.generateJobID(),
+			Metadata:  registerMetadata(pending, adiLabel),
+			Timestamp: time.Now().UTC(),
+		})
+		return
 	}
-
-	// Step 2: Create data account
-	dataAccountLabel := dataAccountURL.Path[1:] // Remove leading slash
-	dataTxID, err := h.accClient.CreateDataAccount(adiURL.String(), dataAccountLabel)
 	if err != nil {
-		// Data account might already exist, continue with writing data
-		// In a real implementation, you'd check if the error is "already exists"
+		slog.ErrorContext(r.Context(), "Failed to provision accounts", logging.DID(req.DID), "operation", "register", "error", err)
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
+		return
 	}
 
 	// Step 3: Write DID document to data account
 	didDocData, err := json.Marshal(req.DIDDocument)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to marshal DID document", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to marshal DID document", http.StatusInternalServerError, nil)
 		return
 	}
 
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL.String(), didDocData)
+	txID, err := h.accClient.WriteDataEntry(r.Context(), dataAccountURL.String(), didDocData)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to write DID document", http.StatusInternalServerError, nil)
+		slog.ErrorContext(r.Context(), "Failed to write DID document", logging.DID(req.DID), "operation", "register", "error", err)
+		if details, ok := insufficientCreditsDetails(err); ok {
+			h.writeError(w, r, "insufficientCredits", err.Error(), http.StatusPaymentRequired, details)
+			return
+		}
+		h.writeError(w, r, "internalError", "Failed to write DID document", http.StatusInternalServerError, nil)
 		return
 	}
+	slog.InfoContext(r.Context(), "DID document submitted", logging.DID(req.DID), "operation", "register", "tx_id", txID)
 
-	// Build response
-	metadata := map[string]interface{}{
-		"adiTxID":     adiTxID,
-		"dataTxID":    dataTxID,
-		"adiLabel":    adiLabel,
-		"dataAccount": dataAccountURL.String(),
+	// Wait for delivery; a transaction still pending is reported as "wait"
+	tx, err := confirmWrite(r.Context(), h.confirmer, txID)
+	if err != nil {
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
+		return
 	}
+	state := didStateOf(tx)
 
+	// Build response
 	response := NativeResponse{
-		Success:   true,
-		TxID:      txID,
-		DID:       req.DID,
-		JobID:     h.generateJobID(),
-		Metadata:  metadata,
-		Timestamp: time.Now().UTC(),
+		Success:         true,
+		TxID:            txID,
+		State:           state,
+		DID:             req.DID,
+		JobID:           h.generateJobID(),
+		Metadata:        registerMetadata(provisioning, adiLabel),
+		Timestamp:       time.Now().UTC(),
+		SignatureHashes: tx.SignatureHashes,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(state))
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -128,46 +181,69 @@ func (h *NativeHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *NativeHandler) Update(w http.ResponseWriter, r *http.Request) {
 	var req NativeUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateUpdateRequest(&req); err != nil {
-		h.writeError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The caller's API key must cover the DID and operation
+	if err := security.Authorize(r.Context(), security.OpUpdate, req.DID); err != nil {
+		h.writeError(w, r, "forbidden", err.Error(), http.StatusForbidden, nil)
 		return
 	}
 
 	// Parse DID to get data account URL
 	_, dataAccountURL, err := did.ParseDID(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
 	// Write updated DID document
 	didDocData, err := json.Marshal(req.DIDDocument)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to marshal DID document", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to marshal DID document", http.StatusInternalServerError, nil)
+		return
+	}
+
+	txID, err := h.accClient.WriteDataEntry(r.Context(), dataAccountURL.String(), didDocData)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update DID document", logging.DID(req.DID), "operation", "update", "error", err)
+		if details, ok := insufficientCreditsDetails(err); ok {
+			h.writeError(w, r, "insufficientCredits", err.Error(), http.StatusPaymentRequired, details)
+			return
+		}
+		h.writeError(w, r, "internalError", "Failed to update DID document", http.StatusInternalServerError, nil)
 		return
 	}
+	slog.InfoContext(r.Context(), "DID document submitted", logging.DID(req.DID), "operation", "update", "tx_id", txID)
 
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL.String(), didDocData)
+	// Wait for delivery; a transaction still pending is reported as "wait"
+	tx, err := confirmWrite(r.Context(), h.confirmer, txID)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to update DID document", http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
 		return
 	}
+	state := didStateOf(tx)
 
 	response := NativeResponse{
-		Success:   true,
-		TxID:      txID,
-		DID:       req.DID,
-		JobID:     h.generateJobID(),
-		Timestamp: time.Now().UTC(),
+		Success:         true,
+		TxID:            txID,
+		State:           state,
+		DID:             req.DID,
+		JobID:           h.generateJobID(),
+		Timestamp:       time.Now().UTC(),
+		SignatureHashes: tx.SignatureHashes,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(state))
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -175,20 +251,26 @@ func (h *NativeHandler) Update(w http.ResponseWriter, r *http.Request) {
 func (h *NativeHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 	var req api.DeactivateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateDeactivateRequest(&req); err != nil {
-		h.writeError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The caller's API key must cover the DID and operation
+	if err := security.Authorize(r.Context(), security.OpDeactivate, req.DID); err != nil {
+		h.writeError(w, r, "forbidden", err.Error(), http.StatusForbidden, nil)
 		return
 	}
 
 	// Parse DID to get data account URL
 	_, dataAccountURL, err := did.ParseDID(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
@@ -201,29 +283,58 @@ func (h *NativeHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
 
 	didDocData, err := json.Marshal(deactivatedDoc)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to marshal deactivated DID document", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to marshal deactivated DID document", http.StatusInternalServerError, nil)
 		return
 	}
 
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL.String(), didDocData)
+	txID, err := h.accClient.WriteDataEntry(r.Context(), dataAccountURL.String(), didDocData)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to deactivate DID", http.StatusInternalServerError, nil)
+		slog.ErrorContext(r.Context(), "Failed to deactivate DID", logging.DID(req.DID), "operation", "deactivate", "error", err)
+		if details, ok := insufficientCreditsDetails(err); ok {
+			h.writeError(w, r, "insufficientCredits", err.Error(), http.StatusPaymentRequired, details)
+			return
+		}
+		h.writeError(w, r, "internalError", "Failed to deactivate DID", http.StatusInternalServerError, nil)
+		return
+	}
+	slog.InfoContext(r.Context(), "DID document submitted", logging.DID(req.DID), "operation", "deactivate", "tx_id", txID)
+
+	// Wait for delivery; a transaction still pending is reported as "wait"
+	tx, err := confirmWrite(r.Context(), h.confirmer, txID)
+	if err != nil {
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
 		return
 	}
+	state := didStateOf(tx)
 
 	response := NativeResponse{
-		Success:   true,
-		TxID:      txID,
-		DID:       req.DID,
-		JobID:     h.generateJobID(),
-		Timestamp: time.Now().UTC(),
+		Success:         true,
+		TxID:            txID,
+		State:           state,
+		DID:             req.DID,
+		JobID:           h.generateJobID(),
+		Timestamp:       time.Now().UTC(),
+		SignatureHashes: tx.SignatureHashes,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(state))
 	json.NewEncoder(w).Encode(response)
 }
 
+// registerMetadata returns the register response metadata naming the accounts
+// behind the DID and the transactions that created them
+func registerMetadata(provisioning *acc.Provisioning, adiLabel string) map[string]interface{} {
+	return map[string]interface{}{
+		"adi":         provisioning.ADI,
+		"adiTxID":     provisioning.ADITxID,
+		"dataTxID":    provisioning.DataAccountTxID,
+		"adiLabel":    adiLabel,
+		"dataAccount": provisioning.DataAccount,
+	}
+}
+
 // validateRegisterRequest validates the register request
 func (h *NativeHandler) validateRegisterRequest(req *RegisterRequest) error {
 	if req.DID == "" {
@@ -286,11 +397,12 @@ func (h *NativeHandler) generateJobID() string {
 }
 
 // writeError writes an error response
-func (h *NativeHandler) writeError(w http.ResponseWriter, errorCode, message string, status int, details map[string]string) {
+func (h *NativeHandler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int, details map[string]string) {
 	response := api.ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		Details:   details,
+		RequestID: logging.RequestIDFromContext(r.Context()),
 		Timestamp: time.Now().UTC(),
 	}
 
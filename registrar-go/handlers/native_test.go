@@ -213,3 +213,85 @@ func TestNativeDeactivate(t *testing.T) {
 		t.Errorf("expected DID 'did:acc:testuser', got %s", resp.DID)
 	}
 }
+
+func TestNativeRegister_WritesToLedger(t *testing.T) {
+	client := acc.NewFakeSubmitter()
+	handler := NewNativeHandler(client)
+
+	body, err := json.Marshal(RegisterRequest{
+		DID: "did:acc:ledgeruser",
+		DIDDocument: map[string]interface{}{
+			"@context": []string{"https://www.w3.org/ns/did/v1"},
+			"id":       "did:acc:ledgeruser",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request body: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.Register(w, httptest.NewRequest("POST", "/register", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp NativeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	entry, err := client.Ledger().Latest("acc://ledgeruser/did")
+	if err != nil {
+		t.Fatalf("expected the document on the ledger: %v", err)
+	}
+	if entry.TxID != resp.TxID {
+		t.Errorf("expected entry from tx %s, got %s", resp.TxID, entry.TxID)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(entry.Data, &doc); err != nil || doc["id"] != "did:acc:ledgeruser" {
+		t.Errorf("unexpected ledger entry %s", entry.Data)
+	}
+	if _, err := client.Ledger().Identity("acc://ledgeruser"); err != nil {
+		t.Errorf("expected the ADI to be created: %v", err)
+	}
+}
+
+func TestNativeRegister_InsufficientCredits(t *testing.T) {
+	client := acc.NewMockClient()
+	client.GetKeyPageStateFn = func(keyPageURL string) (*acc.KeyPageState, error) {
+		return &acc.KeyPageState{URL: keyPageURL, CreditBalance: 0}, nil
+	}
+	handler := NewNativeHandler(acc.NewCreditGuard(client))
+
+	body, err := json.Marshal(RegisterRequest{
+		DID: "did:acc:brokeuser",
+		DIDDocument: map[string]interface{}{
+			"@context": []string{"https://www.w3.org/ns/did/v1"},
+			"id":       "did:acc:brokeuser",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal request body: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.Register(w, httptest.NewRequest("POST", "/register", bytes.NewReader(body)))
+	if w.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected status 402, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp api.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Error != "insufficientCredits" {
+		t.Errorf("expected error insufficientCredits, got %s", resp.Error)
+	}
+	if resp.Details["keyPage"] != "acc://brokeuser/book/1" || resp.Details["shortfall"] != "500.00" {
+		t.Errorf("unexpected details %v", resp.Details)
+	}
+	if client.LastWriteData != nil {
+		t.Error("expected nothing to be written")
+	}
+}
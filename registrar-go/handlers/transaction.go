@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/registrar-go/internal/api"
+	"github.com/opendlt/accu-did/shared/logging"
+)
+
+// TransactionHandler reports the delivery state of submitted transactions,
+// for clients that got a "wait" state back from a write
+type TransactionHandler struct {
+	accClient acc.Submitter
+}
+
+// NewTransactionHandler creates a transaction status handler
+func NewTransactionHandler(accClient acc.Submitter) *TransactionHandler {
+	return &TransactionHandler{accClient: accClient}
+}
+
+// Status handles GET /transaction?txid=... requests
+func (h *TransactionHandler) Status(w http.ResponseWriter, r *http.Request) {
+	txID := r.URL.Query().Get("txid")
+	if txID == "" {
+		h.writeError(w, r, "invalidRequest", "txid is required", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.accClient.TransactionStatus(r.Context(), txID)
+	if err != nil {
+		if errors.Is(err, acc.ErrTransactionNotFound) {
+			h.writeError(w, r, "notFound", err.Error(), http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to query transaction", "tx_id", txID, "error", err)
+		h.writeError(w, r, "internalError", "Failed to query transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
+
+// writeError writes an error response
+func (h *TransactionHandler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int) {
+	response := api.ErrorResponse{
+		Error:     errorCode,
+		Message:   message,
+		RequestID: logging.RequestIDFromContext(r.Context()),
+		Timestamp: time.Now().UTC(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/opendlt/accu-did/registrar-go/internal/acc"
 	"github.com/opendlt/accu-did/registrar-go/internal/api"
 	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
 	"github.com/opendlt/accu-did/shared/did"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // UniversalHandler handles Universal Registrar compatibility endpoints
@@ -75,24 +79,30 @@ func NewUniversalHandler(accClient acc.Submitter, authPolicy policy.AuthPolicy)
 	}
 }
 
+// WithConfirmer replaces the confirmer that waits for delivery
+func (h *UniversalHandler) WithConfirmer(confirmer *acc.Confirmer) *UniversalHandler {
+	h.nativeHandler.WithConfirmer(confirmer)
+	return h
+}
+
 // UniversalCreate handles POST /1.0/create requests (Universal Registrar)
 func (h *UniversalHandler) UniversalCreate(w http.ResponseWriter, r *http.Request) {
 	var req UniversalCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeUniversalError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateUniversalCreateRequest(&req); err != nil {
-		h.writeUniversalError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
 	// Extract DID from didDocument
 	did, ok := req.DIDDocument["id"].(string)
 	if !ok {
-		h.writeUniversalError(w, "invalidRequest", "didDocument must have an 'id' field", http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", "didDocument must have an 'id' field", http.StatusBadRequest, nil)
 		return
 	}
 
@@ -111,14 +121,15 @@ func (h *UniversalHandler) UniversalCreate(w http.ResponseWriter, r *http.Reques
 
 	// Validate native request
 	if err := h.nativeHandler.validateRegisterRequest(&nativeReq); err != nil {
-		h.writeUniversalError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
 	// Process using native handler logic but return Universal format
-	response, err := h.processNativeRegister(&nativeReq)
+	response, err := h.processNativeRegister(r.Context(), &nativeReq)
 	if err != nil {
-		h.writeUniversalError(w, "internalError", err.Error(), http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeUniversalError(w, r, code, err.Error(), status, details)
 		return
 	}
 
@@ -127,11 +138,16 @@ func (h *UniversalHandler) UniversalCreate(w http.ResponseWriter, r *http.Reques
 		JobID: response.JobID,
 		DIDState: DIDState{
 			DID:    response.DID,
-			State:  "finished",
+			State:  response.State,
 			Action: "create",
 		},
 		DIDRegistrationMetadata: DIDRegistrationMetadata{
-			TxID: response.TxID,
+			TxID:            response.TxID,
+			SignatureHashes: response.SignatureHashes,
+			ADI:             response.Metadata["adi"].(string),
+			ADITxID:         response.Metadata["adiTxID"].(string),
+			DataAccount:     response.Metadata["dataAccount"].(string),
+			DataAccountTxID: response.Metadata["dataTxID"].(string),
 		},
 		DIDDocumentMetadata: DIDDocumentMetadata{
 			Created: response.Timestamp,
@@ -139,7 +155,7 @@ func (h *UniversalHandler) UniversalCreate(w http.ResponseWriter, r *http.Reques
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(response.State))
 	json.NewEncoder(w).Encode(universalResponse)
 }
 
@@ -147,13 +163,13 @@ func (h *UniversalHandler) UniversalCreate(w http.ResponseWriter, r *http.Reques
 func (h *UniversalHandler) UniversalUpdate(w http.ResponseWriter, r *http.Request) {
 	var req UniversalUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeUniversalError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateUniversalUpdateRequest(&req); err != nil {
-		h.writeUniversalError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
@@ -164,7 +180,7 @@ func (h *UniversalHandler) UniversalUpdate(w http.ResponseWriter, r *http.Reques
 	} else if req.Registration != nil && req.Registration.DID != "" {
 		targetDID = req.Registration.DID
 	} else {
-		h.writeUniversalError(w, "invalidRequest", "DID identifier is required", http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", "DID identifier is required", http.StatusBadRequest, nil)
 		return
 	}
 
@@ -175,21 +191,21 @@ func (h *UniversalHandler) UniversalUpdate(w http.ResponseWriter, r *http.Reques
 		// Get current DID document by resolving it
 		currentDoc, err := h.resolveCurrentDIDDocument(targetDID)
 		if err != nil {
-			h.writeUniversalError(w, "notFound", fmt.Sprintf("Could not resolve current DID document: %v", err), http.StatusNotFound, nil)
+			h.writeUniversalError(w, r, "notFound", fmt.Sprintf("Could not resolve current DID document: %v", err), http.StatusNotFound, nil)
 			return
 		}
 
 		// Apply patch to current document
 		updatedDoc, err = h.applyPatch(currentDoc, req.Registration.Patch)
 		if err != nil {
-			h.writeUniversalError(w, "invalidRequest", fmt.Sprintf("Failed to apply patch: %v", err), http.StatusBadRequest, nil)
+			h.writeUniversalError(w, r, "invalidRequest", fmt.Sprintf("Failed to apply patch: %v", err), http.StatusBadRequest, nil)
 			return
 		}
 	} else if req.DIDDocument != nil {
 		// Use provided DID document directly
 		updatedDoc = req.DIDDocument
 	} else {
-		h.writeUniversalError(w, "invalidRequest", "Either didDocument or registration.patch is required", http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", "Either didDocument or registration.patch is required", http.StatusBadRequest, nil)
 		return
 	}
 
@@ -200,9 +216,10 @@ func (h *UniversalHandler) UniversalUpdate(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Process using native handler logic
-	response, err := h.processNativeUpdate(&nativeReq)
+	response, err := h.processNativeUpdate(r.Context(), &nativeReq)
 	if err != nil {
-		h.writeUniversalError(w, "internalError", err.Error(), http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeUniversalError(w, r, code, err.Error(), status, details)
 		return
 	}
 
@@ -211,16 +228,17 @@ func (h *UniversalHandler) UniversalUpdate(w http.ResponseWriter, r *http.Reques
 		JobID: response.JobID,
 		DIDState: DIDState{
 			DID:    response.DID,
-			State:  "finished",
+			State:  response.State,
 			Action: "update",
 		},
 		DIDRegistrationMetadata: DIDRegistrationMetadata{
-			TxID: response.TxID,
+			TxID:            response.TxID,
+			SignatureHashes: response.SignatureHashes,
 		},
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(response.State))
 	json.NewEncoder(w).Encode(universalResponse)
 }
 
@@ -228,13 +246,13 @@ func (h *UniversalHandler) UniversalUpdate(w http.ResponseWriter, r *http.Reques
 func (h *UniversalHandler) UniversalDeactivate(w http.ResponseWriter, r *http.Request) {
 	var req UniversalDeactivateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeUniversalError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateUniversalDeactivateRequest(&req); err != nil {
-		h.writeUniversalError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeUniversalError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
@@ -244,9 +262,10 @@ func (h *UniversalHandler) UniversalDeactivate(w http.ResponseWriter, r *http.Re
 	}
 
 	// Process using native handler logic
-	response, err := h.processNativeDeactivate(&nativeReq)
+	response, err := h.processNativeDeactivate(r.Context(), &nativeReq)
 	if err != nil {
-		h.writeUniversalError(w, "internalError", err.Error(), http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeUniversalError(w, r, code, err.Error(), status, details)
 		return
 	}
 
@@ -255,23 +274,27 @@ func (h *UniversalHandler) UniversalDeactivate(w http.ResponseWriter, r *http.Re
 		JobID: response.JobID,
 		DIDState: DIDState{
 			DID:    response.DID,
-			State:  "finished",
+			State:  response.State,
 			Action: "deactivate",
 		},
 		DIDRegistrationMetadata: DIDRegistrationMetadata{
-			TxID: response.TxID,
+			TxID:            response.TxID,
+			SignatureHashes: response.SignatureHashes,
 		},
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(response.State))
 	json.NewEncoder(w).Encode(universalResponse)
 }
 
 // processNativeRegister processes a register request using native logic
-func (h *UniversalHandler) processNativeRegister(req *RegisterRequest) (*NativeResponse, error) {
+func (h *UniversalHandler) processNativeRegister(ctx context.Context, req *RegisterRequest) (*NativeResponse, error) {
 	// This duplicates the native handler logic to avoid HTTP roundtrip
 	// In a real implementation, you might extract this to a service layer
+	if err := security.Authorize(ctx, security.OpCreate, req.DID); err != nil {
+		return nil, err
+	}
 
 	// Parse DID to get ADI components
 	adiURL, dataAccountURL, err := did.ParseDID(req.DID)
@@ -279,18 +302,31 @@ func (h *UniversalHandler) processNativeRegister(req *RegisterRequest) (*NativeR
 		return nil, err
 	}
 
-	// Create ADI
+	// Create the ADI and data account if missing
 	adiLabel := adiURL.Authority
 	keyPageURL := req.KeyPageURL
 	if keyPageURL == "" {
 		keyPageURL = fmt.Sprintf("acc://%s/book/1", adiLabel)
 	}
 
-	adiTxID, _ := h.accClient.CreateIdentity(adiLabel, keyPageURL)
-
-	// Create data account
-	dataAccountLabel := dataAccountURL.Path[1:]
-	dataTxID, _ := h.accClient.CreateDataAccount(adiURL.String(), dataAccountLabel)
+	// The accounts and the document share one confirmation deadline
+	ctx = h.nativeHandler.confirmer.Begin(ctx)
+	provisioning, err := h.nativeHandler.provisioner.Provision(ctx, dataAccountURL.String(), keyPageURL)
+	if pending, ok := pendingProvisioning(ctx, err); ok {
+		slog.InfoContext(ctx, "Accounts pending", logging.DID(req.DID), "operation", "register", "error", err)
+		return &NativeResponse{
+			Success:   true,
+			State:     "wait",
+			DID:       req.DID,
+			JobID:     h.generateJobID(),
+			Timestamp: time.Now().UTC(),
+			Metadata:  registerMetadata(pending, adiLabel),
+		}, nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to provision accounts", logging.DID(req.DID), "operation", "register", "error", err)
+		return nil, err
+	}
 
 	// Write DID document
 	didDocData, err := json.Marshal(req.DIDDocument)
@@ -298,28 +334,36 @@ func (h *UniversalHandler) processNativeRegister(req *RegisterRequest) (*NativeR
 		return nil, err
 	}
 
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL.String(), didDocData)
+	txID, err := h.accClient.WriteDataEntry(ctx, dataAccountURL.String(), didDocData)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write DID document", logging.DID(req.DID), "operation", "register", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "DID document submitted", logging.DID(req.DID), "operation", "register", "tx_id", txID)
+
+	tx, err := confirmWrite(ctx, h.nativeHandler.confirmer, txID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &NativeResponse{
-		Success:   true,
-		TxID:      txID,
-		DID:       req.DID,
-		JobID:     h.generateJobID(),
-		Timestamp: time.Now().UTC(),
-		Metadata: map[string]interface{}{
-			"adiTxID":     adiTxID,
-			"dataTxID":    dataTxID,
-			"adiLabel":    adiLabel,
-			"dataAccount": dataAccountURL.String(),
-		},
+		Success:         true,
+		TxID:            txID,
+		State:           didStateOf(tx),
+		DID:             req.DID,
+		JobID:           h.generateJobID(),
+		Timestamp:       time.Now().UTC(),
+		Metadata:        registerMetadata(provisioning, adiLabel),
+		SignatureHashes: tx.SignatureHashes,
 	}, nil
 }
 
 // processNativeUpdate processes an update request using native logic
-func (h *UniversalHandler) processNativeUpdate(req *NativeUpdateRequest) (*NativeResponse, error) {
+func (h *UniversalHandler) processNativeUpdate(ctx context.Context, req *NativeUpdateRequest) (*NativeResponse, error) {
+	if err := security.Authorize(ctx, security.OpUpdate, req.DID); err != nil {
+		return nil, err
+	}
+
 	// Parse DID to get data account URL
 	_, dataAccountURL, err := did.ParseDID(req.DID)
 	if err != nil {
@@ -332,7 +376,14 @@ func (h *UniversalHandler) processNativeUpdate(req *NativeUpdateRequest) (*Nativ
 		return nil, err
 	}
 
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL.String(), didDocData)
+	txID, err := h.accClient.WriteDataEntry(ctx, dataAccountURL.String(), didDocData)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write DID document", logging.DID(req.DID), "operation", "update", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "DID document submitted", logging.DID(req.DID), "operation", "update", "tx_id", txID)
+
+	tx, err := confirmWrite(ctx, h.nativeHandler.confirmer, txID)
 	if err != nil {
 		return nil, err
 	}
@@ -340,14 +391,21 @@ func (h *UniversalHandler) processNativeUpdate(req *NativeUpdateRequest) (*Nativ
 	return &NativeResponse{
 		Success:   true,
 		TxID:      txID,
+		State:     didStateOf(tx),
 		DID:       req.DID,
 		JobID:     h.generateJobID(),
 		Timestamp: time.Now().UTC(),
+
+		SignatureHashes: tx.SignatureHashes,
 	}, nil
 }
 
 // processNativeDeactivate processes a deactivate request using native logic
-func (h *UniversalHandler) processNativeDeactivate(req *api.DeactivateRequest) (*NativeResponse, error) {
+func (h *UniversalHandler) processNativeDeactivate(ctx context.Context, req *api.DeactivateRequest) (*NativeResponse, error) {
+	if err := security.Authorize(ctx, security.OpDeactivate, req.DID); err != nil {
+		return nil, err
+	}
+
 	// Parse DID to get data account URL
 	_, dataAccountURL, err := did.ParseDID(req.DID)
 	if err != nil {
@@ -366,7 +424,14 @@ func (h *UniversalHandler) processNativeDeactivate(req *api.DeactivateRequest) (
 		return nil, err
 	}
 
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL.String(), didDocData)
+	txID, err := h.accClient.WriteDataEntry(ctx, dataAccountURL.String(), didDocData)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write DID document", logging.DID(req.DID), "operation", "deactivate", "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "DID document submitted", logging.DID(req.DID), "operation", "deactivate", "tx_id", txID)
+
+	tx, err := confirmWrite(ctx, h.nativeHandler.confirmer, txID)
 	if err != nil {
 		return nil, err
 	}
@@ -374,9 +439,12 @@ func (h *UniversalHandler) processNativeDeactivate(req *api.DeactivateRequest) (
 	return &NativeResponse{
 		Success:   true,
 		TxID:      txID,
+		State:     didStateOf(tx),
 		DID:       req.DID,
 		JobID:     h.generateJobID(),
 		Timestamp: time.Now().UTC(),
+
+		SignatureHashes: tx.SignatureHashes,
 	}, nil
 }
 
@@ -438,13 +506,17 @@ func (h *UniversalHandler) resolveCurrentDIDDocument(didStr string) (map[string]
 		return nil, fmt.Errorf("invalid DID: %w", err)
 	}
 
-	// For FAKE mode, try to read from testdata
-	if _, ok := h.accClient.(*acc.FakeSubmitter); ok {
-		// In FAKE mode, we need to simulate reading the current document
-		// For simplicity, we'll return the existing testdata document
-		// In a real implementation, this would track the current state
+	// For FAKE mode, read the latest entry from the simulated ledger
+	if fake, ok := acc.Unwrap(h.accClient).(*acc.FakeSubmitter); ok {
+		if entry, err := fake.Ledger().Latest(dataAccountURL.String()); err == nil {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(entry.Data, &doc); err != nil {
+				return nil, fmt.Errorf("current document is not valid JSON: %w", err)
+			}
+			return doc, nil
+		}
 
-		// Extract ADI label for testdata lookup
+		// Nothing written yet: fall back to a canned document
 		adiLabel := dataAccountURL.Authority
 
 		// Return a mock current document for beastmode.acme
@@ -572,7 +644,7 @@ func (h *UniversalHandler) applyPatch(currentDoc map[string]interface{}, patch m
 }
 
 // writeUniversalError writes an error response in Universal Registrar format
-func (h *UniversalHandler) writeUniversalError(w http.ResponseWriter, errorCode, message string, status int, details map[string]string) {
+func (h *UniversalHandler) writeUniversalError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int, details map[string]string) {
 	response := map[string]interface{}{
 		"didState": map[string]interface{}{
 			"state":  "failed",
@@ -582,6 +654,12 @@ func (h *UniversalHandler) writeUniversalError(w http.ResponseWriter, errorCode,
 			"error": errorCode,
 		},
 	}
+	if requestID := logging.RequestIDFromContext(r.Context()); requestID != "" {
+		response["didRegistrationMetadata"].(map[string]interface{})["requestId"] = requestID
+	}
+	if len(details) > 0 {
+		response["didRegistrationMetadata"].(map[string]interface{})["details"] = details
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -5,18 +5,22 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/opendlt/accu-did/registrar-go/internal/acc"
 	"github.com/opendlt/accu-did/registrar-go/internal/api"
 	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // UpdateHandler handles DID update requests
 type UpdateHandler struct {
 	accClient  acc.Submitter
 	authPolicy policy.AuthPolicy
+	confirmer  *acc.Confirmer
 }
 
 // LegacyUpdateRequest represents a DID update request for legacy compatibility
@@ -40,28 +44,41 @@ func NewUpdateHandler(accClient acc.Submitter, authPolicy policy.AuthPolicy) *Up
 	return &UpdateHandler{
 		accClient:  accClient,
 		authPolicy: authPolicy,
+		confirmer:  acc.NewConfirmer(accClient),
 	}
 }
 
+// WithConfirmer replaces the confirmer that waits for delivery
+func (h *UpdateHandler) WithConfirmer(confirmer *acc.Confirmer) *UpdateHandler {
+	h.confirmer = confirmer
+	return h
+}
+
 // Update handles POST /update requests
 func (h *UpdateHandler) Update(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	var req LegacyUpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", "Invalid JSON", http.StatusBadRequest, nil)
 		return
 	}
 
 	// Validate request
 	if err := h.validateUpdateRequest(&req); err != nil {
-		h.writeError(w, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidRequest", err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	// The caller's API key must cover the DID and operation
+	if err := security.Authorize(r.Context(), security.OpUpdate, req.DID); err != nil {
+		h.writeError(w, r, "forbidden", err.Error(), http.StatusForbidden, nil)
 		return
 	}
 
 	// Get required key page for authorization
 	_, err := h.authPolicy.GetRequiredKeyPage(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
@@ -82,23 +99,38 @@ func (h *UpdateHandler) Update(w http.ResponseWriter, r *http.Request) {
 	// Get data account URL using safe helper
 	dataAccountURL, err := policy.DIDToDataAccountURL(req.DID)
 	if err != nil {
-		h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
 	// Convert DID document to JSON for writing
 	didDocData, err := json.Marshal(didDoc)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to marshal DID document", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to marshal DID document", http.StatusInternalServerError, nil)
 		return
 	}
 
 	// Submit to Accumulate
-	txID, err := h.accClient.WriteDataEntry(dataAccountURL, didDocData)
+	txID, err := h.accClient.WriteDataEntry(r.Context(), dataAccountURL, didDocData)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to submit update transaction", logging.DID(req.DID), "operation", "update", "error", err)
+		if details, ok := insufficientCreditsDetails(err); ok {
+			h.writeError(w, r, "insufficientCredits", err.Error(), http.StatusPaymentRequired, details)
+			return
+		}
+		h.writeError(w, r, "internalError", "Failed to submit update transaction", http.StatusInternalServerError, nil)
+		return
+	}
+	slog.InfoContext(r.Context(), "DID document submitted", logging.DID(req.DID), "operation", "update", "tx_id", txID)
+
+	// Wait for delivery; a transaction still pending is reported as "wait"
+	tx, err := confirmWrite(r.Context(), h.confirmer, txID)
 	if err != nil {
-		h.writeError(w, "internalError", "Failed to submit update transaction", http.StatusInternalServerError, nil)
+		code, status, details := failureFor(err)
+		h.writeError(w, r, code, err.Error(), status, details)
 		return
 	}
+	state := didStateOf(tx)
 
 	// Generate version ID for this update
 	versionID := fmt.Sprintf("%d-update", time.Now().Unix())
@@ -108,13 +140,14 @@ func (h *UpdateHandler) Update(w http.ResponseWriter, r *http.Request) {
 		JobID: h.generateJobID(),
 		DIDState: DIDState{
 			DID:    req.DID,
-			State:  "finished",
+			State:  state,
 			Action: "update",
 		},
 		DIDRegistrationMetadata: DIDRegistrationMetadata{
-			VersionID:   versionID,
-			ContentHash: h.calculateContentHash(didDocData),
-			TxID:        txID,
+			VersionID:       versionID,
+			ContentHash:     h.calculateContentHash(didDocData),
+			TxID:            txID,
+			SignatureHashes: tx.SignatureHashes,
 		},
 		DIDDocumentMetadata: DIDDocumentMetadata{
 			Created:   time.Now().UTC(), // In real implementation, this would be the original creation time
@@ -124,10 +157,10 @@ func (h *UpdateHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Return response
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(httpStatusFor(state))
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.writeError(w, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
 		return
 	}
 }
@@ -182,11 +215,12 @@ func (h *UpdateHandler) calculateContentHash(data []byte) string {
 }
 
 // writeError writes an error response
-func (h *UpdateHandler) writeError(w http.ResponseWriter, errorCode, message string, status int, details map[string]string) {
+func (h *UpdateHandler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int, details map[string]string) {
 	response := api.ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		Details:   details,
+		RequestID: logging.RequestIDFromContext(r.Context()),
 		Timestamp: time.Now().UTC(),
 	}
 
@@ -0,0 +1,114 @@
+package acc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrTransactionNotFound is returned by TransactionStatus for a transaction
+// that was never submitted
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// Default confirmation settings for Confirmer
+const (
+	DefaultConfirmTimeout  = 10 * time.Second
+	DefaultConfirmInterval = time.Second
+)
+
+// TxState is the delivery state of a submitted transaction
+type TxState string
+
+const (
+	TxPending   TxState = "pending"
+	TxDelivered TxState = "delivered"
+	TxFailed    TxState = "failed"
+)
+
+// TxStatus reports the state of a submitted transaction
+type TxStatus struct {
+	TxID            string   `json:"txid"`
+	State           TxState  `json:"state"`
+	Error           string   `json:"error,omitempty"`
+	SignatureHashes []string `json:"signatureHashes,omitempty"`
+}
+
+// Final reports whether the transaction is delivered or failed
+func (s *TxStatus) Final() bool {
+	return s.State == TxDelivered || s.State == TxFailed
+}
+
+// Confirmer waits for submitted transactions to be delivered
+type Confirmer struct {
+	submitter Submitter
+	timeout   time.Duration
+	interval  time.Duration
+}
+
+// NewConfirmer creates a confirmer that queries s
+func NewConfirmer(s Submitter) *Confirmer {
+	return &Confirmer{
+		submitter: s,
+		timeout:   DefaultConfirmTimeout,
+		interval:  DefaultConfirmInterval,
+	}
+}
+
+// WithTimeout sets how long Wait polls and how often. A zero timeout checks
+// the status once.
+func (c *Confirmer) WithTimeout(timeout, interval time.Duration) *Confirmer {
+	c.timeout = timeout
+	c.interval = interval
+	return c
+}
+
+type deadlineKey struct{}
+
+// Begin returns a context whose waits share one deadline, the timeout from
+// now. A request that waits for several transactions, such as a create
+// provisioning its accounts before writing the document, calls it once so
+// the waits together take no longer than a single one. A context that already
+// has a shared deadline is returned unchanged.
+func (c *Confirmer) Begin(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(deadlineKey{}).(time.Time); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, deadlineKey{}, time.Now().Add(c.timeout))
+}
+
+// Wait polls the status of txID until it is delivered or failed, or the
+// timeout elapses; within a context from Begin it stops at the shared
+// deadline instead. A transaction still pending at the timeout, or whose
+// status cannot be read, is reported as pending; the caller can query it
+// again later.
+func (c *Confirmer) Wait(ctx context.Context, txID string) *TxStatus {
+	deadline := time.Now().Add(c.timeout)
+	if shared, ok := ctx.Value(deadlineKey{}).(time.Time); ok {
+		deadline = shared
+	}
+	last := &TxStatus{TxID: txID, State: TxPending}
+	for {
+		status, err := c.submitter.TransactionStatus(ctx, txID)
+		if err == nil {
+			if status.Final() {
+				return status
+			}
+			last = status
+		} else {
+			slog.DebugContext(ctx, "Transaction status unavailable", "tx_id", txID, "error", err)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return last
+		}
+		timer := time.NewTimer(min(c.interval, remaining))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last
+		case <-timer.C:
+		}
+	}
+}
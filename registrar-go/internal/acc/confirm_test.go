@@ -0,0 +1,92 @@
+package acc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3"
+	accerrors "gitlab.com/accumulatenetwork/accumulate/pkg/errors"
+	"gitlab.com/accumulatenetwork/accumulate/protocol"
+)
+
+func TestConfirmer_Wait(t *testing.T) {
+	client := NewMockClient()
+	states := []TxState{TxPending, TxPending, TxDelivered}
+	calls := 0
+	client.TransactionStatusFn = func(txID string) (*TxStatus, error) {
+		state := states[min(calls, len(states)-1)]
+		calls++
+		return &TxStatus{TxID: txID, State: state, SignatureHashes: []string{"aa"}}, nil
+	}
+
+	status := NewConfirmer(client).WithTimeout(time.Second, time.Millisecond).Wait(context.Background(), "tx")
+	assert.Equal(t, TxDelivered, status.State)
+	assert.Equal(t, []string{"aa"}, status.SignatureHashes)
+	assert.Equal(t, 3, calls)
+}
+
+func TestConfirmer_TimesOutPending(t *testing.T) {
+	client := NewMockClient()
+	client.TransactionStatusFn = func(txID string) (*TxStatus, error) {
+		return nil, assert.AnError
+	}
+
+	status := NewConfirmer(client).WithTimeout(10*time.Millisecond, time.Millisecond).Wait(context.Background(), "tx")
+	assert.Equal(t, TxPending, status.State)
+	assert.Equal(t, "tx", status.TxID)
+}
+
+func TestConfirmer_ZeroTimeoutChecksOnce(t *testing.T) {
+	client := NewMockClient()
+	calls := 0
+	client.TransactionStatusFn = func(txID string) (*TxStatus, error) {
+		calls++
+		return &TxStatus{TxID: txID, State: TxFailed, Error: "rejected"}, nil
+	}
+
+	status := NewConfirmer(client).WithTimeout(0, time.Second).Wait(context.Background(), "tx")
+	assert.Equal(t, TxFailed, status.State)
+	assert.Equal(t, 1, calls)
+}
+
+func TestConfirmer_BeginSharesDeadline(t *testing.T) {
+	client := NewMockClient()
+	client.TransactionStatusFn = func(txID string) (*TxStatus, error) {
+		return &TxStatus{TxID: txID, State: TxPending}, nil
+	}
+	confirmer := NewConfirmer(client).WithTimeout(50*time.Millisecond, time.Millisecond)
+
+	ctx := confirmer.Begin(context.Background())
+	assert.Equal(t, ctx, confirmer.Begin(ctx), "the first deadline is kept")
+
+	start := time.Now()
+	for range 3 {
+		assert.Equal(t, TxPending, confirmer.Wait(ctx, "tx").State)
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "three waits share one timeout")
+}
+
+func TestFakeSubmitter_TransactionStatus(t *testing.T) {
+	client := NewFakeSubmitter()
+	txID, err := client.WriteDataEntry(context.Background(), "acc://alice.acme/did", []byte(`{}`))
+	require.NoError(t, err)
+
+	status, err := client.TransactionStatus(context.Background(), txID)
+	require.NoError(t, err)
+	assert.Equal(t, TxDelivered, status.State)
+
+	_, err = client.TransactionStatus(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+}
+
+func TestRejected(t *testing.T) {
+	assert.Error(t, rejected(nil))
+	assert.NoError(t, rejected([]*api.Submission{{Success: true}}))
+	assert.ErrorContains(t, rejected([]*api.Submission{{Success: true}, {Message: "bad signature"}}), "bad signature")
+
+	failed := &protocol.TransactionStatus{Code: 400, Error: &accerrors.Error{Message: "insufficient balance", Code: 400}}
+	assert.ErrorContains(t, rejected([]*api.Submission{{Success: true, Status: failed}}), "insufficient balance")
+}
@@ -0,0 +1,242 @@
+package acc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"gitlab.com/accumulatenetwork/accumulate/protocol"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+// CreditPurchaser buys credits for a key page. RealSubmitter spends ACME from
+// a lite token account; FakeSubmitter credits the simulated ledger.
+type CreditPurchaser interface {
+	AddCredits(ctx context.Context, keyPageURL string, credits uint64) (string, error)
+}
+
+// InsufficientCreditsError reports that the signing key page cannot pay for
+// an operation. Amounts are in credits x protocol.CreditPrecision.
+type InsufficientCreditsError struct {
+	KeyPage   string
+	Operation string
+	Balance   uint64
+	Required  uint64
+}
+
+func (e *InsufficientCreditsError) Error() string {
+	return fmt.Sprintf("insufficient credits on %s for %s: balance %s, required %s, shortfall %s",
+		e.KeyPage, e.Operation, FormatCredits(e.Balance), FormatCredits(e.Required), FormatCredits(e.Shortfall()))
+}
+
+// Shortfall returns the credits missing to pay for the operation
+func (e *InsufficientCreditsError) Shortfall() uint64 {
+	if e.Balance >= e.Required {
+		return 0
+	}
+	return e.Required - e.Balance
+}
+
+// FormatCredits formats an amount in credits x protocol.CreditPrecision as
+// whole credits, e.g. 2550 as "25.50"
+func FormatCredits(amount uint64) string {
+	return fmt.Sprintf("%d.%02d", amount/protocol.CreditPrecision, amount%protocol.CreditPrecision)
+}
+
+// EstimateCost returns the credits (x protocol.CreditPrecision) an operation
+// costs under the Accumulate fee schedule. dataSize is the entry size of a
+// writeData; it is ignored for other operations.
+func EstimateCost(txType string, dataSize int) uint64 {
+	switch txType {
+	case ledger.TxCreateIdentity:
+		return ledger.FeeCreateIdentity
+	case ledger.TxCreateDataAccount:
+		return ledger.FeeCreateDataAccount
+	case ledger.TxWriteData:
+		return ledger.WriteDataFee(dataSize)
+	case ledger.TxUpdateKeyPage:
+		return ledger.FeeUpdateKeyPage
+	}
+	return 0
+}
+
+// CreditGuard wraps a Submitter and checks the signing key page can pay for
+// each operation before submitting it, so callers get an
+// InsufficientCreditsError instead of a rejected transaction. With a
+// purchaser it first tops the page up when the operation would leave less
+// than the threshold.
+//
+// A balance that cannot be read (e.g. the page does not exist yet) is not
+// checked; the network has the final word.
+type CreditGuard struct {
+	next Submitter
+
+	mu        sync.Mutex // serialises top-ups
+	purchaser CreditPurchaser
+	threshold uint64
+	amount    uint64
+	confirmer *Confirmer
+}
+
+var _ Submitter = (*CreditGuard)(nil)
+
+// NewCreditGuard wraps next
+func NewCreditGuard(next Submitter) *CreditGuard {
+	return &CreditGuard{next: next, confirmer: NewConfirmer(next)}
+}
+
+// WithTopUp buys amount credits through purchaser whenever an operation would
+// leave the signer with less than threshold. Both are whole credits.
+func (g *CreditGuard) WithTopUp(purchaser CreditPurchaser, threshold, amount uint64) *CreditGuard {
+	g.purchaser = purchaser
+	g.threshold = threshold * protocol.CreditPrecision
+	g.amount = amount * protocol.CreditPrecision
+	return g
+}
+
+// WithConfirmer replaces the confirmer that waits for each top-up
+func (g *CreditGuard) WithConfirmer(confirmer *Confirmer) *CreditGuard {
+	g.confirmer = confirmer
+	return g
+}
+
+// Unwrap returns the wrapped submitter
+func (g *CreditGuard) Unwrap() Submitter {
+	return g.next
+}
+
+// ensure checks keyPageURL can pay cost for txType, topping it up first when
+// configured
+func (g *CreditGuard) ensure(ctx context.Context, txType, keyPageURL string, cost uint64) error {
+	state, err := g.next.GetKeyPageState(ctx, keyPageURL)
+	if err != nil {
+		slog.DebugContext(ctx, "Skipping credit check", "key_page", keyPageURL, "error", err)
+		return nil
+	}
+	balance := state.CreditBalance
+
+	if g.purchaser != nil && g.amount > 0 && balance < cost+g.threshold {
+		g.mu.Lock()
+		balance, err = g.topUp(ctx, keyPageURL, cost)
+		g.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	if balance < cost {
+		return &InsufficientCreditsError{KeyPage: keyPageURL, Operation: txType, Balance: balance, Required: cost}
+	}
+	return nil
+}
+
+// topUp buys credits for keyPageURL, at least enough to pay cost, waits for
+// the purchase and returns the balance read afterwards, so an operation is
+// only submitted once the credits are on the page. The caller holds g.mu.
+func (g *CreditGuard) topUp(ctx context.Context, keyPageURL string, cost uint64) (uint64, error) {
+	// Another request may have topped up while this one waited
+	state, err := g.next.GetKeyPageState(ctx, keyPageURL)
+	if err != nil {
+		return 0, err
+	}
+	balance := state.CreditBalance
+	if balance >= cost+g.threshold {
+		return balance, nil
+	}
+
+	amount := g.amount
+	if balance+amount < cost {
+		amount = cost - balance
+	}
+	txID, err := g.purchaser.AddCredits(ctx, keyPageURL, amount)
+	if err != nil {
+		// Fall through to the balance check; the operation may still be affordable
+		slog.WarnContext(ctx, "Credit top-up failed", "key_page", keyPageURL, "credits", FormatCredits(amount), "error", err)
+		return balance, nil
+	}
+	status := g.confirmer.Wait(ctx, txID)
+	switch status.State {
+	case TxDelivered:
+		slog.InfoContext(ctx, "Credits purchased", "key_page", keyPageURL, "credits", FormatCredits(amount), "tx_id", txID)
+	case TxFailed:
+		slog.WarnContext(ctx, "Credit top-up failed", "key_page", keyPageURL, "credits", FormatCredits(amount), "tx_id", txID, "error", status.Error)
+		return balance, nil
+	default:
+		slog.WarnContext(ctx, "Credit top-up not delivered yet", "key_page", keyPageURL, "credits", FormatCredits(amount), "tx_id", txID)
+	}
+
+	// A pending purchase may still land between the wait and this read
+	state, err = g.next.GetKeyPageState(ctx, keyPageURL)
+	if err != nil {
+		return balance, nil
+	}
+	return state.CreditBalance, nil
+}
+
+// CreateIdentity is signed by keyPageURL
+func (g *CreditGuard) CreateIdentity(ctx context.Context, adiLabel string, keyPageURL string) (string, error) {
+	if err := g.ensure(ctx, ledger.TxCreateIdentity, keyPageURL, EstimateCost(ledger.TxCreateIdentity, 0)); err != nil {
+		return "", err
+	}
+	return g.next.CreateIdentity(ctx, adiLabel, keyPageURL)
+}
+
+func (g *CreditGuard) CreateDataAccount(ctx context.Context, adiURL, dataAccountLabel string) (string, error) {
+	if err := g.ensure(ctx, ledger.TxCreateDataAccount, signerPage(adiURL), EstimateCost(ledger.TxCreateDataAccount, 0)); err != nil {
+		return "", err
+	}
+	return g.next.CreateDataAccount(ctx, adiURL, dataAccountLabel)
+}
+
+func (g *CreditGuard) WriteDataEntry(ctx context.Context, dataAccountURL string, data []byte) (string, error) {
+	if err := g.ensure(ctx, ledger.TxWriteData, signerPage(dataAccountURL), EstimateCost(ledger.TxWriteData, len(data))); err != nil {
+		return "", err
+	}
+	return g.next.WriteDataEntry(ctx, dataAccountURL, data)
+}
+
+// SubmitWriteData estimates the cost from the marshalled document, which is
+// what the submitters write
+func (g *CreditGuard) SubmitWriteData(ctx context.Context, dataAccountURL string, envelope *ops.Envelope) (string, error) {
+	data, err := json.Marshal(envelope.Document)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal document: %w", err)
+	}
+	if err := g.ensure(ctx, ledger.TxWriteData, signerPage(dataAccountURL), EstimateCost(ledger.TxWriteData, len(data))); err != nil {
+		return "", err
+	}
+	return g.next.SubmitWriteData(ctx, dataAccountURL, envelope)
+}
+
+func (g *CreditGuard) UpdateKeyPage(ctx context.Context, keyPageURL string, operations []KeyPageOperation) (string, error) {
+	if err := g.ensure(ctx, ledger.TxUpdateKeyPage, keyPageURL, EstimateCost(ledger.TxUpdateKeyPage, 0)); err != nil {
+		return "", err
+	}
+	return g.next.UpdateKeyPage(ctx, keyPageURL, operations)
+}
+
+func (g *CreditGuard) GetKeyPageState(ctx context.Context, keyPageURL string) (*KeyPageState, error) {
+	return g.next.GetKeyPageState(ctx, keyPageURL)
+}
+
+func (g *CreditGuard) AccountExists(ctx context.Context, accountURL string) (bool, error) {
+	return g.next.AccountExists(ctx, accountURL)
+}
+
+func (g *CreditGuard) TransactionStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	return g.next.TransactionStatus(ctx, txID)
+}
+
+func (g *CreditGuard) LatestDataEntry(ctx context.Context, dataAccountURL string) ([]byte, error) {
+	return g.next.LatestDataEntry(ctx, dataAccountURL)
+}
+
+// signerPage returns the key page the submitters sign with for an account:
+// the first page of its ADI's book
+func signerPage(accountURL string) string {
+	return adiOf(accountURL) + "/book/1"
+}
@@ -0,0 +1,120 @@
+package acc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/accumulatenetwork/accumulate/protocol"
+
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+func TestEstimateCost(t *testing.T) {
+	assert.Equal(t, uint64(50000), EstimateCost(ledger.TxCreateIdentity, 0))
+	assert.Equal(t, uint64(2500), EstimateCost(ledger.TxCreateDataAccount, 0))
+	assert.Equal(t, uint64(10), EstimateCost(ledger.TxWriteData, 0))
+	assert.Equal(t, uint64(10), EstimateCost(ledger.TxWriteData, 256))
+	assert.Equal(t, uint64(20), EstimateCost(ledger.TxWriteData, 257))
+	assert.Equal(t, uint64(300), EstimateCost(ledger.TxUpdateKeyPage, 0))
+}
+
+func TestAcmeForCredits(t *testing.T) {
+	// At $0.50 per ACME, 50 credits ($0.50) cost one ACME
+	assert.Equal(t, uint64(100000000), acmeForCredits(5000, 5000))
+	// Round up rather than underpay
+	assert.Equal(t, uint64(33333334), acmeForCredits(1, 3))
+}
+
+func TestCreditGuard_RejectsWithShortfall(t *testing.T) {
+	mock := NewMockClient()
+	mock.GetKeyPageStateFn = func(keyPageURL string) (*KeyPageState, error) {
+		return &KeyPageState{URL: keyPageURL, CreditBalance: 1000}, nil
+	}
+	submitted := false
+	mock.CreateDataAccountFn = func(adiURL, dataAccountLabel string) (string, error) {
+		submitted = true
+		return "tx", nil
+	}
+
+	guard := NewCreditGuard(mock)
+	_, err := guard.CreateDataAccount(context.Background(), "acc://alice.acme", "did")
+
+	var credits *InsufficientCreditsError
+	require.True(t, errors.As(err, &credits), "got %v", err)
+	assert.Equal(t, "acc://alice.acme/book/1", credits.KeyPage)
+	assert.Equal(t, ledger.TxCreateDataAccount, credits.Operation)
+	assert.Equal(t, uint64(1500), credits.Shortfall())
+	assert.Contains(t, err.Error(), "shortfall 15.00")
+	assert.False(t, submitted, "an unaffordable operation must not be submitted")
+
+	// Small writes are still affordable
+	_, err = guard.WriteDataEntry(context.Background(), "acc://alice.acme/did", []byte("{}"))
+	assert.NoError(t, err)
+}
+
+func TestCreditGuard_UnknownBalance(t *testing.T) {
+	mock := NewMockClient()
+	mock.GetKeyPageStateFn = func(keyPageURL string) (*KeyPageState, error) {
+		return nil, errors.New("not found")
+	}
+
+	_, err := NewCreditGuard(mock).CreateIdentity(context.Background(), "alice.acme", "acc://alice.acme/book/1")
+	assert.NoError(t, err)
+}
+
+func TestCreditGuard_TopUp(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.New().WithInitialCredits(0)
+	fake := NewFakeSubmitterWithLedger(l)
+	_, err := l.CreateIdentity("acc://bob.acme", "")
+	require.NoError(t, err)
+
+	// Without top-ups the empty page is rejected
+	_, err = NewCreditGuard(fake).CreateDataAccount(ctx, "acc://bob.acme", "did")
+	var credits *InsufficientCreditsError
+	require.True(t, errors.As(err, &credits), "got %v", err)
+
+	guard := NewCreditGuard(fake).WithTopUp(fake, 10, 100)
+	_, err = guard.CreateDataAccount(ctx, "acc://bob.acme", "did")
+	require.NoError(t, err)
+
+	page, err := l.KeyPage("acc://bob.acme/book/1")
+	require.NoError(t, err)
+	assert.Equal(t, 100*protocol.CreditPrecision-ledger.FeeCreateDataAccount, page.CreditBalance, "100 credits bought, 25 spent")
+
+	// Above the threshold nothing is bought
+	height := l.Height()
+	_, err = guard.WriteDataEntry(ctx, "acc://bob.acme/did", []byte("{}"))
+	require.NoError(t, err)
+	assert.Equal(t, height+1, l.Height())
+}
+
+// pendingPurchaser accepts every purchase but never delivers it
+type pendingPurchaser struct{ calls int }
+
+func (p *pendingPurchaser) AddCredits(ctx context.Context, keyPageURL string, credits uint64) (string, error) {
+	p.calls++
+	return "pending-topup", nil
+}
+
+func TestCreditGuard_TopUpNotDelivered(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.New().WithInitialCredits(0)
+	fake := NewFakeSubmitterWithLedger(l)
+	_, err := l.CreateIdentity("acc://bob.acme", "")
+	require.NoError(t, err)
+
+	purchaser := &pendingPurchaser{}
+	guard := NewCreditGuard(fake).WithTopUp(purchaser, 10, 100).
+		WithConfirmer(NewConfirmer(fake).WithTimeout(0, 0))
+	height := l.Height()
+	_, err = guard.CreateDataAccount(ctx, "acc://bob.acme", "did")
+
+	var credits *InsufficientCreditsError
+	require.True(t, errors.As(err, &credits), "an accepted but undelivered top-up does not pay; got %v", err)
+	assert.Equal(t, 1, purchaser.calls)
+	assert.Equal(t, height, l.Height(), "nothing is submitted")
+}
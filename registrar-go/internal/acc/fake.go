@@ -0,0 +1,254 @@
+package acc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+// FakeSubmitter implements Submitter interface on top of an in-process
+// ledger simulator. Share the ledger with the resolver's FakeClient to
+// resolve documents as soon as they are written.
+type FakeSubmitter struct {
+	ledger *ledger.Ledger
+}
+
+// MockTransaction represents a transaction applied to the fake ledger
+type MockTransaction struct {
+	ID        string
+	Status    string
+	Timestamp time.Time
+	Data      interface{}
+}
+
+// NewFakeSubmitter creates a fake submitter with an empty ledger
+func NewFakeSubmitter() *FakeSubmitter {
+	return NewFakeSubmitterWithLedger(ledger.New())
+}
+
+// NewFakeSubmitterWithLedger creates a fake submitter that writes to l
+func NewFakeSubmitterWithLedger(l *ledger.Ledger) *FakeSubmitter {
+	return &FakeSubmitter{ledger: l}
+}
+
+// Ledger returns the simulator backing the submitter
+func (c *FakeSubmitter) Ledger() *ledger.Ledger {
+	return c.ledger
+}
+
+// CreateIdentity creates a new ADI with keyPageURL as its first key page
+func (c *FakeSubmitter) CreateIdentity(ctx context.Context, adiLabel string, keyPageURL string) (string, error) {
+	tx, err := c.ledger.CreateIdentity("acc://"+adiLabel, keyPageURL)
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
+
+// CreateDataAccount creates a new data account, signed by the ADI's first key page
+func (c *FakeSubmitter) CreateDataAccount(ctx context.Context, adiURL, dataAccountLabel string) (string, error) {
+	tx, err := c.ledger.CreateDataAccount(adiURL+"/"+dataAccountLabel, c.signer(adiURL))
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
+
+// WriteDataEntry appends data to a data account
+func (c *FakeSubmitter) WriteDataEntry(ctx context.Context, dataAccountURL string, data []byte) (string, error) {
+	if err := c.provision(dataAccountURL); err != nil {
+		return "", err
+	}
+
+	tx, _, err := c.ledger.WriteData(dataAccountURL, c.signer(dataAccountURL), data)
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
+
+// SubmitWriteData writes the envelope's document, as RealSubmitter does, and
+// records the transaction ID in the envelope
+func (c *FakeSubmitter) SubmitWriteData(ctx context.Context, dataAccountURL string, envelope *ops.Envelope) (string, error) {
+	data, err := json.Marshal(envelope.Document)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal document: %w", err)
+	}
+
+	txID, err := c.WriteDataEntry(ctx, dataAccountURL, data)
+	if err != nil {
+		return "", err
+	}
+
+	envelope.SetTransactionID(txID)
+	return txID, nil
+}
+
+// UpdateKeyPage applies key operations to a key page
+func (c *FakeSubmitter) UpdateKeyPage(ctx context.Context, keyPageURL string, operations []KeyPageOperation) (string, error) {
+	adiURL := adiOf(keyPageURL)
+	if _, err := c.ledger.Identity(adiURL); errors.Is(err, ledger.ErrNotFound) {
+		if _, err := c.ledger.CreateIdentity(adiURL, keyPageURL); err != nil {
+			return "", err
+		}
+	}
+
+	keyOps := make([]ledger.KeyOperation, len(operations))
+	for i, op := range operations {
+		keyOps[i] = ledger.KeyOperation{Type: op.Type, PublicKey: op.PublicKey, KeyType: op.KeyType}
+	}
+
+	tx, err := c.ledger.UpdateKeyPage(keyPageURL, keyOps)
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
+
+// fakeCreditSource is the lite token account FakeSubmitter buys credits from
+const fakeCreditSource = "acc://fake-faucet/ACME"
+
+// AddCredits credits a key page; the simulated purchase always succeeds
+func (c *FakeSubmitter) AddCredits(ctx context.Context, keyPageURL string, credits uint64) (string, error) {
+	tx, err := c.ledger.AddCredits(keyPageURL, fakeCreditSource, credits)
+	if err != nil {
+		return "", err
+	}
+	return tx.ID, nil
+}
+
+// GetKeyPageState returns the current state of a key page
+func (c *FakeSubmitter) GetKeyPageState(ctx context.Context, keyPageURL string) (*KeyPageState, error) {
+	page, err := c.ledger.KeyPage(keyPageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &KeyPageState{
+		URL:           page.URL,
+		Threshold:     page.Threshold,
+		Keys:          []KeyInfo{},
+		Height:        page.Version,
+		CreditBalance: page.CreditBalance,
+	}
+	for _, key := range page.Keys {
+		state.Keys = append(state.Keys, KeyInfo{PublicKey: key.PublicKey, KeyType: key.KeyType})
+	}
+	return state, nil
+}
+
+// AccountExists reports whether an ADI, key page or data account exists
+func (c *FakeSubmitter) AccountExists(ctx context.Context, accountURL string) (bool, error) {
+	lookups := []func() error{
+		func() error { _, err := c.ledger.Identity(accountURL); return err },
+		func() error { _, err := c.ledger.KeyPage(accountURL); return err },
+		func() error { _, err := c.ledger.Entries(accountURL); return err },
+	}
+	for _, lookup := range lookups {
+		err := lookup()
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, ledger.ErrNotFound) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// TransactionStatus reports a ledger transaction as delivered; the
+// simulator applies transactions as they are submitted
+func (c *FakeSubmitter) TransactionStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	if _, err := c.ledger.Transaction(txID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotFound, txID)
+	}
+	return &TxStatus{TxID: txID, State: TxDelivered}, nil
+}
+
+// LatestDataEntry returns the most recent entry of a data account
+func (c *FakeSubmitter) LatestDataEntry(ctx context.Context, dataAccountURL string) ([]byte, error) {
+	entry, err := c.ledger.Latest(dataAccountURL)
+	if errors.Is(err, ledger.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrNoDataEntry, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry.Data, nil
+}
+
+// GetTransaction returns a transaction by ID (for testing)
+func (c *FakeSubmitter) GetTransaction(txID string) (*MockTransaction, error) {
+	tx, err := c.ledger.Transaction(txID)
+	if err != nil {
+		return nil, err
+	}
+	return toMockTransaction(tx), nil
+}
+
+// ListTransactions returns all transactions (for testing)
+func (c *FakeSubmitter) ListTransactions() map[string]*MockTransaction {
+	transactions := make(map[string]*MockTransaction)
+	for _, tx := range c.ledger.Transactions() {
+		transactions[tx.ID] = toMockTransaction(tx)
+	}
+	return transactions
+}
+
+// provision creates the ADI and data account behind dataAccountURL when they
+// do not exist yet. The /update and /deactivate endpoints write without
+// creating accounts first; against a real network they fail on a DID that
+// was never created, in FAKE mode the write should simply succeed.
+func (c *FakeSubmitter) provision(dataAccountURL string) error {
+	adiURL := adiOf(dataAccountURL)
+	if _, err := c.ledger.Identity(adiURL); errors.Is(err, ledger.ErrNotFound) {
+		if _, err := c.ledger.CreateIdentity(adiURL, ""); err != nil && !errors.Is(err, ledger.ErrExists) {
+			return err
+		}
+	}
+	if _, err := c.ledger.Entries(dataAccountURL); errors.Is(err, ledger.ErrNotFound) {
+		if _, err := c.ledger.CreateDataAccount(dataAccountURL, c.signer(adiURL)); err != nil && !errors.Is(err, ledger.ErrExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// signer returns the first key page of the ADI that owns accountURL, the
+// page RealSubmitter signs with
+func (c *FakeSubmitter) signer(accountURL string) string {
+	adiURL := adiOf(accountURL)
+	if adi, err := c.ledger.Identity(adiURL); err == nil {
+		return adi.KeyBook + "/1"
+	}
+	return adiURL + "/book/1"
+}
+
+// adiOf returns the ADI URL of an account URL
+func adiOf(accountURL string) string {
+	rest := strings.TrimPrefix(ledger.Normalize(accountURL), "acc://")
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest = rest[:i]
+	}
+	return "acc://" + rest
+}
+
+func toMockTransaction(tx ledger.Transaction) *MockTransaction {
+	return &MockTransaction{
+		ID:        tx.ID,
+		Status:    "committed",
+		Timestamp: tx.Timestamp,
+		Data: map[string]interface{}{
+			"type":      tx.Type,
+			"principal": tx.Principal,
+			"signer":    tx.Signer,
+			"height":    tx.Height,
+		},
+	}
+}
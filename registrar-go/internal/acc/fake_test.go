@@ -0,0 +1,80 @@
+package acc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+func TestFakeSubmitter_RegisterFlow(t *testing.T) {
+	ctx := context.Background()
+	l := ledger.New()
+	fake := NewFakeSubmitterWithLedger(l)
+
+	adiTx, err := fake.CreateIdentity(ctx, "alice.acme", "acc://alice.acme/book/1")
+	require.NoError(t, err)
+	_, err = fake.CreateIdentity(ctx, "alice.acme", "acc://alice.acme/book/1")
+	assert.ErrorIs(t, err, ledger.ErrExists)
+
+	_, err = fake.CreateDataAccount(ctx, "acc://alice.acme", "did")
+	require.NoError(t, err)
+
+	txID, err := fake.WriteDataEntry(ctx, "acc://alice.acme/did", []byte(`{"id":"did:acc:alice.acme"}`))
+	require.NoError(t, err)
+
+	entry, err := l.Latest("acc://alice.acme/did")
+	require.NoError(t, err)
+	assert.Equal(t, txID, entry.TxID)
+	assert.Equal(t, uint64(1), entry.Sequence)
+	assert.Equal(t, "acc://alice.acme/book/1", entry.Signer)
+
+	tx, err := fake.GetTransaction(adiTx)
+	require.NoError(t, err)
+	assert.Equal(t, "committed", tx.Status)
+	assert.Len(t, fake.ListTransactions(), 3)
+
+	state, err := fake.GetKeyPageState(ctx, "acc://alice.acme/book/1")
+	require.NoError(t, err)
+	assert.Less(t, state.CreditBalance, ledger.DefaultInitialCredits)
+}
+
+func TestFakeSubmitter_SubmitWriteDataProvisions(t *testing.T) {
+	fake := NewFakeSubmitter()
+
+	envelope, err := ops.BuildEnvelope(map[string]interface{}{
+		"@context": []interface{}{"https://www.w3.org/ns/did/v1"},
+		"id":       "did:acc:bob.acme",
+	}, "acc://bob.acme/book/1", "")
+	require.NoError(t, err)
+
+	txID, err := fake.SubmitWriteData(context.Background(), "acc://bob.acme/did", envelope)
+	require.NoError(t, err)
+	assert.Equal(t, txID, envelope.Meta.Proof.TxID)
+
+	entry, err := fake.Ledger().Latest("acc://bob.acme/did")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"@context":["https://www.w3.org/ns/did/v1"],"id":"did:acc:bob.acme"}`, string(entry.Data))
+}
+
+func TestFakeSubmitter_UpdateKeyPage(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeSubmitter()
+
+	_, err := fake.UpdateKeyPage(ctx, "acc://carol.acme/book/1", []KeyPageOperation{
+		{Type: "add", PublicKey: "ed25519:cc", KeyType: "ed25519"},
+	})
+	require.NoError(t, err)
+
+	state, err := fake.GetKeyPageState(ctx, "acc://carol.acme/book/1")
+	require.NoError(t, err)
+	assert.Equal(t, []KeyInfo{{PublicKey: "ed25519:cc", KeyType: "ed25519"}}, state.Keys)
+	assert.Equal(t, uint64(2), state.Height)
+
+	_, err = fake.GetKeyPageState(ctx, "acc://nobody.acme/book/1")
+	assert.ErrorIs(t, err, ledger.ErrNotFound)
+}
@@ -0,0 +1,159 @@
+package acc
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+	"github.com/opendlt/accu-did/registrar-go/internal/telemetry"
+)
+
+var tracer = otel.Tracer(telemetry.TracerName + "/acc")
+
+// InstrumentedSubmitter wraps a Submitter with a span and submission metrics
+// for every operation
+type InstrumentedSubmitter struct {
+	next    Submitter
+	metrics *telemetry.Metrics
+}
+
+var _ Submitter = (*InstrumentedSubmitter)(nil)
+
+// NewInstrumentedSubmitter wraps next. metrics may be nil.
+func NewInstrumentedSubmitter(next Submitter, metrics *telemetry.Metrics) *InstrumentedSubmitter {
+	return &InstrumentedSubmitter{next: next, metrics: metrics}
+}
+
+// Unwrap returns the wrapped submitter
+func (s *InstrumentedSubmitter) Unwrap() Submitter {
+	return s.next
+}
+
+// Unwrap strips any decorators and returns the underlying submitter, so
+// callers can still detect FAKE mode with a type assertion
+func Unwrap(s Submitter) Submitter {
+	for {
+		w, ok := s.(interface{ Unwrap() Submitter })
+		if !ok {
+			return s
+		}
+		s = w.Unwrap()
+	}
+}
+
+func (s *InstrumentedSubmitter) start(ctx context.Context, txType, account string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "acc."+txType,
+		trace.WithAttributes(
+			attribute.String("accumulate.tx_type", txType),
+			attribute.String("accumulate.account", account),
+		),
+	)
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		s.metrics.ObserveSubmission(txType, err, time.Since(start))
+	}
+}
+
+func (s *InstrumentedSubmitter) CreateIdentity(ctx context.Context, adiLabel string, keyPageURL string) (txID string, err error) {
+	ctx, done := s.start(ctx, "createIdentity", adiLabel)
+	defer func() { done(err) }()
+	return s.next.CreateIdentity(ctx, adiLabel, keyPageURL)
+}
+
+func (s *InstrumentedSubmitter) CreateDataAccount(ctx context.Context, adiURL, dataAccountLabel string) (txID string, err error) {
+	ctx, done := s.start(ctx, "createDataAccount", adiURL)
+	defer func() { done(err) }()
+	return s.next.CreateDataAccount(ctx, adiURL, dataAccountLabel)
+}
+
+func (s *InstrumentedSubmitter) WriteDataEntry(ctx context.Context, dataAccountURL string, data []byte) (txID string, err error) {
+	ctx, done := s.start(ctx, "writeData", dataAccountURL)
+	defer func() { done(err) }()
+	return s.next.WriteDataEntry(ctx, dataAccountURL, data)
+}
+
+func (s *InstrumentedSubmitter) SubmitWriteData(ctx context.Context, dataAccountURL string, envelope *ops.Envelope) (txID string, err error) {
+	ctx, done := s.start(ctx, "writeData", dataAccountURL)
+	defer func() { done(err) }()
+	return s.next.SubmitWriteData(ctx, dataAccountURL, envelope)
+}
+
+func (s *InstrumentedSubmitter) UpdateKeyPage(ctx context.Context, keyPageURL string, operations []KeyPageOperation) (txID string, err error) {
+	ctx, done := s.start(ctx, "updateKeyPage", keyPageURL)
+	defer func() { done(err) }()
+	return s.next.UpdateKeyPage(ctx, keyPageURL, operations)
+}
+
+// GetKeyPageState is a query, so it is traced but not counted as a submission
+func (s *InstrumentedSubmitter) GetKeyPageState(ctx context.Context, keyPageURL string) (*KeyPageState, error) {
+	ctx, span := tracer.Start(ctx, "acc.getKeyPageState",
+		trace.WithAttributes(attribute.String("accumulate.account", keyPageURL)),
+	)
+	defer span.End()
+
+	state, err := s.next.GetKeyPageState(ctx, keyPageURL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return state, err
+}
+
+// AccountExists is a query, so it is traced but not counted as a submission
+func (s *InstrumentedSubmitter) AccountExists(ctx context.Context, accountURL string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "acc.accountExists",
+		trace.WithAttributes(attribute.String("accumulate.account", accountURL)),
+	)
+	defer span.End()
+
+	exists, err := s.next.AccountExists(ctx, accountURL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return exists, err
+}
+
+// TransactionStatus is a query, so it is traced but not counted as a
+// submission
+func (s *InstrumentedSubmitter) TransactionStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	ctx, span := tracer.Start(ctx, "acc.transactionStatus",
+		trace.WithAttributes(attribute.String("accumulate.tx_id", txID)),
+	)
+	defer span.End()
+
+	status, err := s.next.TransactionStatus(ctx, txID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.String("accumulate.tx_state", string(status.State)))
+	}
+	return status, err
+}
+
+// LatestDataEntry is a query, so it is traced but not counted as a submission
+func (s *InstrumentedSubmitter) LatestDataEntry(ctx context.Context, dataAccountURL string) ([]byte, error) {
+	ctx, span := tracer.Start(ctx, "acc.latestDataEntry",
+		trace.WithAttributes(attribute.String("accumulate.account", dataAccountURL)),
+	)
+	defer span.End()
+
+	data, err := s.next.LatestDataEntry(ctx, dataAccountURL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return data, err
+}
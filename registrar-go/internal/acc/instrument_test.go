@@ -0,0 +1,42 @@
+package acc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/telemetry"
+)
+
+func TestInstrumentedSubmitter_SpansAndUnwrap(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tel := telemetry.NewWithExporter(telemetry.DefaultConfig(), exporter)
+	defer tel.Shutdown(context.Background())
+
+	mock := NewMockClient()
+	mock.CreateIdentityFn = func(adiLabel string, keyPageURL string) (string, error) {
+		return "", errors.New("insufficient credits")
+	}
+
+	s := NewInstrumentedSubmitter(mock, tel.Metrics)
+
+	_, err := s.WriteDataEntry(context.Background(), "acc://alice.acme/did", []byte("{}"))
+	require.NoError(t, err)
+	_, err = s.CreateIdentity(context.Background(), "alice.acme", "acc://alice.acme/book/1")
+	require.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "acc.writeData", spans[0].Name)
+	assert.Equal(t, "acc.createIdentity", spans[1].Name)
+	assert.Len(t, spans[1].Events, 1, "error should be recorded on the span")
+
+	// FAKE mode detection must see through the decorator
+	fake := NewFakeSubmitter()
+	_, ok := Unwrap(NewInstrumentedSubmitter(fake, nil)).(*FakeSubmitter)
+	assert.True(t, ok)
+}
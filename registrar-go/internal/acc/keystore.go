@@ -0,0 +1,46 @@
+package acc
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadKeyStoreFile reads a JSON key store into a DefaultSignerHook. The file
+// maps key page URLs to hex-encoded Ed25519 private keys (64 bytes) or seeds
+// (32 bytes):
+//
+//	{"acc://alice.acme/book/1": "9d61b19d..."}
+func LoadKeyStoreFile(path string) (*DefaultSignerHook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key store: %w", err)
+	}
+
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse key store %s: %w", path, err)
+	}
+
+	hook := NewDefaultSignerHook()
+	for keyPageURL, encoded := range entries {
+		raw, err := hex.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key store entry %s: invalid hex: %w", keyPageURL, err)
+		}
+
+		switch len(raw) {
+		case ed25519.SeedSize:
+			hook.RegisterKey(keyPageURL, ed25519.NewKeyFromSeed(raw))
+		case ed25519.PrivateKeySize:
+			hook.RegisterKey(keyPageURL, ed25519.PrivateKey(raw))
+		default:
+			return nil, fmt.Errorf("key store entry %s: expected %d or %d bytes, got %d",
+				keyPageURL, ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+		}
+	}
+
+	return hook, nil
+}
@@ -1,6 +1,11 @@
 package acc
 
-import "github.com/opendlt/accu-did/registrar-go/internal/ops"
+import (
+	"context"
+	"fmt"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+)
 
 type MockClient struct {
 	CreateIdentityFn    func(adiLabel string, keyPageURL string) (string, error)
@@ -9,30 +14,44 @@ type MockClient struct {
 	SubmitWriteDataFn   func(dataAccountURL string, payload *ops.Envelope) (string, error)
 	UpdateKeyPageFn     func(keyPageURL string, operations []KeyPageOperation) (string, error)
 	GetKeyPageStateFn   func(keyPageURL string) (*KeyPageState, error)
+	AccountExistsFn     func(accountURL string) (bool, error)
+	TransactionStatusFn func(txID string) (*TxStatus, error)
+	LatestDataEntryFn   func(dataAccountURL string) ([]byte, error)
 
 	// Recorded values for test inspection
 	LastWriteData  []byte
 	LastEnvelope   *ops.Envelope
 	LastAccountURL string
+	Created        []string // accounts created through the mock, in order
 }
 
 var _ Submitter = (*MockClient)(nil)
 
-func (m *MockClient) CreateIdentity(adiLabel string, keyPageURL string) (string, error) {
+func (m *MockClient) CreateIdentity(ctx context.Context, adiLabel string, keyPageURL string) (string, error) {
+	txID := "txid-create-identity-mock"
 	if m.CreateIdentityFn != nil {
-		return m.CreateIdentityFn(adiLabel, keyPageURL)
+		var err error
+		if txID, err = m.CreateIdentityFn(adiLabel, keyPageURL); err != nil {
+			return "", err
+		}
 	}
-	return "txid-create-identity-mock", nil
+	m.Created = append(m.Created, "acc://"+adiLabel)
+	return txID, nil
 }
 
-func (m *MockClient) CreateDataAccount(adiURL, dataAccountLabel string) (string, error) {
+func (m *MockClient) CreateDataAccount(ctx context.Context, adiURL, dataAccountLabel string) (string, error) {
+	txID := "txid-create-data-account-mock"
 	if m.CreateDataAccountFn != nil {
-		return m.CreateDataAccountFn(adiURL, dataAccountLabel)
+		var err error
+		if txID, err = m.CreateDataAccountFn(adiURL, dataAccountLabel); err != nil {
+			return "", err
+		}
 	}
-	return "txid-create-data-account-mock", nil
+	m.Created = append(m.Created, adiURL+"/"+dataAccountLabel)
+	return txID, nil
 }
 
-func (m *MockClient) WriteDataEntry(dataAccountURL string, data []byte) (string, error) {
+func (m *MockClient) WriteDataEntry(ctx context.Context, dataAccountURL string, data []byte) (string, error) {
 	// record for tests
 	m.LastAccountURL = dataAccountURL
 	m.LastWriteData = data
@@ -43,7 +62,7 @@ func (m *MockClient) WriteDataEntry(dataAccountURL string, data []byte) (string,
 	return "txid-write-data-mock", nil
 }
 
-func (m *MockClient) SubmitWriteData(dataAccountURL string, payload *ops.Envelope) (string, error) {
+func (m *MockClient) SubmitWriteData(ctx context.Context, dataAccountURL string, payload *ops.Envelope) (string, error) {
 	// record for tests
 	m.LastAccountURL = dataAccountURL
 	m.LastEnvelope = payload
@@ -54,14 +73,14 @@ func (m *MockClient) SubmitWriteData(dataAccountURL string, payload *ops.Envelop
 	return "txid-submit-write-mock", nil
 }
 
-func (m *MockClient) UpdateKeyPage(keyPageURL string, operations []KeyPageOperation) (string, error) {
+func (m *MockClient) UpdateKeyPage(ctx context.Context, keyPageURL string, operations []KeyPageOperation) (string, error) {
 	if m.UpdateKeyPageFn != nil {
 		return m.UpdateKeyPageFn(keyPageURL, operations)
 	}
 	return "txid-update-key-page-mock", nil
 }
 
-func (m *MockClient) GetKeyPageState(keyPageURL string) (*KeyPageState, error) {
+func (m *MockClient) GetKeyPageState(ctx context.Context, keyPageURL string) (*KeyPageState, error) {
 	if m.GetKeyPageStateFn != nil {
 		return m.GetKeyPageStateFn(keyPageURL)
 	}
@@ -73,6 +92,41 @@ func (m *MockClient) GetKeyPageState(keyPageURL string) (*KeyPageState, error) {
 	}, nil
 }
 
+// AccountExists reports accounts created through the mock unless
+// AccountExistsFn is set
+func (m *MockClient) AccountExists(ctx context.Context, accountURL string) (bool, error) {
+	if m.AccountExistsFn != nil {
+		return m.AccountExistsFn(accountURL)
+	}
+	for _, created := range m.Created {
+		if created == accountURL {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TransactionStatus reports every transaction delivered unless
+// TransactionStatusFn is set
+func (m *MockClient) TransactionStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	if m.TransactionStatusFn != nil {
+		return m.TransactionStatusFn(txID)
+	}
+	return &TxStatus{TxID: txID, State: TxDelivered}, nil
+}
+
+// LatestDataEntry returns the last data written through the mock unless
+// LatestDataEntryFn is set
+func (m *MockClient) LatestDataEntry(ctx context.Context, dataAccountURL string) ([]byte, error) {
+	if m.LatestDataEntryFn != nil {
+		return m.LatestDataEntryFn(dataAccountURL)
+	}
+	if m.LastWriteData == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoDataEntry, dataAccountURL)
+	}
+	return m.LastWriteData, nil
+}
+
 func NewMockClient() *MockClient {
 	return &MockClient{}
 }
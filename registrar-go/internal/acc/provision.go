@@ -0,0 +1,164 @@
+package acc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+// Provisioning reports the accounts behind a DID and the transactions that
+// created them. A transaction ID is empty when the account already existed.
+type Provisioning struct {
+	ADI             string `json:"adi"`
+	ADITxID         string `json:"adiTxId,omitempty"`
+	DataAccount     string `json:"dataAccount"`
+	DataAccountTxID string `json:"dataAccountTxId,omitempty"`
+}
+
+// ErrProvisionPending is wrapped by the ProvisionError of a step whose
+// transaction was submitted but not delivered before the wait ended. Repeating
+// the request waits for that transaction instead of submitting another one.
+var ErrProvisionPending = errors.New("not delivered yet")
+
+// ProvisionError reports the step that failed and what was provisioned
+// before it. Those accounts stay on chain, so repeating the request resumes
+// from the failed step.
+type ProvisionError struct {
+	Step         string // ledger.TxCreateIdentity or ledger.TxCreateDataAccount
+	Provisioning Provisioning
+	Err          error
+}
+
+func (e *ProvisionError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Step, e.Err)
+}
+
+func (e *ProvisionError) Unwrap() error {
+	return e.Err
+}
+
+// Pending reports whether the step is still waiting for its transaction
+func (e *ProvisionError) Pending() bool {
+	return errors.Is(e.Err, ErrProvisionPending)
+}
+
+// Provisioner creates the ADI and data account a DID document is written to,
+// as method spec §3.1 describes for create: each missing account is created
+// in order and the next step waits until its transaction is delivered.
+//
+// Transactions that are still pending when a wait ends are remembered per
+// account, so a repeated request checks them instead of paying for a second
+// one. They are kept in memory and not shared between registrar instances.
+type Provisioner struct {
+	submitter Submitter
+	confirmer *Confirmer
+
+	mu      sync.Mutex
+	pending map[string]string // account URL -> transaction creating it
+}
+
+// NewProvisioner creates a provisioner that submits through s
+func NewProvisioner(s Submitter) *Provisioner {
+	return &Provisioner{submitter: s, confirmer: NewConfirmer(s), pending: make(map[string]string)}
+}
+
+// WithConfirmer replaces the confirmer that waits for each account
+func (p *Provisioner) WithConfirmer(confirmer *Confirmer) *Provisioner {
+	p.confirmer = confirmer
+	return p
+}
+
+// Provision ensures the ADI of dataAccountURL and the data account exist.
+// keyPageURL becomes the first key page of a new ADI.
+func (p *Provisioner) Provision(ctx context.Context, dataAccountURL, keyPageURL string) (*Provisioning, error) {
+	adiURL := adiOf(dataAccountURL)
+	result := Provisioning{ADI: adiURL, DataAccount: ledger.Normalize(dataAccountURL)}
+
+	adiLabel := strings.TrimPrefix(adiURL, "acc://")
+	txID, err := p.ensure(ctx, adiURL, func() (string, error) {
+		return p.submitter.CreateIdentity(ctx, adiLabel, keyPageURL)
+	})
+	result.ADITxID = txID
+	if err != nil {
+		return nil, &ProvisionError{Step: ledger.TxCreateIdentity, Provisioning: result, Err: err}
+	}
+	if txID != "" {
+		slog.InfoContext(ctx, "ADI created", "adi", adiURL, "tx_id", txID)
+	}
+
+	label := strings.TrimPrefix(result.DataAccount, adiURL+"/")
+	txID, err = p.ensure(ctx, result.DataAccount, func() (string, error) {
+		return p.submitter.CreateDataAccount(ctx, adiURL, label)
+	})
+	result.DataAccountTxID = txID
+	if err != nil {
+		return nil, &ProvisionError{Step: ledger.TxCreateDataAccount, Provisioning: result, Err: err}
+	}
+	if txID != "" {
+		slog.InfoContext(ctx, "Data account created", "data_account", result.DataAccount, "tx_id", txID)
+	}
+
+	return &result, nil
+}
+
+// ensure creates accountURL with create unless it exists, and waits until
+// the transaction is delivered: a step must be delivered before the next one
+// can be signed against it. A transaction still pending from an earlier
+// request is waited for instead of submitting a new one. It returns the
+// transaction ID, empty when the account already existed.
+func (p *Provisioner) ensure(ctx context.Context, accountURL string, create func() (string, error)) (string, error) {
+	exists, err := p.submitter.AccountExists(ctx, accountURL)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		p.setPending(accountURL, "")
+		return "", nil
+	}
+
+	txID := p.pendingTx(accountURL)
+	if txID == "" {
+		if txID, err = create(); err != nil {
+			return "", err
+		}
+	} else {
+		slog.InfoContext(ctx, "Waiting for pending account creation", "account", accountURL, "tx_id", txID)
+	}
+
+	status := p.confirmer.Wait(ctx, txID)
+	switch status.State {
+	case TxDelivered:
+		p.setPending(accountURL, "")
+		return txID, nil
+	case TxFailed:
+		p.setPending(accountURL, "")
+		return txID, fmt.Errorf("transaction %s failed: %s", txID, status.Error)
+	default:
+		p.setPending(accountURL, txID)
+		return txID, fmt.Errorf("transaction %s %w", txID, ErrProvisionPending)
+	}
+}
+
+// pendingTx returns the transaction still creating accountURL, if any
+func (p *Provisioner) pendingTx(accountURL string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending[accountURL]
+}
+
+// setPending records the pending transaction of accountURL; an empty txID
+// forgets it
+func (p *Provisioner) setPending(accountURL, txID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if txID == "" {
+		delete(p.pending, accountURL)
+		return
+	}
+	p.pending[accountURL] = txID
+}
@@ -0,0 +1,110 @@
+package acc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+func TestProvisioner_CreatesMissingAccounts(t *testing.T) {
+	client := NewFakeSubmitter()
+	p := NewProvisioner(client)
+
+	result, err := p.Provision(context.Background(), "acc://alice.acme/did", "acc://alice.acme/book/1")
+	require.NoError(t, err)
+	assert.Equal(t, "acc://alice.acme", result.ADI)
+	assert.Equal(t, "acc://alice.acme/did", result.DataAccount)
+	assert.NotEmpty(t, result.ADITxID)
+	assert.NotEmpty(t, result.DataAccountTxID)
+
+	exists, err := client.AccountExists(context.Background(), "acc://alice.acme/did")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Nothing left to create
+	again, err := p.Provision(context.Background(), "acc://alice.acme/did", "acc://alice.acme/book/1")
+	require.NoError(t, err)
+	assert.Empty(t, again.ADITxID)
+	assert.Empty(t, again.DataAccountTxID)
+	assert.Equal(t, result.DataAccount, again.DataAccount)
+}
+
+func TestProvisioner_Resume(t *testing.T) {
+	client := NewMockClient()
+	failing := errors.New("node unavailable")
+	client.CreateDataAccountFn = func(adiURL, dataAccountLabel string) (string, error) {
+		return "", failing
+	}
+	p := NewProvisioner(client)
+
+	_, err := p.Provision(context.Background(), "acc://bob.acme/did", "acc://bob.acme/book/1")
+	var provision *ProvisionError
+	require.ErrorAs(t, err, &provision)
+	assert.ErrorIs(t, err, failing)
+	assert.Equal(t, ledger.TxCreateDataAccount, provision.Step)
+	assert.Equal(t, "txid-create-identity-mock", provision.Provisioning.ADITxID)
+
+	// The retry skips the ADI created by the first attempt
+	client.CreateDataAccountFn = nil
+	result, err := p.Provision(context.Background(), "acc://bob.acme/did", "acc://bob.acme/book/1")
+	require.NoError(t, err)
+	assert.Empty(t, result.ADITxID)
+	assert.Equal(t, "txid-create-data-account-mock", result.DataAccountTxID)
+	assert.Equal(t, []string{"acc://bob.acme", "acc://bob.acme/did"}, client.Created)
+}
+
+func TestProvisioner_NotDelivered(t *testing.T) {
+	client := NewMockClient()
+	client.TransactionStatusFn = func(txID string) (*TxStatus, error) {
+		return &TxStatus{TxID: txID, State: TxPending}, nil
+	}
+	p := NewProvisioner(client).WithConfirmer(NewConfirmer(client).WithTimeout(20*time.Millisecond, 5*time.Millisecond))
+
+	_, err := p.Provision(context.Background(), "acc://carol.acme/did", "acc://carol.acme/book/1")
+	var provision *ProvisionError
+	require.ErrorAs(t, err, &provision)
+	assert.Equal(t, ledger.TxCreateIdentity, provision.Step)
+	assert.ErrorContains(t, err, "not delivered")
+	assert.True(t, provision.Pending())
+	assert.Equal(t, "txid-create-identity-mock", provision.Provisioning.ADITxID)
+}
+
+func TestProvisioner_RetryWaitsForPendingTransaction(t *testing.T) {
+	client := NewMockClient()
+	delivered := false
+	client.AccountExistsFn = func(accountURL string) (bool, error) {
+		return delivered && accountURL == "acc://dave.acme", nil
+	}
+	client.TransactionStatusFn = func(txID string) (*TxStatus, error) {
+		if delivered {
+			return &TxStatus{TxID: txID, State: TxDelivered}, nil
+		}
+		return &TxStatus{TxID: txID, State: TxPending}, nil
+	}
+	p := NewProvisioner(client).WithConfirmer(NewConfirmer(client).WithTimeout(20*time.Millisecond, 5*time.Millisecond))
+
+	_, err := p.Provision(context.Background(), "acc://dave.acme/did", "acc://dave.acme/book/1")
+	var provision *ProvisionError
+	require.ErrorAs(t, err, &provision)
+	require.True(t, provision.Pending())
+
+	// Still pending: the retry waits for the same transaction
+	_, err = p.Provision(context.Background(), "acc://dave.acme/did", "acc://dave.acme/book/1")
+	require.ErrorAs(t, err, &provision)
+	assert.True(t, provision.Pending())
+	assert.Equal(t, []string{"acc://dave.acme"}, client.Created)
+
+	// Delivered: the ADI is not created again
+	delivered = true
+	result, err := p.Provision(context.Background(), "acc://dave.acme/did", "acc://dave.acme/book/1")
+	require.NoError(t, err)
+	assert.Empty(t, result.ADITxID)
+	assert.Equal(t, "txid-create-data-account-mock", result.DataAccountTxID)
+	assert.Equal(t, []string{"acc://dave.acme", "acc://dave.acme/did"}, client.Created)
+}
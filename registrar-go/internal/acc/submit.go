@@ -4,30 +4,44 @@ import (
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
 	"time"
 
 	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3/jsonrpc"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/build"
+	accerrors "gitlab.com/accumulatenetwork/accumulate/pkg/errors"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/types/messaging"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/url"
 	"gitlab.com/accumulatenetwork/accumulate/protocol"
 
 	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+	"github.com/opendlt/accu-did/shared/nodepool"
 )
 
 // Submitter interface for Accumulate operations
 type Submitter interface {
-	CreateIdentity(adiLabel string, keyPageURL string) (string, error)
-	CreateDataAccount(adiURL, dataAccountLabel string) (string, error)
-	WriteDataEntry(dataAccountURL string, data []byte) (string, error)
-	SubmitWriteData(dataAccountURL string, envelope *ops.Envelope) (string, error)
-	UpdateKeyPage(keyPageURL string, operations []KeyPageOperation) (string, error)
-	GetKeyPageState(keyPageURL string) (*KeyPageState, error)
+	CreateIdentity(ctx context.Context, adiLabel string, keyPageURL string) (string, error)
+	CreateDataAccount(ctx context.Context, adiURL, dataAccountLabel string) (string, error)
+	WriteDataEntry(ctx context.Context, dataAccountURL string, data []byte) (string, error)
+	SubmitWriteData(ctx context.Context, dataAccountURL string, envelope *ops.Envelope) (string, error)
+	UpdateKeyPage(ctx context.Context, keyPageURL string, operations []KeyPageOperation) (string, error)
+	GetKeyPageState(ctx context.Context, keyPageURL string) (*KeyPageState, error)
+	AccountExists(ctx context.Context, accountURL string) (bool, error)
+	TransactionStatus(ctx context.Context, txID string) (*TxStatus, error)
+	LatestDataEntry(ctx context.Context, dataAccountURL string) ([]byte, error)
 }
 
+// ErrNoDataEntry is returned by LatestDataEntry for a data account that is
+// missing or has no entries
+var ErrNoDataEntry = errors.New("no data entry")
+
 // KeyPageOperation represents a key page operation
 type KeyPageOperation struct {
 	Type      string `json:"type"` // "add", "remove", "update"
@@ -37,30 +51,20 @@ type KeyPageOperation struct {
 
 // KeyPageState represents the current state of a key page
 type KeyPageState struct {
-	URL       string    `json:"url"`
-	Threshold int       `json:"threshold"`
-	Keys      []KeyInfo `json:"keys"`
-	Height    uint64    `json:"height"`
+	URL           string    `json:"url"`
+	Threshold     int       `json:"threshold"`
+	Keys          []KeyInfo `json:"keys"`
+	Height        uint64    `json:"height"`
+	CreditBalance uint64    `json:"creditBalance"` // in credits x 100 (protocol.CreditPrecision)
 }
 
-// KeyInfo represents a key in a key page
+// KeyInfo represents a key in a key page. Pages read from the network hold
+// only the SHA-256 hash of each key, in KeyHash.
 type KeyInfo struct {
 	PublicKey string `json:"publicKey"`
 	KeyType   string `json:"keyType"`
-}
-
-// FakeSubmitter implements Submitter interface for testing and development
-type FakeSubmitter struct {
-	transactions map[string]*MockTransaction
-	keyPages     map[string]*KeyPageState
-}
 
-// MockTransaction represents a mock transaction
-type MockTransaction struct {
-	ID        string
-	Status    string
-	Timestamp time.Time
-	Data      interface{}
+	KeyHash string `json:"keyHash,omitempty"`
 }
 
 // NewSubmitter creates a new submitter based on mode
@@ -71,215 +75,114 @@ func NewSubmitter(realMode bool, nodeURL string) Submitter {
 	return NewFakeSubmitter()
 }
 
-// NewFakeSubmitter creates a new fake submitter
-func NewFakeSubmitter() *FakeSubmitter {
-	return &FakeSubmitter{
-		transactions: make(map[string]*MockTransaction),
-		keyPages:     make(map[string]*KeyPageState),
-	}
-}
-
-// CreateIdentity creates a new ADI (fake implementation)
-func (c *FakeSubmitter) CreateIdentity(adiLabel string, keyPageURL string) (string, error) {
-	txID := c.generateTxID()
+// Default timeouts for RealSubmitter JSON-RPC calls
+const (
+	DefaultSubmitTimeout = 30 * time.Second
+	DefaultQueryTimeout  = 15 * time.Second
+)
 
-	transaction := &MockTransaction{
-		ID:        txID,
-		Status:    "committed",
-		Timestamp: time.Now().UTC(),
-		Data: map[string]interface{}{
-			"type":       "createIdentity",
-			"adiLabel":   adiLabel,
-			"keyPageURL": keyPageURL,
-		},
-	}
+// RealSubmitter implements Submitter interface using JSON-RPC v3
+type RealSubmitter struct {
+	client        *jsonrpc.Client
+	signerHook    SignerHook
+	submitTimeout time.Duration
+	queryTimeout  time.Duration
+	creditSource  string // lite token account that pays for AddCredits
 
-	c.transactions[txID] = transaction
-	return txID, nil
+	mu         sync.Mutex
+	signatures map[string][]string // by transaction ID, see track
 }
 
-// CreateDataAccount creates a new data account (fake implementation)
-func (c *FakeSubmitter) CreateDataAccount(adiURL, dataAccountLabel string) (string, error) {
-	txID := c.generateTxID()
+// SignerHook provides cryptographic signing capability
+type SignerHook interface {
+	// Sign signs the given message with the specified private key
+	Sign(privateKey []byte, message []byte) ([]byte, error)
 
-	transaction := &MockTransaction{
-		ID:        txID,
-		Status:    "committed",
-		Timestamp: time.Now().UTC(),
-		Data: map[string]interface{}{
-			"type":             "createDataAccount",
-			"adiURL":           adiURL,
-			"dataAccountLabel": dataAccountLabel,
-		},
-	}
+	// GetPrivateKey retrieves the private key for a given key page URL
+	GetPrivateKey(keyPageURL string) ([]byte, error)
 
-	c.transactions[txID] = transaction
-	return txID, nil
+	// GetPublicKey retrieves the public key for a given key page URL
+	GetPublicKey(keyPageURL string) ([]byte, error)
 }
 
-// WriteDataEntry writes data to a data account (fake implementation)
-func (c *FakeSubmitter) WriteDataEntry(dataAccountURL string, data []byte) (string, error) {
-	txID := c.generateTxID()
-
-	transaction := &MockTransaction{
-		ID:        txID,
-		Status:    "committed",
-		Timestamp: time.Now().UTC(),
-		Data: map[string]interface{}{
-			"type":           "writeData",
-			"dataAccountURL": dataAccountURL,
-			"data":           string(data),
-		},
+// NewRealSubmitter creates a new real submitter that connects to Accumulate network
+func NewRealSubmitter(nodeURL string) *RealSubmitter {
+	return &RealSubmitter{
+		client:        jsonrpc.NewClient(nodeURL),
+		signerHook:    NewDefaultSignerHook(),
+		submitTimeout: DefaultSubmitTimeout,
+		queryTimeout:  DefaultQueryTimeout,
 	}
-
-	c.transactions[txID] = transaction
-	return txID, nil
 }
 
-// SubmitWriteData submits a writeData transaction to Accumulate (fake implementation)
-func (c *FakeSubmitter) SubmitWriteData(dataAccountURL string, envelope *ops.Envelope) (string, error) {
-	// Generate mock transaction ID
-	txID := c.generateTxID()
-
-	// Create mock transaction
-	transaction := &MockTransaction{
-		ID:        txID,
-		Status:    "committed",
-		Timestamp: time.Now().UTC(),
-		Data:      envelope,
+// NewRealSubmitterWithSigner creates a new real submitter with custom signer
+func NewRealSubmitterWithSigner(nodeURL string, signerHook SignerHook) *RealSubmitter {
+	return &RealSubmitter{
+		client:        jsonrpc.NewClient(nodeURL),
+		signerHook:    signerHook,
+		submitTimeout: DefaultSubmitTimeout,
+		queryTimeout:  DefaultQueryTimeout,
 	}
-
-	// Store transaction
-	c.transactions[txID] = transaction
-
-	// Set transaction ID in envelope
-	envelope.SetTransactionID(txID)
-
-	return txID, nil
 }
 
-// UpdateKeyPage updates a key page (fake implementation)
-func (c *FakeSubmitter) UpdateKeyPage(keyPageURL string, operations []KeyPageOperation) (string, error) {
-	// Generate mock transaction ID
-	txID := c.generateTxID()
-
-	// Create mock transaction
-	transaction := &MockTransaction{
-		ID:        txID,
-		Status:    "committed",
-		Timestamp: time.Now().UTC(),
-		Data:      operations,
-	}
-
-	// Store transaction
-	c.transactions[txID] = transaction
-
-	// Mock: apply operations to key page state
-	if _, exists := c.keyPages[keyPageURL]; !exists {
-		c.keyPages[keyPageURL] = &KeyPageState{
-			URL:       keyPageURL,
-			Threshold: 1,
-			Keys:      []KeyInfo{},
-			Height:    0,
-		}
-	}
-
-	keyPage := c.keyPages[keyPageURL]
-	keyPage.Height++
-
-	// Apply operations (simplified)
-	for _, op := range operations {
-		switch op.Type {
-		case "add":
-			keyPage.Keys = append(keyPage.Keys, KeyInfo{
-				PublicKey: op.PublicKey,
-				KeyType:   op.KeyType,
-			})
-		case "remove":
-			// Remove key by public key
-			for i, key := range keyPage.Keys {
-				if key.PublicKey == op.PublicKey {
-					keyPage.Keys = append(keyPage.Keys[:i], keyPage.Keys[i+1:]...)
-					break
-				}
-			}
-		}
+// NewRealSubmitterWithTransport creates a real submitter whose JSON-RPC calls go
+// through the given HTTP transport (used for tracing and upstream metrics)
+func NewRealSubmitterWithTransport(nodeURL string, transport http.RoundTripper) *RealSubmitter {
+	client := jsonrpc.NewClient(nodeURL)
+	client.Client.Transport = transport
+	return &RealSubmitter{
+		client:        client,
+		signerHook:    NewDefaultSignerHook(),
+		submitTimeout: DefaultSubmitTimeout,
+		queryTimeout:  DefaultQueryTimeout,
 	}
-
-	return txID, nil
 }
 
-// GetKeyPageState returns the current state of a key page
-func (c *FakeSubmitter) GetKeyPageState(keyPageURL string) (*KeyPageState, error) {
-	if keyPage, exists := c.keyPages[keyPageURL]; exists {
-		return keyPage, nil
-	}
-
-	// Return default key page for testing
-	return &KeyPageState{
-		URL:       keyPageURL,
-		Threshold: 1,
-		Keys: []KeyInfo{
-			{
-				PublicKey: "ed25519:mockkey123...",
-				KeyType:   "ed25519",
-			},
-		},
-		Height: 1,
-	}, nil
+// NewRealSubmitterWithPool creates a real submitter whose JSON-RPC calls are
+// spread over the nodes of pool. Transactions are never sent to more than
+// one node.
+func NewRealSubmitterWithPool(pool *nodepool.Pool) *RealSubmitter {
+	return NewRealSubmitterWithTransport(pool.URL(), pool)
 }
 
-// GetTransaction returns a transaction by ID (for testing)
-func (c *FakeSubmitter) GetTransaction(txID string) (*MockTransaction, error) {
-	if tx, exists := c.transactions[txID]; exists {
-		return tx, nil
-	}
-	return nil, fmt.Errorf("transaction not found: %s", txID)
+// WithTimeouts sets the timeouts for transaction submissions and queries
+func (c *RealSubmitter) WithTimeouts(submit, query time.Duration) *RealSubmitter {
+	c.submitTimeout = submit
+	c.queryTimeout = query
+	return c
 }
 
-// generateTxID generates a mock transaction ID
-func (c *FakeSubmitter) generateTxID() string {
-	timestamp := time.Now().UnixNano()
-	return fmt.Sprintf("0x%016x", timestamp)
+// WithSignerHook replaces the signer hook
+func (c *RealSubmitter) WithSignerHook(signerHook SignerHook) *RealSubmitter {
+	c.signerHook = signerHook
+	return c
 }
 
-// ListTransactions returns all transactions (for testing)
-func (c *FakeSubmitter) ListTransactions() map[string]*MockTransaction {
-	return c.transactions
+// WithCreditSource sets the lite token account AddCredits spends ACME from.
+// The signer hook must hold the key of its lite identity.
+func (c *RealSubmitter) WithCreditSource(liteTokenAccount string) *RealSubmitter {
+	c.creditSource = liteTokenAccount
+	return c
 }
 
-// RealSubmitter implements Submitter interface using JSON-RPC v3
-type RealSubmitter struct {
-	client     *jsonrpc.Client
-	signerHook SignerHook
+// Signer returns the signer hook used for transactions
+func (c *RealSubmitter) Signer() SignerHook {
+	return c.signerHook
 }
 
-// SignerHook provides cryptographic signing capability
-type SignerHook interface {
-	// Sign signs the given message with the specified private key
-	Sign(privateKey []byte, message []byte) ([]byte, error)
-
-	// GetPrivateKey retrieves the private key for a given key page URL
-	GetPrivateKey(keyPageURL string) ([]byte, error)
-
-	// GetPublicKey retrieves the public key for a given key page URL
-	GetPublicKey(keyPageURL string) ([]byte, error)
-}
+// NetworkID returns the name of the network the node belongs to
+func (c *RealSubmitter) NetworkID(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+	defer cancel()
 
-// NewRealSubmitter creates a new real submitter that connects to Accumulate network
-func NewRealSubmitter(nodeURL string) *RealSubmitter {
-	return &RealSubmitter{
-		client:     jsonrpc.NewClient(nodeURL),
-		signerHook: NewDefaultSignerHook(),
+	info, err := c.client.NodeInfo(ctx, api.NodeInfoOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to query node info: %w", err)
 	}
-}
-
-// NewRealSubmitterWithSigner creates a new real submitter with custom signer
-func NewRealSubmitterWithSigner(nodeURL string, signerHook SignerHook) *RealSubmitter {
-	return &RealSubmitter{
-		client:     jsonrpc.NewClient(nodeURL),
-		signerHook: signerHook,
+	if info == nil {
+		return "", fmt.Errorf("node info response was empty")
 	}
+	return info.Network, nil
 }
 
 // DefaultSignerHook implements SignerHook with in-memory key management
@@ -343,7 +246,7 @@ func (s *DefaultSignerHook) GetPublicKey(keyPageURL string) ([]byte, error) {
 
 // CreateIdentity creates a new ADI using Accumulate API
 // Credit cost: approximately 10 credits per ADI creation (variable based on network conditions)
-func (c *RealSubmitter) CreateIdentity(adiLabel string, keyPageURL string) (string, error) {
+func (c *RealSubmitter) CreateIdentity(ctx context.Context, adiLabel string, keyPageURL string) (string, error) {
 	// Parse URLs
 	keyPageParsed, err := url.Parse(keyPageURL)
 	if err != nil {
@@ -361,17 +264,14 @@ func (c *RealSubmitter) CreateIdentity(adiLabel string, keyPageURL string) (stri
 	if err != nil {
 		// Generate new key if not found
 		if defaultSigner, ok := c.signerHook.(*DefaultSignerHook); ok {
-			publicKey, genErr := defaultSigner.GenerateKey(keyPageURL)
-			if genErr != nil {
+			if _, genErr := defaultSigner.GenerateKey(keyPageURL); genErr != nil {
 				return "", fmt.Errorf("failed to generate key for %s: %w", keyPageURL, genErr)
 			}
 			privateKey, err = c.signerHook.GetPrivateKey(keyPageURL)
 			if err != nil {
 				return "", fmt.Errorf("failed to retrieve generated key: %w", err)
 			}
-
-			// Log the public key for reference
-			fmt.Printf("Generated new key for %s: %x\n", keyPageURL, publicKey)
+			slog.DebugContext(ctx, "Generated signing key", "key_page", keyPageURL)
 		} else {
 			return "", fmt.Errorf("key not found for %s and cannot generate with custom signer", keyPageURL)
 		}
@@ -398,7 +298,7 @@ func (c *RealSubmitter) CreateIdentity(adiLabel string, keyPageURL string) (stri
 	}
 
 	// Submit to network
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.submitTimeout)
 	defer cancel()
 
 	submissions, err := c.client.Submit(ctx, envelope, api.SubmitOptions{})
@@ -406,22 +306,17 @@ func (c *RealSubmitter) CreateIdentity(adiLabel string, keyPageURL string) (stri
 		return "", fmt.Errorf("failed to submit CreateIdentity transaction to Accumulate network (check ACC_NODE_URL and network connectivity): %w", err)
 	}
 
-	if len(submissions) == 0 {
-		return "", fmt.Errorf("no submissions returned")
-	}
-
-	if !submissions[0].Success {
-		return "", fmt.Errorf("CreateIdentity submission failed (may be due to insufficient credits): %v", submissions[0].Message)
+	if err := rejected(submissions); err != nil {
+		return "", fmt.Errorf("CreateIdentity submission failed (may be due to insufficient credits): %w", err)
 	}
 
-	// Extract transaction ID from envelope
-	txID := extractTxID(envelope)
+	txID := c.track(envelope)
 	return txID, nil
 }
 
 // CreateDataAccount creates a new data account using Accumulate API
 // Credit cost: approximately 5 credits per data account creation
-func (c *RealSubmitter) CreateDataAccount(adiURL, dataAccountLabel string) (string, error) {
+func (c *RealSubmitter) CreateDataAccount(ctx context.Context, adiURL, dataAccountLabel string) (string, error) {
 	// Parse the ADI URL
 	adiParsed, err := url.Parse(adiURL)
 	if err != nil {
@@ -458,7 +353,7 @@ func (c *RealSubmitter) CreateDataAccount(adiURL, dataAccountLabel string) (stri
 	}
 
 	// Submit to network
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.submitTimeout)
 	defer cancel()
 
 	submissions, err := c.client.Submit(ctx, envelope, api.SubmitOptions{})
@@ -466,22 +361,17 @@ func (c *RealSubmitter) CreateDataAccount(adiURL, dataAccountLabel string) (stri
 		return "", fmt.Errorf("failed to submit CreateDataAccount transaction to Accumulate network (check ACC_NODE_URL and network connectivity): %w", err)
 	}
 
-	if len(submissions) == 0 {
-		return "", fmt.Errorf("no submissions returned")
-	}
-
-	if !submissions[0].Success {
-		return "", fmt.Errorf("CreateDataAccount submission failed (may be due to insufficient credits): %v", submissions[0].Message)
+	if err := rejected(submissions); err != nil {
+		return "", fmt.Errorf("CreateDataAccount submission failed (may be due to insufficient credits): %w", err)
 	}
 
-	// Extract transaction ID from envelope
-	txID := extractTxID(envelope)
+	txID := c.track(envelope)
 	return txID, nil
 }
 
 // WriteDataEntry writes data to a data account using Accumulate API
 // Credit cost: approximately 2-5 credits per write operation depending on data size
-func (c *RealSubmitter) WriteDataEntry(dataAccountURL string, data []byte) (string, error) {
+func (c *RealSubmitter) WriteDataEntry(ctx context.Context, dataAccountURL string, data []byte) (string, error) {
 	// Parse the data account URL
 	dataAccountParsed, err := url.Parse(dataAccountURL)
 	if err != nil {
@@ -517,7 +407,7 @@ func (c *RealSubmitter) WriteDataEntry(dataAccountURL string, data []byte) (stri
 	}
 
 	// Submit to network
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.submitTimeout)
 	defer cancel()
 
 	submissions, err := c.client.Submit(ctx, envelope, api.SubmitOptions{})
@@ -525,21 +415,16 @@ func (c *RealSubmitter) WriteDataEntry(dataAccountURL string, data []byte) (stri
 		return "", fmt.Errorf("failed to submit WriteData transaction to Accumulate network (check ACC_NODE_URL and network connectivity): %w", err)
 	}
 
-	if len(submissions) == 0 {
-		return "", fmt.Errorf("no submissions returned")
+	if err := rejected(submissions); err != nil {
+		return "", fmt.Errorf("WriteData submission failed (may be due to insufficient credits): %w", err)
 	}
 
-	if !submissions[0].Success {
-		return "", fmt.Errorf("WriteData submission failed (may be due to insufficient credits): %v", submissions[0].Message)
-	}
-
-	// Extract transaction ID from envelope
-	txID := extractTxID(envelope)
+	txID := c.track(envelope)
 	return txID, nil
 }
 
 // SubmitWriteData submits a writeData transaction to Accumulate
-func (c *RealSubmitter) SubmitWriteData(dataAccountURL string, envelope *ops.Envelope) (string, error) {
+func (c *RealSubmitter) SubmitWriteData(ctx context.Context, dataAccountURL string, envelope *ops.Envelope) (string, error) {
 	// Parse the data account URL
 	accountURL, err := url.Parse(dataAccountURL)
 	if err != nil {
@@ -553,7 +438,7 @@ func (c *RealSubmitter) SubmitWriteData(dataAccountURL string, envelope *ops.Env
 	}
 
 	// Submit the envelope
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.submitTimeout)
 	defer cancel()
 
 	submissions, err := c.client.Submit(ctx, msgEnvelope, api.SubmitOptions{})
@@ -561,25 +446,17 @@ func (c *RealSubmitter) SubmitWriteData(dataAccountURL string, envelope *ops.Env
 		return "", fmt.Errorf("failed to submit transaction: %w", err)
 	}
 
-	// Return the transaction ID from the first submission
-	if len(submissions) == 0 {
-		return "", fmt.Errorf("no submissions returned")
-	}
-
-	// Extract transaction ID from submission
-	// Note: The actual field name may vary based on the API structure
-	// Generate a placeholder transaction ID since Success is boolean
-	// In a real implementation, this should use the actual transaction hash from submissions
-	if !submissions[0].Success {
-		return "", fmt.Errorf("submission failed")
+	if err := rejected(submissions); err != nil {
+		return "", fmt.Errorf("WriteData submission failed: %w", err)
 	}
-	txID := fmt.Sprintf("0x%x", time.Now().UnixNano()) // Temporary solution
 
+	txID := c.track(msgEnvelope)
+	envelope.SetTransactionID(txID)
 	return txID, nil
 }
 
 // UpdateKeyPage updates a key page
-func (c *RealSubmitter) UpdateKeyPage(keyPageURL string, operations []KeyPageOperation) (string, error) {
+func (c *RealSubmitter) UpdateKeyPage(ctx context.Context, keyPageURL string, operations []KeyPageOperation) (string, error) {
 	// Parse the key page URL
 	keyPageParsed, err := url.Parse(keyPageURL)
 	if err != nil {
@@ -610,7 +487,7 @@ func (c *RealSubmitter) UpdateKeyPage(keyPageURL string, operations []KeyPageOpe
 	}
 
 	// Submit to network
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.submitTimeout)
 	defer cancel()
 
 	submissions, err := c.client.Submit(ctx, envelope, api.SubmitOptions{})
@@ -618,21 +495,16 @@ func (c *RealSubmitter) UpdateKeyPage(keyPageURL string, operations []KeyPageOpe
 		return "", fmt.Errorf("failed to submit UpdateKeyPage transaction: %w", err)
 	}
 
-	if len(submissions) == 0 {
-		return "", fmt.Errorf("no submissions returned")
-	}
-
-	if !submissions[0].Success {
-		return "", fmt.Errorf("UpdateKeyPage submission failed: %v", submissions[0].Message)
+	if err := rejected(submissions); err != nil {
+		return "", fmt.Errorf("UpdateKeyPage submission failed: %w", err)
 	}
 
-	// Extract transaction ID from envelope
-	txID := extractTxID(envelope)
+	txID := c.track(envelope)
 	return txID, nil
 }
 
 // GetKeyPageState returns the current state of a key page
-func (c *RealSubmitter) GetKeyPageState(keyPageURL string) (*KeyPageState, error) {
+func (c *RealSubmitter) GetKeyPageState(ctx context.Context, keyPageURL string) (*KeyPageState, error) {
 	// Parse the key page URL
 	pageURL, err := url.Parse(keyPageURL)
 	if err != nil {
@@ -640,7 +512,7 @@ func (c *RealSubmitter) GetKeyPageState(keyPageURL string) (*KeyPageState, error
 	}
 
 	// Query the key page state using QueryAccount for proper AccountRecord return
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.queryTimeout)
 	defer cancel()
 
 	// Create a querier wrapper around the client
@@ -660,22 +532,203 @@ func (c *RealSubmitter) GetKeyPageState(keyPageURL string) (*KeyPageState, error
 		Height:    0,           // Will be populated below
 	}
 
-	// Extract information from AccountRecord
-	if accountRecord != nil && accountRecord.Account != nil {
-		// For key pages, the account should be a KeyPage type
-		// This is a simplified conversion - actual implementation would need to
-		// handle the specific protocol.KeyPage type
-		keyPageState.Height = 1 // Placeholder - would extract from chain info
-
-		// Note: In a complete implementation, we would:
-		// 1. Cast accountRecord.Account to *protocol.KeyPage
-		// 2. Extract the threshold and keys from the KeyPage
-		// 3. Convert to our KeyInfo format
+	// Extract information from AccountRecord. Anything but a key page is an
+	// error, so a zero credit balance is never reported for a missing page.
+	if accountRecord == nil || accountRecord.Account == nil {
+		return nil, fmt.Errorf("key page %s: empty account record", keyPageURL)
+	}
+	page, ok := accountRecord.Account.(*protocol.KeyPage)
+	if !ok {
+		return nil, fmt.Errorf("account %s is not a key page", keyPageURL)
+	}
+	keyPageState.Height = 1 // Placeholder - would extract from chain info
+	keyPageState.CreditBalance = page.CreditBalance
+	if page.AcceptThreshold > 0 {
+		keyPageState.Threshold = int(page.AcceptThreshold)
+	}
+	for _, key := range page.Keys {
+		if len(key.PublicKeyHash) > 0 {
+			keyPageState.Keys = append(keyPageState.Keys, KeyInfo{KeyType: "ed25519", KeyHash: hex.EncodeToString(key.PublicKeyHash)})
+		}
 	}
 
 	return keyPageState, nil
 }
 
+// AccountExists reports whether an account exists. Only a not-found response
+// means false; any other failure is returned as an error.
+func (c *RealSubmitter) AccountExists(ctx context.Context, accountURL string) (bool, error) {
+	u, err := url.Parse(accountURL)
+	if err != nil {
+		return false, fmt.Errorf("invalid account URL %s: %w", accountURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	querier := api.Querier2{Querier: c.client}
+	_, err = querier.QueryAccount(ctx, u, nil)
+	var accErr *accerrors.Error
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &accErr) && accErr.Code == accerrors.NotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to query account %s: %w", accountURL, err)
+	}
+}
+
+// TransactionStatus queries the delivery state of a transaction submitted by
+// this submitter or any other. A transaction the network does not know yet
+// is pending.
+func (c *RealSubmitter) TransactionStatus(ctx context.Context, txID string) (*TxStatus, error) {
+	id, err := url.ParseTxID(txID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID %s: %w", txID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	status := &TxStatus{TxID: txID, State: TxPending}
+	querier := api.Querier2{Querier: c.client}
+	record, err := querier.QueryTransaction(ctx, id, nil)
+	var accErr *accerrors.Error
+	switch {
+	case errors.As(err, &accErr) && accErr.Code == accerrors.NotFound:
+	case err != nil:
+		return nil, fmt.Errorf("failed to query transaction %s: %w", txID, err)
+	case record == nil:
+		return nil, fmt.Errorf("transaction %s: empty record", txID)
+	case !record.Status.Success():
+		status.State = TxFailed
+		if record.Error != nil {
+			status.Error = record.Error.Error()
+		}
+	case record.Status.Delivered():
+		status.State = TxDelivered
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status.SignatureHashes = c.signatures[txID]
+	if status.Final() {
+		delete(c.signatures, txID)
+	}
+	return status, nil
+}
+
+// LatestDataEntry returns the data of the most recent entry written to a
+// data account
+func (c *RealSubmitter) LatestDataEntry(ctx context.Context, dataAccountURL string) ([]byte, error) {
+	u, err := url.Parse(dataAccountURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data account URL %s: %w", dataAccountURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+	defer cancel()
+
+	count := uint64(1)
+	expand := true
+	querier := api.Querier2{Querier: c.client}
+	entries, err := querier.QueryDataEntries(ctx, u, &api.DataQuery{
+		Range: &api.RangeOptions{Count: &count, Expand: &expand, FromEnd: true},
+	})
+	var accErr *accerrors.Error
+	switch {
+	case errors.As(err, &accErr) && accErr.Code == accerrors.NotFound:
+		return nil, fmt.Errorf("%w: %s does not exist", ErrNoDataEntry, dataAccountURL)
+	case err != nil:
+		return nil, fmt.Errorf("failed to query data entries for %s: %w", dataAccountURL, err)
+	case entries == nil || len(entries.Records) == 0:
+		return nil, fmt.Errorf("%w: %s has no entries", ErrNoDataEntry, dataAccountURL)
+	}
+
+	record := entries.Records[len(entries.Records)-1]
+	if record == nil || record.Value == nil || record.Value.Message == nil {
+		return nil, fmt.Errorf("data entry of %s: empty record", dataAccountURL)
+	}
+	writeData, ok := record.Value.Message.Transaction.Body.(*protocol.WriteData)
+	if !ok || writeData.Entry == nil || len(writeData.Entry.GetData()) == 0 {
+		return nil, fmt.Errorf("data entry of %s: not a WriteData entry", dataAccountURL)
+	}
+	return writeData.Entry.GetData()[0], nil
+}
+
+// AddCredits buys credits for a key page with ACME from the credit source,
+// priced at the current ACME oracle
+func (c *RealSubmitter) AddCredits(ctx context.Context, keyPageURL string, credits uint64) (string, error) {
+	if c.creditSource == "" {
+		return "", fmt.Errorf("no credit source configured")
+	}
+	sourceURL, err := url.Parse(c.creditSource)
+	if err != nil {
+		return "", fmt.Errorf("invalid credit source %s: %w", c.creditSource, err)
+	}
+	recipient, err := url.Parse(keyPageURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid key page URL %s: %w", keyPageURL, err)
+	}
+
+	// Lite token accounts are signed for by their lite identity
+	liteIdentity := sourceURL.RootIdentity()
+	privateKey, err := c.signerHook.GetPrivateKey(liteIdentity.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to get private key for %s: %w", liteIdentity, err)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, c.queryTimeout)
+	status, err := c.client.NetworkStatus(queryCtx, api.NetworkStatusOptions{Partition: "Directory"})
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("failed to query ACME oracle: %w", err)
+	}
+	if status == nil || status.Oracle == nil || status.Oracle.Price == 0 {
+		return "", fmt.Errorf("network status has no ACME oracle price")
+	}
+	oracle := status.Oracle.Price
+	acme := acmeForCredits(credits, oracle)
+
+	envelope, err := build.Transaction().
+		For(sourceURL).
+		AddCredits().
+		To(recipient).
+		WithOracle(float64(oracle) / protocol.AcmeOraclePrecision).
+		Spend(float64(acme) / protocol.AcmePrecision).
+		SignWith(liteIdentity).
+		Version(1).
+		Timestamp(build.UnixTimeNow()).
+		PrivateKey(privateKey).
+		Done()
+	if err != nil {
+		return "", fmt.Errorf("failed to build AddCredits transaction: %w", err)
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, c.submitTimeout)
+	defer cancel()
+
+	submissions, err := c.client.Submit(ctx, envelope, api.SubmitOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to submit AddCredits transaction: %w", err)
+	}
+	if err := rejected(submissions); err != nil {
+		return "", fmt.Errorf("AddCredits submission failed (may be due to insufficient ACME in %s): %w", c.creditSource, err)
+	}
+
+	return c.track(envelope), nil
+}
+
+// acmeForCredits returns the ACME (x protocol.AcmePrecision) that buys
+// credits (x protocol.CreditPrecision) at oracle (dollars per ACME x
+// protocol.AcmeOraclePrecision), rounded up. A credit costs one cent.
+func acmeForCredits(credits, oracle uint64) uint64 {
+	const acmeUnitsPerCreditUnit = protocol.AcmePrecision / (100 * protocol.CreditPrecision)
+	scaled := credits * acmeUnitsPerCreditUnit * protocol.AcmeOraclePrecision
+	return (scaled + oracle - 1) / oracle
+}
+
 // convertToMessagingEnvelope converts ops.Envelope to messaging.Envelope
 func (c *RealSubmitter) convertToMessagingEnvelope(opsEnv *ops.Envelope, accountURL *url.URL) (*messaging.Envelope, error) {
 	// Marshal the DID document from the envelope
@@ -712,10 +765,60 @@ func (c *RealSubmitter) convertToMessagingEnvelope(opsEnv *ops.Envelope, account
 	return envelope, nil
 }
 
-// extractTxID extracts transaction ID from envelope
+// rejected returns an error if the network rejected any message of the
+// envelope, or returned nothing to show it was accepted
+func rejected(submissions []*api.Submission) error {
+	if len(submissions) == 0 {
+		return fmt.Errorf("no submissions returned")
+	}
+	for _, sub := range submissions {
+		if sub.Status != nil && sub.Status.Failed() {
+			if err := sub.Status.AsError(); err != nil {
+				return err
+			}
+			return fmt.Errorf("status %v", sub.Status.Code)
+		}
+		if !sub.Success {
+			return fmt.Errorf("%s", sub.Message)
+		}
+	}
+	return nil
+}
+
+// maxTracked bounds the signature hashes kept for transactions that were
+// never confirmed
+const maxTracked = 1024
+
+// track returns the ID of the envelope's transaction and keeps its signature
+// hashes for TransactionStatus until the transaction is final
+func (c *RealSubmitter) track(envelope *messaging.Envelope) string {
+	txID := extractTxID(envelope)
+
+	hashes := make([]string, 0, len(envelope.Signatures))
+	for _, sig := range envelope.Signatures {
+		hashes = append(hashes, fmt.Sprintf("%x", sig.Hash()))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.signatures == nil {
+		c.signatures = make(map[string][]string)
+	}
+	if len(c.signatures) >= maxTracked {
+		for id := range c.signatures {
+			delete(c.signatures, id)
+			break
+		}
+	}
+	c.signatures[txID] = hashes
+	return txID
+}
+
+// extractTxID returns the transaction ID of the envelope, in the
+// acc://<hash>@<principal> form the network reports
 func extractTxID(envelope *messaging.Envelope) string {
 	if len(envelope.Transaction) > 0 {
-		return fmt.Sprintf("%x", envelope.Transaction[0].GetHash())
+		return envelope.Transaction[0].ID().String()
 	}
 	return "unknown"
 }
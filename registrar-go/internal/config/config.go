@@ -0,0 +1,433 @@
+// Package config loads the registrar configuration from defaults, an optional
+// YAML or TOML file and environment variables, and validates the result
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/shared/logging"
+	"github.com/opendlt/accu-did/shared/tlsutil"
+)
+
+// Config is the complete registrar configuration
+type Config struct {
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	TLS        TLSConfig        `yaml:"tls" toml:"tls"`
+	Accumulate AccumulateConfig `yaml:"accumulate" toml:"accumulate"`
+	Security   SecurityConfig   `yaml:"security" toml:"security"`
+	Policy     PolicyConfig     `yaml:"policy" toml:"policy"`
+	KeyStore   KeyStoreConfig   `yaml:"keyStore" toml:"keyStore"`
+	Credits    CreditsConfig    `yaml:"credits" toml:"credits"`
+	Logging    LoggingConfig    `yaml:"logging" toml:"logging"`
+	Telemetry  TelemetryConfig  `yaml:"telemetry" toml:"telemetry"`
+	Readiness  ReadinessConfig  `yaml:"readiness" toml:"readiness"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+}
+
+// ServerConfig holds listener settings and HTTP timeouts
+type ServerConfig struct {
+	Bind            string   `yaml:"bind" toml:"bind"`
+	Addr            string   `yaml:"addr" toml:"addr"`
+	ReadTimeout     Duration `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout    Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout     Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+	RequestTimeout  Duration `yaml:"requestTimeout" toml:"requestTimeout"`
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+}
+
+// ListenAddr returns the full address the server binds to
+func (s ServerConfig) ListenAddr() string {
+	return s.Bind + s.Addr
+}
+
+// TLSConfig enables HTTPS when both files are set. The files are re-read
+// when they change on disk, so certificates can be rotated without a restart.
+type TLSConfig struct {
+	CertFile string `yaml:"certFile" toml:"certFile"`
+	KeyFile  string `yaml:"keyFile" toml:"keyFile"`
+
+	// ClientCAFile is a PEM bundle client certificates must chain to (mTLS).
+	// ClientAuth is none, optional or require; it defaults to require when a
+	// bundle is set.
+	ClientCAFile string `yaml:"clientCaFile" toml:"clientCaFile"`
+	ClientAuth   string `yaml:"clientAuth" toml:"clientAuth"`
+}
+
+// Enabled reports whether TLS is configured
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// AccumulateConfig selects FAKE or REAL mode, the nodes to submit to and the
+// per-call timeouts
+type AccumulateConfig struct {
+	Real          bool       `yaml:"real" toml:"real"`
+	NodeURL       string     `yaml:"nodeUrl" toml:"nodeUrl"`
+	Nodes         []string   `yaml:"nodes" toml:"nodes"`     // additional nodes for the pool
+	Network       string     `yaml:"network" toml:"network"` // expected network ID, checked by /readyz
+	SubmitTimeout Duration   `yaml:"submitTimeout" toml:"submitTimeout"`
+	QueryTimeout  Duration   `yaml:"queryTimeout" toml:"queryTimeout"`
+	Pool          PoolConfig `yaml:"pool" toml:"pool"`
+	Fake          FakeConfig `yaml:"fake" toml:"fake"`
+
+	// ConfirmTimeout bounds how long a write waits for its transactions to be
+	// delivered before reporting them pending; 0 checks once. A create shares
+	// it between its account and document transactions, and it must be shorter
+	// than server.writeTimeout and server.requestTimeout so the answer is sent.
+	ConfirmTimeout Duration `yaml:"confirmTimeout" toml:"confirmTimeout"`
+}
+
+// NodeURLs returns NodeURL followed by Nodes, without duplicates
+func (a AccumulateConfig) NodeURLs() []string {
+	var urls []string
+	for _, u := range append([]string{a.NodeURL}, a.Nodes...) {
+		if u != "" && !slices.Contains(urls, u) {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// FakeConfig persists the simulated ledger used in FAKE mode. Point the
+// resolver and registrar at the same StateDir to resolve what was registered.
+type FakeConfig struct {
+	StateDir string `yaml:"stateDir" toml:"stateDir"` // empty keeps state in memory
+	Fixture  string `yaml:"fixture" toml:"fixture"`   // ledger export imported into an empty ledger
+}
+
+// PoolConfig tunes node health probes, circuit breaking and query retries
+type PoolConfig struct {
+	ProbeInterval    Duration `yaml:"probeInterval" toml:"probeInterval"` // 0 disables probes
+	ProbeTimeout     Duration `yaml:"probeTimeout" toml:"probeTimeout"`
+	FailureThreshold int      `yaml:"failureThreshold" toml:"failureThreshold"`
+	Cooldown         Duration `yaml:"cooldown" toml:"cooldown"`
+	Retries          int      `yaml:"retries" toml:"retries"`
+}
+
+// SecurityConfig holds API key, allowlist and rate limit settings
+type SecurityConfig struct {
+	APIKey    string          `yaml:"apiKey" toml:"apiKey"`
+	AllowList []string        `yaml:"allowList" toml:"allowList"` // CIDR/IP list
+	RateLimit RateLimitConfig `yaml:"rateLimit" toml:"rateLimit"`
+
+	// APIKeysFile holds issued API keys, each scoped to ADIs or DID prefixes
+	// and operations; the admin endpoints write it. Setting it enforces API
+	// keys even without APIKey.
+	APIKeysFile string `yaml:"apiKeysFile" toml:"apiKeysFile"`
+	// DailyQuota caps writes per API key, or client IP, per UTC day; 0 is
+	// unlimited. Keys may carry their own quota.
+	DailyQuota int `yaml:"dailyQuota" toml:"dailyQuota"`
+	// DIDAuth accepts updates and deactivations signed by a key of the DID's
+	// current document or key page in place of an API key
+	DIDAuth DIDAuthConfig `yaml:"didAuth" toml:"didAuth"`
+	// TrustedProxies lists the CIDR blocks or IPs of reverse proxies whose
+	// Forwarded, X-Forwarded-For and X-Real-IP headers name the client.
+	// Headers from other peers are ignored.
+	TrustedProxies []string `yaml:"trustedProxies" toml:"trustedProxies"`
+}
+
+// DIDAuthConfig enables DID-Auth request signatures
+type DIDAuthConfig struct {
+	Enabled bool     `yaml:"enabled" toml:"enabled"`
+	MaxSkew Duration `yaml:"maxSkew" toml:"maxSkew"` // how old or early a signature may be
+}
+
+// RateLimitConfig holds token bucket parameters
+type RateLimitConfig struct {
+	RPS   int `yaml:"rps" toml:"rps"`
+	Burst int `yaml:"burst" toml:"burst"`
+}
+
+// PolicyConfig selects the authorization policy
+type PolicyConfig struct {
+	Version string `yaml:"version" toml:"version"` // v1
+}
+
+// KeyStoreConfig selects where REAL mode signing keys come from
+type KeyStoreConfig struct {
+	Type string `yaml:"type" toml:"type"` // memory or file
+	Path string `yaml:"path" toml:"path"` // JSON key store, for type file
+
+	// KeyPages the registrar signs for; /readyz checks each has a key and credits
+	KeyPages []string `yaml:"keyPages" toml:"keyPages"`
+}
+
+// CreditsConfig controls credit checks before submissions and automatic
+// top-ups. Amounts are whole credits.
+type CreditsConfig struct {
+	Check            bool   `yaml:"check" toml:"check"`                       // reject early when the signer cannot pay
+	TopUpThreshold   uint64 `yaml:"topUpThreshold" toml:"topUpThreshold"`     // top up when an operation would leave less
+	TopUpAmount      uint64 `yaml:"topUpAmount" toml:"topUpAmount"`           // credits bought per top-up; 0 disables top-ups
+	LiteTokenAccount string `yaml:"liteTokenAccount" toml:"liteTokenAccount"` // ACME source for top-ups in REAL mode
+}
+
+// LoggingConfig mirrors logging.Config
+type LoggingConfig struct {
+	Level      string `yaml:"level" toml:"level"`
+	Format     string `yaml:"format" toml:"format"`
+	RedactDIDs bool   `yaml:"redactDids" toml:"redactDids"`
+	RedactIPs  bool   `yaml:"redactIps" toml:"redactIps"`
+}
+
+// TelemetryConfig holds tracing and metrics settings
+type TelemetryConfig struct {
+	OTLPEndpoint     string  `yaml:"otlpEndpoint" toml:"otlpEndpoint"`
+	OTLPInsecure     bool    `yaml:"otlpInsecure" toml:"otlpInsecure"`
+	TraceSampleRatio float64 `yaml:"traceSampleRatio" toml:"traceSampleRatio"`
+	Metrics          bool    `yaml:"metrics" toml:"metrics"`
+}
+
+// ReadinessConfig tunes the /readyz checks
+type ReadinessConfig struct {
+	Timeout    Duration `yaml:"timeout" toml:"timeout"`       // per check
+	MinCredits uint64   `yaml:"minCredits" toml:"minCredits"` // warn below this balance; 0 disables
+}
+
+// IdempotencyConfig controls how long write responses are kept for replay to
+// requests retried with the same Idempotency-Key
+type IdempotencyConfig struct {
+	TTL        Duration `yaml:"ttl" toml:"ttl"`               // 0 disables Idempotency-Key handling
+	MaxEntries int      `yaml:"maxEntries" toml:"maxEntries"` // keys kept in memory
+}
+
+// Default returns the built-in configuration, matching the historical flag defaults
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Bind:            "127.0.0.1",
+			Addr:            ":8081",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(15 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			RequestTimeout:  Duration(30 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Accumulate: AccumulateConfig{
+			SubmitTimeout:  Duration(30 * time.Second),
+			QueryTimeout:   Duration(15 * time.Second),
+			ConfirmTimeout: Duration(10 * time.Second),
+			Pool: PoolConfig{
+				ProbeInterval:    Duration(10 * time.Second),
+				ProbeTimeout:     Duration(5 * time.Second),
+				FailureThreshold: 3,
+				Cooldown:         Duration(30 * time.Second),
+				Retries:          2,
+			},
+		},
+		Security: SecurityConfig{
+			RateLimit: RateLimitConfig{RPS: 50, Burst: 100},
+			DIDAuth:   DIDAuthConfig{MaxSkew: Duration(5 * time.Minute)},
+		},
+		Policy: PolicyConfig{
+			Version: "v1",
+		},
+		KeyStore: KeyStoreConfig{
+			Type: "memory",
+		},
+		Credits: CreditsConfig{
+			Check: true,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Telemetry: TelemetryConfig{
+			TraceSampleRatio: 1.0,
+			Metrics:          true,
+		},
+		Readiness: ReadinessConfig{
+			Timeout:    Duration(5 * time.Second),
+			MinCredits: 100,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:        Duration(24 * time.Hour),
+			MaxEntries: 10000,
+		},
+	}
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		add("server.addr is required")
+	}
+	for _, t := range []struct {
+		name string
+		d    Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.requestTimeout", c.Server.RequestTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"accumulate.submitTimeout", c.Accumulate.SubmitTimeout},
+		{"accumulate.queryTimeout", c.Accumulate.QueryTimeout},
+		{"readiness.timeout", c.Readiness.Timeout},
+	} {
+		if t.d <= 0 {
+			add("%s must be positive", t.name)
+		}
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		add("tls.certFile and tls.keyFile must be set together")
+	}
+	if (c.TLS.ClientCAFile != "" || c.TLS.ClientAuth != "") && !c.TLS.Enabled() {
+		add("tls.clientCaFile and tls.clientAuth require tls.certFile and tls.keyFile")
+	}
+	if _, err := tlsutil.ParseClientAuth(c.TLS.ClientAuth, c.TLS.ClientCAFile != ""); err != nil {
+		add("tls.clientAuth: %v", err)
+	}
+
+	if c.Accumulate.Real {
+		urls := c.Accumulate.NodeURLs()
+		if len(urls) == 0 {
+			add("accumulate.nodeUrl (ACC_NODE_URL) or accumulate.nodes is required in REAL mode")
+		}
+		for _, nodeURL := range urls {
+			if u, err := url.Parse(nodeURL); err != nil || u.Scheme == "" || u.Host == "" {
+				add("accumulate node URL %q is not a valid URL", nodeURL)
+			}
+		}
+	}
+	if c.Accumulate.ConfirmTimeout < 0 {
+		add("accumulate.confirmTimeout must not be negative")
+	}
+	if c.Accumulate.ConfirmTimeout >= c.Server.WriteTimeout {
+		add("accumulate.confirmTimeout must be shorter than server.writeTimeout")
+	}
+	if c.Accumulate.ConfirmTimeout >= c.Server.RequestTimeout {
+		add("accumulate.confirmTimeout must be shorter than server.requestTimeout")
+	}
+	pool := c.Accumulate.Pool
+	if pool.ProbeInterval < 0 {
+		add("accumulate.pool.probeInterval must not be negative")
+	}
+	if pool.ProbeTimeout <= 0 {
+		add("accumulate.pool.probeTimeout must be positive")
+	}
+	if pool.FailureThreshold < 1 {
+		add("accumulate.pool.failureThreshold must be at least 1")
+	}
+	if pool.Cooldown <= 0 {
+		add("accumulate.pool.cooldown must be positive")
+	}
+	if pool.Retries < 0 {
+		add("accumulate.pool.retries must not be negative")
+	}
+
+	for _, list := range []struct {
+		name    string
+		entries []string
+	}{
+		{"security.allowList", c.Security.AllowList},
+		{"security.trustedProxies", c.Security.TrustedProxies},
+	} {
+		for _, entry := range list.entries {
+			if strings.Contains(entry, "/") {
+				if _, _, err := net.ParseCIDR(entry); err != nil {
+					add("%s: invalid CIDR %q", list.name, entry)
+				}
+			} else if net.ParseIP(entry) == nil {
+				add("%s: invalid IP %q", list.name, entry)
+			}
+		}
+	}
+	if c.Security.RateLimit.RPS <= 0 {
+		add("security.rateLimit.rps must be positive")
+	}
+	if c.Security.RateLimit.Burst <= 0 {
+		add("security.rateLimit.burst must be positive")
+	}
+	if c.Security.DailyQuota < 0 {
+		add("security.dailyQuota must not be negative")
+	}
+	if c.Security.DIDAuth.Enabled && c.Security.DIDAuth.MaxSkew <= 0 {
+		add("security.didAuth.maxSkew must be positive")
+	}
+
+	if c.Policy.Version != "v1" {
+		add("policy.version must be 'v1', got %q", c.Policy.Version)
+	}
+
+	switch c.KeyStore.Type {
+	case "memory":
+	case "file":
+		if c.KeyStore.Path == "" {
+			add("keyStore.path is required when keyStore.type is 'file'")
+		}
+	default:
+		add("keyStore.type must be 'memory' or 'file', got %q", c.KeyStore.Type)
+	}
+
+	if c.Credits.TopUpAmount > 0 {
+		if !c.Credits.Check {
+			add("credits.topUpAmount requires credits.check")
+		}
+		if c.Accumulate.Real && c.Credits.LiteTokenAccount == "" {
+			add("credits.liteTokenAccount is required for top-ups in REAL mode")
+		}
+	}
+
+	if c.Idempotency.TTL < 0 {
+		add("idempotency.ttl must not be negative")
+	}
+	if c.Idempotency.TTL > 0 && c.Idempotency.MaxEntries < 1 {
+		add("idempotency.maxEntries must be at least 1")
+	}
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		add("logging.level: %v", err)
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		add("logging.format must be 'json' or 'text', got %q", c.Logging.Format)
+	}
+
+	if c.Telemetry.TraceSampleRatio < 0 || c.Telemetry.TraceSampleRatio > 1 {
+		add("telemetry.traceSampleRatio must be between 0 and 1")
+	}
+
+	return errors.Join(errs...)
+}
+
+// RestartRequired lists the settings that differ between c and next but
+// cannot be applied by a reload. Log level, API key, allowlist, rate limits
+// and daily quota are reloadable; everything else needs a restart.
+func (c *Config) RestartRequired(next *Config) []string {
+	var changed []string
+	check := func(name string, same bool) {
+		if !same {
+			changed = append(changed, name)
+		}
+	}
+
+	check("server", c.Server == next.Server)
+	check("tls", c.TLS == next.TLS)
+	check("security.apiKeysFile", c.Security.APIKeysFile == next.Security.APIKeysFile)
+	check("security.didAuth", c.Security.DIDAuth == next.Security.DIDAuth)
+	check("accumulate", reflect.DeepEqual(c.Accumulate, next.Accumulate))
+	check("policy", c.Policy == next.Policy)
+	check("keyStore", reflect.DeepEqual(c.KeyStore, next.KeyStore))
+	check("credits", c.Credits == next.Credits)
+	check("logging.format", c.Logging.Format == next.Logging.Format)
+	check("logging.redactDids", c.Logging.RedactDIDs == next.Logging.RedactDIDs)
+	check("logging.redactIps", c.Logging.RedactIPs == next.Logging.RedactIPs)
+	check("telemetry", c.Telemetry == next.Telemetry)
+	check("readiness", c.Readiness == next.Readiness)
+	check("idempotency", c.Idempotency == next.Idempotency)
+
+	return changed
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestDefault_IsValid(t *testing.T) {
+	require.NoError(t, Default().Validate())
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		path := writeFile(t, "registrar.yaml", `
+accumulate:
+  submitTimeout: 45s
+  confirmTimeout: 0s
+security:
+  apiKey: secret
+  allowList: ["10.0.0.0/8", "192.168.1.10"]
+  rateLimit:
+    rps: 5
+keyStore:
+  type: file
+  path: /etc/registrar/keys.json
+`)
+		cfg := Default()
+		require.NoError(t, cfg.loadFile(path))
+
+		assert.Equal(t, 45*time.Second, cfg.Accumulate.SubmitTimeout.Std())
+		assert.Equal(t, 15*time.Second, cfg.Accumulate.QueryTimeout.Std(), "unset keys keep their defaults")
+		assert.Zero(t, cfg.Accumulate.ConfirmTimeout, "a zero confirm timeout is allowed")
+		assert.Equal(t, "secret", cfg.Security.APIKey)
+		assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10"}, cfg.Security.AllowList)
+		assert.Equal(t, 5, cfg.Security.RateLimit.RPS)
+		assert.Equal(t, 100, cfg.Security.RateLimit.Burst)
+		assert.Equal(t, "file", cfg.KeyStore.Type)
+		require.NoError(t, cfg.Validate())
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		path := writeFile(t, "registrar.toml", `
+[server]
+addr = ":9091"
+
+[security.rateLimit]
+burst = 10
+`)
+		cfg := Default()
+		require.NoError(t, cfg.loadFile(path))
+
+		assert.Equal(t, ":9091", cfg.Server.Addr)
+		assert.Equal(t, 10, cfg.Security.RateLimit.Burst)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeFile(t, "registrar.yaml", "security:\n  apikey: secret\n")
+		assert.Error(t, Default().loadFile(path))
+	})
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"REGISTRAR_API_KEY":       "from-env",
+		"REGISTRAR_ALLOWLIST":     "127.0.0.1, 10.0.0.0/8",
+		"REGISTRAR_RATE_RPS":      "7",
+		"REGISTRAR_KEYSTORE_TYPE": "file",
+		"ACC_NODE_URL":            "http://node:26660/v3",
+		"REGISTRAR_FAKE_FIXTURE":  "fixtures/ledger.jsonl",
+	}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+
+	cfg := Default()
+	require.NoError(t, cfg.ApplyEnv(lookup))
+
+	assert.Equal(t, "from-env", cfg.Security.APIKey)
+	assert.Equal(t, []string{"127.0.0.1", "10.0.0.0/8"}, cfg.Security.AllowList)
+	assert.Equal(t, 7, cfg.Security.RateLimit.RPS)
+	assert.Equal(t, "file", cfg.KeyStore.Type)
+	assert.Equal(t, "http://node:26660/v3", cfg.Accumulate.NodeURL)
+	assert.Equal(t, "fixtures/ledger.jsonl", cfg.Accumulate.Fake.Fixture)
+
+	env = map[string]string{"REGISTRAR_RATE_RPS": "fast"}
+	err := Default().ApplyEnv(lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "REGISTRAR_RATE_RPS")
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Accumulate.SubmitTimeout = 0
+	cfg.Security.AllowList = []string{"10.0.0.0/33", "not-an-ip"}
+	cfg.Security.RateLimit.RPS = 0
+	cfg.Policy.Version = "v2"
+	cfg.KeyStore.Type = "file"
+	cfg.Idempotency.MaxEntries = 0
+	cfg.Security.DIDAuth = DIDAuthConfig{Enabled: true}
+	cfg.TLS.ClientCAFile = "ca.pem"
+	cfg.Security.TrustedProxies = []string{"10.0.0.1/40"}
+	cfg.Accumulate.ConfirmTimeout = cfg.Server.WriteTimeout
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		"accumulate.submitTimeout",
+		`invalid CIDR "10.0.0.0/33"`,
+		`invalid IP "not-an-ip"`,
+		`security.trustedProxies: invalid CIDR "10.0.0.1/40"`,
+		"security.rateLimit.rps",
+		"policy.version",
+		"keyStore.path",
+		"idempotency.maxEntries",
+		"security.didAuth.maxSkew",
+		"tls.clientCaFile and tls.clientAuth require tls.certFile",
+		"accumulate.confirmTimeout must be shorter than server.writeTimeout",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	cur := Default()
+
+	next := Default()
+	next.Logging.Level = "debug"
+	next.Security.APIKey = "rotated"
+	next.Security.AllowList = []string{"10.0.0.0/8"}
+	next.Security.RateLimit = RateLimitConfig{RPS: 1, Burst: 1}
+	assert.Empty(t, cur.RestartRequired(next), "reloadable settings do not need a restart")
+
+	next.KeyStore.Type = "file"
+	next.TLS.CertFile = "cert.pem"
+	assert.Equal(t, []string{"tls", "keyStore"}, cur.RestartRequired(next))
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+
+	"github.com/opendlt/accu-did/shared/config"
+)
+
+// EnvPrefix starts the name of every registrar environment variable except
+// the ones shared with other services (ACC_NODE_URL, ACC_NODE_URLS, LOG_LEVEL)
+const EnvPrefix = "REGISTRAR_"
+
+// Duration is a time.Duration that reads and writes strings like "15s"
+type Duration = config.Duration
+
+// Load builds a configuration from the defaults, the file at path (skipped
+// when path is empty) and the process environment. The result is not
+// validated so callers can apply flag overrides first.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML (.yaml, .yml) or TOML (.toml) file over c
+func (c *Config) loadFile(path string) error {
+	return config.LoadFile(path, c)
+}
+
+// ApplyEnv overrides c with environment variables found by lookup
+// (os.LookupEnv in production)
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	return config.ApplyEnv(EnvPrefix, c.envBindings(), lookup)
+}
+
+func (c *Config) envBindings() []config.Binding {
+	return []config.Binding{
+		config.Env("BIND", config.StringVar(&c.Server.Bind)),
+		config.Env("ADDR", config.StringVar(&c.Server.Addr)),
+		config.Env("READ_TIMEOUT", config.DurationVar(&c.Server.ReadTimeout)),
+		config.Env("WRITE_TIMEOUT", config.DurationVar(&c.Server.WriteTimeout)),
+		config.Env("IDLE_TIMEOUT", config.DurationVar(&c.Server.IdleTimeout)),
+		config.Env("REQUEST_TIMEOUT", config.DurationVar(&c.Server.RequestTimeout)),
+		config.Env("SHUTDOWN_TIMEOUT", config.DurationVar(&c.Server.ShutdownTimeout)),
+		config.Env("TLS_CERT_FILE", config.StringVar(&c.TLS.CertFile)),
+		config.Env("TLS_KEY_FILE", config.StringVar(&c.TLS.KeyFile)),
+		config.Env("TLS_CLIENT_CA_FILE", config.StringVar(&c.TLS.ClientCAFile)),
+		config.Env("TLS_CLIENT_AUTH", config.StringVar(&c.TLS.ClientAuth)),
+		config.Env("REAL", config.BoolVar(&c.Accumulate.Real)),
+		config.GlobalEnv("ACC_NODE_URL", config.StringVar(&c.Accumulate.NodeURL)),
+		config.GlobalEnv("ACC_NODE_URLS", config.ListVar(&c.Accumulate.Nodes)),
+		config.Env("POOL_PROBE_INTERVAL", config.DurationVar(&c.Accumulate.Pool.ProbeInterval)),
+		config.Env("POOL_PROBE_TIMEOUT", config.DurationVar(&c.Accumulate.Pool.ProbeTimeout)),
+		config.Env("POOL_FAILURE_THRESHOLD", config.IntVar(&c.Accumulate.Pool.FailureThreshold)),
+		config.Env("POOL_COOLDOWN", config.DurationVar(&c.Accumulate.Pool.Cooldown)),
+		config.Env("POOL_RETRIES", config.IntVar(&c.Accumulate.Pool.Retries)),
+		config.Env("ACC_NETWORK", config.StringVar(&c.Accumulate.Network)),
+		config.Env("FAKE_STATE_DIR", config.StringVar(&c.Accumulate.Fake.StateDir)),
+		config.Env("FAKE_FIXTURE", config.StringVar(&c.Accumulate.Fake.Fixture)),
+		config.Env("SUBMIT_TIMEOUT", config.DurationVar(&c.Accumulate.SubmitTimeout)),
+		config.Env("QUERY_TIMEOUT", config.DurationVar(&c.Accumulate.QueryTimeout)),
+		config.Env("CONFIRM_TIMEOUT", config.DurationVar(&c.Accumulate.ConfirmTimeout)),
+		config.Env("API_KEY", config.StringVar(&c.Security.APIKey)),
+		config.Env("ALLOWLIST", config.ListVar(&c.Security.AllowList)),
+		config.Env("RATE_RPS", config.IntVar(&c.Security.RateLimit.RPS)),
+		config.Env("RATE_BURST", config.IntVar(&c.Security.RateLimit.Burst)),
+		config.Env("API_KEYS_FILE", config.StringVar(&c.Security.APIKeysFile)),
+		config.Env("DAILY_QUOTA", config.IntVar(&c.Security.DailyQuota)),
+		config.Env("DID_AUTH", config.BoolVar(&c.Security.DIDAuth.Enabled)),
+		config.Env("DID_AUTH_MAX_SKEW", config.DurationVar(&c.Security.DIDAuth.MaxSkew)),
+		config.Env("TRUSTED_PROXIES", config.ListVar(&c.Security.TrustedProxies)),
+		config.Env("POLICY_VERSION", config.StringVar(&c.Policy.Version)),
+		config.Env("KEYSTORE_TYPE", config.StringVar(&c.KeyStore.Type)),
+		config.Env("KEYSTORE_PATH", config.StringVar(&c.KeyStore.Path)),
+		config.Env("KEYSTORE_KEY_PAGES", config.ListVar(&c.KeyStore.KeyPages)),
+		config.Env("CREDITS_CHECK", config.BoolVar(&c.Credits.Check)),
+		config.Env("CREDITS_TOPUP_THRESHOLD", config.Uint64Var(&c.Credits.TopUpThreshold)),
+		config.Env("CREDITS_TOPUP_AMOUNT", config.Uint64Var(&c.Credits.TopUpAmount)),
+		config.Env("CREDITS_LITE_TOKEN_ACCOUNT", config.StringVar(&c.Credits.LiteTokenAccount)),
+		config.GlobalEnv("LOG_LEVEL", config.StringVar(&c.Logging.Level)),
+		config.Env("LOG_FORMAT", config.StringVar(&c.Logging.Format)),
+		config.Env("LOG_REDACT_DIDS", config.BoolVar(&c.Logging.RedactDIDs)),
+		config.Env("LOG_REDACT_IPS", config.BoolVar(&c.Logging.RedactIPs)),
+		config.Env("OTLP_ENDPOINT", config.StringVar(&c.Telemetry.OTLPEndpoint)),
+		config.Env("OTLP_INSECURE", config.BoolVar(&c.Telemetry.OTLPInsecure)),
+		config.Env("TRACE_SAMPLE_RATIO", config.FloatVar(&c.Telemetry.TraceSampleRatio)),
+		config.Env("METRICS", config.BoolVar(&c.Telemetry.Metrics)),
+		config.Env("READINESS_TIMEOUT", config.DurationVar(&c.Readiness.Timeout)),
+		config.Env("READINESS_MIN_CREDITS", config.Uint64Var(&c.Readiness.MinCredits)),
+		config.Env("IDEMPOTENCY_TTL", config.DurationVar(&c.Idempotency.TTL)),
+		config.Env("IDEMPOTENCY_MAX_ENTRIES", config.IntVar(&c.Idempotency.MaxEntries)),
+	}
+}
+
+// SplitList splits a comma-separated flag or env value, dropping empty items
+func SplitList(v string) []string {
+	return config.SplitList(v)
+}
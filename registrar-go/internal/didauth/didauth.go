@@ -0,0 +1,337 @@
+// Package didauth authenticates registrar requests signed by a DID
+// controller instead of carrying an operator-issued API key.
+//
+// The caller signs the request body with a detached compact JWS (RFC 7515
+// appendix F) and sends it as
+//
+//	Authorization: DIDAuth <base64url(header)>..<base64url(signature)>
+//
+// The signing input is base64url(header) + "." + base64url(body). The
+// protected header binds the signature to the request and names the key:
+//
+//	{"alg":"EdDSA","kid":"did:acc:alice.acme#key-1","iat":1735689600,
+//	 "htm":"POST","htu":"/update"}
+//
+// A kid that is a DID URL names a verification method of that DID's current
+// document, listed under authentication or capabilityInvocation; the caller
+// may then write that DID only. A kid that is the ADI's key page (e.g.
+// acc://alice.acme/book/1) also carries the public key as a "jwk" header and
+// must be on that page; the caller may then write every DID of the ADI.
+package didauth
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
+)
+
+// Scheme is the Authorization scheme of signed requests
+const Scheme = "DIDAuth"
+
+// Algorithm is the only JWS algorithm accepted
+const Algorithm = "EdDSA"
+
+// DefaultMaxSkew is how far iat may be from the registrar's clock
+const DefaultMaxSkew = 5 * time.Minute
+
+// DefaultMaxSignatures bounds how many accepted signatures are remembered
+// to reject replays
+const DefaultMaxSignatures = 100_000
+
+// Errors returned by Verify
+var (
+	ErrInvalidSignature = errors.New("invalid DID-Auth signature")
+	ErrUnknownKey       = errors.New("signing key is not authorized for the DID")
+	ErrReplayed         = errors.New("DID-Auth signature was already used")
+	ErrTooManyRequests  = errors.New("too many recent DID-Auth signatures to check for replay")
+)
+
+// Header is the protected header of a DID-Auth signature
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	JWK *JWK   `json:"jwk,omitempty"`
+	Iat int64  `json:"iat"`           // Unix seconds
+	Htm string `json:"htm"`           // request method
+	Htu string `json:"htu"`           // request path
+	Jti string `json:"jti,omitempty"` // optional nonce; tells apart identical requests signed in the same second
+}
+
+// JWK is an Ed25519 public key (RFC 8037)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// Principal is a verified signer
+type Principal struct {
+	KeyID string // kid of the signature
+	DID   string // set when signed with a document key
+	ADI   string // set when signed with a key page key
+}
+
+// APIKey returns the principal as a scoped key for security.Authorize, rate
+// limits and quotas. Signed callers may update and deactivate, never create.
+func (p *Principal) APIKey() *security.APIKey {
+	key := &security.APIKey{
+		ID:         p.KeyID,
+		Name:       "did-auth",
+		Operations: []string{security.OpUpdate, security.OpDeactivate},
+	}
+	if p.DID != "" {
+		key.DIDs = []string{p.DID}
+	}
+	if p.ADI != "" {
+		key.ADIs = []string{p.ADI}
+	}
+	return key
+}
+
+// Sign returns the Authorization credential, without the scheme, for body
+// signed by key under header. Alg is filled in.
+func Sign(key ed25519.PrivateKey, header Header, body []byte) (string, error) {
+	header.Alg = Algorithm
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(encoded)
+	signature := ed25519.Sign(key, signingInput(protected, body))
+	return protected + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func signingInput(protected string, body []byte) []byte {
+	return []byte(protected + "." + base64.RawURLEncoding.EncodeToString(body))
+}
+
+// Verifier checks DID-Auth signatures against the keys the signer's DID
+// currently lists. Each signature is accepted once.
+type Verifier struct {
+	resolver Resolver
+	maxSkew  time.Duration
+	now      func() time.Time
+
+	mu            sync.Mutex
+	maxSignatures int
+	seen          map[string]struct{}
+	expiry        expiryQueue // seen signatures by when they may be forgotten
+}
+
+// NewVerifier creates a verifier resolving keys with resolver
+func NewVerifier(resolver Resolver) *Verifier {
+	return &Verifier{
+		resolver: resolver,
+		maxSkew:  DefaultMaxSkew,
+		now:      time.Now,
+
+		maxSignatures: DefaultMaxSignatures,
+		seen:          make(map[string]struct{}),
+	}
+}
+
+// WithMaxSkew sets how far iat may be from the registrar's clock
+func (v *Verifier) WithMaxSkew(maxSkew time.Duration) *Verifier {
+	if maxSkew > 0 {
+		v.maxSkew = maxSkew
+	}
+	return v
+}
+
+// WithMaxSignatures sets how many unexpired signatures are remembered; once
+// that many are, new signatures are refused until the oldest expire
+func (v *Verifier) WithMaxSignatures(n int) *Verifier {
+	if n > 0 {
+		v.maxSignatures = n
+	}
+	return v
+}
+
+// Verify checks credential, the Authorization value after the scheme, signs
+// body for the method and path of r
+func (v *Verifier) Verify(ctx context.Context, r *http.Request, credential string, body []byte) (*Principal, error) {
+	parts := strings.Split(credential, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return nil, fmt.Errorf("%w: expected a detached compact JWS", ErrInvalidSignature)
+	}
+	var header Header
+	encoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err == nil {
+		err = json.Unmarshal(encoded, &header)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	if header.Alg != Algorithm {
+		return nil, fmt.Errorf("%w: alg must be %s", ErrInvalidSignature, Algorithm)
+	}
+	if !strings.EqualFold(header.Htm, r.Method) || header.Htu != r.URL.Path {
+		return nil, fmt.Errorf("%w: htm and htu must match the request", ErrInvalidSignature)
+	}
+	now := v.now()
+	issued := time.Unix(header.Iat, 0)
+	if issued.Before(now.Add(-v.maxSkew)) || issued.After(now.Add(v.maxSkew)) {
+		return nil, fmt.Errorf("%w: iat is outside the allowed clock skew", ErrInvalidSignature)
+	}
+
+	publicKey, principal, err := v.signingKey(ctx, header)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(publicKey, signingInput(parts[0], body), signature) {
+		return nil, fmt.Errorf("%w: signature does not match", ErrInvalidSignature)
+	}
+	if err := v.remember(parts[2], issued.Add(v.maxSkew), now); err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+// signingKey returns the key named by the header, once the signer's DID or
+// ADI is found to list it
+func (v *Verifier) signingKey(ctx context.Context, header Header) (ed25519.PublicKey, *Principal, error) {
+	if strings.HasPrefix(header.Kid, "did:") {
+		did, _, _ := strings.Cut(header.Kid, "#")
+		doc, err := v.resolver.Document(ctx, did)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %w", ErrUnknownKey, header.Kid, err)
+		}
+		publicKey, err := documentKey(doc, did, header.Kid)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s: %w", ErrUnknownKey, header.Kid, err)
+		}
+		return publicKey, &Principal{KeyID: header.Kid, DID: did}, nil
+	}
+
+	if header.JWK == nil {
+		return nil, nil, fmt.Errorf("%w: a key page kid needs a jwk header", ErrInvalidSignature)
+	}
+	publicKey, err := header.JWK.publicKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	adi, err := keyPageADI(header.Kid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %w", ErrUnknownKey, header.Kid, err)
+	}
+	page, err := v.resolver.KeyPage(ctx, adi)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %w", ErrUnknownKey, header.Kid, err)
+	}
+	if !pageHasKey(page, publicKey) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownKey, header.Kid)
+	}
+	return publicKey, &Principal{KeyID: header.Kid, ADI: adi}, nil
+}
+
+// remember records a signature until it expires. It fails with ErrReplayed
+// when the signature was seen before and ErrTooManyRequests when the
+// verifier already remembers maxSignatures signatures.
+func (v *Verifier) remember(signature string, expires, now time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for len(v.expiry) > 0 && now.After(v.expiry[0].expires) {
+		delete(v.seen, heap.Pop(&v.expiry).(seenSignature).signature)
+	}
+	if _, ok := v.seen[signature]; ok {
+		return ErrReplayed
+	}
+	if len(v.seen) >= v.maxSignatures {
+		return ErrTooManyRequests
+	}
+	v.seen[signature] = struct{}{}
+	heap.Push(&v.expiry, seenSignature{signature: signature, expires: expires})
+	return nil
+}
+
+// seenSignature is an accepted signature and when it may be forgotten
+type seenSignature struct {
+	signature string
+	expires   time.Time
+}
+
+// expiryQueue is a min-heap of seen signatures ordered by expiry
+type expiryQueue []seenSignature
+
+func (q expiryQueue) Len() int           { return len(q) }
+func (q expiryQueue) Less(i, j int) bool { return q[i].expires.Before(q[j].expires) }
+func (q expiryQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x interface{}) { *q = append(*q, x.(seenSignature)) }
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// Middleware returns middleware that authenticates requests carrying a
+// DIDAuth Authorization header and passes others through untouched. It runs
+// ahead of API key authentication, which accepts the signer as the caller.
+func (v *Verifier) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential, ok := strings.CutPrefix(r.Header.Get("Authorization"), Scheme+" ")
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, security.MaxBodyBytes+1))
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "invalidRequest", "Failed to read request body")
+				return
+			}
+			if len(body) > security.MaxBodyBytes {
+				writeError(w, r, http.StatusRequestEntityTooLarge, "invalidRequest", "Request body too large to verify")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			principal, err := v.Verify(r.Context(), r, credential, body)
+			if errors.Is(err, ErrTooManyRequests) {
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, http.StatusServiceUnavailable, "serviceUnavailable", err.Error())
+				return
+			}
+			if err != nil {
+				writeError(w, r, http.StatusUnauthorized, "unauthorized", err.Error())
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(security.WithAPIKey(r.Context(), principal.APIKey())))
+		})
+	}
+}
+
+// writeError writes a canonical error response
+func writeError(w http.ResponseWriter, r *http.Request, status int, errorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(struct {
+		Code      int    `json:"code"`
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	}{status, errorCode, message, logging.RequestIDFromContext(r.Context())})
+}
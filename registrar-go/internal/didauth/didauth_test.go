@@ -0,0 +1,309 @@
+package didauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/registrar-go/internal/ops"
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+const aliceDID = "did:acc:alice.acme"
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return pub, priv
+}
+
+// newFake returns a FAKE submitter holding alice's document, listing pub as
+// key-1, and bob's key page holding pagePub
+func newFake(t *testing.T, pub, pagePub ed25519.PublicKey) acc.Submitter {
+	t.Helper()
+	fake := acc.NewFakeSubmitterWithLedger(ledger.New())
+	doc, err := json.Marshal(map[string]interface{}{
+		"id": aliceDID,
+		"verificationMethod": []interface{}{
+			map[string]interface{}{
+				"id":   "#key-1",
+				"type": "JsonWebKey2020",
+				"publicKeyJwk": map[string]interface{}{
+					"kty": "OKP", "crv": "Ed25519", "x": base64.RawURLEncoding.EncodeToString(pub),
+				},
+			},
+			map[string]interface{}{
+				"id":           aliceDID + "#assert",
+				"type":         "Ed25519VerificationKey2018",
+				"publicKeyHex": hex.EncodeToString(pub),
+			},
+		},
+		"authentication": []interface{}{aliceDID + "#key-1"},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = fake.WriteDataEntry(ctx, "acc://alice.acme/did", doc)
+	require.NoError(t, err)
+	_, err = fake.UpdateKeyPage(ctx, "acc://bob.acme/book/1", []acc.KeyPageOperation{
+		{Type: "add", PublicKey: "ed25519:" + hex.EncodeToString(pagePub), KeyType: "ed25519"},
+	})
+	require.NoError(t, err)
+	return acc.NewInstrumentedSubmitter(fake, nil)
+}
+
+// signedRequest builds a POST to path signed by key under header
+func signedRequest(t *testing.T, key ed25519.PrivateKey, header Header, path string, body []byte) *http.Request {
+	t.Helper()
+	if header.Htm == "" {
+		header.Htm = http.MethodPost
+	}
+	if header.Htu == "" {
+		header.Htu = path
+	}
+	if header.Iat == 0 {
+		header.Iat = time.Now().Unix()
+	}
+	credential, err := Sign(key, header, body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", Scheme+" "+credential)
+	return req
+}
+
+func TestMiddleware_DocumentKey(t *testing.T) {
+	pub, priv := newKey(t)
+	pagePub, _ := newKey(t)
+	verifier := NewVerifier(NewSubmitterResolver(newFake(t, pub, pagePub)))
+
+	var seen *security.APIKey
+	var seenBody []byte
+	h := verifier.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = security.APIKeyFromContext(r.Context())
+		seenBody, _ = io.ReadAll(r.Body)
+	}))
+
+	body := []byte(`{"did":"did:acc:alice.acme","didDocument":{"id":"did:acc:alice.acme"}}`)
+	req := signedRequest(t, priv, Header{Kid: aliceDID + "#key-1"}, "/update", body)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, body, seenBody, "the body is passed on")
+
+	require.NotNil(t, seen)
+	assert.Equal(t, aliceDID+"#key-1", seen.ID)
+	ctx := security.WithAPIKey(context.Background(), seen)
+	assert.NoError(t, security.Authorize(ctx, security.OpUpdate, aliceDID))
+	assert.NoError(t, security.Authorize(ctx, security.OpDeactivate, aliceDID))
+	assert.Error(t, security.Authorize(ctx, security.OpUpdate, "did:acc:alice.acme/other"), "a document key covers its own DID only")
+	assert.Error(t, security.Authorize(ctx, security.OpCreate, aliceDID))
+
+	// The same signature is accepted once
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequestReplay(req, body))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "already used")
+
+	// Requests without a DIDAuth header pass through untouched
+	seen = nil
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/update", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, seen)
+}
+
+// signedRequestReplay copies req with its body
+func signedRequestReplay(req *http.Request, body []byte) *http.Request {
+	replay := httptest.NewRequest(req.Method, req.URL.Path, bytes.NewReader(body))
+	replay.Header = req.Header.Clone()
+	return replay
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	pub, priv := newKey(t)
+	pagePub, _ := newKey(t)
+	_, otherPriv := newKey(t)
+	verifier := NewVerifier(NewSubmitterResolver(newFake(t, pub, pagePub)))
+	body := []byte(`{"did":"did:acc:alice.acme"}`)
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		wantErr error
+	}{
+		{"wrong key", signedRequest(t, otherPriv, Header{Kid: aliceDID + "#key-1"}, "/update", body), ErrInvalidSignature},
+		{"other path", signedRequest(t, priv, Header{Kid: aliceDID + "#key-1", Htu: "/deactivate"}, "/update", body), ErrInvalidSignature},
+		{"stale", signedRequest(t, priv, Header{Kid: aliceDID + "#key-1", Iat: time.Now().Add(-time.Hour).Unix()}, "/update", body), ErrInvalidSignature},
+		{"not an authentication key", signedRequest(t, priv, Header{Kid: aliceDID + "#assert"}, "/update", body), ErrUnknownKey},
+		{"unknown DID", signedRequest(t, priv, Header{Kid: "did:acc:carol.acme#key-1"}, "/update", body), ErrUnknownKey},
+		{"key page without jwk", signedRequest(t, priv, Header{Kid: "acc://bob.acme/book/1"}, "/update", body), ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential := tt.req.Header.Get("Authorization")[len(Scheme)+1:]
+			_, err := verifier.Verify(context.Background(), tt.req, credential, body)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	// A tampered body breaks the signature
+	req := signedRequest(t, priv, Header{Kid: aliceDID + "#key-1"}, "/update", body)
+	credential := req.Header.Get("Authorization")[len(Scheme)+1:]
+	_, err := verifier.Verify(context.Background(), req, credential, []byte(`{"did":"did:acc:bob.acme"}`))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestVerify_ReplayCache(t *testing.T) {
+	pub, priv := newKey(t)
+	pagePub, _ := newKey(t)
+	verifier := NewVerifier(NewSubmitterResolver(newFake(t, pub, pagePub))).WithMaxSignatures(2)
+	clock := time.Now()
+	verifier.now = func() time.Time { return clock }
+	body := []byte(`{"did":"did:acc:alice.acme"}`)
+
+	verify := func(jti string) error {
+		req := signedRequest(t, priv, Header{Kid: aliceDID + "#key-1", Iat: clock.Unix(), Jti: jti}, "/update", body)
+		credential := req.Header.Get("Authorization")[len(Scheme)+1:]
+		_, err := verifier.Verify(context.Background(), req, credential, body)
+		return err
+	}
+
+	require.NoError(t, verify("1"))
+	require.NoError(t, verify("2"))
+	assert.ErrorIs(t, verify("3"), ErrTooManyRequests, "the cache is full until signatures expire")
+
+	// Expired signatures are dropped, oldest first, making room again
+	clock = clock.Add(DefaultMaxSkew + time.Second)
+	require.NoError(t, verify("3"))
+	assert.Len(t, verifier.seen, 1)
+	assert.Len(t, verifier.expiry, 1)
+}
+
+func TestVerify_KeyPage(t *testing.T) {
+	pub, _ := newKey(t)
+	pagePub, pagePriv := newKey(t)
+	verifier := NewVerifier(NewSubmitterResolver(newFake(t, pub, pagePub)))
+	body := []byte(`{"did":"did:acc:bob.acme/profile"}`)
+	jwk := &JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pagePub)}
+
+	req := signedRequest(t, pagePriv, Header{Kid: "acc://bob.acme/book/1", JWK: jwk}, "/native/deactivate", body)
+	credential := req.Header.Get("Authorization")[len(Scheme)+1:]
+	principal, err := verifier.Verify(context.Background(), req, credential, body)
+	require.NoError(t, err)
+	assert.Equal(t, "bob.acme", principal.ADI)
+	assert.NoError(t, principal.APIKey().Allows(security.OpDeactivate, "did:acc:bob.acme/profile"), "a key page key covers every DID of its ADI")
+
+	// A key that is not on the page is refused, even with a valid signature
+	otherPub, otherPriv := newKey(t)
+	jwk = &JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(otherPub)}
+	req = signedRequest(t, otherPriv, Header{Kid: "acc://bob.acme/book/1", JWK: jwk}, "/native/deactivate", body)
+	credential = req.Header.Get("Authorization")[len(Scheme)+1:]
+	_, err = verifier.Verify(context.Background(), req, credential, body)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestSubmitterResolver_Document(t *testing.T) {
+	pub, priv := newKey(t)
+	doc, err := json.Marshal(map[string]interface{}{
+		"id": aliceDID,
+		"verificationMethod": []interface{}{
+			map[string]interface{}{
+				"id":           "#key-1",
+				"type":         "Ed25519VerificationKey2018",
+				"publicKeyHex": hex.EncodeToString(pub),
+			},
+		},
+		"authentication": []interface{}{"#key-1"},
+	})
+	require.NoError(t, err)
+
+	// Not the FAKE ledger: the document is read from the data account
+	client := acc.NewMockClient()
+	var read []string
+	client.LatestDataEntryFn = func(dataAccountURL string) ([]byte, error) {
+		read = append(read, dataAccountURL)
+		if dataAccountURL != "acc://alice.acme/did" {
+			return nil, fmt.Errorf("%w: %s", acc.ErrNoDataEntry, dataAccountURL)
+		}
+		return doc, nil
+	}
+	resolver := NewSubmitterResolver(acc.NewInstrumentedSubmitter(client, nil))
+
+	var seen *security.APIKey
+	h := NewVerifier(resolver).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = security.APIKeyFromContext(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(t, priv, Header{Kid: aliceDID + "#key-1"}, "/update", []byte(`{}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NotNil(t, seen)
+	assert.Equal(t, aliceDID+"#key-1", seen.ID)
+	assert.Equal(t, []string{"acc://alice.acme/did"}, read)
+
+	_, err = resolver.Document(context.Background(), "did:acc:carol.acme")
+	assert.ErrorIs(t, err, ErrNoDocument)
+}
+
+func TestSubmitterResolver_EnvelopeEntry(t *testing.T) {
+	pub, priv := newKey(t)
+	envelope, err := json.Marshal(ops.Envelope{
+		ContentType: "application/did+ld+json",
+		Document: map[string]interface{}{
+			"id": aliceDID,
+			"verificationMethod": []interface{}{
+				map[string]interface{}{
+					"id":           "#key-1",
+					"type":         "Ed25519VerificationKey2018",
+					"publicKeyHex": hex.EncodeToString(pub),
+				},
+			},
+			"authentication": []interface{}{"#key-1"},
+		},
+		Meta: ops.EnvelopeMeta{VersionID: "1", Timestamp: time.Now()},
+	})
+	require.NoError(t, err)
+	client := acc.NewMockClient()
+	client.LatestDataEntryFn = func(string) ([]byte, error) { return envelope, nil }
+	resolver := NewSubmitterResolver(client)
+
+	doc, err := resolver.Document(context.Background(), aliceDID)
+	require.NoError(t, err)
+	assert.Equal(t, aliceDID, doc["id"])
+
+	h := NewVerifier(resolver).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(t, priv, Header{Kid: aliceDID + "#key-1"}, "/update", []byte(`{}`)))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestPageHasKey_Hash(t *testing.T) {
+	pub, _ := newKey(t)
+	hash := sha256.Sum256(pub)
+	page := &acc.KeyPageState{Keys: []acc.KeyInfo{{KeyType: "ed25519", KeyHash: hex.EncodeToString(hash[:])}}}
+	assert.True(t, pageHasKey(page, pub), "network key pages list key hashes")
+}
+
+func TestMultibaseKey(t *testing.T) {
+	key, err := multibaseKey("z6MkqRYqQiSgvZQdnBytw86Qbs2ZWUkGv22od935YF4s8M7V")
+	require.NoError(t, err)
+	assert.Len(t, key, ed25519.PublicKeySize, "the multicodec prefix is stripped")
+
+	_, err = multibaseKey("f0123")
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package didauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+	"github.com/opendlt/accu-did/shared/did"
+)
+
+// ErrNoDocument is returned by a Resolver that cannot read DID documents
+var ErrNoDocument = errors.New("current DID document is not available")
+
+// Resolver looks up the keys allowed to sign for a DID
+type Resolver interface {
+	// Document returns the current document of did
+	Document(ctx context.Context, did string) (map[string]interface{}, error)
+	// KeyPage returns the first key page of adi, the page that controls it
+	KeyPage(ctx context.Context, adi string) (*acc.KeyPageState, error)
+}
+
+// SubmitterResolver reads documents and key pages through a Submitter, from
+// the FAKE ledger or the Accumulate network
+type SubmitterResolver struct {
+	submitter acc.Submitter
+}
+
+// NewSubmitterResolver creates a resolver backed by submitter
+func NewSubmitterResolver(submitter acc.Submitter) *SubmitterResolver {
+	return &SubmitterResolver{submitter: submitter}
+}
+
+// Document returns the latest document written for didStr
+func (s *SubmitterResolver) Document(ctx context.Context, didStr string) (map[string]interface{}, error) {
+	_, dataAccountURL, err := did.ParseDID(didStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID: %w", err)
+	}
+	data, err := s.submitter.LatestDataEntry(ctx, dataAccountURL.String())
+	if errors.Is(err, acc.ErrNoDataEntry) {
+		return nil, fmt.Errorf("%w: %w", ErrNoDocument, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read current document: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("current document is not valid JSON: %w", err)
+	}
+	// The legacy /create path writes an ops.Envelope holding the document
+	if inner, ok := doc["document"].(map[string]interface{}); ok && doc["id"] == nil {
+		return inner, nil
+	}
+	return doc, nil
+}
+
+// KeyPage returns the state of acc://<adi>/book/1
+func (s *SubmitterResolver) KeyPage(ctx context.Context, adi string) (*acc.KeyPageState, error) {
+	return s.submitter.GetKeyPageState(ctx, "acc://"+adi+"/book/1")
+}
+
+// documentKey returns the public key of the verification method kid, which
+// doc must list under authentication or capabilityInvocation
+func documentKey(doc map[string]interface{}, didStr, kid string) (ed25519.PublicKey, error) {
+	if deactivated, _ := doc["deactivated"].(bool); deactivated {
+		return nil, errors.New("DID is deactivated")
+	}
+
+	methods := make(map[string]map[string]interface{})
+	if list, ok := doc["verificationMethod"].([]interface{}); ok {
+		for _, item := range list {
+			if method, ok := item.(map[string]interface{}); ok {
+				id, _ := method["id"].(string)
+				methods[absoluteID(didStr, id)] = method
+			}
+		}
+	}
+
+	for _, relationship := range []string{"authentication", "capabilityInvocation"} {
+		list, _ := doc[relationship].([]interface{})
+		for _, item := range list {
+			var method map[string]interface{}
+			switch v := item.(type) {
+			case string:
+				if absoluteID(didStr, v) == kid {
+					method = methods[kid]
+				}
+			case map[string]interface{}:
+				if id, _ := v["id"].(string); absoluteID(didStr, id) == kid {
+					method = v
+				}
+			}
+			if method != nil {
+				return methodKey(method)
+			}
+		}
+	}
+	return nil, errors.New("not an authentication key of the current document")
+}
+
+// absoluteID expands a verification method ID relative to didStr
+func absoluteID(didStr, id string) string {
+	if strings.HasPrefix(id, "#") {
+		return didStr + id
+	}
+	return id
+}
+
+// methodKey decodes the Ed25519 key of a verification method
+func methodKey(method map[string]interface{}) (ed25519.PublicKey, error) {
+	if multibase, ok := method["publicKeyMultibase"].(string); ok {
+		return multibaseKey(multibase)
+	}
+	if raw, ok := method["publicKeyJwk"].(map[string]interface{}); ok {
+		var jwk JWK
+		jwk.Kty, _ = raw["kty"].(string)
+		jwk.Crv, _ = raw["crv"].(string)
+		jwk.X, _ = raw["x"].(string)
+		return jwk.publicKey()
+	}
+	if hexKey, ok := method["publicKeyHex"].(string); ok {
+		return rawKey(hex.DecodeString(hexKey))
+	}
+	return nil, errors.New("verification method has no Ed25519 key")
+}
+
+// multicodecEd25519 prefixes Ed25519 public keys in publicKeyMultibase
+var multicodecEd25519 = []byte{0xed, 0x01}
+
+// multibaseKey decodes a base58btc publicKeyMultibase, with or without the
+// Ed25519 multicodec prefix
+func multibaseKey(value string) (ed25519.PublicKey, error) {
+	if !strings.HasPrefix(value, "z") {
+		return nil, errors.New("publicKeyMultibase must be base58btc")
+	}
+	decoded, err := decodeBase58(value[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) == ed25519.PublicKeySize+len(multicodecEd25519) && decoded[0] == multicodecEd25519[0] && decoded[1] == multicodecEd25519[1] {
+		decoded = decoded[len(multicodecEd25519):]
+	}
+	return rawKey(decoded, nil)
+}
+
+// publicKey decodes an OKP Ed25519 JWK
+func (j *JWK) publicKey() (ed25519.PublicKey, error) {
+	if j.Kty != "OKP" || j.Crv != "Ed25519" {
+		return nil, errors.New("jwk must be an OKP Ed25519 key")
+	}
+	return rawKey(base64.RawURLEncoding.DecodeString(j.X))
+}
+
+func rawKey(key []byte, err error) (ed25519.PublicKey, error) {
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("malformed Ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodeBase58 decodes the Bitcoin base58 alphabet
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// keyPageADI returns the ADI whose first key page is kid
+func keyPageADI(kid string) (string, error) {
+	rest, isURL := strings.CutPrefix(strings.ToLower(kid), "acc://")
+	adi, page, _ := strings.Cut(rest, "/")
+	if !isURL || page != "book/1" {
+		return "", errors.New("kid must be a DID URL or the ADI's key page acc://<adi>/book/1")
+	}
+	return policy.ADIFromDID("did:acc:" + adi)
+}
+
+// pageHasKey reports whether publicKey is on page. Pages list keys as hex,
+// optionally prefixed with their type, or as SHA-256 key hashes.
+func pageHasKey(page *acc.KeyPageState, publicKey ed25519.PublicKey) bool {
+	hash := sha256.Sum256(publicKey)
+	for _, key := range page.Keys {
+		if key.KeyHash != "" && strings.EqualFold(key.KeyHash, hex.EncodeToString(hash[:])) {
+			return true
+		}
+		value := key.PublicKey
+		if _, rest, ok := strings.Cut(value, ":"); ok {
+			value = rest
+		}
+		if strings.EqualFold(value, hex.EncodeToString(publicKey)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+// Package idempotency replays the stored response of a write retried with the
+// same Idempotency-Key, so client retries do not submit a second transaction
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+	"github.com/opendlt/accu-did/shared/logging"
+)
+
+// Header names
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// MaxKeyLength bounds the Idempotency-Key header
+const MaxKeyLength = 255
+
+type retryableKey struct{}
+
+// MarkRetryable tells the middleware not to store the response of the request
+// in ctx, so a retry with the same key runs the handler again. Handlers use it
+// for responses that ask the client to repeat the request, such as a create
+// still waiting for its accounts. Without the middleware it does nothing.
+func MarkRetryable(ctx context.Context) {
+	if retryable, ok := ctx.Value(retryableKey{}).(*atomic.Bool); ok {
+		retryable.Store(true)
+	}
+}
+
+// Record is a stored response
+type Record struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// entry is a key in flight (record nil) or completed
+type entry struct {
+	fingerprint string
+	record      *Record
+	expires     time.Time
+}
+
+// Store keeps the responses of requests sent with an Idempotency-Key for a
+// TTL. Keys are scoped by the API key of the caller, so two clients cannot
+// see each other's responses. Records live in memory and are not shared
+// between registrar instances.
+type Store struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewStore creates a store keeping responses for ttl and at most maxEntries
+// keys; when full the record closest to expiry is dropped first
+func NewStore(ttl time.Duration, maxEntries int) *Store {
+	return &Store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*entry),
+	}
+}
+
+// Len returns the number of stored and in-flight keys
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// lookup outcomes
+type outcome int
+
+const (
+	started  outcome = iota // key reserved; the caller runs the request
+	replay                  // completed with the same fingerprint
+	mismatch                // used with a different request
+	inFlight                // same request still running
+)
+
+// begin reserves key for a request with fingerprint, or reports why it
+// cannot be run
+func (s *Store) begin(key, fingerprint string) (outcome, *Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		switch {
+		case e.fingerprint != fingerprint:
+			return mismatch, nil
+		case e.record == nil:
+			return inFlight, nil
+		default:
+			return replay, e.record
+		}
+	}
+
+	if len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+	s.entries[key] = &entry{fingerprint: fingerprint, expires: now.Add(s.ttl)}
+	return started, nil
+}
+
+// complete stores the response of a request started with begin
+func (s *Store) complete(key string, record *Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.record = record
+		e.expires = s.now().Add(s.ttl)
+	}
+}
+
+// release forgets a key whose request did not produce a response worth
+// replaying, so a retry runs again
+func (s *Store) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// evict drops expired entries and, if the store is still full, the completed
+// entry closest to expiry. The caller holds s.mu.
+func (s *Store) evict(now time.Time) {
+	var oldest string
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+			continue
+		}
+		if e.record != nil && (oldest == "" || e.expires.Before(s.entries[oldest].expires)) {
+			oldest = key
+		}
+	}
+	if len(s.entries) >= s.maxEntries && oldest != "" {
+		delete(s.entries, oldest)
+	}
+}
+
+// Middleware applies the store to requests carrying an Idempotency-Key.
+// The first request runs and its response is stored unless it may succeed
+// when retried: a 5xx, a retryable 4xx (see retryableStatus) or a response
+// marked with MarkRetryable. A repeat with the same method, path and body
+// gets the stored response with Idempotent-Replayed: true; a repeat with a
+// different request gets 422, and one arriving while the first is still
+// running 409.
+func (s *Store) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validKey(key) {
+				writeError(w, r, http.StatusBadRequest, "invalidRequest", "Idempotency-Key must be 1-255 printable ASCII characters")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, security.MaxBodyBytes))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, r, http.StatusRequestEntityTooLarge, "invalidRequest", "Request body too large")
+				return
+			}
+			if err != nil {
+				writeError(w, r, http.StatusBadRequest, "invalidRequest", "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scoped := scope(r) + ":" + key
+			switch state, record := s.begin(scoped, fingerprint(r, body)); state {
+			case replay:
+				slog.InfoContext(r.Context(), "Replaying idempotent response", "idempotency_key", key, "status", record.Status)
+				for name, values := range record.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set(HeaderReplayed, "true")
+				w.WriteHeader(record.Status)
+				w.Write(record.Body)
+				return
+			case mismatch:
+				writeError(w, r, http.StatusUnprocessableEntity, "idempotencyKeyReused", "Idempotency-Key was already used with a different request")
+				return
+			case inFlight:
+				writeError(w, r, http.StatusConflict, "requestInProgress", "A request with this Idempotency-Key is still being processed")
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					s.release(scoped)
+				}
+			}()
+
+			retryable := new(atomic.Bool)
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), retryableKey{}, retryable)))
+
+			if retryableStatus(rec.status) || retryable.Load() {
+				return
+			}
+			header := make(http.Header)
+			for _, name := range replayedHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					header[name] = append([]string(nil), values...)
+				}
+			}
+			s.complete(scoped, &Record{Status: rec.status, Header: header, Body: rec.body.Bytes()})
+			completed = true
+		})
+	}
+}
+
+// replayedHeaders are the response headers kept with a record. Headers that
+// describe the request rather than the result, such as X-Request-Id, rate
+// limit and quota counters or Retry-After, are not replayed.
+var replayedHeaders = []string{
+	"Cache-Control",
+	"Content-Language",
+	"Content-Type",
+	"Etag",
+	"Last-Modified",
+	"Location",
+}
+
+// retryableStatus reports whether a response with status may succeed when
+// the same request is repeated: server errors, and client errors caused by
+// the registrar's state rather than the request (credits, timeouts,
+// conflicts, rate limits and quotas)
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusPaymentRequired,
+		http.StatusRequestTimeout,
+		http.StatusConflict,
+		http.StatusTooEarly,
+		http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
+
+// recorder copies the response into a buffer while writing it through
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// validKey reports whether an Idempotency-Key is safe to store and log
+func validKey(key string) bool {
+	if len(key) > MaxKeyLength {
+		return false
+	}
+	for _, c := range key {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// scope identifies the caller by a hash of the API key it presented; callers
+// without one share the anonymous scope. DID-Auth signatures differ on every
+// retry and client certificates carry no header, so those callers are
+// identified by the key they authenticated as.
+func scope(r *http.Request) string {
+	credential := r.Header.Get("Authorization")
+	if apiKey := r.Header.Get(security.APIKeyHeader); credential == "" && apiKey != "" {
+		credential = "Bearer " + apiKey
+	}
+	if key := security.APIKeyFromContext(r.Context()); key != nil && (credential == "" || strings.HasPrefix(credential, "DIDAuth ")) {
+		credential = "key:" + key.ID
+	}
+	credential = strings.TrimPrefix(credential, "Bearer ")
+	credential = strings.TrimPrefix(credential, "ApiKey ")
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprint hashes what makes two requests the same operation
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeError writes a canonical error response
+func writeError(w http.ResponseWriter, r *http.Request, status int, errorCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(struct {
+		Code      int    `json:"code"`
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	}{status, errorCode, message, logging.RequestIDFromContext(r.Context())})
+}
@@ -0,0 +1,258 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/security"
+)
+
+// counting returns a handler that answers with the number of times it ran
+func counting(calls *atomic.Int32, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", fmt.Sprintf("req_%d", n))
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"txid":"tx-%d"}`, n)
+	})
+}
+
+func post(h http.Handler, path, key, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Replay(t *testing.T) {
+	var calls atomic.Int32
+	h := NewStore(time.Hour, 100).Middleware()(counting(&calls, http.StatusOK))
+
+	first := post(h, "/register", "k1", "", `{"did":"did:acc:alice"}`)
+	second := post(h, "/register", "k1", "", `{"did":"did:acc:alice"}`)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(HeaderReplayed))
+	assert.Empty(t, first.Header().Get(HeaderReplayed))
+	assert.Empty(t, second.Header().Get("X-Request-Id"), "request IDs are not replayed")
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+}
+
+func TestMiddleware_ReplaysOnlyResultHeaders(t *testing.T) {
+	h := NewStore(time.Hour, 100).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/resolve/did:acc:alice")
+		w.Header().Set("X-RateLimit-Remaining", "9")
+		w.Header().Set("X-Quota-Remaining", "4")
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	post(h, "/register", "k1", "", `{}`)
+	second := post(h, "/register", "k1", "", `{}`)
+
+	assert.Equal(t, "true", second.Header().Get(HeaderReplayed))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "/resolve/did:acc:alice", second.Header().Get("Location"))
+	assert.Empty(t, second.Header().Get("X-RateLimit-Remaining"))
+	assert.Empty(t, second.Header().Get("X-Quota-Remaining"))
+	assert.Empty(t, second.Header().Get("Retry-After"))
+}
+
+func TestMiddleware_DifferentBody(t *testing.T) {
+	var calls atomic.Int32
+	h := NewStore(time.Hour, 100).Middleware()(counting(&calls, http.StatusOK))
+
+	post(h, "/register", "k1", "", `{"did":"did:acc:alice"}`)
+	w := post(h, "/register", "k1", "", `{"did":"did:acc:bob"}`)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "idempotencyKeyReused", body["error"])
+
+	// The same key on another endpoint is a different request too
+	w = post(h, "/native/update", "k1", "", `{"did":"did:acc:alice"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestMiddleware_ScopedByAPIKey(t *testing.T) {
+	var calls atomic.Int32
+	h := NewStore(time.Hour, 100).Middleware()(counting(&calls, http.StatusOK))
+
+	post(h, "/register", "k1", "key-a", `{}`)
+	w := post(h, "/register", "k1", "key-b", `{}`)
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Empty(t, w.Header().Get(HeaderReplayed))
+	assert.Equal(t, `{"txid":"tx-2"}`, w.Body.String())
+}
+
+func TestMiddleware_NoKey(t *testing.T) {
+	var calls atomic.Int32
+	h := NewStore(time.Hour, 100).Middleware()(counting(&calls, http.StatusOK))
+
+	post(h, "/register", "", "", `{}`)
+	post(h, "/register", "", "", `{}`)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMiddleware_ServerErrorNotStored(t *testing.T) {
+	var calls atomic.Int32
+	store := NewStore(time.Hour, 100)
+	h := store.Middleware()(counting(&calls, http.StatusBadGateway))
+
+	post(h, "/register", "k1", "", `{}`)
+	w := post(h, "/register", "k1", "", `{}`)
+
+	assert.Equal(t, int32(2), calls.Load(), "a 5xx may be retried")
+	assert.Empty(t, w.Header().Get(HeaderReplayed))
+	assert.Zero(t, store.Len())
+}
+
+func TestMiddleware_RetryableClientErrorNotStored(t *testing.T) {
+	for _, status := range []int{402, 408, 409, 425, 429} {
+		var calls atomic.Int32
+		store := NewStore(time.Hour, 100)
+		h := store.Middleware()(counting(&calls, status))
+
+		post(h, "/register", "k1", "", `{}`)
+		w := post(h, "/register", "k1", "", `{}`)
+
+		assert.Equal(t, int32(2), calls.Load(), "a %d may be retried", status)
+		assert.Empty(t, w.Header().Get(HeaderReplayed))
+		assert.Zero(t, store.Len())
+	}
+
+	// Other client errors are final
+	var calls atomic.Int32
+	h := NewStore(time.Hour, 100).Middleware()(counting(&calls, http.StatusBadRequest))
+	post(h, "/register", "k1", "", `{}`)
+	w := post(h, "/register", "k1", "", `{}`)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, "true", w.Header().Get(HeaderReplayed))
+}
+
+func TestMiddleware_QuotaRejectionRetried(t *testing.T) {
+	var calls atomic.Int32
+	quota := security.NewQuotaLimiter(1)
+	h := NewStore(time.Hour, 100).Middleware()(quota.Middleware()(counting(&calls, http.StatusOK)))
+
+	require.Equal(t, http.StatusOK, post(h, "/register", "k1", "", `{"did":"did:acc:alice"}`).Code)
+	require.Equal(t, http.StatusTooManyRequests, post(h, "/register", "k2", "", `{"did":"did:acc:bob"}`).Code)
+
+	// Once the quota allows it, the retry with the same key reaches the handler
+	quota.Update(2)
+	w := post(h, "/register", "k2", "", `{"did":"did:acc:bob"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(HeaderReplayed))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMiddleware_RetryableNotStored(t *testing.T) {
+	var calls atomic.Int32
+	handler := counting(&calls, http.StatusAccepted)
+	h := NewStore(time.Hour, 100).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MarkRetryable(r.Context())
+		handler.ServeHTTP(w, r)
+	}))
+
+	post(h, "/register", "k1", "", `{}`)
+	second := post(h, "/register", "k1", "", `{}`)
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, http.StatusAccepted, second.Code)
+	assert.Empty(t, second.Header().Get(HeaderReplayed))
+}
+
+func TestMiddleware_InFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := NewStore(time.Hour, 100).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(h, "/register", "k1", "", `{}`) }()
+	<-started
+
+	w := post(h, "/register", "k1", "", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-done).Code)
+}
+
+func TestMiddleware_InvalidKey(t *testing.T) {
+	var calls atomic.Int32
+	h := NewStore(time.Hour, 100).Middleware()(counting(&calls, http.StatusOK))
+
+	w := post(h, "/register", strings.Repeat("k", MaxKeyLength+1), "", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Zero(t, calls.Load())
+}
+
+func TestMiddleware_BodyTooLarge(t *testing.T) {
+	var calls atomic.Int32
+	store := NewStore(time.Hour, 100)
+	h := store.Middleware()(counting(&calls, http.StatusOK))
+
+	w := post(h, "/register", "k1", "", strings.Repeat("x", security.MaxBodyBytes+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Zero(t, calls.Load())
+	assert.Zero(t, store.Len())
+}
+
+func TestStore_Expiry(t *testing.T) {
+	var calls atomic.Int32
+	store := NewStore(time.Minute, 100)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	h := store.Middleware()(counting(&calls, http.StatusOK))
+
+	post(h, "/register", "k1", "", `{}`)
+	now = now.Add(2 * time.Minute)
+	w := post(h, "/register", "k1", "", `{"changed":true}`)
+
+	assert.Equal(t, int32(2), calls.Load(), "an expired key can be reused")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestStore_Eviction(t *testing.T) {
+	var calls atomic.Int32
+	store := NewStore(time.Hour, 2)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	h := store.Middleware()(counting(&calls, http.StatusOK))
+
+	for _, key := range []string{"k1", "k2", "k3"} {
+		post(h, "/register", key, "", `{}`)
+		now = now.Add(time.Second)
+	}
+	assert.Equal(t, 2, store.Len())
+
+	// k1 was closest to expiry and was dropped; k3 is still replayed
+	assert.Empty(t, post(h, "/register", "k1", "", `{}`).Header().Get(HeaderReplayed))
+	assert.Equal(t, "true", post(h, "/register", "k3", "", `{}`).Header().Get(HeaderReplayed))
+}
@@ -21,3 +21,9 @@ func DIDToDataAccountURL(did string) (string, error) {
 	}
 	return fmt.Sprintf("acc://%s/data/did", rest), nil
 }
+
+// ADIFromDID returns the normalized ADI name of a did:acc DID, e.g.
+// "alice.acme" for did:acc:Alice.acme/path
+func ADIFromDID(did string) (string, error) {
+	return extractADI(did)
+}
@@ -0,0 +1,79 @@
+// Package readiness holds the registrar's own /readyz checks; the checker
+// and the node and network checks are in shared/health
+package readiness
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gitlab.com/accumulatenetwork/accumulate/protocol"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+	"github.com/opendlt/accu-did/shared/health"
+)
+
+// KeyStoreCheck re-reads the key store file so that a deleted, unreadable or
+// corrupted file is reported before the next signing attempt fails
+func KeyStoreCheck(path string) health.Check {
+	return health.Check{
+		Name:     "keystore",
+		Critical: true,
+		Run: func(ctx context.Context) (string, error) {
+			if _, err := acc.LoadKeyStoreFile(path); err != nil {
+				return "", err
+			}
+			return "file " + path, nil
+		},
+	}
+}
+
+// SignerKeysCheck fails when the signer has no private key for one of the
+// configured key pages
+func SignerKeysCheck(signer acc.SignerHook, keyPages []string) health.Check {
+	return health.Check{
+		Name:     "signer.keys",
+		Critical: true,
+		Run: func(ctx context.Context) (string, error) {
+			var missing []string
+			for _, page := range keyPages {
+				if _, err := signer.GetPrivateKey(page); err != nil {
+					missing = append(missing, page)
+				}
+			}
+			if len(missing) > 0 {
+				return "", fmt.Errorf("no signing key for %s", strings.Join(missing, ", "))
+			}
+			return fmt.Sprintf("%d key pages", len(keyPages)), nil
+		},
+	}
+}
+
+// KeyPageStateFunc is implemented by acc.Submitter.GetKeyPageState
+type KeyPageStateFunc func(ctx context.Context, keyPageURL string) (*acc.KeyPageState, error)
+
+// CreditsCheck warns when a configured key page holds fewer than minCredits
+// credits. It is non-critical: reads still work and operators get time to
+// top up before writes start failing.
+func CreditsCheck(getKeyPageState KeyPageStateFunc, keyPages []string, minCredits uint64) health.Check {
+	return health.Check{
+		Name: "accumulate.credits",
+		Run: func(ctx context.Context) (string, error) {
+			var low []string
+			for _, page := range keyPages {
+				state, err := getKeyPageState(ctx, page)
+				if err != nil {
+					return "", fmt.Errorf("query %s: %w", page, err)
+				}
+				credits := state.CreditBalance / protocol.CreditPrecision
+				if credits < minCredits {
+					low = append(low, fmt.Sprintf("%s has %d credits", page, credits))
+				}
+			}
+			if len(low) > 0 {
+				return "", fmt.Errorf("below %d credits: %s", minCredits, strings.Join(low, "; "))
+			}
+			return fmt.Sprintf("%d key pages at or above %d credits", len(keyPages), minCredits), nil
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package readiness
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/acc"
+)
+
+func TestKeyStoreCheck(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys.json")
+	seed := "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	require.NoError(t, os.WriteFile(path, []byte(`{"acc://alice.acme/book/1": "`+seed+`"}`), 0o600))
+
+	_, err := KeyStoreCheck(path).Run(context.Background())
+	assert.NoError(t, err)
+
+	_, err = KeyStoreCheck(filepath.Join(dir, "missing.json")).Run(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSignerKeysCheck(t *testing.T) {
+	signer := acc.NewDefaultSignerHook()
+	signer.RegisterKey("acc://alice.acme/book/1", make([]byte, 64))
+
+	msg, err := SignerKeysCheck(signer, []string{"acc://alice.acme/book/1"}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1 key pages", msg)
+
+	_, err = SignerKeysCheck(signer, []string{"acc://alice.acme/book/1", "acc://bob.acme/book/1"}).Run(context.Background())
+	assert.ErrorContains(t, err, "acc://bob.acme/book/1")
+}
+
+func TestCreditsCheck(t *testing.T) {
+	balances := map[string]uint64{
+		"acc://alice.acme/book/1": 500 * 100,
+		"acc://bob.acme/book/1":   20 * 100,
+	}
+	getKeyPageState := func(ctx context.Context, url string) (*acc.KeyPageState, error) {
+		return &acc.KeyPageState{URL: url, CreditBalance: balances[url]}, nil
+	}
+
+	check := CreditsCheck(getKeyPageState, []string{"acc://alice.acme/book/1"}, 100)
+	assert.False(t, check.Critical)
+	_, err := check.Run(context.Background())
+	assert.NoError(t, err)
+
+	_, err = CreditsCheck(getKeyPageState, []string{"acc://alice.acme/book/1", "acc://bob.acme/book/1"}, 100).Run(context.Background())
+	assert.ErrorContains(t, err, "acc://bob.acme/book/1 has 20 credits")
+}
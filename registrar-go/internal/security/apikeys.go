@@ -0,0 +1,445 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/policy"
+)
+
+// Operations an API key can be scoped to
+const (
+	OpCreate     = "create"
+	OpUpdate     = "update"
+	OpDeactivate = "deactivate"
+)
+
+// secretPrefix marks registrar-issued API keys
+const secretPrefix = "acdk_"
+
+// Errors returned by APIKeyStore
+var (
+	ErrKeyNotFound = errors.New("API key not found")
+	ErrInvalidKey  = errors.New("invalid API key")
+)
+
+// APIKey is an issued API key and what it may do. Only the SHA-256 of the
+// secret is kept.
+type APIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	Hash        string     `json:"hash,omitempty"`
+	ADIs        []string   `json:"adis,omitempty"`        // ADIs the key may write
+	DIDPrefixes []string   `json:"didPrefixes,omitempty"` // DID prefixes the key may write
+	Operations  []string   `json:"operations,omitempty"`  // empty allows all
+	Admin       bool       `json:"admin,omitempty"`       // may issue and revoke keys
+	RateLimit   *RateLimit `json:"rateLimit,omitempty"`   // replaces security.rateLimit
+	DailyQuota  int        `json:"dailyQuota,omitempty"`  // writes per UTC day; 0 uses security.dailyQuota
+	CreatedAt   time.Time  `json:"createdAt"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+
+	// DIDs are the exact DIDs a DID-Auth caller may write; never stored
+	DIDs []string `json:"-"`
+	// ClientSubjects are TLS client certificate subjects (e.g.
+	// "CN=ci-deployer,O=Acme") that authenticate as this key without a secret
+	ClientSubjects []string `json:"clientSubjects,omitempty"`
+}
+
+// RateLimit is a token bucket for one API key
+type RateLimit struct {
+	RPS   int `json:"rps"`
+	Burst int `json:"burst"`
+}
+
+// ScopeError reports an operation outside the scope of an API key
+type ScopeError struct {
+	KeyID     string
+	Operation string
+	DID       string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("API key %s may not %s %s", e.KeyID, e.Operation, e.DID)
+}
+
+// Allows reports whether the key may perform op on did. A key without ADIs,
+// DID prefixes and DIDs may write any DID.
+func (k *APIKey) Allows(op, did string) error {
+	if len(k.Operations) > 0 && !slices.Contains(k.Operations, op) {
+		return &ScopeError{KeyID: k.ID, Operation: op, DID: did}
+	}
+	if len(k.ADIs) == 0 && len(k.DIDPrefixes) == 0 && len(k.DIDs) == 0 {
+		return nil
+	}
+	if slices.Contains(k.DIDs, did) {
+		return nil
+	}
+	if adi, err := policy.ADIFromDID(did); err == nil && slices.Contains(k.ADIs, adi) {
+		return nil
+	}
+	for _, prefix := range k.DIDPrefixes {
+		if matchesPrefix(did, prefix) {
+			return nil
+		}
+	}
+	return &ScopeError{KeyID: k.ID, Operation: op, DID: did}
+}
+
+// matchesPrefix reports whether did starts with prefix, comparing ADI names
+// case-insensitively. The match must end at a boundary: the end of the DID or
+// of its ADI name, a '/', ':' or '#', or a '.' within the path. So did:acc:bob
+// covers did:acc:bob/profile but not did:acc:bobby or did:acc:bob.evil. A
+// prefix ending in a separator, such as did:acc:tenant-, marks its own.
+func matchesPrefix(did, prefix string) bool {
+	did, prefix = foldADI(did), foldADI(prefix)
+	if !strings.HasPrefix(did, prefix) {
+		return false
+	}
+	n := len(prefix)
+	switch {
+	case n == len(did) || n == adiEnd(did):
+		return true
+	case strings.ContainsRune(".-/:#", rune(prefix[n-1])):
+		return true
+	case strings.ContainsRune("/:#", rune(did[n])):
+		return true
+	}
+	return did[n] == '.' && n > adiEnd(did)
+}
+
+// adiEnd returns the offset in a did:acc DID where its ADI name ends
+func adiEnd(did string) int {
+	const method = "did:acc:"
+	if i := strings.IndexAny(did[min(len(method), len(did)):], "/?#;"); i >= 0 {
+		return len(method) + i
+	}
+	return len(did)
+}
+
+// foldADI lower-cases the ADI name of a did:acc DID; paths and fragments
+// keep their case
+func foldADI(did string) string {
+	if !strings.HasPrefix(did, "did:acc:") {
+		return did
+	}
+	end := adiEnd(did)
+	return strings.ToLower(did[:end]) + did[end:]
+}
+
+// Revoked reports whether the key has been revoked
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// validate checks the scope and limits of a key about to be issued
+func (k *APIKey) validate() error {
+	var errs []error
+	for _, op := range k.Operations {
+		if op != OpCreate && op != OpUpdate && op != OpDeactivate {
+			errs = append(errs, fmt.Errorf("unknown operation %q", op))
+		}
+	}
+	for _, prefix := range k.DIDPrefixes {
+		if !strings.HasPrefix(prefix, "did:acc:") {
+			errs = append(errs, fmt.Errorf("DID prefix %q must start with did:acc:", prefix))
+		}
+	}
+	if k.RateLimit != nil && (k.RateLimit.RPS <= 0 || k.RateLimit.Burst <= 0) {
+		errs = append(errs, fmt.Errorf("rateLimit.rps and rateLimit.burst must be positive"))
+	}
+	if k.DailyQuota < 0 {
+		errs = append(errs, fmt.Errorf("dailyQuota must not be negative"))
+	}
+	for _, subject := range k.ClientSubjects {
+		if strings.TrimSpace(subject) == "" {
+			errs = append(errs, fmt.Errorf("client subjects must not be empty"))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidKey, errors.Join(errs...))
+	}
+	return nil
+}
+
+// APIKeyStore holds issued API keys. A store with a path persists them as
+// JSON and is re-read by Reload; without one keys live in memory.
+type APIKeyStore struct {
+	path string
+	now  func() time.Time
+
+	mu     sync.RWMutex
+	keys   map[string]*APIKey // by ID
+	byHash map[string]*APIKey
+}
+
+// apiKeyFile is the on-disk format of an APIKeyStore
+type apiKeyFile struct {
+	Keys []*APIKey `json:"keys"`
+}
+
+// NewAPIKeyStore creates an in-memory store
+func NewAPIKeyStore() *APIKeyStore {
+	return &APIKeyStore{
+		now:    time.Now,
+		keys:   make(map[string]*APIKey),
+		byHash: make(map[string]*APIKey),
+	}
+}
+
+// LoadAPIKeyStore reads the store at path. A missing file is an empty store;
+// it is created when the first key is issued.
+func LoadAPIKeyStore(path string) (*APIKeyStore, error) {
+	s := NewAPIKeyStore()
+	s.path = path
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Persistent reports whether the store is backed by a file
+func (s *APIKeyStore) Persistent() bool {
+	return s.path != ""
+}
+
+// Reload re-reads the file, e.g. after an operator edited it
+func (s *APIKeyStore) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		data = []byte(`{"keys":[]}`)
+	} else if err != nil {
+		return fmt.Errorf("failed to read API key store: %w", err)
+	}
+
+	var file apiKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse API key store %s: %w", s.path, err)
+	}
+	keys := make(map[string]*APIKey, len(file.Keys))
+	byHash := make(map[string]*APIKey, len(file.Keys))
+	for _, key := range file.Keys {
+		if key.ID == "" || key.Hash == "" {
+			return fmt.Errorf("API key store %s: every key needs an id and hash", s.path)
+		}
+		if err := key.validate(); err != nil {
+			return fmt.Errorf("API key store %s: key %s: %w", s.path, key.ID, err)
+		}
+		key.ADIs = normalizeADIs(key.ADIs)
+		keys[key.ID] = key
+		byHash[key.Hash] = key
+	}
+
+	s.mu.Lock()
+	s.keys, s.byHash = keys, byHash
+	s.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the unrevoked key whose secret is secret
+func (s *APIKeyStore) Lookup(secret string) (*APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.byHash[hashSecret(secret)]
+	if !ok || key.Revoked() {
+		return nil, false
+	}
+	return key, true
+}
+
+// LookupSubject returns the unrevoked key bound to a verified TLS client
+// certificate subject
+func (s *APIKeyStore) LookupSubject(subject string) (*APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.subjectKey(subject)
+}
+
+// subjectKey finds the key bound to subject. The caller holds s.mu.
+func (s *APIKeyStore) subjectKey(subject string) (*APIKey, bool) {
+	for _, key := range s.keys {
+		if !key.Revoked() && slices.Contains(key.ClientSubjects, subject) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Issue creates a key with the scope and limits of spec and returns it with
+// its secret. The secret is not stored and cannot be recovered.
+func (s *APIKeyStore) Issue(spec APIKey) (*APIKey, string, error) {
+	if err := spec.validate(); err != nil {
+		return nil, "", err
+	}
+	s.mu.RLock()
+	for _, subject := range spec.ClientSubjects {
+		if bound, ok := s.subjectKey(subject); ok {
+			s.mu.RUnlock()
+			return nil, "", fmt.Errorf("%w: client subject %q is bound to %s", ErrInvalidKey, subject, bound.ID)
+		}
+	}
+	s.mu.RUnlock()
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, "", err
+	}
+	secret = secretPrefix + secret
+
+	key := spec
+	key.ADIs = normalizeADIs(spec.ADIs)
+	key.ID = "key_" + id
+	key.Hash = hashSecret(secret)
+	key.CreatedAt = s.now().UTC()
+	key.RevokedAt = nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = &key
+	s.byHash[key.Hash] = &key
+	if err := s.save(); err != nil {
+		delete(s.keys, key.ID)
+		delete(s.byHash, key.Hash)
+		return nil, "", err
+	}
+	return &key, secret, nil
+}
+
+// Revoke disables a key. Revoked keys stay listed.
+func (s *APIKeyStore) Revoke(id string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	if key.Revoked() {
+		return key, nil
+	}
+
+	revoked := *key
+	now := s.now().UTC()
+	revoked.RevokedAt = &now
+	s.keys[id] = &revoked
+	s.byHash[key.Hash] = &revoked
+	if err := s.save(); err != nil {
+		s.keys[id] = key
+		s.byHash[key.Hash] = key
+		return nil, err
+	}
+	return &revoked, nil
+}
+
+// List returns every key, oldest first
+func (s *APIKeyStore) List() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// save writes the store atomically. The caller holds s.mu.
+func (s *APIKeyStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	file := apiKeyFile{Keys: make([]*APIKey, 0, len(s.keys))}
+	for _, key := range s.keys {
+		file.Keys = append(file.Keys, key)
+	}
+	sort.Slice(file.Keys, func(i, j int) bool { return file.Keys[i].ID < file.Keys[j].ID })
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".apikeys-*")
+	if err != nil {
+		return fmt.Errorf("failed to write API key store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write API key store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write API key store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write API key store: %w", err)
+	}
+	return nil
+}
+
+// normalizeADIs accepts ADIs as names or acc:// URLs, in any case
+func normalizeADIs(adis []string) []string {
+	var normalized []string
+	for _, adi := range adis {
+		adi = strings.TrimPrefix(strings.ToLower(adi), "acc://")
+		normalized = append(normalized, strings.TrimSuffix(adi, "."))
+	}
+	return normalized
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type apiKeyContextKey struct{}
+
+// WithAPIKey returns a context carrying the API key of the caller
+func WithAPIKey(ctx context.Context, key *APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// APIKeyFromContext returns the API key the caller authenticated with, or
+// nil when API keys are not enforced
+func APIKeyFromContext(ctx context.Context) *APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	return key
+}
+
+// Authorize checks the caller's API key may perform op on did. Callers
+// without a key (API keys not enforced) may do anything.
+func Authorize(ctx context.Context, op, did string) error {
+	key := APIKeyFromContext(ctx)
+	if key == nil {
+		return nil
+	}
+	return key.Allows(op, did)
+}
@@ -0,0 +1,221 @@
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey_Allows(t *testing.T) {
+	key := &APIKey{
+		ID:          "key_1",
+		ADIs:        []string{"alice.acme"},
+		DIDPrefixes: []string{"did:acc:tenant-"},
+		Operations:  []string{OpCreate, OpUpdate},
+	}
+
+	assert.NoError(t, key.Allows(OpCreate, "did:acc:alice.acme"))
+	assert.NoError(t, key.Allows(OpUpdate, "did:acc:Alice.acme/profile"), "ADIs match case-insensitively, with paths")
+	assert.NoError(t, key.Allows(OpCreate, "did:acc:tenant-42.acme"))
+
+	var scopeErr *ScopeError
+	require.ErrorAs(t, key.Allows(OpCreate, "did:acc:bob.acme"), &scopeErr)
+	assert.Equal(t, "key_1", scopeErr.KeyID)
+	assert.ErrorAs(t, key.Allows(OpDeactivate, "did:acc:alice.acme"), &scopeErr)
+
+	unscoped := &APIKey{ID: "key_2"}
+	assert.NoError(t, unscoped.Allows(OpDeactivate, "did:acc:anyone.acme"))
+
+	exact := &APIKey{ID: "did:acc:alice.acme#key-1", DIDs: []string{"did:acc:alice.acme"}}
+	assert.NoError(t, exact.Allows(OpUpdate, "did:acc:alice.acme"))
+	assert.ErrorAs(t, exact.Allows(OpUpdate, "did:acc:alice.acme/profile"), &scopeErr, "DIDs match exactly")
+}
+
+func TestAPIKey_AllowsPrefixBoundary(t *testing.T) {
+	key := &APIKey{ID: "key_1", DIDPrefixes: []string{"did:acc:bob"}}
+
+	assert.NoError(t, key.Allows(OpUpdate, "did:acc:bob"))
+	assert.NoError(t, key.Allows(OpUpdate, "did:acc:bob/profile"))
+	assert.NoError(t, key.Allows(OpUpdate, "did:acc:bob#key-1"))
+
+	var scopeErr *ScopeError
+	assert.ErrorAs(t, key.Allows(OpUpdate, "did:acc:bobby"), &scopeErr, "lookalike ADIs are not covered")
+	assert.ErrorAs(t, key.Allows(OpUpdate, "did:acc:bobby.acme"), &scopeErr)
+	assert.ErrorAs(t, key.Allows(OpUpdate, "did:acc:bob.evil"), &scopeErr)
+
+	sub := &APIKey{ID: "key_2", DIDPrefixes: []string{"did:acc:bob.acme"}}
+	assert.ErrorAs(t, sub.Allows(OpUpdate, "did:acc:bob.acme.evil"), &scopeErr)
+	assert.ErrorAs(t, sub.Allows(OpUpdate, "did:acc:bob.acmeevil"), &scopeErr)
+
+	path := &APIKey{ID: "key_4", DIDPrefixes: []string{"did:acc:acme/tenants"}}
+	assert.NoError(t, path.Allows(OpUpdate, "did:acc:ACME/tenants.eu"), "'.' is a boundary within the path")
+	assert.ErrorAs(t, path.Allows(OpUpdate, "did:acc:acme/tenantsx"), &scopeErr)
+
+	mixed := &APIKey{ID: "key_3", DIDPrefixes: []string{"did:acc:Bob"}}
+	assert.NoError(t, mixed.Allows(OpUpdate, "did:acc:bob"), "ADI names compare case-insensitively")
+	assert.NoError(t, mixed.Allows(OpUpdate, "did:acc:BOB/profile"))
+	assert.NoError(t, key.Allows(OpUpdate, "did:acc:Bob/profile"))
+	assert.ErrorAs(t, mixed.Allows(OpUpdate, "did:acc:bobby"), &scopeErr)
+}
+
+func TestAPIKeyStore_IssueRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apikeys.json")
+	store, err := LoadAPIKeyStore(path)
+	require.NoError(t, err, "a missing file is an empty store")
+
+	key, secret, err := store.Issue(APIKey{Name: "acme", ADIs: []string{"acc://ACME.acme"}, Operations: []string{OpCreate}})
+	require.NoError(t, err)
+	assert.Regexp(t, `^acdk_[0-9a-f]{48}$`, secret)
+	assert.Equal(t, []string{"acme.acme"}, key.ADIs)
+
+	found, ok := store.Lookup(secret)
+	require.True(t, ok)
+	assert.Equal(t, key.ID, found.ID)
+	_, ok = store.Lookup("acdk_wrong")
+	assert.False(t, ok)
+
+	// The file holds the hash, never the secret
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), secret)
+	assert.Contains(t, string(data), key.Hash)
+
+	reopened, err := LoadAPIKeyStore(path)
+	require.NoError(t, err)
+	_, ok = reopened.Lookup(secret)
+	assert.True(t, ok, "issued keys survive a restart")
+
+	revoked, err := store.Revoke(key.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked.Revoked())
+	_, ok = store.Lookup(secret)
+	assert.False(t, ok)
+	assert.Len(t, store.List(), 1, "revoked keys stay listed")
+
+	// Another instance picks up the revocation on reload
+	require.NoError(t, reopened.Reload())
+	_, ok = reopened.Lookup(secret)
+	assert.False(t, ok)
+
+	_, err = store.Revoke("key_missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestAPIKeyStore_IssueInvalid(t *testing.T) {
+	store := NewAPIKeyStore()
+	_, _, err := store.Issue(APIKey{Operations: []string{"delete"}, DIDPrefixes: []string{"acme"}, DailyQuota: -1})
+	require.ErrorIs(t, err, ErrInvalidKey)
+	for _, want := range []string{`unknown operation "delete"`, `DID prefix "acme"`, "dailyQuota"} {
+		assert.Contains(t, err.Error(), want)
+	}
+	assert.Empty(t, store.List())
+}
+
+func TestAPIKeyAuth_Store(t *testing.T) {
+	store, err := LoadAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	require.NoError(t, err)
+	_, secret, err := store.Issue(APIKey{Name: "tenant"})
+	require.NoError(t, err)
+
+	var seen *APIKey
+	h := NewAPIKeyAuth("").WithStore(store).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = APIKeyFromContext(r.Context())
+	}))
+	call := func(auth string) int {
+		req := httptest.NewRequest(http.MethodPost, "/register", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(""), "a persistent store enforces keys without a configured key")
+	assert.Equal(t, http.StatusUnauthorized, call("Bearer acdk_wrong"))
+	assert.Equal(t, http.StatusOK, call("Bearer "+secret))
+	require.NotNil(t, seen)
+	assert.Equal(t, "tenant", seen.Name)
+
+	// X-API-Key is accepted as well
+	seen = nil
+	req := httptest.NewRequest(http.MethodPost, "/register", nil)
+	req.Header.Set(APIKeyHeader, secret)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "tenant", seen.Name)
+	req.Header.Set(APIKeyHeader, "acdk_wrong")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Callers authenticated earlier, e.g. by DID-Auth, need no API key
+	signed := &APIKey{ID: "did:acc:alice.acme#key-1"}
+	req = httptest.NewRequest(http.MethodPost, "/update", nil)
+	req = req.WithContext(WithAPIKey(req.Context(), signed))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Same(t, signed, seen)
+}
+
+func TestAPIKeyAuth_ClientCertificate(t *testing.T) {
+	store, err := LoadAPIKeyStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	require.NoError(t, err)
+	issued, _, err := store.Issue(APIKey{Name: "ci", ClientSubjects: []string{"CN=ci-deployer,O=Acme"}})
+	require.NoError(t, err)
+
+	_, _, err = store.Issue(APIKey{Name: "other", ClientSubjects: []string{"CN=ci-deployer,O=Acme"}})
+	assert.ErrorIs(t, err, ErrInvalidKey, "a subject is bound to one key")
+
+	var seen *APIKey
+	h := NewAPIKeyAuth("").WithStore(store).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = APIKeyFromContext(r.Context())
+	}))
+	call := func(cn string) int {
+		req := httptest.NewRequest(http.MethodPost, "/register", nil)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: cn, Organization: []string{"Acme"}}},
+		}}}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call("ci-deployer"))
+	require.NotNil(t, seen)
+	assert.Equal(t, issued.ID, seen.ID)
+	assert.Equal(t, http.StatusUnauthorized, call("someone-else"), "unbound subjects still need a key")
+
+	_, err = store.Revoke(issued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, call("ci-deployer"))
+}
+
+func TestRequireAdmin(t *testing.T) {
+	h := NewAPIKeyAuth("admin-secret").WithStore(NewAPIKeyStore()).Middleware()(
+		RequireAdmin()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/keys", nil)
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "the configured key is an admin key")
+
+	// Without enforced API keys nobody is an admin
+	open := RequireAdmin()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w = httptest.NewRecorder()
+	open.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/keys", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
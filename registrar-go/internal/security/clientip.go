@@ -0,0 +1,179 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/opendlt/accu-did/shared/logging"
+)
+
+// ParseCIDRs parses CIDR blocks and single IPs, which become host-sized
+// blocks. Every malformed entry is reported.
+func ParseCIDRs(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	var errs []error
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			_, cidr, err := net.ParseCIDR(entry)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid CIDR %q", entry))
+				continue
+			}
+			nets = append(nets, cidr)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			errs = append(errs, fmt.Errorf("invalid IP %q", entry))
+			continue
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, errors.Join(errs...)
+}
+
+// containsIP reports whether any of nets contains ip
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrustedProxies resolves the client IP of each request. Forwarding headers
+// can be set by anyone, so Forwarded (RFC 7239), X-Forwarded-For and
+// X-Real-IP are only believed when the peer is one of the trusted proxies;
+// with none configured the peer address is the client. The list can be
+// replaced at runtime (e.g. on config reload) with Update.
+type TrustedProxies struct {
+	nets atomic.Pointer[[]*net.IPNet]
+}
+
+// NewTrustedProxies creates a resolver trusting the proxies in cidrs
+func NewTrustedProxies(cidrs []string) (*TrustedProxies, error) {
+	p := &TrustedProxies{}
+	if err := p.Update(cidrs); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Update replaces the trusted proxies; a malformed list is rejected and the
+// current one kept
+func (p *TrustedProxies) Update(cidrs []string) error {
+	nets, err := ParseCIDRs(cidrs)
+	if err != nil {
+		return fmt.Errorf("trusted proxies: %w", err)
+	}
+	p.nets.Store(&nets)
+	return nil
+}
+
+// ClientIP returns the address of the client that sent r. Forwarding headers
+// from a trusted peer are walked from the nearest hop outwards; the first hop
+// that is not a trusted proxy is the client. A hop that cannot be parsed (such
+// as an obfuscated "_node" identifier) ends the walk at the proxy that added it.
+func (p *TrustedProxies) ClientIP(r *http.Request) net.IP {
+	peer := peerIP(r)
+	nets := *p.nets.Load()
+	if peer == nil || !containsIP(nets, peer) {
+		return peer
+	}
+
+	hops := forwardedHops(r.Header)
+	if len(hops) == 0 {
+		if ip := parseHop(r.Header.Get("X-Real-IP")); ip != nil {
+			return ip
+		}
+		return peer
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !containsIP(nets, ip) {
+			break
+		}
+	}
+	return client
+}
+
+// Middleware resolves the client IP once and stores it in the request context
+// for the allowlist, rate limiter, quota and request log. It must run ahead
+// of them.
+func (p *TrustedProxies) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := p.ClientIP(r); ip != nil {
+				r = r.WithContext(logging.WithClientIP(r.Context(), ip.String()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedHops returns the forwarding chain, client first, from the
+// Forwarded header or, when it is absent, X-Forwarded-For
+func forwardedHops(h http.Header) []string {
+	var hops []string
+	if values := h.Values("Forwarded"); len(values) > 0 {
+		for _, value := range values {
+			for _, element := range strings.Split(value, ",") {
+				hop := ""
+				for _, pair := range strings.Split(element, ";") {
+					k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if ok && strings.EqualFold(k, "for") {
+						hop = v
+					}
+				}
+				hops = append(hops, hop)
+			}
+		}
+		return hops
+	}
+	for _, value := range h.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	return hops
+}
+
+// parseHop parses a forwarding hop: an IP, optionally quoted, bracketed
+// (IPv6) or followed by a port
+func parseHop(hop string) net.IP {
+	hop = strings.Trim(strings.TrimSpace(hop), `"`)
+	if strings.HasPrefix(hop, "[") {
+		end := strings.Index(hop, "]")
+		if end < 0 {
+			return nil
+		}
+		return net.ParseIP(hop[1:end])
+	}
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	return net.ParseIP(hop)
+}
+
+// peerIP returns the address of the directly connected peer
+func peerIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return net.ParseIP(r.RemoteAddr)
+	}
+	return net.ParseIP(host)
+}
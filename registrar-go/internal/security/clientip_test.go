@@ -0,0 +1,116 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/shared/logging"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.10", "2001:db8::1"})
+	require.NoError(t, err)
+	require.Len(t, nets, 3)
+	assert.Equal(t, "192.168.1.10/32", nets[1].String())
+	assert.Equal(t, "2001:db8::1/128", nets[2].String())
+
+	_, err = ParseCIDRs([]string{"10.0.0.0/33", "10.0.0.1", "not-an-ip"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid CIDR "10.0.0.0/33"`)
+	assert.Contains(t, err.Error(), `invalid IP "not-an-ip"`)
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/8", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{"no headers", "203.0.113.7:4000", nil, "203.0.113.7"},
+		{"spoofed X-Forwarded-For from an untrusted peer", "203.0.113.7:4000",
+			map[string]string{"X-Forwarded-For": "10.1.2.3"}, "203.0.113.7"},
+		{"spoofed X-Real-IP from an untrusted peer", "203.0.113.7:4000",
+			map[string]string{"X-Real-IP": "10.1.2.3"}, "203.0.113.7"},
+		{"spoofed Forwarded from an untrusted peer", "203.0.113.7:4000",
+			map[string]string{"Forwarded": "for=10.1.2.3"}, "203.0.113.7"},
+		{"X-Forwarded-For from a trusted proxy", "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "198.51.100.9"}, "198.51.100.9"},
+		{"client-prepended X-Forwarded-For through a proxy", "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "10.1.2.3, 198.51.100.9"}, "198.51.100.9"},
+		{"chain of trusted proxies", "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "198.51.100.9, 10.0.0.5"}, "198.51.100.9"},
+		{"every hop trusted", "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "10.0.0.9, 10.0.0.5"}, "10.0.0.9"},
+		{"X-Real-IP from a trusted proxy", "10.0.0.2:4000",
+			map[string]string{"X-Real-IP": "198.51.100.9"}, "198.51.100.9"},
+		{"Forwarded with port and quoted IPv6", "10.0.0.2:4000",
+			map[string]string{"Forwarded": `for="[2001:db8:cafe::17]:4711", for=198.51.100.9:80;proto=https`}, "198.51.100.9"},
+		{"Forwarded takes precedence", "10.0.0.2:4000",
+			map[string]string{"Forwarded": "for=198.51.100.9", "X-Forwarded-For": "192.0.2.1"}, "198.51.100.9"},
+		{"obfuscated hop stops at the proxy", "10.0.0.2:4000",
+			map[string]string{"Forwarded": "for=192.0.2.1, for=_hidden"}, "10.0.0.2"},
+		{"garbage from a trusted proxy", "10.0.0.2:4000",
+			map[string]string{"X-Forwarded-For": "not-an-ip"}, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, proxies.ClientIP(req).String())
+		})
+	}
+
+	// Without trusted proxies headers are never honored
+	none, err := NewTrustedProxies(nil)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.2:4000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	assert.Equal(t, "10.0.0.2", none.ClientIP(req).String())
+}
+
+func TestIPAllowList_Spoofing(t *testing.T) {
+	allow, err := NewIPAllowList([]string{"192.168.1.0/24"})
+	require.NoError(t, err)
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var seenIP string
+	h := proxies.Middleware()(allow.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenIP = logging.ClientIPFromContext(r.Context())
+	})))
+	call := func(peer, xff string) int {
+		req := httptest.NewRequest(http.MethodPost, "/register", nil)
+		req.RemoteAddr = peer
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call("192.168.1.5:1234", ""))
+	assert.Equal(t, "192.168.1.5", seenIP)
+	assert.Equal(t, http.StatusForbidden, call("203.0.113.7:1234", "192.168.1.5"), "a direct caller cannot claim an allowed IP")
+	assert.Equal(t, http.StatusOK, call("10.0.0.2:1234", "192.168.1.5"))
+	assert.Equal(t, "192.168.1.5", seenIP)
+	assert.Equal(t, http.StatusForbidden, call("10.0.0.2:1234", "192.168.1.5, 203.0.113.7"), "the proxy-appended hop wins")
+
+	_, err = NewIPAllowList([]string{"192.168.1.0/24", "192.168.1.300"})
+	assert.Error(t, err, "a malformed entry is not skipped")
+	assert.NoError(t, allow.Update(nil))
+	assert.Error(t, allow.Update([]string{"bogus"}))
+	assert.Equal(t, http.StatusOK, call("203.0.113.7:1234", ""), "a failed update keeps the current list")
+}
@@ -1,14 +1,22 @@
 package security
 
 import (
-	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/opendlt/accu-did/shared/logging"
+	"github.com/opendlt/accu-did/shared/telemetry"
 )
 
 // SecurityConfig holds security configuration
@@ -29,20 +37,80 @@ func DefaultSecurityConfig() *SecurityConfig {
 	}
 }
 
+// APIKeyHeader carries an API key as an alternative to the Authorization
+// header
+const APIKeyHeader = "X-API-Key"
+
+// MaxBodyBytes bounds the request bodies middleware buffers before the
+// handlers run, to check a signature or fingerprint a retried write
+const MaxBodyBytes = 1 << 20
+
 // APIKeyMiddleware enforces API key authentication if configured
 func APIKeyMiddleware(apiKey string) func(http.Handler) http.Handler {
+	return NewAPIKeyAuth(apiKey).Middleware()
+}
+
+// APIKeyAuth holds the API key enforced by its middleware. The key can be
+// rotated at runtime (e.g. on config reload) with Update.
+//
+// With a store, keys it issued are accepted too, each limited to its scope.
+// The configured key is an admin key with no scope restrictions.
+type APIKeyAuth struct {
+	key   atomic.Pointer[string]
+	store *APIKeyStore
+}
+
+// NewAPIKeyAuth creates an API key check; an empty key disables it
+func NewAPIKeyAuth(apiKey string) *APIKeyAuth {
+	a := &APIKeyAuth{}
+	a.Update(apiKey)
+	return a
+}
+
+// WithStore accepts the keys held by store. A persistent store enforces API
+// keys even when no key is configured.
+func (a *APIKeyAuth) WithStore(store *APIKeyStore) *APIKeyAuth {
+	a.store = store
+	return a
+}
+
+// Update replaces the API key
+func (a *APIKeyAuth) Update(apiKey string) {
+	a.key.Store(&apiKey)
+}
+
+// Middleware returns the API key middleware backed by this check
+func (a *APIKeyAuth) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := *a.key.Load()
+
+			// Callers already authenticated, e.g. by a DID-Auth signature,
+			// need no API key
+			if APIKeyFromContext(r.Context()) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Skip API key check if not configured
-			if apiKey == "" {
+			if apiKey == "" && (a.store == nil || !a.store.Persistent()) {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Check Authorization header
+			// Check Authorization header, then X-API-Key
 			auth := r.Header.Get("Authorization")
+			if auth == "" && r.Header.Get(APIKeyHeader) != "" {
+				auth = "ApiKey " + r.Header.Get(APIKeyHeader)
+			}
 			if auth == "" {
-				writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "API key required")
+				// A verified client certificate bound to a key stands in
+				// for its secret
+				if key := a.certificateKey(r); key != nil {
+					next.ServeHTTP(w, r.WithContext(WithAPIKey(r.Context(), key)))
+					return
+				}
+				writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "API key required")
 				return
 			}
 
@@ -53,80 +121,132 @@ func APIKeyMiddleware(apiKey string) func(http.Handler) http.Handler {
 			} else if strings.HasPrefix(auth, "ApiKey ") {
 				providedKey = strings.TrimPrefix(auth, "ApiKey ")
 			} else {
-				writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Invalid authorization format")
+				writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "Invalid authorization format")
 				return
 			}
 
-			if providedKey != apiKey {
-				writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Invalid API key")
+			key := a.authenticate(apiKey, providedKey)
+			if key == nil {
+				writeErrorResponse(w, r, http.StatusUnauthorized, "unauthorized", "Invalid API key")
 				return
 			}
 
+			next.ServeHTTP(w, r.WithContext(WithAPIKey(r.Context(), key)))
+		})
+	}
+}
+
+// defaultAPIKey stands for the configured key
+var defaultAPIKey = &APIKey{ID: "default", Admin: true}
+
+// authenticate returns the key matching provided, or nil
+func (a *APIKeyAuth) authenticate(apiKey, provided string) *APIKey {
+	if apiKey != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) == 1 {
+		return defaultAPIKey
+	}
+	if a.store != nil {
+		if key, ok := a.store.Lookup(provided); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// certificateKey returns the key bound to the subject of the verified TLS
+// client certificate, or nil
+func (a *APIKeyAuth) certificateKey(r *http.Request) *APIKey {
+	subject := ClientSubject(r)
+	if subject == "" || a.store == nil {
+		return nil
+	}
+	key, _ := a.store.LookupSubject(subject)
+	return key
+}
+
+// ClientSubject returns the subject of the client certificate verified
+// during the TLS handshake (mTLS), or "" when there is none
+func ClientSubject(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.String()
+}
+
+// RequireAdmin rejects callers whose API key may not manage keys. When API
+// keys are not enforced every caller is rejected.
+func RequireAdmin() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := APIKeyFromContext(r.Context())
+			if key == nil || !key.Admin {
+				writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "Admin API key required")
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// IPAllowListMiddleware enforces IP allowlisting if configured
+// IPAllowListMiddleware enforces IP allowlisting if configured. It panics on
+// a malformed list; use NewIPAllowList to handle the error.
 func IPAllowListMiddleware(allowList []string) func(http.Handler) http.Handler {
-	// Parse CIDR blocks and IPs at startup
-	var cidrs []*net.IPNet
-	var ips []net.IP
-
-	for _, item := range allowList {
-		if strings.Contains(item, "/") {
-			// CIDR block
-			_, cidr, err := net.ParseCIDR(item)
-			if err != nil {
-				// Log error but don't fail startup
-				continue
-			}
-			cidrs = append(cidrs, cidr)
-		} else {
-			// Single IP
-			ip := net.ParseIP(item)
-			if ip != nil {
-				ips = append(ips, ip)
-			}
-		}
+	a, err := NewIPAllowList(allowList)
+	if err != nil {
+		panic(err)
+	}
+	return a.Middleware()
+}
+
+// IPAllowList holds the parsed allowlist enforced by its middleware. The list
+// can be replaced at runtime (e.g. on config reload) with Update.
+type IPAllowList struct {
+	list atomic.Pointer[[]*net.IPNet]
+}
+
+// NewIPAllowList creates an allowlist of CIDR blocks and IPs; an empty list
+// allows every client. A malformed entry is an error rather than skipped, so
+// a typo cannot silently lock clients out or let them in.
+func NewIPAllowList(allowList []string) (*IPAllowList, error) {
+	a := &IPAllowList{}
+	if err := a.Update(allowList); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Update replaces the allowlist; a malformed list is rejected and the current
+// one kept
+func (a *IPAllowList) Update(allowList []string) error {
+	nets, err := ParseCIDRs(allowList)
+	if err != nil {
+		return fmt.Errorf("IP allowlist: %w", err)
 	}
+	a.list.Store(&nets)
+	return nil
+}
 
+// Middleware returns the allowlist middleware backed by this list. It checks
+// the client IP resolved by TrustedProxies, or the peer address when that
+// middleware is not installed.
+func (a *IPAllowList) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			list := *a.list.Load()
+
 			// Skip if no allowlist configured
-			if len(allowList) == 0 {
+			if len(list) == 0 {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Get client IP (handle X-Forwarded-For, X-Real-IP)
 			clientIP := getClientIP(r)
 			if clientIP == nil {
-				writeErrorResponse(w, http.StatusForbidden, "forbidden", "Cannot determine client IP")
+				writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "Cannot determine client IP")
 				return
 			}
 
-			// Check against allowed IPs
-			allowed := false
-			for _, ip := range ips {
-				if ip.Equal(clientIP) {
-					allowed = true
-					break
-				}
-			}
-
-			// Check against CIDR blocks
-			if !allowed {
-				for _, cidr := range cidrs {
-					if cidr.Contains(clientIP) {
-						allowed = true
-						break
-					}
-				}
-			}
-
-			if !allowed {
-				writeErrorResponse(w, http.StatusForbidden, "forbidden", "IP address not allowed")
+			if !containsIP(list, clientIP) {
+				writeErrorResponse(w, r, http.StatusForbidden, "forbidden", "IP address not allowed")
 				return
 			}
 
@@ -137,12 +257,105 @@ func IPAllowListMiddleware(allowList []string) func(http.Handler) http.Handler {
 
 // RateLimitMiddleware implements token bucket rate limiting
 func RateLimitMiddleware(rps int, burst int) func(http.Handler) http.Handler {
-	limiter := rate.NewLimiter(rate.Limit(rps), burst)
+	return NewRateLimiter(rps, burst).Middleware()
+}
+
+// RateLimiter keeps a token bucket per API key, or per client IP for callers
+// without one. A key may carry its own rate; the default rate and burst can
+// be changed at runtime (e.g. on config reload) with Update.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rps     int
+	burst   int
+	buckets map[string]*bucket
+	metrics *telemetry.HTTPMetrics
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Idle buckets are dropped once this many callers are tracked
+const (
+	maxBuckets    = 10000
+	bucketIdleTTL = 10 * time.Minute
+)
+
+// NewRateLimiter creates a limiter allowing each caller rps requests per second
+func NewRateLimiter(rps int, burst int) *RateLimiter {
+	return &RateLimiter{rps: rps, burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// WithMetrics counts rejected requests in metrics
+func (l *RateLimiter) WithMetrics(metrics *telemetry.HTTPMetrics) *RateLimiter {
+	l.metrics = metrics
+	return l
+}
+
+// Update changes the default rate and burst; tokens already in the buckets
+// are kept
+func (l *RateLimiter) Update(rps int, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rps, l.burst = rps, burst
+}
+
+// take spends a token from the bucket of caller and returns the limiter after
+// the attempt
+func (l *RateLimiter) take(caller string, key *APIKey, now time.Time) (*rate.Limiter, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
+	rps, burst := l.rps, l.burst
+	if key != nil && key.RateLimit != nil {
+		rps, burst = key.RateLimit.RPS, key.RateLimit.Burst
+	}
+
+	b, ok := l.buckets[caller]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			for id, idle := range l.buckets {
+				if now.Sub(idle.lastSeen) > bucketIdleTTL {
+					delete(l.buckets, id)
+				}
+			}
+		}
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(rps), burst)}
+		l.buckets[caller] = b
+	} else if b.limiter.Limit() != rate.Limit(rps) || b.limiter.Burst() != burst {
+		b.limiter.SetLimitAt(now, rate.Limit(rps))
+		b.limiter.SetBurstAt(now, burst)
+	}
+	b.lastSeen = now
+	return b.limiter, b.limiter.AllowN(now, 1)
+}
+
+// Middleware returns the rate limiting middleware backed by this limiter. It
+// runs after API key authentication so each key gets its own bucket, and
+// reports the bucket in X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (seconds until full).
+func (l *RateLimiter) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !limiter.Allow() {
-				writeErrorResponse(w, http.StatusTooManyRequests, "rateLimitExceeded", "Rate limit exceeded")
+			key := APIKeyFromContext(r.Context())
+			caller := "ip:" + clientID(r)
+			if key != nil {
+				caller = "key:" + key.ID
+			}
+
+			now := time.Now()
+			limiter, allowed := l.take(caller, key, now)
+			tokens := max(limiter.TokensAt(now), 0)
+			perSecond := float64(limiter.Limit())
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.Burst()))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(tokens)))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(secondsUntil(float64(limiter.Burst())-tokens, perSecond)))
+
+			if !allowed {
+				l.metrics.ObserveRateLimited()
+				w.Header().Set("Retry-After", strconv.Itoa(max(secondsUntil(1-tokens, perSecond), 1)))
+				writeErrorResponse(w, r, http.StatusTooManyRequests, "rateLimitExceeded", "Rate limit exceeded")
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -150,12 +363,28 @@ func RateLimitMiddleware(rps int, burst int) func(http.Handler) http.Handler {
 	}
 }
 
+// secondsUntil returns the whole seconds needed to refill tokens at rate
+func secondsUntil(tokens, rate float64) int {
+	if tokens <= 0 || rate <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens / rate))
+}
+
+// clientID identifies a caller without an API key by its IP
+func clientID(r *http.Request) string {
+	if ip := getClientIP(r); ip != nil {
+		return ip.String()
+	}
+	return "unknown"
+}
+
 // RequestIDMiddleware adds X-Request-Id header if not present
 func RequestIDMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			requestID := r.Header.Get("X-Request-Id")
-			if requestID == "" {
+			if !validRequestID(requestID) {
 				requestID = generateRequestID()
 				r.Header.Set("X-Request-Id", requestID)
 			}
@@ -163,43 +392,35 @@ func RequestIDMiddleware() func(http.Handler) http.Handler {
 			// Add to response headers
 			w.Header().Set("X-Request-Id", requestID)
 
-			// Add to context for logging
-			ctx := context.WithValue(r.Context(), "request_id", requestID)
+			// Add to context for logging and error responses
+			ctx := logging.WithRequestID(r.Context(), requestID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// getClientIP extracts the real client IP from request
+// getClientIP returns the client IP resolved by TrustedProxies, falling back
+// to the peer address. Forwarding headers are never read here, so they cannot
+// be spoofed by clients that do not come through a trusted proxy.
 func getClientIP(r *http.Request) net.IP {
-	// Try X-Forwarded-For first
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		// Take first IP from comma-separated list
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			ip := net.ParseIP(strings.TrimSpace(ips[0]))
-			if ip != nil {
-				return ip
-			}
-		}
+	if ip := net.ParseIP(logging.ClientIPFromContext(r.Context())); ip != nil {
+		return ip
 	}
+	return peerIP(r)
+}
 
-	// Try X-Real-IP
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		ip := net.ParseIP(xri)
-		if ip != nil {
-			return ip
-		}
+// validRequestID reports whether a client-supplied request ID is safe to echo
+// back in headers, logs and error bodies
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
 	}
-
-	// Fall back to RemoteAddr
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return nil
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
 	}
-	return net.ParseIP(host)
+	return true
 }
 
 // generateRequestID creates a simple request ID
@@ -208,10 +429,14 @@ func generateRequestID() string {
 }
 
 // writeErrorResponse writes a canonical error response
-func writeErrorResponse(w http.ResponseWriter, status int, errorCode, message string) {
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, errorCode, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	response := fmt.Sprintf(`{"code":%d,"error":"%s","message":"%s"}`, status, errorCode, message)
-	w.Write([]byte(response))
+	json.NewEncoder(w).Encode(struct {
+		Code      int    `json:"code"`
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	}{status, errorCode, message, logging.RequestIDFromContext(r.Context())})
 }
\ No newline at end of file
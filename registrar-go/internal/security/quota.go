@@ -0,0 +1,108 @@
+package security
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/telemetry"
+)
+
+// QuotaLimiter caps the writes each API key, or each client IP for callers
+// without one, may make per UTC day. A key may carry its own quota; the
+// default can be changed at runtime (e.g. on config reload) with Update.
+type QuotaLimiter struct {
+	daily   atomic.Int64
+	now     func() time.Time
+	metrics *telemetry.Metrics
+
+	mu     sync.Mutex
+	day    string
+	counts map[string]int
+}
+
+// NewQuotaLimiter creates a limiter allowing daily writes per caller; 0
+// leaves callers without a quota of their own unlimited
+func NewQuotaLimiter(daily int) *QuotaLimiter {
+	q := &QuotaLimiter{now: time.Now, counts: make(map[string]int)}
+	q.Update(daily)
+	return q
+}
+
+// WithMetrics counts rejected writes in metrics
+func (q *QuotaLimiter) WithMetrics(metrics *telemetry.Metrics) *QuotaLimiter {
+	q.metrics = metrics
+	return q
+}
+
+// Update changes the default daily quota; writes already counted today are
+// kept
+func (q *QuotaLimiter) Update(daily int) {
+	q.daily.Store(int64(daily))
+}
+
+// take counts a write by caller against limit and returns the writes used,
+// including this one if it was allowed
+func (q *QuotaLimiter) take(caller string, limit int, now time.Time) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Counts start over every UTC day
+	if day := now.UTC().Format(time.DateOnly); day != q.day {
+		q.day = day
+		clear(q.counts)
+	}
+	if q.counts[caller] >= limit {
+		return q.counts[caller], false
+	}
+	q.counts[caller]++
+	return q.counts[caller], true
+}
+
+// Middleware returns the quota middleware backed by this limiter, for the
+// write routes. It reports the quota in X-Quota-Limit, X-Quota-Remaining and
+// X-Quota-Reset (seconds until the next UTC day).
+func (q *QuotaLimiter) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := APIKeyFromContext(r.Context())
+			limit := int(q.daily.Load())
+			caller := "ip:" + clientID(r)
+			if key != nil {
+				caller = "key:" + key.ID
+				if key.DailyQuota > 0 {
+					limit = key.DailyQuota
+				}
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			now := q.now()
+			used, allowed := q.take(caller, limit, now)
+			reset := strconv.Itoa(secondsUntilNextDay(now))
+			w.Header().Set("X-Quota-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-Quota-Remaining", strconv.Itoa(max(limit-used, 0)))
+			w.Header().Set("X-Quota-Reset", reset)
+
+			if !allowed {
+				q.metrics.ObserveQuotaExceeded()
+				w.Header().Set("Retry-After", reset)
+				writeErrorResponse(w, r, http.StatusTooManyRequests, "quotaExceeded", "Daily write quota exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// secondsUntilNextDay returns the whole seconds until the next UTC midnight
+func secondsUntilNextDay(now time.Time) int {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return int(math.Ceil(midnight.Sub(now).Seconds()))
+}
@@ -0,0 +1,127 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/opendlt/accu-did/registrar-go/internal/telemetry"
+)
+
+func serve(h http.Handler, remoteAddr string, key *APIKey) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/register", nil)
+	req.RemoteAddr = remoteAddr
+	if key != nil {
+		req = req.WithContext(WithAPIKey(req.Context(), key))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestRateLimiter_PerCaller(t *testing.T) {
+	h := NewRateLimiter(1, 2).Middleware()(okHandler)
+
+	first := serve(h, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234", nil).Code)
+
+	limited := serve(h, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+	assert.Equal(t, "0", limited.Header().Get("X-RateLimit-Remaining"))
+
+	// Other clients and keys have their own buckets
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.2:1234", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234", &APIKey{ID: "key_1"}).Code)
+}
+
+func TestRateLimiter_KeyOverride(t *testing.T) {
+	h := NewRateLimiter(1, 1).Middleware()(okHandler)
+	key := &APIKey{ID: "key_1", RateLimit: &RateLimit{RPS: 100, Burst: 5}}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234", key).Code)
+	}
+	w := serve(h, "10.0.0.1:1234", key)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestQuotaLimiter(t *testing.T) {
+	q := NewQuotaLimiter(2)
+	now := time.Date(2025, 1, 1, 23, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+	h := q.Middleware()(okHandler)
+
+	w := serve(h, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Quota-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-Quota-Remaining"))
+	assert.Equal(t, "3600", w.Header().Get("X-Quota-Reset"))
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234", nil).Code)
+
+	w = serve(h, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+
+	// A key's own quota replaces the default
+	key := &APIKey{ID: "key_1", DailyQuota: 3}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234", key).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, "10.0.0.1:1234", key).Code)
+
+	// Counts start over at UTC midnight
+	now = now.Add(time.Hour)
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234", nil).Code)
+}
+
+func TestQuotaLimiter_Unlimited(t *testing.T) {
+	h := NewQuotaLimiter(0).Middleware()(okHandler)
+	w := serve(h, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Quota-Limit"))
+}
+
+// counterValue reads a counter without labels from reg
+func counterValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func TestLimiters_Metrics(t *testing.T) {
+	tel := telemetry.NewWithExporter(telemetry.DefaultConfig(), tracetest.NewInMemoryExporter())
+	reg := tel.Registry()
+
+	rateLimited := NewRateLimiter(1, 1).WithMetrics(tel.HTTP).Middleware()(okHandler)
+	assert.Equal(t, http.StatusOK, serve(rateLimited, "10.0.0.1:1234", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(rateLimited, "10.0.0.1:1234", nil).Code)
+
+	quotaLimited := NewQuotaLimiter(1).WithMetrics(tel.Metrics).Middleware()(okHandler)
+	assert.Equal(t, http.StatusOK, serve(quotaLimited, "10.0.0.1:1234", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(quotaLimited, "10.0.0.1:1234", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(quotaLimited, "10.0.0.1:1234", nil).Code)
+
+	// Each rejection is counted once, by the limiter that made it
+	assert.Equal(t, 1.0, counterValue(t, reg, "accdid_registrar_rate_limit_rejections_total"))
+	assert.Equal(t, 2.0, counterValue(t, reg, "accdid_registrar_quota_rejections_total"))
+}
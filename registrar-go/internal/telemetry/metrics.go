@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/opendlt/accu-did/shared/telemetry"
+)
+
+const subsystem = "registrar"
+
+// Submission results used as the "result" label
+const (
+	SubmissionSuccess = "success"
+	SubmissionFailure = "failure"
+)
+
+// Metrics holds the registrar-specific Prometheus collectors; HTTP, rate limit
+// and upstream metrics are in the shared HTTPMetrics. A nil *Metrics is valid
+// and records nothing.
+type Metrics struct {
+	submissions        *prometheus.CounterVec
+	submissionDuration *prometheus.HistogramVec
+	quotaExceeded      prometheus.Counter
+}
+
+// NewMetrics creates the registrar collectors and registers them
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		submissions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: telemetry.Namespace,
+			Subsystem: subsystem,
+			Name:      "submissions_total",
+			Help:      "Accumulate submissions by transaction type and result.",
+		}, []string{"type", "result"}),
+		submissionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: telemetry.Namespace,
+			Subsystem: subsystem,
+			Name:      "submission_duration_seconds",
+			Help:      "Accumulate submission latency by transaction type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"type"}),
+		quotaExceeded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: telemetry.Namespace,
+			Subsystem: subsystem,
+			Name:      "quota_rejections_total",
+			Help:      "Writes rejected by the daily quota.",
+		}),
+	}
+
+	reg.MustRegister(
+		m.submissions,
+		m.submissionDuration,
+		m.quotaExceeded,
+	)
+
+	return m
+}
+
+// ObserveQuotaExceeded records a write rejected by the daily quota
+func (m *Metrics) ObserveQuotaExceeded() {
+	if m == nil {
+		return
+	}
+	m.quotaExceeded.Inc()
+}
+
+// ObserveSubmission records an Accumulate submission such as "createIdentity"
+// or "writeData"
+func (m *Metrics) ObserveSubmission(txType string, err error, d time.Duration) {
+	if m == nil {
+		return
+	}
+	result := SubmissionSuccess
+	if err != nil {
+		result = SubmissionFailure
+	}
+	m.submissions.WithLabelValues(txType, result).Inc()
+	m.submissionDuration.WithLabelValues(txType).Observe(d.Seconds())
+}
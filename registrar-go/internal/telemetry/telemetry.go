@@ -0,0 +1,49 @@
+// Package telemetry configures the shared tracing and metrics for the registrar
+// and adds the registrar metrics
+package telemetry
+
+import (
+	"context"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/opendlt/accu-did/shared/telemetry"
+)
+
+// TracerName is the instrumentation scope used for all registrar spans
+const TracerName = "github.com/opendlt/accu-did/registrar-go"
+
+// Config holds telemetry configuration
+type Config = telemetry.Config
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	cfg := telemetry.DefaultConfig()
+	cfg.ServiceName = "accu-did-registrar"
+	cfg.TracerName = TracerName
+	cfg.Subsystem = subsystem
+	return cfg
+}
+
+// Telemetry is the shared telemetry with the registrar metrics
+type Telemetry struct {
+	*telemetry.Telemetry
+	Metrics *Metrics
+}
+
+// New configures tracing and metrics. When no OTLP endpoint is configured
+// tracing is a no-op, metrics are always collected.
+func New(ctx context.Context, cfg Config) (*Telemetry, error) {
+	t, err := telemetry.New(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Telemetry{Telemetry: t, Metrics: NewMetrics(t.Registry())}, nil
+}
+
+// NewWithExporter configures telemetry with a caller-supplied span exporter,
+// for tests with an in-memory exporter
+func NewWithExporter(cfg Config, exporter sdktrace.SpanExporter) *Telemetry {
+	t := telemetry.NewWithExporter(cfg, exporter)
+	return &Telemetry{Telemetry: t, Metrics: NewMetrics(t.Registry())}
+}
@@ -3,121 +3,331 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
-	"github.com/opendlt/accu-did/resolver-go/handlers"
 	"github.com/opendlt/accu-did/resolver-go/internal/acc"
+	"github.com/opendlt/accu-did/resolver-go/internal/config"
+	"github.com/opendlt/accu-did/resolver-go/internal/readiness"
 	"github.com/opendlt/accu-did/resolver-go/internal/resolve"
 	"github.com/opendlt/accu-did/resolver-go/internal/security"
+	"github.com/opendlt/accu-did/resolver-go/internal/telemetry"
+	"github.com/opendlt/accu-did/shared/health"
+	"github.com/opendlt/accu-did/shared/ledger"
+	"github.com/opendlt/accu-did/shared/logging"
+	"github.com/opendlt/accu-did/shared/nodepool"
+	"github.com/opendlt/accu-did/shared/tlsutil"
 )
 
 func main() {
-	// Parse command line flags
+	// Parse command line flags. Flags that are set explicitly override the
+	// config file and environment.
 	var (
-		addr            = flag.String("addr", ":8080", "listen address")
-		bind            = flag.String("bind", "127.0.0.1", "bind address (security: 127.0.0.1 for localhost only)")
-		real            = flag.Bool("real", false, "enable real mode (connect to Accumulate network)")
+		configPath       = flag.String("config", os.Getenv("RESOLVER_CONFIG"), "path to a YAML or TOML config file (env: RESOLVER_CONFIG)")
+		addr             = flag.String("addr", ":8080", "listen address")
+		bind             = flag.String("bind", "127.0.0.1", "bind address (security: 127.0.0.1 for localhost only)")
+		real             = flag.Bool("real", false, "enable real mode (connect to Accumulate network)")
+		tlsCert          = flag.String("tls-cert", "", "TLS certificate file; enables HTTPS (env: RESOLVER_TLS_CERT_FILE)")
+		tlsKey           = flag.String("tls-key", "", "TLS private key file (env: RESOLVER_TLS_KEY_FILE)")
+		tlsClientCA      = flag.String("tls-client-ca", "", "PEM bundle of CAs for client certificates (mTLS)")
+		tlsClientAuth    = flag.String("tls-client-auth", "", "client certificates: none, optional or require (default require with a CA bundle)")
 		corsAllowOrigins = flag.String("cors-allow-origins", "", "comma-separated CORS allowed origins (empty=none, *=all)")
-		resolveOrder    = flag.String("resolve-order", "sequence", "resolution ordering strategy: sequence or timestamp")
+		resolveOrder     = flag.String("resolve-order", "sequence", "resolution ordering strategy: sequence or timestamp")
+		otlpEndpoint     = flag.String("otlp-endpoint", "", "OTLP/HTTP trace collector endpoint, host:port (empty=tracing disabled)")
+		otlpInsecure     = flag.Bool("otlp-insecure", false, "use plain HTTP for the OTLP trace collector")
+		traceSample      = flag.Float64("trace-sample-ratio", 1.0, "fraction of new traces to sample (0.0-1.0)")
+		metricsEnabled   = flag.Bool("metrics", true, "expose Prometheus metrics on /metrics")
+		logLevel         = flag.String("log-level", "info", "log level: debug, info, warn or error (env: LOG_LEVEL)")
+		logFormat        = flag.String("log-format", "json", "log format: json or text")
+		logRedactDIDs    = flag.Bool("log-redact-dids", false, "replace DIDs in logs with a stable hash")
+		logRedactIPs     = flag.Bool("log-redact-ips", false, "truncate client IPs in logs to their network prefix")
 	)
 	flag.Parse()
 
-	// Get Accumulate node URL from environment if in real mode
-	var nodeURL string
-	if *real {
-		nodeURL = os.Getenv("ACC_NODE_URL")
-		if nodeURL == "" {
-			log.Fatal("ACC_NODE_URL environment variable is required when using --real mode")
+	applyFlags := func(cfg *config.Config) {
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "addr":
+				cfg.Server.Addr = *addr
+			case "bind":
+				cfg.Server.Bind = *bind
+			case "real":
+				cfg.Accumulate.Real = *real
+			case "tls-cert":
+				cfg.TLS.CertFile = *tlsCert
+			case "tls-key":
+				cfg.TLS.KeyFile = *tlsKey
+			case "tls-client-ca":
+				cfg.TLS.ClientCAFile = *tlsClientCA
+			case "tls-client-auth":
+				cfg.TLS.ClientAuth = *tlsClientAuth
+			case "cors-allow-origins":
+				cfg.CORS.AllowOrigins = config.SplitList(*corsAllowOrigins)
+			case "resolve-order":
+				cfg.Resolve.Order = *resolveOrder
+			case "otlp-endpoint":
+				cfg.Telemetry.OTLPEndpoint = *otlpEndpoint
+			case "otlp-insecure":
+				cfg.Telemetry.OTLPInsecure = *otlpInsecure
+			case "trace-sample-ratio":
+				cfg.Telemetry.TraceSampleRatio = *traceSample
+			case "metrics":
+				cfg.Telemetry.Metrics = *metricsEnabled
+			case "log-level":
+				cfg.Logging.Level = *logLevel
+			case "log-format":
+				cfg.Logging.Format = *logFormat
+			case "log-redact-dids":
+				cfg.Logging.RedactDIDs = *logRedactDIDs
+			case "log-redact-ips":
+				cfg.Logging.RedactIPs = *logRedactIPs
+			}
+		})
+	}
+
+	// loadConfig layers defaults, config file, environment and flags
+	loadConfig := func() (*config.Config, error) {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return nil, err
+		}
+		applyFlags(cfg)
+		if err := cfg.Validate(); err != nil {
+			return nil, err
 		}
+		return cfg, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fatal("Invalid configuration", "error", err)
 	}
 
+	// Setup structured logging. The level can be changed on reload.
+	logLevelVar := new(slog.LevelVar)
+	logger, err := logging.NewWithLevelVar(os.Stdout, logging.Config{
+		Level:      cfg.Logging.Level,
+		Format:     cfg.Logging.Format,
+		RedactDIDs: cfg.Logging.RedactDIDs,
+		RedactIPs:  cfg.Logging.RedactIPs,
+	}, logLevelVar)
+	if err != nil {
+		fatal("Invalid logging configuration", "error", err)
+	}
+	slog.SetDefault(logger)
+
 	// Determine mode for logging
 	mode := "FAKE"
-	if *real {
+	if cfg.Accumulate.Real {
 		mode = "REAL"
 	}
 
-	// Validate and parse resolve order
-	var order resolve.ResolveOrder
-	switch *resolveOrder {
-	case "sequence":
-		order = resolve.ResolveOrderSequence
-	case "timestamp":
+	order := resolve.ResolveOrderSequence
+	if cfg.Resolve.Order == "timestamp" {
 		order = resolve.ResolveOrderTimestamp
-	default:
-		log.Fatalf("Invalid resolve-order: %s (must be 'sequence' or 'timestamp')", *resolveOrder)
-	}
-
-	// Parse CORS origins
-	var corsOrigins []string
-	if *corsAllowOrigins != "" {
-		corsOrigins = strings.Split(*corsAllowOrigins, ",")
-		for i, origin := range corsOrigins {
-			corsOrigins[i] = strings.TrimSpace(origin)
-		}
 	}
 
 	// Build full bind address
-	fullAddr := *bind + *addr
+	fullAddr := cfg.Server.ListenAddr()
 
 	// Log startup configuration
-	log.Printf("Starting DID Resolver")
-	log.Printf("  Mode: %s", mode)
-	log.Printf("  Bind: %s", fullAddr)
-	log.Printf("  Resolve Order: %s", *resolveOrder)
-	log.Printf("  CORS Origins: %v", corsOrigins)
-	if *real && nodeURL != "" {
-		log.Printf("  Accumulate Node: %s", nodeURL)
+	logger.Info("Starting DID Resolver",
+		"mode", mode,
+		"config_file", *configPath,
+		"bind", fullAddr,
+		"tls", cfg.TLS.Enabled(),
+		"resolve_order", cfg.Resolve.Order,
+		"cors_origins", cfg.CORS.AllowOrigins,
+		"accumulate_nodes", cfg.Accumulate.NodeURLs(),
+		"cache", cfg.Cache.Enabled,
+		"tracing", func() string {
+			if cfg.Telemetry.OTLPEndpoint != "" {
+				return cfg.Telemetry.OTLPEndpoint
+			}
+			return "disabled"
+		}(),
+		"metrics", cfg.Telemetry.Metrics,
+		"log_level", cfg.Logging.Level,
+	)
+
+	// Setup tracing and metrics
+	telCfg := telemetry.DefaultConfig()
+	telCfg.OTLPEndpoint = cfg.Telemetry.OTLPEndpoint
+	telCfg.OTLPInsecure = cfg.Telemetry.OTLPInsecure
+	telCfg.SampleRatio = cfg.Telemetry.TraceSampleRatio
+	tel, err := telemetry.New(context.Background(), telCfg)
+	if err != nil {
+		fatal("Failed to initialize telemetry", "error", err)
 	}
 
-	// Create Accumulate client
-	accClient := acc.NewClient(*real, nodeURL)
+	// Create Accumulate client. In REAL mode queries are spread over a pool
+	// of health-checked nodes.
+	var accClient acc.Client
+	healthHandler := health.NewHealthHandler(nil)
+	checker := health.NewChecker(cfg.Readiness.Timeout.Std())
+	if cfg.Accumulate.Real {
+		pool, err := nodepool.New(cfg.Accumulate.NodeURLs(), nodepool.Config{
+			ProbeInterval:    cfg.Accumulate.Pool.ProbeInterval.Std(),
+			ProbeTimeout:     cfg.Accumulate.Pool.ProbeTimeout.Std(),
+			FailureThreshold: cfg.Accumulate.Pool.FailureThreshold,
+			Cooldown:         cfg.Accumulate.Pool.Cooldown.Std(),
+			Retries:          cfg.Accumulate.Pool.Retries,
+		}, func(nodeURL string) http.RoundTripper {
+			return tel.Transport(nodeURL, nil)
+		})
+		if err != nil {
+			fatal("Failed to create Accumulate node pool", "error", err)
+		}
+		pool.Start()
+		defer pool.Close()
+		healthHandler = health.NewHealthHandler(pool)
+		realClient := acc.NewRealClientWithPool(pool).WithTimeout(cfg.Accumulate.Timeout.Std())
+		// The background probes keep the pool status fresh; allow one
+		// missed round before /readyz probes itself
+		checker.Add(health.NodesCheck(pool, 2*cfg.Accumulate.Pool.ProbeInterval.Std()))
+		checker.Add(health.NetworkCheck(realClient.NetworkID, cfg.Accumulate.Network))
+		accClient = realClient
+	} else {
+		fakeLedger, err := openFakeLedger(cfg.Accumulate.Fake)
+		if err != nil {
+			fatal("Failed to open fake ledger", "error", err)
+		}
+		defer fakeLedger.Close()
+		acc.SeedLedger(fakeLedger, "testdata")
+		accClient = acc.NewFakeClientWithLedger(fakeLedger)
+		logger.Info("Using fake ledger", "state_dir", cfg.Accumulate.Fake.StateDir, "height", fakeLedger.Height())
+	}
 
 	// Setup router
 	r := chi.NewRouter()
 
 	// Security middleware
+	cors := security.NewCORS(cfg.CORS.AllowOrigins)
 	r.Use(security.RequestIDMiddleware())
-	r.Use(security.CORSMiddleware(corsOrigins))
+	r.Use(cors.Middleware())
+
+	// Tracing and HTTP metrics
+	r.Use(tel.Middleware())
 
 	// Standard middleware
-	r.Use(middleware.Logger)
+	r.Use(logging.Middleware(logger))
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(middleware.Timeout(cfg.Server.RequestTimeout.Std()))
+
+	// Liveness (cheap) and readiness (deep) checks
+	r.Get("/healthz", healthHandler.Healthz)
+	r.Get("/readyz", health.NewReadyHandler(checker).Readyz)
 
-	// Health check
-	r.Get("/healthz", handlers.Healthz)
+	// Prometheus metrics
+	if cfg.Telemetry.Metrics {
+		r.Handle("/metrics", tel.MetricsHandler())
+	}
 
 	// DID resolution
-	resolveHandler := resolve.NewHandlerWithOrder(accClient, order)
-	r.Get("/resolve", resolveHandler.Resolve)
+	resolveHandler := resolve.NewHandlerWithOrder(accClient, order).
+		WithMetrics(tel.Metrics).
+		WithMaxAge(cfg.Cache.MaxAge.Std())
+	var cache *resolve.Cache
+	if cfg.Cache.Enabled {
+		cache = resolve.NewCache(cfg.Cache.TTL.Std(), cfg.Cache.MaxEntries)
+		resolveHandler = resolveHandler.WithCache(cache)
+		checker.Add(readiness.CacheCheck(cache))
+	}
+
+	// Resolution is rate limited per client IP when rateLimit.rps is set
+	rateLimiter := security.NewRateLimiter(cfg.RateLimit.RPS, cfg.RateLimit.Burst).
+		WithMetrics(tel.HTTP)
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimiter.Middleware())
+		r.Get("/resolve", resolveHandler.Resolve)
 
-	// Universal Resolver 1.0 compatibility
-	r.Get("/1.0/identifiers/{did}", resolveHandler.UniversalResolve)
+		// Universal Resolver 1.0 compatibility
+		r.Get("/1.0/identifiers/{did}", resolveHandler.UniversalResolve)
+	})
 
 	// Create server
 	srv := &http.Server{
 		Addr:         fullAddr,
 		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.Server.ReadTimeout.Std(),
+		WriteTimeout: cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Std(),
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	// Serve TLS from the configured files, re-read when they are rotated,
+	// verifying client certificates when a CA bundle is set
+	var tlsReloader *tlsutil.Reloader
+	if cfg.TLS.Enabled() {
+		tlsReloader, err = tlsutil.New(tlsutil.Options{
+			CertFile:     cfg.TLS.CertFile,
+			KeyFile:      cfg.TLS.KeyFile,
+			ClientCAFile: cfg.TLS.ClientCAFile,
+			ClientAuth:   cfg.TLS.ClientAuth,
+		})
+		if err != nil {
+			fatal("Failed to load TLS certificate", "error", err)
+		}
+		srv.TLSConfig = tlsReloader.TLSConfig()
 	}
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Server listening on %s", fullAddr)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+		logger.Info("Server listening", "addr", fullAddr, "tls", cfg.TLS.Enabled(), "mtls", cfg.TLS.ClientCAFile != "")
+		var err error
+		if cfg.TLS.Enabled() {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed to start", "error", err)
+		}
+	}()
+
+	// Reload the configuration on SIGHUP. Only the log level, CORS origins,
+	// rate limit, cache TTL and max-age are applied live, and the TLS certificate is re-read;
+	// other changes are reported and need a restart.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			next, err := loadConfig()
+			if err != nil {
+				logger.Error("Config reload failed, keeping current configuration", "error", err)
+				continue
+			}
+			for _, setting := range cfg.RestartRequired(next) {
+				logger.Warn("Config change requires a restart to take effect", "setting", setting)
+			}
+
+			level, _ := logging.ParseLevel(next.Logging.Level)
+			logLevelVar.Set(level)
+			if tlsReloader != nil {
+				if err := tlsReloader.Reload(); err != nil {
+					logger.Error("TLS reload failed, keeping current certificate", "error", err)
+				}
+			}
+			cors.Update(next.CORS.AllowOrigins)
+			rateLimiter.Update(next.RateLimit.RPS, next.RateLimit.Burst)
+			if cache != nil {
+				cache.SetTTL(next.Cache.TTL.Std())
+			}
+			resolveHandler.SetMaxAge(next.Cache.MaxAge.Std())
+			logger.Info("Configuration reloaded",
+				"log_level", next.Logging.Level,
+				"cors_origins", next.CORS.AllowOrigins,
+				"rate_rps", next.RateLimit.RPS,
+				"cache_ttl", next.Cache.TTL.Std().String(),
+				"cache_max_age", next.Cache.MaxAge.Std().String(),
+			)
 		}
 	}()
 
@@ -126,15 +336,45 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
 
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal("Server forced to shutdown", "error", err)
 	}
 
-	log.Println("Server exited")
+	if err := tel.Shutdown(ctx); err != nil {
+		logger.Error("Telemetry shutdown error", "error", err)
+	}
+
+	logger.Info("Server exited")
+}
+
+// fatal logs at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// openFakeLedger opens the ledger simulated in FAKE mode: persistent when a
+// state directory is configured, in memory otherwise. A fixture is imported
+// only into an empty ledger, so restarts keep the state written since.
+func openFakeLedger(cfg config.FakeConfig) (*ledger.Ledger, error) {
+	l := ledger.New()
+	if cfg.StateDir != "" {
+		var err error
+		if l, err = ledger.Open(cfg.StateDir); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Fixture != "" && l.Height() == 0 {
+		if err := l.ImportFile(cfg.Fixture); err != nil {
+			l.Close()
+			return nil, fmt.Errorf("failed to import fixture %s: %w", cfg.Fixture, err)
+		}
+	}
+	return l, nil
 }
@@ -1,29 +0,0 @@
-package handlers
-
-import (
-	"encoding/json"
-	"net/http"
-	"time"
-)
-
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-}
-
-// Healthz handles health check requests
-func Healthz(w http.ResponseWriter, r *http.Request) {
-	response := HealthResponse{
-		Status:    "ok",
-		Timestamp: time.Now().UTC(),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
-	}
-}
@@ -2,16 +2,16 @@ package acc
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"net/http"
 	"time"
 
 	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/api/v3/jsonrpc"
 	"gitlab.com/accumulatenetwork/accumulate/pkg/url"
 	"gitlab.com/accumulatenetwork/accumulate/protocol"
+
+	"github.com/opendlt/accu-did/shared/nodepool"
 )
 
 // Envelope represents a DID document entry envelope
@@ -52,15 +52,25 @@ type Key struct {
 
 // Client interface for Accumulate operations
 type Client interface {
-	GetLatestDIDEntry(adi string) (Envelope, error)
-	GetEntryAtTime(adi string, t time.Time) (Envelope, error)
-	GetKeyPageState(url string) (KeyPageState, error)
-	GetDataAccountEntry(dataAccountURL *url.URL) ([]byte, error)
+	GetLatestDIDEntry(ctx context.Context, adi string) (Envelope, error)
+	GetEntryAtTime(ctx context.Context, adi string, t time.Time) (Envelope, error)
+	GetKeyPageState(ctx context.Context, url string) (KeyPageState, error)
+	GetDataAccountEntry(ctx context.Context, dataAccountURL *url.URL) ([]byte, error)
+}
+
+// DataEntry is one entry of a data account with its chain metadata
+type DataEntry struct {
+	Data      []byte
+	Sequence  uint64 // 1-based position in the account's data chain
+	Timestamp time.Time
+	TxID      string
 }
 
-// FakeClient implements Client interface using golden files
-type FakeClient struct {
-	testdataDir string
+// EntryLister is implemented by clients that can return the full history of
+// a data account, oldest first. Resolution uses it when available so entry
+// ordering and versionTime use real chain metadata.
+type EntryLister interface {
+	GetDataEntries(ctx context.Context, dataAccountURL *url.URL) ([]DataEntry, error)
 }
 
 // NewClient creates a new client based on mode
@@ -71,111 +81,63 @@ func NewClient(realMode bool, nodeURL string) Client {
 	return NewFakeClient("testdata")
 }
 
-// NewFakeClient creates a new fake client that reads from testdata
-func NewFakeClient(testdataDir string) *FakeClient {
-	return &FakeClient{
-		testdataDir: testdataDir,
-	}
-}
+// DefaultQueryTimeout bounds each JSON-RPC query made by RealClient
+const DefaultQueryTimeout = 15 * time.Second
 
-// GetLatestDIDEntry returns the latest DID entry for an ADI
-func (c *FakeClient) GetLatestDIDEntry(adi string) (Envelope, error) {
-	// For testing, return the update.service version as "latest"
-	return c.loadEnvelope("entry.update.service.json")
+// RealClient implements Client interface using JSON-RPC v3
+type RealClient struct {
+	client  *jsonrpc.Client
+	timeout time.Duration
 }
 
-// GetEntryAtTime returns a DID entry at a specific time
-func (c *FakeClient) GetEntryAtTime(adi string, t time.Time) (Envelope, error) {
-	// Simple logic: before 2024-01-02 returns v1, after returns v2
-	cutoff := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
-
-	if t.Before(cutoff) {
-		return c.loadEnvelope("entry.v1.json")
+// NewRealClient creates a new real client that connects to Accumulate network
+func NewRealClient(nodeURL string) *RealClient {
+	return &RealClient{
+		client:  jsonrpc.NewClient(nodeURL),
+		timeout: DefaultQueryTimeout,
 	}
-
-	return c.loadEnvelope("entry.update.service.json")
 }
 
-// GetKeyPageState returns the state of a Key Page
-func (c *FakeClient) GetKeyPageState(url string) (KeyPageState, error) {
-	// Return a mock key page state
-	return KeyPageState{
-		URL:       url,
-		Threshold: 1,
-		Keys: []Key{
-			{
-				PublicKey: "ed25519:abc123...",
-				KeyType:   "ed25519",
-			},
-		},
-	}, nil
-}
-
-// loadEnvelope loads an envelope from testdata
-func (c *FakeClient) loadEnvelope(filename string) (Envelope, error) {
-	// Try examples directory first
-	path := filepath.Join(c.testdataDir, "examples", filename)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return Envelope{}, fmt.Errorf("failed to read %s: %w", path, err)
-	}
-
-	// Parse as DID document and wrap in envelope
-	var doc map[string]interface{}
-	if err := json.Unmarshal(data, &doc); err != nil {
-		return Envelope{}, fmt.Errorf("failed to parse %s: %w", filename, err)
+// NewRealClientWithTransport creates a real client whose JSON-RPC calls go
+// through the given HTTP transport (used for tracing and upstream metrics)
+func NewRealClientWithTransport(nodeURL string, transport http.RoundTripper) *RealClient {
+	client := jsonrpc.NewClient(nodeURL)
+	client.Client.Transport = transport
+	return &RealClient{
+		client:  client,
+		timeout: DefaultQueryTimeout,
 	}
+}
 
-	// Create envelope wrapper
-	envelope := Envelope{
-		ContentType: "application/did+json",
-		Document:    doc,
-		Meta: EnvelopeMeta{
-			VersionID:     "1704067200-8b4c4f7b",
-			Timestamp:     time.Now().UTC(),
-			AuthorKeyPage: "acc://alice/book/1",
-			Proof: Proof{
-				TxID:        "0x1234567890abcdef",
-				ContentHash: "", // Will be computed if needed
-			},
-		},
-	}
+// NewRealClientWithPool creates a real client whose JSON-RPC calls are
+// spread over the nodes of pool
+func NewRealClientWithPool(pool *nodepool.Pool) *RealClient {
+	return NewRealClientWithTransport(pool.URL(), pool)
+}
 
-	return envelope, nil
+// WithTimeout sets the per-query timeout
+func (c *RealClient) WithTimeout(timeout time.Duration) *RealClient {
+	c.timeout = timeout
+	return c
 }
 
-// GetDataAccountEntry reads from testdata for FAKE mode
-func (c *FakeClient) GetDataAccountEntry(dataAccountURL *url.URL) ([]byte, error) {
-	// Extract ADI from URL for testdata lookup
-	adiLabel := dataAccountURL.Authority
-	filename := fmt.Sprintf("did-%s.json", adiLabel)
+// NetworkID returns the name of the network the node belongs to
+func (c *RealClient) NetworkID(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
 
-	path := filepath.Join(c.testdataDir, "entries", filename)
-	data, err := os.ReadFile(path)
-	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("DID not found: %s", dataAccountURL.String())
-	}
+	info, err := c.client.NodeInfo(ctx, api.NodeInfoOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read testdata: %w", err)
+		return "", fmt.Errorf("failed to query node info: %w", err)
 	}
-
-	return data, nil
-}
-
-// RealClient implements Client interface using JSON-RPC v3
-type RealClient struct {
-	client *jsonrpc.Client
-}
-
-// NewRealClient creates a new real client that connects to Accumulate network
-func NewRealClient(nodeURL string) *RealClient {
-	return &RealClient{
-		client: jsonrpc.NewClient(nodeURL),
+	if info == nil {
+		return "", fmt.Errorf("node info response was empty")
 	}
+	return info.Network, nil
 }
 
 // GetLatestDIDEntry returns the latest DID entry for an ADI
-func (c *RealClient) GetLatestDIDEntry(adi string) (Envelope, error) {
+func (c *RealClient) GetLatestDIDEntry(ctx context.Context, adi string) (Envelope, error) {
 	// Build the account URL for the ADI
 	accountURL, err := url.Parse(fmt.Sprintf("acc://%s", adi))
 	if err != nil {
@@ -183,7 +145,7 @@ func (c *RealClient) GetLatestDIDEntry(adi string) (Envelope, error) {
 	}
 
 	// Query the account to get the latest DID entry
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	record, err := c.client.Query(ctx, accountURL, nil)
@@ -196,7 +158,7 @@ func (c *RealClient) GetLatestDIDEntry(adi string) (Envelope, error) {
 }
 
 // GetEntryAtTime returns a DID entry at a specific time
-func (c *RealClient) GetEntryAtTime(adi string, t time.Time) (Envelope, error) {
+func (c *RealClient) GetEntryAtTime(ctx context.Context, adi string, t time.Time) (Envelope, error) {
 	// Build the account URL for the ADI
 	accountURL, err := url.Parse(fmt.Sprintf("acc://%s", adi))
 	if err != nil {
@@ -209,7 +171,7 @@ func (c *RealClient) GetEntryAtTime(adi string, t time.Time) (Envelope, error) {
 	}
 
 	// Query the account at the specific time
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	record, err := c.client.Query(ctx, accountURL, query)
@@ -222,7 +184,7 @@ func (c *RealClient) GetEntryAtTime(adi string, t time.Time) (Envelope, error) {
 }
 
 // GetKeyPageState returns the state of a Key Page
-func (c *RealClient) GetKeyPageState(keyPageURLStr string) (KeyPageState, error) {
+func (c *RealClient) GetKeyPageState(ctx context.Context, keyPageURLStr string) (KeyPageState, error) {
 	// Parse the key page URL
 	keyPageURL, err := url.Parse(keyPageURLStr)
 	if err != nil {
@@ -230,7 +192,7 @@ func (c *RealClient) GetKeyPageState(keyPageURLStr string) (KeyPageState, error)
 	}
 
 	// Query the key page state
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	// Create a querier wrapper around the client
@@ -272,8 +234,8 @@ func (c *RealClient) GetKeyPageState(keyPageURLStr string) (KeyPageState, error)
 }
 
 // GetDataAccountEntry reads latest data entry from a data account
-func (c *RealClient) GetDataAccountEntry(dataAccountURL *url.URL) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+func (c *RealClient) GetDataAccountEntry(ctx context.Context, dataAccountURL *url.URL) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	// Create a querier wrapper around the client for typed queries
@@ -327,6 +289,61 @@ func (c *RealClient) GetDataAccountEntry(dataAccountURL *url.URL) ([]byte, error
 	return nil, fmt.Errorf("unsupported transaction type in data entry for %s", dataAccountURL.String())
 }
 
+// dataEntriesPageSize is the number of entries fetched per data query
+const dataEntriesPageSize = 100
+
+// GetDataEntries reads every entry of a data account, oldest first
+func (c *RealClient) GetDataEntries(ctx context.Context, dataAccountURL *url.URL) ([]DataEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	querier := api.Querier2{Querier: c.client}
+	count := uint64(dataEntriesPageSize)
+	expand := true
+
+	var result []DataEntry
+	for start := uint64(0); ; start += count {
+		page, err := querier.QueryDataEntries(ctx, dataAccountURL, &api.DataQuery{
+			Range: &api.RangeOptions{Start: start, Count: &count, Expand: &expand},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query data entries for %s: %w", dataAccountURL.String(), err)
+		}
+		if page == nil || len(page.Records) == 0 {
+			break
+		}
+
+		for _, record := range page.Records {
+			if record == nil || record.Value == nil || record.Value.Message == nil {
+				continue
+			}
+			writeData, ok := record.Value.Message.Transaction.Body.(*protocol.WriteData)
+			if !ok || len(writeData.Entry.GetData()) == 0 {
+				continue
+			}
+
+			entry := DataEntry{
+				Data:     writeData.Entry.GetData()[0],
+				Sequence: record.Index + 1,
+				TxID:     fmt.Sprintf("%x", record.Entry),
+			}
+			if record.LastBlockTime != nil {
+				entry.Timestamp = record.LastBlockTime.UTC()
+			}
+			result = append(result, entry)
+		}
+
+		if start+uint64(len(page.Records)) >= page.Total {
+			break
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no data entries found for %s", dataAccountURL.String())
+	}
+	return result, nil
+}
+
 // recordToEnvelope converts an API record to our Envelope format
 func (c *RealClient) recordToEnvelope(record api.Record) (Envelope, error) {
 	// For now, use a simplified approach that doesn't depend on unstable API methods
@@ -0,0 +1,173 @@
+package acc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/accumulatenetwork/accumulate/pkg/url"
+
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+// fakeGenesis timestamps the entries seeded from testdata
+var fakeGenesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// FakeClient implements Client interface on top of an in-process ledger
+// simulator. Share the ledger with the registrar's FakeSubmitter to resolve
+// documents as soon as they are written.
+type FakeClient struct {
+	ledger *ledger.Ledger
+}
+
+// NewFakeClient creates a fake client whose ledger is seeded from the golden
+// files in testdataDir (see SeedLedger)
+func NewFakeClient(testdataDir string) *FakeClient {
+	l := ledger.New()
+	SeedLedger(l, testdataDir)
+	return NewFakeClientWithLedger(l)
+}
+
+// NewFakeClientWithLedger creates a fake client that reads from l
+func NewFakeClientWithLedger(l *ledger.Ledger) *FakeClient {
+	return &FakeClient{ledger: l}
+}
+
+// Ledger returns the simulator backing the client
+func (c *FakeClient) Ledger() *ledger.Ledger {
+	return c.ledger
+}
+
+// SeedLedger adds the golden files in testdataDir/entries to l:
+// did-<adi>.json becomes the first entry of acc://<adi>/did, timestamped at
+// fakeGenesis unless the ledger is already past it. ADIs that already exist
+// are skipped, so seeding a persistent ledger again is harmless. The ledger
+// clock is left at time.Now.
+func SeedLedger(l *ledger.Ledger, testdataDir string) {
+	l.WithClock(func() time.Time { return fakeGenesis })
+	defer l.WithClock(time.Now)
+
+	files, _ := filepath.Glob(filepath.Join(testdataDir, "entries", "did-*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		label := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "did-"), ".json")
+		adiURL := "acc://" + label
+		keyPageURL := adiURL + "/book/1"
+
+		if _, err := l.CreateIdentity(adiURL, keyPageURL); err != nil {
+			continue
+		}
+		if _, err := l.CreateDataAccount(adiURL+"/did", keyPageURL); err != nil {
+			continue
+		}
+		l.WriteData(adiURL+"/did", keyPageURL, data)
+	}
+}
+
+// GetLatestDIDEntry returns the latest DID entry for an ADI
+func (c *FakeClient) GetLatestDIDEntry(ctx context.Context, adi string) (Envelope, error) {
+	entry, err := c.ledger.Latest(didAccount(adi))
+	if err != nil {
+		return Envelope{}, err
+	}
+	return entryToEnvelope(entry)
+}
+
+// GetEntryAtTime returns the DID entry that was current at t
+func (c *FakeClient) GetEntryAtTime(ctx context.Context, adi string, t time.Time) (Envelope, error) {
+	entry, err := c.ledger.EntryAt(didAccount(adi), t)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return entryToEnvelope(entry)
+}
+
+// GetKeyPageState returns the state of a Key Page
+func (c *FakeClient) GetKeyPageState(ctx context.Context, url string) (KeyPageState, error) {
+	page, err := c.ledger.KeyPage(url)
+	if err != nil {
+		return KeyPageState{}, err
+	}
+
+	state := KeyPageState{
+		URL:       page.URL,
+		Threshold: page.Threshold,
+		Keys:      []Key{},
+	}
+	for _, key := range page.Keys {
+		state.Keys = append(state.Keys, Key{PublicKey: key.PublicKey, KeyType: key.KeyType})
+	}
+	return state, nil
+}
+
+// GetDataAccountEntry returns the latest entry of a data account
+func (c *FakeClient) GetDataAccountEntry(ctx context.Context, dataAccountURL *url.URL) ([]byte, error) {
+	entry, err := c.ledger.Latest(dataAccountURL.String())
+	if errors.Is(err, ledger.ErrNotFound) {
+		return nil, fmt.Errorf("DID not found: %s", dataAccountURL.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry.Data, nil
+}
+
+// GetDataEntries returns every entry of a data account, oldest first
+func (c *FakeClient) GetDataEntries(ctx context.Context, dataAccountURL *url.URL) ([]DataEntry, error) {
+	entries, err := c.ledger.Entries(dataAccountURL.String())
+	if errors.Is(err, ledger.ErrNotFound) {
+		return nil, fmt.Errorf("DID not found: %s", dataAccountURL.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DataEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = DataEntry{
+			Data:      entry.Data,
+			Sequence:  entry.Sequence,
+			Timestamp: entry.Timestamp,
+			TxID:      entry.TxID,
+		}
+	}
+	return result, nil
+}
+
+// didAccount returns the DID data account of an ADI label or URL
+func didAccount(adi string) string {
+	return ledger.Normalize(adi) + "/did"
+}
+
+// entryToEnvelope wraps a ledger entry in an envelope
+func entryToEnvelope(entry ledger.Entry) (Envelope, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(entry.Data, &doc); err != nil {
+		return Envelope{}, fmt.Errorf("entry %d is not a JSON document: %w", entry.Sequence, err)
+	}
+
+	hash := sha256.Sum256(entry.Data)
+	return Envelope{
+		ContentType: "application/did+json",
+		Document:    doc,
+		Meta: EnvelopeMeta{
+			VersionID:     fmt.Sprintf("%d-%s", entry.Timestamp.Unix(), entry.TxID[:8]),
+			Timestamp:     entry.Timestamp,
+			AuthorKeyPage: entry.Signer,
+			Proof: Proof{
+				TxID:        entry.TxID,
+				ContentHash: hex.EncodeToString(hash[:]),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,88 @@
+package acc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/accumulatenetwork/accumulate/pkg/url"
+
+	"github.com/opendlt/accu-did/shared/ledger"
+)
+
+func TestFakeClient_SeedsTestdata(t *testing.T) {
+	client := NewFakeClient("../../testdata")
+
+	u, err := url.Parse("acc://alice/did")
+	require.NoError(t, err)
+
+	data, err := client.GetDataAccountEntry(context.Background(), u)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"did:acc:alice"`)
+
+	env, err := client.GetLatestDIDEntry(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "did:acc:alice", env.Document["id"])
+	assert.Equal(t, "acc://alice/book/1", env.Meta.AuthorKeyPage)
+	assert.Len(t, env.Meta.Proof.TxID, 64)
+
+	missing, err := url.Parse("acc://nobody/did")
+	require.NoError(t, err)
+	_, err = client.GetDataAccountEntry(context.Background(), missing)
+	assert.ErrorContains(t, err, "DID not found")
+}
+
+func TestFakeClient_SharedLedger(t *testing.T) {
+	l := ledger.New()
+	client := NewFakeClientWithLedger(l)
+
+	_, err := l.CreateIdentity("acc://bob.acme", "", ledger.Key{PublicKey: "ed25519:bb", KeyType: "ed25519"})
+	require.NoError(t, err)
+	_, err = l.CreateDataAccount("acc://bob.acme/did", "acc://bob.acme/book/1")
+	require.NoError(t, err)
+	_, first, err := l.WriteData("acc://bob.acme/did", "acc://bob.acme/book/1", []byte(`{"id":"did:acc:bob.acme","v":1}`))
+	require.NoError(t, err)
+	_, _, err = l.WriteData("acc://bob.acme/did", "acc://bob.acme/book/1", []byte(`{"id":"did:acc:bob.acme","v":2}`))
+	require.NoError(t, err)
+
+	u, err := url.Parse("acc://bob.acme/did")
+	require.NoError(t, err)
+	entries, err := client.GetDataEntries(context.Background(), u)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(1), entries[0].Sequence)
+	assert.Equal(t, uint64(2), entries[1].Sequence)
+	assert.True(t, entries[1].Timestamp.After(entries[0].Timestamp))
+
+	env, err := client.GetEntryAtTime(context.Background(), "bob.acme", first.Timestamp)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), env.Document["v"])
+
+	page, err := client.GetKeyPageState(context.Background(), "acc://bob.acme/book/1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Threshold)
+	assert.Equal(t, []Key{{PublicKey: "ed25519:bb", KeyType: "ed25519"}}, page.Keys)
+}
+
+func TestSeedLedger_Persistent(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := ledger.Open(dir)
+	require.NoError(t, err)
+	SeedLedger(l, "../../testdata")
+	height := l.Height()
+	require.NotZero(t, height)
+	require.NoError(t, l.Close())
+
+	// Seeding the reopened ledger again changes nothing
+	reopened, err := ledger.Open(dir)
+	require.NoError(t, err)
+	defer reopened.Close()
+	SeedLedger(reopened, "../../testdata")
+	assert.Equal(t, height, reopened.Height())
+
+	env, err := NewFakeClientWithLedger(reopened).GetLatestDIDEntry(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "did:acc:alice", env.Document["id"])
+}
@@ -0,0 +1,321 @@
+// Package config loads the resolver configuration from defaults, an optional
+// YAML or TOML file and environment variables, and validates the result
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/opendlt/accu-did/shared/logging"
+	"github.com/opendlt/accu-did/shared/tlsutil"
+)
+
+// Config is the complete resolver configuration
+type Config struct {
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	TLS        TLSConfig        `yaml:"tls" toml:"tls"`
+	Accumulate AccumulateConfig `yaml:"accumulate" toml:"accumulate"`
+	Resolve    ResolveConfig    `yaml:"resolve" toml:"resolve"`
+	Cache      CacheConfig      `yaml:"cache" toml:"cache"`
+	CORS       CORSConfig       `yaml:"cors" toml:"cors"`
+	RateLimit  RateLimitConfig  `yaml:"rateLimit" toml:"rateLimit"`
+	Logging    LoggingConfig    `yaml:"logging" toml:"logging"`
+	Telemetry  TelemetryConfig  `yaml:"telemetry" toml:"telemetry"`
+	Readiness  ReadinessConfig  `yaml:"readiness" toml:"readiness"`
+}
+
+// ServerConfig holds listener settings and HTTP timeouts
+type ServerConfig struct {
+	Bind            string   `yaml:"bind" toml:"bind"`
+	Addr            string   `yaml:"addr" toml:"addr"`
+	ReadTimeout     Duration `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout    Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout     Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+	RequestTimeout  Duration `yaml:"requestTimeout" toml:"requestTimeout"`
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+}
+
+// ListenAddr returns the full address the server binds to
+func (s ServerConfig) ListenAddr() string {
+	return s.Bind + s.Addr
+}
+
+// TLSConfig enables HTTPS when both files are set. The files are re-read
+// when they change on disk, so certificates can be rotated without a restart.
+type TLSConfig struct {
+	CertFile string `yaml:"certFile" toml:"certFile"`
+	KeyFile  string `yaml:"keyFile" toml:"keyFile"`
+
+	// ClientCAFile is a PEM bundle client certificates must chain to (mTLS).
+	// ClientAuth is none, optional or require; it defaults to require when a
+	// bundle is set.
+	ClientCAFile string `yaml:"clientCaFile" toml:"clientCaFile"`
+	ClientAuth   string `yaml:"clientAuth" toml:"clientAuth"`
+}
+
+// Enabled reports whether TLS is configured
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// AccumulateConfig selects FAKE or REAL mode and the nodes to query
+type AccumulateConfig struct {
+	Real    bool       `yaml:"real" toml:"real"`
+	NodeURL string     `yaml:"nodeUrl" toml:"nodeUrl"`
+	Nodes   []string   `yaml:"nodes" toml:"nodes"`     // additional nodes for the pool
+	Network string     `yaml:"network" toml:"network"` // expected network ID, checked by /readyz
+	Timeout Duration   `yaml:"timeout" toml:"timeout"`
+	Pool    PoolConfig `yaml:"pool" toml:"pool"`
+	Fake    FakeConfig `yaml:"fake" toml:"fake"`
+}
+
+// NodeURLs returns NodeURL followed by Nodes, without duplicates
+func (a AccumulateConfig) NodeURLs() []string {
+	var urls []string
+	for _, u := range append([]string{a.NodeURL}, a.Nodes...) {
+		if u != "" && !slices.Contains(urls, u) {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// FakeConfig persists the simulated ledger used in FAKE mode. Point the
+// resolver and registrar at the same StateDir to resolve what was registered.
+type FakeConfig struct {
+	StateDir string `yaml:"stateDir" toml:"stateDir"` // empty keeps state in memory
+	Fixture  string `yaml:"fixture" toml:"fixture"`   // ledger export imported into an empty ledger
+}
+
+// PoolConfig tunes node health probes, circuit breaking and query retries
+type PoolConfig struct {
+	ProbeInterval    Duration `yaml:"probeInterval" toml:"probeInterval"` // 0 disables probes
+	ProbeTimeout     Duration `yaml:"probeTimeout" toml:"probeTimeout"`
+	FailureThreshold int      `yaml:"failureThreshold" toml:"failureThreshold"`
+	Cooldown         Duration `yaml:"cooldown" toml:"cooldown"`
+	Retries          int      `yaml:"retries" toml:"retries"`
+}
+
+// ResolveConfig holds resolution policy
+type ResolveConfig struct {
+	Order string `yaml:"order" toml:"order"` // sequence or timestamp
+}
+
+// CacheConfig controls the in-process resolution cache
+type CacheConfig struct {
+	Enabled    bool     `yaml:"enabled" toml:"enabled"`
+	TTL        Duration `yaml:"ttl" toml:"ttl"`
+	MaxEntries int      `yaml:"maxEntries" toml:"maxEntries"`
+
+	// MaxAge is the Cache-Control max-age of resolutions; 0 sends no-cache,
+	// so clients revalidate each time with the ETag
+	MaxAge Duration `yaml:"maxAge" toml:"maxAge"`
+}
+
+// CORSConfig holds allowed CORS origins (empty=none, *=all)
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allowOrigins" toml:"allowOrigins"`
+}
+
+// RateLimitConfig holds the per-client-IP token bucket for resolution
+// requests; an RPS of 0 turns rate limiting off
+type RateLimitConfig struct {
+	RPS   int `yaml:"rps" toml:"rps"`
+	Burst int `yaml:"burst" toml:"burst"`
+}
+
+// LoggingConfig mirrors logging.Config
+type LoggingConfig struct {
+	Level      string `yaml:"level" toml:"level"`
+	Format     string `yaml:"format" toml:"format"`
+	RedactDIDs bool   `yaml:"redactDids" toml:"redactDids"`
+	RedactIPs  bool   `yaml:"redactIps" toml:"redactIps"`
+}
+
+// TelemetryConfig holds tracing and metrics settings
+type TelemetryConfig struct {
+	OTLPEndpoint     string  `yaml:"otlpEndpoint" toml:"otlpEndpoint"`
+	OTLPInsecure     bool    `yaml:"otlpInsecure" toml:"otlpInsecure"`
+	TraceSampleRatio float64 `yaml:"traceSampleRatio" toml:"traceSampleRatio"`
+	Metrics          bool    `yaml:"metrics" toml:"metrics"`
+}
+
+// ReadinessConfig tunes the /readyz checks
+type ReadinessConfig struct {
+	Timeout Duration `yaml:"timeout" toml:"timeout"` // per check
+}
+
+// Default returns the built-in configuration, matching the historical flag defaults
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Bind:            "127.0.0.1",
+			Addr:            ":8080",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(15 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			RequestTimeout:  Duration(30 * time.Second),
+			ShutdownTimeout: Duration(30 * time.Second),
+		},
+		Accumulate: AccumulateConfig{
+			Timeout: Duration(15 * time.Second),
+			Pool: PoolConfig{
+				ProbeInterval:    Duration(10 * time.Second),
+				ProbeTimeout:     Duration(5 * time.Second),
+				FailureThreshold: 3,
+				Cooldown:         Duration(30 * time.Second),
+				Retries:          2,
+			},
+		},
+		Resolve: ResolveConfig{
+			Order: "sequence",
+		},
+		Cache: CacheConfig{
+			TTL:        Duration(30 * time.Second),
+			MaxEntries: 10000,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Telemetry: TelemetryConfig{
+			TraceSampleRatio: 1.0,
+			Metrics:          true,
+		},
+		Readiness: ReadinessConfig{
+			Timeout: Duration(5 * time.Second),
+		},
+	}
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		add("server.addr is required")
+	}
+	for _, t := range []struct {
+		name string
+		d    Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.requestTimeout", c.Server.RequestTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"accumulate.timeout", c.Accumulate.Timeout},
+		{"readiness.timeout", c.Readiness.Timeout},
+	} {
+		if t.d <= 0 {
+			add("%s must be positive", t.name)
+		}
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		add("tls.certFile and tls.keyFile must be set together")
+	}
+	if (c.TLS.ClientCAFile != "" || c.TLS.ClientAuth != "") && !c.TLS.Enabled() {
+		add("tls.clientCaFile and tls.clientAuth require tls.certFile and tls.keyFile")
+	}
+	if _, err := tlsutil.ParseClientAuth(c.TLS.ClientAuth, c.TLS.ClientCAFile != ""); err != nil {
+		add("tls.clientAuth: %v", err)
+	}
+
+	if c.Accumulate.Real {
+		urls := c.Accumulate.NodeURLs()
+		if len(urls) == 0 {
+			add("accumulate.nodeUrl (ACC_NODE_URL) or accumulate.nodes is required in REAL mode")
+		}
+		for _, nodeURL := range urls {
+			if u, err := url.Parse(nodeURL); err != nil || u.Scheme == "" || u.Host == "" {
+				add("accumulate node URL %q is not a valid URL", nodeURL)
+			}
+		}
+	}
+	pool := c.Accumulate.Pool
+	if pool.ProbeInterval < 0 {
+		add("accumulate.pool.probeInterval must not be negative")
+	}
+	if pool.ProbeTimeout <= 0 {
+		add("accumulate.pool.probeTimeout must be positive")
+	}
+	if pool.FailureThreshold < 1 {
+		add("accumulate.pool.failureThreshold must be at least 1")
+	}
+	if pool.Cooldown <= 0 {
+		add("accumulate.pool.cooldown must be positive")
+	}
+	if pool.Retries < 0 {
+		add("accumulate.pool.retries must not be negative")
+	}
+
+	if c.Resolve.Order != "sequence" && c.Resolve.Order != "timestamp" {
+		add("resolve.order must be 'sequence' or 'timestamp', got %q", c.Resolve.Order)
+	}
+
+	if c.Cache.MaxAge < 0 {
+		add("cache.maxAge must not be negative")
+	}
+	if c.Cache.Enabled {
+		if c.Cache.TTL <= 0 {
+			add("cache.ttl must be positive when the cache is enabled")
+		}
+		if c.Cache.MaxEntries <= 0 {
+			add("cache.maxEntries must be positive when the cache is enabled")
+		}
+	}
+
+	if c.RateLimit.RPS < 0 {
+		add("rateLimit.rps must not be negative")
+	}
+	if c.RateLimit.RPS > 0 && c.RateLimit.Burst <= 0 {
+		add("rateLimit.burst must be positive when rateLimit.rps is set")
+	}
+
+	if _, err := logging.ParseLevel(c.Logging.Level); err != nil {
+		add("logging.level: %v", err)
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		add("logging.format must be 'json' or 'text', got %q", c.Logging.Format)
+	}
+
+	if c.Telemetry.TraceSampleRatio < 0 || c.Telemetry.TraceSampleRatio > 1 {
+		add("telemetry.traceSampleRatio must be between 0 and 1")
+	}
+
+	return errors.Join(errs...)
+}
+
+// RestartRequired lists the settings that differ between c and next but
+// cannot be applied by a reload. Log level, CORS origins, rate limit, cache
+// TTL and max-age are reloadable; everything else needs a restart.
+func (c *Config) RestartRequired(next *Config) []string {
+	var changed []string
+	check := func(name string, same bool) {
+		if !same {
+			changed = append(changed, name)
+		}
+	}
+
+	check("server", c.Server == next.Server)
+	check("tls", c.TLS == next.TLS)
+	check("accumulate", reflect.DeepEqual(c.Accumulate, next.Accumulate))
+	check("resolve", c.Resolve == next.Resolve)
+	check("cache.enabled", c.Cache.Enabled == next.Cache.Enabled)
+	check("cache.maxEntries", c.Cache.MaxEntries == next.Cache.MaxEntries)
+	check("logging.format", c.Logging.Format == next.Logging.Format)
+	check("logging.redactDids", c.Logging.RedactDIDs == next.Logging.RedactDIDs)
+	check("logging.redactIps", c.Logging.RedactIPs == next.Logging.RedactIPs)
+	check("telemetry", c.Telemetry == next.Telemetry)
+	check("readiness", c.Readiness == next.Readiness)
+
+	return changed
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestDefault_IsValid(t *testing.T) {
+	require.NoError(t, Default().Validate())
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		path := writeFile(t, "resolver.yaml", `
+server:
+  addr: ":9090"
+  readTimeout: 5s
+cache:
+  enabled: true
+  ttl: 1m
+cors:
+  allowOrigins: ["https://example.com"]
+`)
+		cfg := Default()
+		require.NoError(t, cfg.loadFile(path))
+
+		assert.Equal(t, ":9090", cfg.Server.Addr)
+		assert.Equal(t, 5*time.Second, cfg.Server.ReadTimeout.Std())
+		assert.Equal(t, 15*time.Second, cfg.Server.WriteTimeout.Std(), "unset keys keep their defaults")
+		assert.True(t, cfg.Cache.Enabled)
+		assert.Equal(t, time.Minute, cfg.Cache.TTL.Std())
+		assert.Equal(t, []string{"https://example.com"}, cfg.CORS.AllowOrigins)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		path := writeFile(t, "resolver.toml", `
+[accumulate]
+real = true
+nodeUrl = "http://localhost:26660/v3"
+timeout = "3s"
+`)
+		cfg := Default()
+		require.NoError(t, cfg.loadFile(path))
+
+		assert.True(t, cfg.Accumulate.Real)
+		assert.Equal(t, "http://localhost:26660/v3", cfg.Accumulate.NodeURL)
+		assert.Equal(t, 3*time.Second, cfg.Accumulate.Timeout.Std())
+	})
+
+	t.Run("unknown yaml key", func(t *testing.T) {
+		path := writeFile(t, "resolver.yaml", "server:\n  adr: \":9090\"\n")
+		assert.Error(t, Default().loadFile(path))
+	})
+
+	t.Run("unknown toml key", func(t *testing.T) {
+		path := writeFile(t, "resolver.toml", "[server]\nadr = \":9090\"\n")
+		assert.Error(t, Default().loadFile(path))
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		path := writeFile(t, "resolver.json", "{}")
+		assert.Error(t, Default().loadFile(path))
+	})
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"RESOLVER_ADDR":               ":7070",
+		"ACC_NODE_URL":                "http://node:26660/v3",
+		"ACC_NODE_URLS":               "http://node2:26660/v3, http://node:26660/v3",
+		"RESOLVER_CACHE_TTL":          "90s",
+		"RESOLVER_CACHE_MAX_AGE":      "15s",
+		"RESOLVER_CORS_ALLOW_ORIGINS": "https://a.example, https://b.example",
+		"RESOLVER_TRACE_SAMPLE_RATIO": "0.25",
+		"RESOLVER_RATE_RPS":           "20",
+		"RESOLVER_RATE_BURST":         "40",
+		"LOG_LEVEL":                   "debug",
+		"RESOLVER_FAKE_STATE_DIR":     "/var/lib/accu-did",
+	}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+
+	cfg := Default()
+	require.NoError(t, cfg.ApplyEnv(lookup))
+
+	assert.Equal(t, ":7070", cfg.Server.Addr)
+	assert.Equal(t, "http://node:26660/v3", cfg.Accumulate.NodeURL)
+	assert.Equal(t, []string{"http://node:26660/v3", "http://node2:26660/v3"}, cfg.Accumulate.NodeURLs())
+	assert.Equal(t, 90*time.Second, cfg.Cache.TTL.Std())
+	assert.Equal(t, 15*time.Second, cfg.Cache.MaxAge.Std())
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORS.AllowOrigins)
+	assert.Equal(t, 0.25, cfg.Telemetry.TraceSampleRatio)
+	assert.Equal(t, RateLimitConfig{RPS: 20, Burst: 40}, cfg.RateLimit)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, "/var/lib/accu-did", cfg.Accumulate.Fake.StateDir)
+
+	env = map[string]string{"RESOLVER_CACHE_ENABLED": "maybe"}
+	err := Default().ApplyEnv(lookup)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RESOLVER_CACHE_ENABLED")
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Server.ReadTimeout = 0
+	cfg.TLS.CertFile = "cert.pem"
+	cfg.TLS.ClientAuth = "require"
+	cfg.Accumulate.Real = true
+	cfg.Resolve.Order = "random"
+	cfg.Cache.MaxAge = Duration(-time.Second)
+	cfg.RateLimit.RPS = 10
+	cfg.Logging.Level = "loud"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		"server.readTimeout",
+		"tls.certFile",
+		"tls.clientAuth",
+		"accumulate.nodeUrl",
+		"resolve.order",
+		"cache.maxAge",
+		"rateLimit.burst",
+		"logging.level",
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	cur := Default()
+
+	next := Default()
+	next.Logging.Level = "debug"
+	next.CORS.AllowOrigins = []string{"*"}
+	next.Cache.TTL = Duration(time.Hour)
+	next.Cache.MaxAge = Duration(time.Minute)
+	next.RateLimit = RateLimitConfig{RPS: 5, Burst: 10}
+	assert.Empty(t, cur.RestartRequired(next), "reloadable settings do not need a restart")
+
+	next.Server.Addr = ":9999"
+	next.Accumulate.NodeURL = "http://other:26660/v3"
+	assert.Equal(t, []string{"server", "accumulate"}, cur.RestartRequired(next))
+}
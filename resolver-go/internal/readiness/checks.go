@@ -0,0 +1,26 @@
+// Package readiness holds the resolver's own /readyz checks; the checker
+// and the node and network checks are in shared/health
+package readiness
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/opendlt/accu-did/shared/health"
+)
+
+// Sizer is implemented by resolve.Cache
+type Sizer interface {
+	Len() int
+}
+
+// CacheCheck reports the resolution cache. The in-process cache cannot fail,
+// so the check is non-critical and only surfaces its size.
+func CacheCheck(cache Sizer) health.Check {
+	return health.Check{
+		Name: "cache",
+		Run: func(ctx context.Context) (string, error) {
+			return fmt.Sprintf("%d entries", cache.Len()), nil
+		},
+	}
+}
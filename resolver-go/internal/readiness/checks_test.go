@@ -0,0 +1,22 @@
+package readiness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sizer int
+
+func (s sizer) Len() int { return int(s) }
+
+func TestCacheCheck(t *testing.T) {
+	check := CacheCheck(sizer(3))
+	assert.False(t, check.Critical)
+
+	msg, err := check.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "3 entries", msg)
+}
@@ -0,0 +1,104 @@
+package resolve
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a bounded, TTL-based cache of resolution results keyed by DID and
+// versionTime. Only successful resolutions (including deactivated DIDs) are
+// cached; errors always go back to the ledger.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // front = most recently used
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	result  *DIDResolutionResult
+	expires time.Time
+}
+
+// NewCache creates a cache holding at most maxEntries results for ttl each
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// cacheKey builds the lookup key for a DID and optional versionTime
+func cacheKey(did string, versionTime *time.Time) string {
+	if versionTime == nil {
+		return did
+	}
+	return did + "@" + versionTime.UTC().Format(time.RFC3339Nano)
+}
+
+// Get returns the cached result, if present and not expired
+func (c *Cache) Get(did string, versionTime *time.Time) (*DIDResolutionResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[cacheKey(did, versionTime)]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// Put stores a result, evicting the least recently used entry when full
+func (c *Cache) Put(did string, versionTime *time.Time, result *DIDResolutionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(did, versionTime)
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{
+		key:     key,
+		result:  result,
+		expires: c.now().Add(c.ttl),
+	})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// SetTTL changes the TTL applied to entries stored from now on
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// Len returns the number of cached entries
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *Cache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
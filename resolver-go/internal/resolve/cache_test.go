@@ -0,0 +1,40 @@
+package resolve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_TTLAndEviction(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	alice := &DIDResolutionResult{}
+	cache.Put("did:acc:alice", nil, alice)
+
+	got, ok := cache.Get("did:acc:alice", nil)
+	require.True(t, ok)
+	assert.Same(t, alice, got)
+
+	// versionTime is part of the key
+	vt := now.Add(-time.Hour)
+	_, ok = cache.Get("did:acc:alice", &vt)
+	assert.False(t, ok)
+
+	// Least recently used entry is evicted when full
+	cache.Put("did:acc:bob", nil, &DIDResolutionResult{})
+	cache.Get("did:acc:alice", nil)
+	cache.Put("did:acc:carol", nil, &DIDResolutionResult{})
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("did:acc:bob", nil)
+	assert.False(t, ok)
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get("did:acc:alice", nil)
+	assert.False(t, ok)
+}
@@ -1,20 +1,28 @@
 package resolve
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"sort"
 	"time"
 
 	"gitlab.com/accumulatenetwork/accumulate/pkg/url"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 
 	"github.com/opendlt/accu-did/resolver-go/internal/acc"
+	"github.com/opendlt/accu-did/resolver-go/internal/telemetry"
 	"github.com/opendlt/accu-did/shared/did"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
+var tracer = otel.Tracer(telemetry.TracerName + "/resolve")
+
 // ResolveOrder defines the ordering strategy for selecting the latest DID document
 type ResolveOrder string
 
@@ -99,30 +107,63 @@ func NewDeterministicResolver(client acc.Client, order ResolveOrder) *Determinis
 
 // ResolveDID resolves a DID according to the deterministic algorithm
 func (r *DeterministicResolver) ResolveDID(didStr string, versionTime *time.Time) (*DIDResolutionResult, error) {
+	return r.ResolveDIDContext(context.Background(), didStr, versionTime)
+}
+
+// ResolveDIDContext resolves a DID according to the deterministic algorithm.
+// Each step is recorded as a child span of the span carried by ctx.
+func (r *DeterministicResolver) ResolveDIDContext(ctx context.Context, didStr string, versionTime *time.Time) (result *DIDResolutionResult, err error) {
 	start := time.Now()
 
+	ctx, span := tracer.Start(ctx, "resolve.ResolveDID")
+	span.SetAttributes(
+		attribute.String("did", didStr),
+		attribute.String("resolve.order", string(r.order)),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	// Step 1: Parse DID into Accumulate URLs
+	_, stepSpan := tracer.Start(ctx, "resolve.parse")
 	_, dataAccountURL, err := did.ParseDID(didStr)
+	stepSpan.End()
 	if err != nil {
 		return nil, &InvalidDIDError{DID: didStr, Reason: err.Error()}
 	}
 
 	// Step 2: Get all data entries from the data account
-	entries, err := r.getAllDataEntries(dataAccountURL, versionTime)
+	fetchCtx, stepSpan := tracer.Start(ctx, "resolve.fetchEntries")
+	stepSpan.SetAttributes(attribute.String("accumulate.account", dataAccountURL.String()))
+	entries, err := r.getAllDataEntries(fetchCtx, dataAccountURL, versionTime)
 	if err != nil {
+		stepSpan.RecordError(err)
+		stepSpan.End()
 		return nil, &NotFoundError{DID: didStr}
 	}
+	stepSpan.SetAttributes(attribute.Int("resolve.entries", len(entries)))
+	stepSpan.End()
 
 	if len(entries) == 0 {
 		return nil, &NotFoundError{DID: didStr}
 	}
 
 	// Step 3: Apply deterministic selection algorithm
-	selectedEntry, validEntries := r.selectLatestEntry(entries, didStr)
+	_, stepSpan = tracer.Start(ctx, "resolve.select")
+	selectedEntry, validEntries := r.selectLatestEntry(ctx, entries, didStr)
+	stepSpan.SetAttributes(attribute.Int("resolve.valid_entries", validEntries))
+	stepSpan.End()
 	if selectedEntry == nil {
 		return nil, &NotFoundError{DID: didStr}
 	}
 
+	_, stepSpan = tracer.Start(ctx, "resolve.buildResult")
+	defer stepSpan.End()
+
 	// Step 4: Parse the selected DID document
 	var didDoc map[string]interface{}
 	if err := json.Unmarshal(selectedEntry.Data, &didDoc); err != nil {
@@ -133,7 +174,7 @@ func (r *DeterministicResolver) ResolveDID(didStr string, versionTime *time.Time
 	// Step 5: Check if deactivated
 	if deactivated, exists := didDoc["deactivated"].(bool); exists && deactivated {
 		// Return deactivated DID with minimal document
-		return r.buildDeactivatedResult(didStr, selectedEntry, start), nil
+		return r.buildDeactivatedResult(ctx, didStr, selectedEntry, start), nil
 	}
 
 	// Step 6: Extract version ID if present
@@ -143,7 +184,7 @@ func (r *DeterministicResolver) ResolveDID(didStr string, versionTime *time.Time
 	}
 
 	// Step 7: Build successful resolution result
-	result := &DIDResolutionResult{
+	result = &DIDResolutionResult{
 		DIDDocument: didDoc,
 		DIDDocumentMetadata: DIDDocumentMetadata{
 			Updated:      selectedEntry.Timestamp,
@@ -162,15 +203,19 @@ func (r *DeterministicResolver) ResolveDID(didStr string, versionTime *time.Time
 	}
 
 	// Log resolution details
-	log.Printf("DID resolved: did=%s sequence=%v timestamp=%s hash=%s deactivated=false valid_entries=%d",
-		didStr, selectedEntry.Sequence, selectedEntry.Timestamp.Format(time.RFC3339),
-		selectedEntry.ContentHash[:8], validEntries)
+	slog.InfoContext(ctx, "DID resolved",
+		logging.DID(didStr),
+		"sequence", sequenceAttr(selectedEntry.Sequence),
+		"timestamp", selectedEntry.Timestamp.Format(time.RFC3339),
+		"hash", selectedEntry.ContentHash,
+		"deactivated", false,
+		"valid_entries", validEntries)
 
 	return result, nil
 }
 
 // buildDeactivatedResult builds a 410 Gone response for deactivated DIDs
-func (r *DeterministicResolver) buildDeactivatedResult(didStr string, selectedEntry *DataEntry, start time.Time) *DIDResolutionResult {
+func (r *DeterministicResolver) buildDeactivatedResult(ctx context.Context, didStr string, selectedEntry *DataEntry, start time.Time) *DIDResolutionResult {
 	// Parse to extract deactivatedAt if present
 	var didDoc map[string]interface{}
 	json.Unmarshal(selectedEntry.Data, &didDoc)
@@ -205,18 +250,45 @@ func (r *DeterministicResolver) buildDeactivatedResult(didStr string, selectedEn
 	}
 
 	// Log deactivation
-	log.Printf("DID resolved: did=%s sequence=%v timestamp=%s hash=%s deactivated=true",
-		didStr, selectedEntry.Sequence, selectedEntry.Timestamp.Format(time.RFC3339),
-		selectedEntry.ContentHash[:8])
+	slog.InfoContext(ctx, "DID resolved",
+		logging.DID(didStr),
+		"sequence", sequenceAttr(selectedEntry.Sequence),
+		"timestamp", selectedEntry.Timestamp.Format(time.RFC3339),
+		"hash", selectedEntry.ContentHash,
+		"deactivated", true)
 
 	return result
 }
 
-// getAllDataEntries retrieves all data entries from the data account
-func (r *DeterministicResolver) getAllDataEntries(dataAccountURL *url.URL, versionTime *time.Time) ([]*DataEntry, error) {
-	// For now, use the simplified client interface
-	// In a full implementation, this would query all entries with pagination
-	data, err := r.client.GetDataAccountEntry(dataAccountURL)
+// getAllDataEntries retrieves all data entries from the data account. Clients
+// that implement acc.EntryLister return the full history with sequence and
+// timestamp; entries written after versionTime are dropped. Other clients
+// only expose the latest entry.
+func (r *DeterministicResolver) getAllDataEntries(ctx context.Context, dataAccountURL *url.URL, versionTime *time.Time) ([]*DataEntry, error) {
+	if lister, ok := r.client.(acc.EntryLister); ok {
+		history, err := lister.GetDataEntries(ctx, dataAccountURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var entries []*DataEntry
+		for _, e := range history {
+			if versionTime != nil && !e.Timestamp.IsZero() && e.Timestamp.After(*versionTime) {
+				continue
+			}
+			sequence := e.Sequence
+			hash := sha256.Sum256(e.Data)
+			entries = append(entries, &DataEntry{
+				Data:        e.Data,
+				Timestamp:   e.Timestamp,
+				Sequence:    &sequence,
+				ContentHash: hex.EncodeToString(hash[:]),
+			})
+		}
+		return entries, nil
+	}
+
+	data, err := r.client.GetDataAccountEntry(ctx, dataAccountURL)
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +297,7 @@ func (r *DeterministicResolver) getAllDataEntries(dataAccountURL *url.URL, versi
 	hash := sha256.Sum256(data)
 	contentHash := hex.EncodeToString(hash[:])
 
-	// Create a single entry (simplified for current client interface)
+	// Create a single entry (the client only exposes the latest entry)
 	entry := &DataEntry{
 		Data:        data,
 		Timestamp:   time.Now().UTC(), // Would be extracted from transaction metadata
@@ -236,15 +308,23 @@ func (r *DeterministicResolver) getAllDataEntries(dataAccountURL *url.URL, versi
 	return []*DataEntry{entry}, nil
 }
 
+// sequenceAttr logs a sequence by value rather than as a pointer
+func sequenceAttr(sequence *uint64) any {
+	if sequence == nil {
+		return nil
+	}
+	return *sequence
+}
+
 // selectLatestEntry applies the deterministic selection algorithm
-func (r *DeterministicResolver) selectLatestEntry(entries []*DataEntry, didStr string) (*DataEntry, int) {
+func (r *DeterministicResolver) selectLatestEntry(ctx context.Context, entries []*DataEntry, didStr string) (*DataEntry, int) {
 	var validEntries []*DataEntry
 
 	// Filter out malformed JSON entries
 	for _, entry := range entries {
 		var doc map[string]interface{}
 		if err := json.Unmarshal(entry.Data, &doc); err != nil {
-			log.Printf("WARN: Skipping malformed JSON entry for DID %s: %v", didStr, err)
+			slog.WarnContext(ctx, "Skipping malformed JSON entry", logging.DID(didStr), "error", err)
 			continue
 		}
 		validEntries = append(validEntries, entry)
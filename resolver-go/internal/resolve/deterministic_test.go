@@ -1,6 +1,7 @@
 package resolve
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -124,22 +125,22 @@ func (m *DeterministicMockClient) GetDataEntries(adi string) ([]*DataEntry, erro
 }
 
 // Implement acc.Client exactly:
-func (m *DeterministicMockClient) GetLatestDIDEntry(adi string) (acc.Envelope, error) {
+func (m *DeterministicMockClient) GetLatestDIDEntry(ctx context.Context, adi string) (acc.Envelope, error) {
 	// Not used by these tests; return zero-value envelope.
 	return acc.Envelope{}, nil
 }
 
-func (m *DeterministicMockClient) GetEntryAtTime(adi string, t time.Time) (acc.Envelope, error) {
+func (m *DeterministicMockClient) GetEntryAtTime(ctx context.Context, adi string, t time.Time) (acc.Envelope, error) {
 	// Not used by these tests; return zero-value envelope.
 	return acc.Envelope{}, nil
 }
 
-func (m *DeterministicMockClient) GetKeyPageState(u string) (acc.KeyPageState, error) {
+func (m *DeterministicMockClient) GetKeyPageState(ctx context.Context, u string) (acc.KeyPageState, error) {
 	// Minimal stub for tests
 	return acc.KeyPageState{URL: u, Threshold: 1}, nil
 }
 
-func (m *DeterministicMockClient) GetDataAccountEntry(dataAccountURL *accurl.URL) ([]byte, error) {
+func (m *DeterministicMockClient) GetDataAccountEntry(ctx context.Context, dataAccountURL *accurl.URL) ([]byte, error) {
 	if len(m.entries) > 0 {
 		return m.entries[len(m.entries)-1].Data, nil
 	}
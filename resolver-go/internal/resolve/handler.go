@@ -1,19 +1,30 @@
 package resolve
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/opendlt/accu-did/resolver-go/internal/acc"
+	"github.com/opendlt/accu-did/resolver-go/internal/telemetry"
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // Handler handles DID resolution requests
 type Handler struct {
 	resolver *DeterministicResolver
+	metrics  *telemetry.Metrics
+	cache    *Cache
+	maxAge   atomic.Int64 // Cache-Control max-age in seconds
 }
 
 // NewHandler creates a new resolve handler
@@ -30,6 +41,29 @@ func NewHandlerWithOrder(accClient acc.Client, order ResolveOrder) *Handler {
 	}
 }
 
+// WithMetrics records resolution latency and outcomes in m
+func (h *Handler) WithMetrics(m *telemetry.Metrics) *Handler {
+	h.metrics = m
+	return h
+}
+
+// WithCache serves repeated resolutions from c
+func (h *Handler) WithCache(c *Cache) *Handler {
+	h.cache = c
+	return h
+}
+
+// WithMaxAge lets clients reuse a resolution for d before revalidating it
+func (h *Handler) WithMaxAge(d time.Duration) *Handler {
+	h.SetMaxAge(d)
+	return h
+}
+
+// SetMaxAge replaces the max-age of responses, e.g. on configuration reload
+func (h *Handler) SetMaxAge(d time.Duration) {
+	h.maxAge.Store(int64(d / time.Second))
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error     string            `json:"error"`
@@ -44,7 +78,7 @@ func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
 	// Extract DID from query parameter
 	did := r.URL.Query().Get("did")
 	if did == "" {
-		h.writeError(w, "invalidDid", "DID parameter is required", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", "DID parameter is required", http.StatusBadRequest, nil)
 		return
 	}
 
@@ -55,7 +89,7 @@ func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			// Try Unix timestamp
 			if parsed, err = time.Parse("1704067200", vt); err != nil {
-				h.writeError(w, "invalidVersionTime", "Invalid versionTime format", http.StatusBadRequest, map[string]string{
+				h.writeError(w, r, "invalidVersionTime", "Invalid versionTime format", http.StatusBadRequest, map[string]string{
 					"versionTime": vt,
 					"expected":    "ISO 8601 or Unix timestamp",
 				})
@@ -66,17 +100,17 @@ func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Resolve DID using deterministic resolver
-	result, err := h.resolver.ResolveDID(did, versionTime)
+	result, err := h.resolve(r.Context(), did, versionTime)
 	if err != nil {
 		switch err.(type) {
 		case *NotFoundError:
-			h.writeError(w, "notFound", err.Error(), http.StatusNotFound, nil)
+			h.writeError(w, r, "notFound", err.Error(), http.StatusNotFound, nil)
 		case *InvalidDIDError:
-			h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+			h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		case *DeactivatedError:
-			h.writeError(w, "deactivated", err.Error(), http.StatusGone, nil)
+			h.writeError(w, r, "deactivated", err.Error(), http.StatusGone, nil)
 		default:
-			h.writeError(w, "internalError", "Internal server error", http.StatusInternalServerError, nil)
+			h.writeError(w, r, "internalError", "Internal server error", http.StatusInternalServerError, nil)
 		}
 		return
 	}
@@ -86,19 +120,13 @@ func (h *Handler) Resolve(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/did+ld+json")
 		w.WriteHeader(http.StatusGone)
 		if err := json.NewEncoder(w).Encode(result); err != nil {
-			h.writeError(w, "internalError", "Failed to encode deactivated response", http.StatusInternalServerError, nil)
+			h.writeError(w, r, "internalError", "Failed to encode deactivated response", http.StatusInternalServerError, nil)
 		}
 		return
 	}
 
 	// Return successful resolution
-	w.Header().Set("Content-Type", "application/did+ld+json")
-	w.WriteHeader(http.StatusOK)
-
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.writeError(w, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
-		return
-	}
+	h.writeResult(w, r, result)
 }
 
 // UniversalResolve handles Universal Resolver v1.0 style requests
@@ -107,7 +135,7 @@ func (h *Handler) UniversalResolve(w http.ResponseWriter, r *http.Request) {
 	// Extract DID from URL path
 	did := chi.URLParam(r, "did")
 	if did == "" {
-		h.writeError(w, "invalidDid", "DID parameter is required", http.StatusBadRequest, nil)
+		h.writeError(w, r, "invalidDid", "DID parameter is required", http.StatusBadRequest, nil)
 		return
 	}
 
@@ -121,7 +149,7 @@ func (h *Handler) UniversalResolve(w http.ResponseWriter, r *http.Request) {
 	if vt := r.URL.Query().Get("versionTime"); vt != "" {
 		parsed, err := time.Parse(time.RFC3339, vt)
 		if err != nil {
-			h.writeError(w, "invalidVersionTime", "Invalid versionTime format", http.StatusBadRequest, map[string]string{
+			h.writeError(w, r, "invalidVersionTime", "Invalid versionTime format", http.StatusBadRequest, map[string]string{
 				"versionTime": vt,
 				"expected":    "ISO 8601",
 			})
@@ -131,17 +159,17 @@ func (h *Handler) UniversalResolve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Resolve DID using deterministic resolver
-	result, err := h.resolver.ResolveDID(did, versionTime)
+	result, err := h.resolve(r.Context(), did, versionTime)
 	if err != nil {
 		switch err.(type) {
 		case *NotFoundError:
-			h.writeError(w, "notFound", err.Error(), http.StatusNotFound, nil)
+			h.writeError(w, r, "notFound", err.Error(), http.StatusNotFound, nil)
 		case *InvalidDIDError:
-			h.writeError(w, "invalidDid", err.Error(), http.StatusBadRequest, nil)
+			h.writeError(w, r, "invalidDid", err.Error(), http.StatusBadRequest, nil)
 		case *DeactivatedError:
-			h.writeError(w, "deactivated", err.Error(), http.StatusGone, nil)
+			h.writeError(w, r, "deactivated", err.Error(), http.StatusGone, nil)
 		default:
-			h.writeError(w, "internalError", "Internal server error", http.StatusInternalServerError, nil)
+			h.writeError(w, r, "internalError", "Internal server error", http.StatusInternalServerError, nil)
 		}
 		return
 	}
@@ -151,26 +179,115 @@ func (h *Handler) UniversalResolve(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/did+ld+json")
 		w.WriteHeader(http.StatusGone)
 		if err := json.NewEncoder(w).Encode(result); err != nil {
-			h.writeError(w, "internalError", "Failed to encode deactivated response", http.StatusInternalServerError, nil)
+			h.writeError(w, r, "internalError", "Failed to encode deactivated response", http.StatusInternalServerError, nil)
 		}
 		return
 	}
 
 	// Return successful resolution in Universal Resolver format
+	h.writeResult(w, r, result)
+}
+
+// writeResult writes a resolution with its ETag and Cache-Control, or 304
+// Not Modified when If-None-Match names the same version
+func (h *Handler) writeResult(w http.ResponseWriter, r *http.Request, result *DIDResolutionResult) {
+	tag := entityTag(result)
+	w.Header().Set("ETag", tag)
+	if maxAge := h.maxAge.Load(); maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/did+ld+json")
 	w.WriteHeader(http.StatusOK)
 
 	if err := json.NewEncoder(w).Encode(result); err != nil {
-		h.writeError(w, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
+		h.writeError(w, r, "internalError", "Failed to encode response", http.StatusInternalServerError, nil)
 		return
 	}
 }
 
-func (h *Handler) writeError(w http.ResponseWriter, errorCode, message string, status int, details map[string]string) {
+// entityTag identifies the document version of result. The document metadata
+// covers the content hash as well as the version, so rewriting the same
+// document gets a new tag. The tag is weak because resolution metadata such
+// as the retrieval time differs between responses.
+func entityTag(result *DIDResolutionResult) string {
+	encoded, _ := json.Marshal(result.DIDDocumentMetadata)
+	hash := sha256.Sum256(encoded)
+	return `W/"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison of If-None-Match (RFC 9110
+// section 13.1.2) to the header value and tag
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	opaque := strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve runs the deterministic resolver and records the outcome
+func (h *Handler) resolve(ctx context.Context, did string, versionTime *time.Time) (*DIDResolutionResult, error) {
+	if h.cache != nil {
+		if cached, ok := h.cache.Get(did, versionTime); ok {
+			h.metrics.ObserveCache("resolution", true)
+			return cached, nil
+		}
+		h.metrics.ObserveCache("resolution", false)
+	}
+
+	start := time.Now()
+	result, err := h.resolver.ResolveDIDContext(ctx, did, versionTime)
+	if err == nil && h.cache != nil {
+		h.cache.Put(did, versionTime, result)
+	}
+
+	outcome := telemetry.OutcomeSuccess
+	switch err.(type) {
+	case nil:
+		if result.DIDDocumentMetadata.Deactivated {
+			outcome = telemetry.OutcomeDeactivated
+		}
+	case *NotFoundError:
+		outcome = telemetry.OutcomeNotFound
+	case *InvalidDIDError:
+		outcome = telemetry.OutcomeInvalidDID
+	case *DeactivatedError:
+		outcome = telemetry.OutcomeDeactivated
+	default:
+		outcome = telemetry.OutcomeError
+	}
+	h.metrics.ObserveResolution(outcome, time.Since(start))
+
+	if outcome == telemetry.OutcomeError {
+		slog.ErrorContext(ctx, "DID resolution failed", logging.DID(did), "error", err)
+	} else {
+		slog.DebugContext(ctx, "DID resolution finished", logging.DID(did), "outcome", outcome,
+			"duration", time.Since(start))
+	}
+
+	return result, err
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, errorCode, message string, status int, details map[string]string) {
 	response := ErrorResponse{
 		Error:     errorCode,
 		Message:   message,
 		Details:   details,
+		RequestID: logging.RequestIDFromContext(r.Context()),
 		Timestamp: time.Now().UTC(),
 	}
 
@@ -0,0 +1,48 @@
+package resolve
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/resolver-go/internal/acc"
+)
+
+func TestHandler_ConditionalRequests(t *testing.T) {
+	handler := NewHandler(acc.NewFakeClient("../../testdata"))
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/resolve?did=did:acc:alice", nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.Resolve(rec, req)
+		return rec
+	}
+
+	first := get(nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	tag := first.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, tag)
+	assert.Equal(t, "no-cache", first.Header().Get("Cache-Control"))
+
+	// The same version keeps its tag, though resolution metadata changes
+	assert.Equal(t, tag, get(nil).Header().Get("ETag"))
+
+	notModified := get(http.Header{"If-None-Match": {`"other", ` + tag}})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.Bytes())
+	assert.Equal(t, tag, notModified.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNotModified, get(http.Header{"If-None-Match": {tag[2:]}}).Code, "weak comparison")
+	assert.Equal(t, http.StatusOK, get(http.Header{"If-None-Match": {`W/"stale"`}}).Code)
+
+	handler.SetMaxAge(90 * time.Second)
+	assert.Equal(t, "max-age=90", get(nil).Header().Get("Cache-Control"))
+
+}
@@ -1,6 +1,7 @@
 package resolve
 
 import (
+	"context"
 	"time"
 
 	"github.com/opendlt/accu-did/resolver-go/internal/acc"
@@ -33,7 +34,7 @@ type MockClient struct {
 
 var _ acc.Client = (*MockClient)(nil)
 
-func (m *MockClient) GetLatestDIDEntry(adi string) (acc.Envelope, error) {
+func (m *MockClient) GetLatestDIDEntry(ctx context.Context, adi string) (acc.Envelope, error) {
 	m.CallsGetLatestDIDEntry++
 	m.LastADI = adi
 
@@ -47,7 +48,7 @@ func (m *MockClient) GetLatestDIDEntry(adi string) (acc.Envelope, error) {
 	return acc.Envelope{}, nil
 }
 
-func (m *MockClient) GetEntryAtTime(adi string, t time.Time) (acc.Envelope, error) {
+func (m *MockClient) GetEntryAtTime(ctx context.Context, adi string, t time.Time) (acc.Envelope, error) {
 	m.CallsGetEntryAtTime++
 	m.LastADI = adi
 	m.LastAtTime = t
@@ -62,7 +63,7 @@ func (m *MockClient) GetEntryAtTime(adi string, t time.Time) (acc.Envelope, erro
 	return acc.Envelope{}, nil
 }
 
-func (m *MockClient) GetKeyPageState(u string) (acc.KeyPageState, error) {
+func (m *MockClient) GetKeyPageState(ctx context.Context, u string) (acc.KeyPageState, error) {
 	m.CallsGetKeyPageState++
 	m.LastKeyPageURL = u
 
@@ -73,7 +74,7 @@ func (m *MockClient) GetKeyPageState(u string) (acc.KeyPageState, error) {
 	return acc.KeyPageState{}, nil
 }
 
-func (m *MockClient) GetDataAccountEntry(dataAccountURL *url.URL) ([]byte, error) {
+func (m *MockClient) GetDataAccountEntry(ctx context.Context, dataAccountURL *url.URL) ([]byte, error) {
 	m.CallsGetDataAccountEntry++
 	m.LastDataAccountURL = dataAccountURL
 
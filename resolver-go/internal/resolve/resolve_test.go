@@ -1,6 +1,7 @@
 package resolve
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/opendlt/accu-did/resolver-go/internal/acc"
 	"github.com/opendlt/accu-did/resolver-go/internal/normalize"
+	"github.com/opendlt/accu-did/shared/ledger"
 )
 
 func TestNormalizeDID(t *testing.T) {
@@ -117,7 +119,7 @@ func TestResolveDID_Deactivated(t *testing.T) {
 // mockDeactivatedClient returns a deactivated DID document
 type mockDeactivatedClient struct{}
 
-func (c *mockDeactivatedClient) GetLatestDIDEntry(adi string) (acc.Envelope, error) {
+func (c *mockDeactivatedClient) GetLatestDIDEntry(ctx context.Context, adi string) (acc.Envelope, error) {
 	doc := map[string]interface{}{
 		"@context":    []interface{}{"https://www.w3.org/ns/did/v1"},
 		"id":          "did:acc:alice",
@@ -138,15 +140,52 @@ func (c *mockDeactivatedClient) GetLatestDIDEntry(adi string) (acc.Envelope, err
 	}, nil
 }
 
-func (c *mockDeactivatedClient) GetEntryAtTime(adi string, t time.Time) (acc.Envelope, error) {
-	return c.GetLatestDIDEntry(adi)
+func (c *mockDeactivatedClient) GetEntryAtTime(ctx context.Context, adi string, t time.Time) (acc.Envelope, error) {
+	return c.GetLatestDIDEntry(ctx, adi)
 }
 
-func (c *mockDeactivatedClient) GetKeyPageState(url string) (acc.KeyPageState, error) {
+func (c *mockDeactivatedClient) GetKeyPageState(ctx context.Context, url string) (acc.KeyPageState, error) {
 	return acc.KeyPageState{}, nil
 }
 
 // 🔑 New method to satisfy acc.Client
-func (c *mockDeactivatedClient) GetDataAccountEntry(dataAccountURL *url.URL) ([]byte, error) {
+func (c *mockDeactivatedClient) GetDataAccountEntry(ctx context.Context, dataAccountURL *url.URL) ([]byte, error) {
 	return []byte(`{"@context":["https://www.w3.org/ns/did/v1"],"id":"did:acc:alice","deactivated":true}`), nil
 }
+
+func TestResolveDID_LedgerHistory(t *testing.T) {
+	clock := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	l := ledger.New().WithClock(func() time.Time { return clock })
+	_, err := l.CreateIdentity("acc://carol.acme", "")
+	require.NoError(t, err)
+	_, err = l.CreateDataAccount("acc://carol.acme/did", "acc://carol.acme/book/1")
+	require.NoError(t, err)
+
+	write := func(at time.Time, doc string) {
+		clock = at
+		_, _, err := l.WriteData("acc://carol.acme/did", "acc://carol.acme/book/1", []byte(doc))
+		require.NoError(t, err)
+	}
+	write(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), `{"id":"did:acc:carol.acme","versionId":"v1"}`)
+	write(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), `{"id":"did:acc:carol.acme","versionId":"v2"}`)
+	write(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), `{"id":"did:acc:carol.acme","deactivated":true}`)
+
+	client := acc.NewFakeClientWithLedger(l)
+
+	latest, err := ResolveDID(client, "did:acc:carol.acme", nil)
+	require.NoError(t, err)
+	assert.True(t, latest.DIDDocumentMetadata.Deactivated)
+	assert.Equal(t, uint64(3), *latest.DIDDocumentMetadata.Sequence)
+
+	versionTime := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	result, err := ResolveDID(client, "did:acc:carol.acme", &versionTime)
+	require.NoError(t, err)
+	require.NotNil(t, result.DIDDocumentMetadata.Sequence)
+	assert.Equal(t, uint64(2), *result.DIDDocumentMetadata.Sequence)
+	assert.Equal(t, "v2", *result.DIDDocumentMetadata.VersionID)
+	assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), result.DIDDocumentMetadata.Updated)
+
+	beforeFirst := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	_, err = ResolveDID(client, "did:acc:carol.acme", &beforeFirst)
+	assert.IsType(t, &NotFoundError{}, err)
+}
@@ -1,10 +1,13 @@
 package security
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
+
+	"github.com/opendlt/accu-did/shared/logging"
 )
 
 // SecurityConfig holds security configuration for resolver
@@ -22,9 +25,33 @@ func DefaultSecurityConfig() *SecurityConfig {
 
 // CORSMiddleware handles CORS configuration
 func CORSMiddleware(allowOrigins []string) func(http.Handler) http.Handler {
+	return NewCORS(allowOrigins).Middleware()
+}
+
+// CORS holds the allowed origins for the CORS middleware. Origins can be
+// replaced at runtime (e.g. on config reload) with Update.
+type CORS struct {
+	origins atomic.Pointer[[]string]
+}
+
+// NewCORS creates a CORS policy allowing the given origins
+func NewCORS(allowOrigins []string) *CORS {
+	c := &CORS{}
+	c.Update(allowOrigins)
+	return c
+}
+
+// Update replaces the allowed origins
+func (c *CORS) Update(allowOrigins []string) {
+	c.origins.Store(&allowOrigins)
+}
+
+// Middleware returns the CORS middleware backed by this policy
+func (c *CORS) Middleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
+			allowOrigins := *c.origins.Load()
 
 			// If no origins configured, no CORS headers
 			if len(allowOrigins) == 0 {
@@ -68,7 +95,7 @@ func RequestIDMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			requestID := r.Header.Get("X-Request-Id")
-			if requestID == "" {
+			if !validRequestID(requestID) {
 				requestID = generateRequestID()
 				r.Header.Set("X-Request-Id", requestID)
 			}
@@ -76,23 +103,41 @@ func RequestIDMiddleware() func(http.Handler) http.Handler {
 			// Add to response headers
 			w.Header().Set("X-Request-Id", requestID)
 
-			// Add to context for logging
-			ctx := context.WithValue(r.Context(), "request_id", requestID)
+			// Add to context for logging and error responses
+			ctx := logging.WithRequestID(r.Context(), requestID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// validRequestID reports whether a client-supplied request ID is safe to echo
+// back in headers, logs and error bodies
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
 // generateRequestID creates a simple request ID
 func generateRequestID() string {
 	return fmt.Sprintf("req_%d", time.Now().UnixNano())
 }
 
 // writeErrorResponse writes a canonical error response
-func writeErrorResponse(w http.ResponseWriter, status int, errorCode, message string) {
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, errorCode, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	response := fmt.Sprintf(`{"code":%d,"error":"%s","message":"%s"}`, status, errorCode, message)
-	w.Write([]byte(response))
+	json.NewEncoder(w).Encode(struct {
+		Code      int    `json:"code"`
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		RequestID string `json:"requestId,omitempty"`
+	}{status, errorCode, message, logging.RequestIDFromContext(r.Context())})
 }
\ No newline at end of file
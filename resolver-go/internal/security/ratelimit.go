@@ -0,0 +1,131 @@
+package security
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/opendlt/accu-did/shared/telemetry"
+)
+
+// RateLimiter keeps a token bucket per client IP. A rate of 0 lets every
+// request through. The rate and burst can be changed at runtime (e.g. on
+// config reload) with Update.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rps     int
+	burst   int
+	buckets map[string]*bucket
+	metrics *telemetry.HTTPMetrics
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Idle buckets are dropped once this many clients are tracked
+const (
+	maxBuckets    = 10000
+	bucketIdleTTL = 10 * time.Minute
+)
+
+// NewRateLimiter creates a limiter allowing each client IP rps requests per
+// second; 0 disables it
+func NewRateLimiter(rps int, burst int) *RateLimiter {
+	return &RateLimiter{rps: rps, burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// WithMetrics counts rejected requests in metrics
+func (l *RateLimiter) WithMetrics(metrics *telemetry.HTTPMetrics) *RateLimiter {
+	l.metrics = metrics
+	return l
+}
+
+// Update changes the rate and burst; tokens already in the buckets are kept
+func (l *RateLimiter) Update(rps int, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rps, l.burst = rps, burst
+}
+
+// take spends a token from the bucket of client and returns the limiter after
+// the attempt, or nil when rate limiting is off
+func (l *RateLimiter) take(client string, now time.Time) (*rate.Limiter, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rps <= 0 {
+		return nil, true
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			for id, idle := range l.buckets {
+				if now.Sub(idle.lastSeen) > bucketIdleTTL {
+					delete(l.buckets, id)
+				}
+			}
+		}
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.rps), l.burst)}
+		l.buckets[client] = b
+	} else if b.limiter.Limit() != rate.Limit(l.rps) || b.limiter.Burst() != l.burst {
+		b.limiter.SetLimitAt(now, rate.Limit(l.rps))
+		b.limiter.SetBurstAt(now, l.burst)
+	}
+	b.lastSeen = now
+	return b.limiter, b.limiter.AllowN(now, 1)
+}
+
+// Middleware returns the rate limiting middleware backed by this limiter. It
+// reports the bucket in X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset (seconds until full).
+func (l *RateLimiter) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := time.Now()
+			limiter, allowed := l.take(clientIP(r), now)
+			if limiter == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tokens := max(limiter.TokensAt(now), 0)
+			perSecond := float64(limiter.Limit())
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limiter.Burst()))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(tokens)))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(secondsUntil(float64(limiter.Burst())-tokens, perSecond)))
+
+			if !allowed {
+				l.metrics.ObserveRateLimited()
+				w.Header().Set("Retry-After", strconv.Itoa(max(secondsUntil(1-tokens, perSecond), 1)))
+				writeErrorResponse(w, r, http.StatusTooManyRequests, "rateLimitExceeded", "Rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// secondsUntil returns the whole seconds needed to refill tokens at rate
+func secondsUntil(tokens, rate float64) int {
+	if tokens <= 0 || rate <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens / rate))
+}
+
+// clientIP returns the host part of the peer address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,68 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/opendlt/accu-did/shared/telemetry"
+)
+
+func serve(h http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/resolve?did=did:acc:alice", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestRateLimiter_PerClientIP(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	limiter := NewRateLimiter(1, 2).WithMetrics(telemetry.NewHTTPMetrics(reg, "resolver"))
+	h := limiter.Middleware()(okHandler)
+
+	first := serve(h, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:5678").Code, "the port is not part of the client")
+
+	limited := serve(h, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+	assert.Contains(t, limited.Body.String(), "rateLimitExceeded")
+
+	// Other clients have their own buckets
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.2:1234").Code)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var rejections float64
+	for _, family := range families {
+		if family.GetName() == "accdid_resolver_rate_limit_rejections_total" {
+			rejections = family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, 1.0, rejections)
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	limiter := NewRateLimiter(0, 0)
+	h := limiter.Middleware()(okHandler)
+	for i := 0; i < 5; i++ {
+		w := serve(h, "10.0.0.1:1234")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	// Turning it on at runtime applies to the next request
+	limiter.Update(1, 1)
+	assert.Equal(t, http.StatusOK, serve(h, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(h, "10.0.0.1:1234").Code)
+}
@@ -0,0 +1,74 @@
+package telemetry
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/opendlt/accu-did/shared/telemetry"
+)
+
+const subsystem = "resolver"
+
+// Resolution outcomes used as the "outcome" label
+const (
+	OutcomeSuccess     = "success"
+	OutcomeDeactivated = "deactivated"
+	OutcomeNotFound    = "notFound"
+	OutcomeInvalidDID  = "invalidDid"
+	OutcomeError       = "error"
+)
+
+// Metrics holds the resolver-specific Prometheus collectors; HTTP, rate limit
+// and upstream metrics are in the shared HTTPMetrics. A nil *Metrics is valid
+// and records nothing.
+type Metrics struct {
+	resolutionDuration *prometheus.HistogramVec
+	cacheRequests      *prometheus.CounterVec
+}
+
+// NewMetrics creates the resolver collectors and registers them
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		resolutionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: telemetry.Namespace,
+			Subsystem: subsystem,
+			Name:      "resolution_duration_seconds",
+			Help:      "DID resolution latency by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: telemetry.Namespace,
+			Subsystem: subsystem,
+			Name:      "cache_requests_total",
+			Help:      "Cache lookups by cache name and result (hit or miss).",
+		}, []string{"cache", "result"}),
+	}
+
+	reg.MustRegister(
+		m.resolutionDuration,
+		m.cacheRequests,
+	)
+
+	return m
+}
+
+// ObserveResolution records a resolution attempt and its outcome
+func (m *Metrics) ObserveResolution(outcome string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.resolutionDuration.WithLabelValues(outcome).Observe(d.Seconds())
+}
+
+// ObserveCache records a cache lookup; the hit ratio is hits / (hits + misses)
+func (m *Metrics) ObserveCache(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheRequests.WithLabelValues(cache, result).Inc()
+}
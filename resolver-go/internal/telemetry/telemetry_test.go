@@ -0,0 +1,40 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewWithExporter_RegistersResolverMetrics(t *testing.T) {
+	tel := NewWithExporter(DefaultConfig(), tracetest.NewInMemoryExporter())
+	t.Cleanup(func() { _ = tel.Shutdown(context.Background()) })
+
+	tel.Metrics.ObserveResolution(OutcomeSuccess, time.Millisecond)
+	tel.Metrics.ObserveCache("resolution", true)
+	tel.HTTP.ObserveHTTP("/resolve", "GET", "200", time.Millisecond)
+
+	families, err := tel.Registry().Gather()
+	require.NoError(t, err)
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	for _, want := range []string{
+		"accdid_resolver_resolution_duration_seconds",
+		"accdid_resolver_cache_requests_total",
+		"accdid_resolver_http_requests_total",
+	} {
+		assert.Contains(t, names, want)
+	}
+}
+
+func TestMetrics_NilIsValid(t *testing.T) {
+	var m *Metrics
+	m.ObserveResolution(OutcomeError, time.Millisecond)
+	m.ObserveCache("resolution", false)
+}
@@ -0,0 +1,114 @@
+package accdid
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// didResolutionContentType is the media type of a full DID resolution result
+const didResolutionContentType = `application/ld+json;profile="https://w3id.org/did-resolution"`
+
+// UniversalResolverHandler serves resolver over the Universal Resolver driver
+// interface, GET /1.0/identifiers/{did} with an optional versionTime, so it
+// can be deployed as a driver or called by any library speaking that
+// interface. Requests accepting application/did+json get the document alone;
+// others get the resolution result. Errors are reported in
+// didResolutionMetadata: invalidDid, methodNotSupported, notFound,
+// deactivated, invalidVersionTime, invalidOptions or internalError.
+func UniversalResolverHandler(resolver Resolver) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /1.0/identifiers/{did...}", func(w http.ResponseWriter, r *http.Request) {
+		did := r.PathValue("did")
+
+		var (
+			result *ResolutionResult
+			err    error
+		)
+		if vt := r.URL.Query().Get("versionTime"); vt != "" {
+			versionTime, parseErr := time.Parse(time.RFC3339, vt)
+			if parseErr != nil {
+				writeResolutionError(w, http.StatusBadRequest, "invalidVersionTime", fmt.Sprintf("invalid versionTime %q", vt))
+				return
+			}
+			result, err = resolver.ResolveAt(r.Context(), did, versionTime)
+		} else {
+			result, err = resolver.Resolve(r.Context(), did)
+		}
+		if err != nil {
+			status, code := resolutionErrorCode(err)
+			writeResolutionError(w, status, code, err.Error())
+			return
+		}
+
+		accept := r.Header.Get("Accept")
+		if strings.Contains(accept, "application/did+json") || strings.Contains(accept, "application/did+ld+json") {
+			w.Header().Set("Content-Type", "application/did+json")
+			json.NewEncoder(w).Encode(result.DIDDocument)
+			return
+		}
+		w.Header().Set("Content-Type", didResolutionContentType)
+		json.NewEncoder(w).Encode(UniversalResolveResponse{
+			DIDResolutionMetadata: result.Metadata,
+			DIDDocument:           result.DIDDocument,
+			DIDDocumentMetadata:   result.DocumentMetadata,
+		})
+	})
+	return mux
+}
+
+// resolutionErrorCode maps a resolution error to its HTTP status and DID
+// resolution error code
+func resolutionErrorCode(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrMethodNotSupported):
+		return http.StatusNotImplemented, "methodNotSupported"
+	case errors.Is(err, ErrInvalidDID):
+		return http.StatusBadRequest, "invalidDid"
+	case errors.Is(err, ErrGoneDeactivated):
+		return http.StatusGone, "deactivated"
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound, "notFound"
+	case errors.Is(err, ErrBadRequest):
+		return http.StatusBadRequest, "invalidOptions"
+	}
+	return http.StatusInternalServerError, "internalError"
+}
+
+func writeResolutionError(w http.ResponseWriter, status int, code, message string) {
+	response := UniversalResolveResponse{
+		DIDResolutionMetadata: map[string]interface{}{"error": code, "errorMessage": message},
+	}
+	if code == "deactivated" {
+		response.DIDDocumentMetadata = map[string]interface{}{"deactivated": true}
+	}
+	w.Header().Set("Content-Type", didResolutionContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// KeyFunc returns a key lookup for JOSE and JWT libraries: given the kid of
+// a token, a DID URL such as did:acc:alice#key-1, it resolves the DID and
+// returns the key as an ed25519.PublicKey or *ecdsa.PublicKey. The key must
+// be in rel (assertionMethod when empty) and its DID not deactivated, as for
+// Verifier. The standard library has no secp256k1 curve, so those keys are
+// reported as keys.ErrUnsupportedKey.
+func KeyFunc(resolver Resolver, rel Relationship) func(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	verifier := NewVerifier(resolver)
+	return func(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+		var result VerificationResult
+		key, ok, err := verifier.lookup(ctx, keyID, VerifyOptions{Relationship: rel}, AssertionMethod, &result)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("key %s rejected (%s): %s", keyID, result.Failure, result.Reason)
+		}
+		return key.CryptoPublicKey()
+	}
+}
@@ -0,0 +1,143 @@
+package accdid
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+func TestUniversalResolverHandler(t *testing.T) {
+	key, _ := keys.Generate(keys.Ed25519)
+	m := NewMethodResolver()
+	m.Register("acc", &stubResolver{docs: map[string]*ResolutionResult{
+		"did:acc:alice/profile": {DIDDocument: &Document{ID: "did:acc:alice/profile"}, DocumentMetadata: map[string]interface{}{"versionId": "2"}},
+	}})
+	m.Register("gone", &stubResolver{err: ErrGoneDeactivated})
+	server := httptest.NewServer(UniversalResolverHandler(m))
+	defer server.Close()
+
+	get := func(did, query, accept string) (int, string, UniversalResolveResponse) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/1.0/identifiers/"+url.PathEscape(did)+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body UniversalResolveResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, resp.Header.Get("Content-Type"), body
+	}
+
+	status, contentType, body := get("did:acc:alice/profile", "", "")
+	if status != http.StatusOK || contentType != didResolutionContentType || body.DIDDocument.ID != "did:acc:alice/profile" || body.DIDDocumentMetadata["versionId"] != "2" {
+		t.Errorf("Unexpected resolution %d %s %+v", status, contentType, body)
+	}
+	status, _, body = get(key.Public.DIDKey(), "?versionTime=2024-01-01T00:00:00Z", "")
+	if status != http.StatusOK || len(body.DIDDocument.VerificationMethod) != 1 {
+		t.Errorf("Expected the did:key document, got %d %+v", status, body)
+	}
+
+	// The document alone is served as application/did+json
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/1.0/identifiers/"+key.Public.DIDKey(), nil)
+	req.Header.Set("Accept", "application/did+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc Document
+	json.NewDecoder(resp.Body).Decode(&doc)
+	resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "application/did+json" || doc.ID != key.Public.DIDKey() {
+		t.Errorf("Expected the bare document, got %s %+v", resp.Header.Get("Content-Type"), doc)
+	}
+
+	tests := []struct {
+		did, query string
+		status     int
+		code       string
+	}{
+		{"did:example:123", "", http.StatusNotImplemented, "methodNotSupported"},
+		{"did:key:zInvalid", "", http.StatusBadRequest, "invalidDid"},
+		{"did:acc:bob", "", http.StatusNotFound, "notFound"},
+		{"did:gone:carol", "", http.StatusGone, "deactivated"},
+		{"did:acc:alice/profile", "?versionTime=yesterday", http.StatusBadRequest, "invalidVersionTime"},
+	}
+	for _, tt := range tests {
+		status, _, body := get(tt.did, tt.query, "")
+		if status != tt.status || body.DIDResolutionMetadata["error"] != tt.code {
+			t.Errorf("%s%s: got %d %v, want %d %s", tt.did, tt.query, status, body.DIDResolutionMetadata, tt.status, tt.code)
+		}
+	}
+}
+
+func TestKeyFunc(t *testing.T) {
+	f := newVerifierFixture(t)
+	m := NewMethodResolver()
+	m.Register("acc", f.resolver)
+	keyFunc := KeyFunc(m, "")
+	ctx := context.Background()
+
+	pub, err := keyFunc(ctx, "did:acc:alice#ed")
+	if err != nil {
+		t.Fatalf("KeyFunc: %v", err)
+	}
+	message := []byte("token")
+	sig, _ := f.ed.Sign(message)
+	if edPub, ok := pub.(ed25519.PublicKey); !ok || !ed25519.Verify(edPub, message, sig) {
+		t.Errorf("Expected the Ed25519 key, got %T", pub)
+	}
+	if pub, err := KeyFunc(m, Authentication)(ctx, "did:acc:alice#p256"); err != nil {
+		t.Errorf("KeyFunc: %v", err)
+	} else if _, ok := pub.(*ecdsa.PublicKey); !ok {
+		t.Errorf("Expected an ECDSA key, got %T", pub)
+	}
+	if _, err := keyFunc(ctx, "did:acc:alice#k1"); !errors.Is(err, keys.ErrUnsupportedKey) {
+		t.Errorf("Expected ErrUnsupportedKey for secp256k1, got %v", err)
+	}
+
+	// did:key works without registering anything
+	key, _ := keys.Generate(keys.Ed25519)
+	if pub, err := keyFunc(ctx, key.Public.DIDKey()+"#"+key.Public.Fingerprint()); err != nil || !key.Public.Equal(mustPublicKey(t, pub)) {
+		t.Errorf("Expected the did:key key, got %v, %v", pub, err)
+	}
+
+	if _, err := KeyFunc(m, KeyAgreement)(ctx, "did:acc:alice#ed"); err == nil {
+		t.Error("Expected a key outside the relationship to be rejected")
+	}
+	if _, err := keyFunc(ctx, "did:acc:alice#missing"); err == nil {
+		t.Error("Expected an unknown key to be rejected")
+	}
+	if _, err := keyFunc(ctx, "did:acc:bob#ed"); err == nil {
+		t.Error("Expected a key of a deactivated DID to be rejected")
+	}
+
+	// The key is looked up in the document as it was at versionTime
+	if pub, err := keyFunc(ctx, "did:acc:alice?versionTime=2024-06-01T00:00:00Z#ed"); err != nil || !f.old.Public.Equal(mustPublicKey(t, pub)) {
+		t.Errorf("Expected the previous key, got %v, %v", pub, err)
+	}
+}
+
+func mustPublicKey(t *testing.T, pub interface{}) keys.PublicKey {
+	t.Helper()
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("Expected an Ed25519 key, got %T", pub)
+	}
+	key, err := keys.NewPublicKey(keys.Ed25519, edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
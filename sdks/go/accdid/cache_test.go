@@ -0,0 +1,206 @@
+package accdid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cachingResolver serves one document version with the given Cache-Control
+// and answers If-None-Match for it with 304
+type cachingResolver struct {
+	mu           sync.Mutex
+	version      int
+	cacheControl string
+	full, cond   int32 // 200 and 304 responses
+	release      chan struct{}
+}
+
+func (s *cachingResolver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	version, cacheControl := s.version, s.cacheControl
+	s.mu.Unlock()
+
+	if version < 0 {
+		w.WriteHeader(http.StatusGone)
+		w.Write([]byte(`{"error":"deactivated","message":"deactivated"}`))
+		return
+	}
+	tag := fmt.Sprintf(`W/"v%d"`, version)
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if r.Header.Get("If-None-Match") == tag {
+		atomic.AddInt32(&s.cond, 1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	atomic.AddInt32(&s.full, 1)
+	fmt.Fprintf(w, `{"didDocument":{"id":"did:acc:alice"},"didDocumentMetadata":{"versionId":"%d"}}`, version)
+}
+
+func newCachedResolver(t *testing.T, handler http.Handler) (*ResolverClient, *MemoryCache) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := NewResolverClient(ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewMemoryCache(100)
+	return client.WithCache(cache), cache
+}
+
+func resolveVersion(t *testing.T, client *ResolverClient) string {
+	t.Helper()
+	res, err := client.Resolve(context.Background(), "did:acc:alice")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	return metadataString(res.DocumentMetadata, "versionId")
+}
+
+func TestResolverCache_Revalidation(t *testing.T) {
+	server := &cachingResolver{version: 1, cacheControl: "no-cache"}
+	client, cache := newCachedResolver(t, server)
+
+	for i := 0; i < 3; i++ {
+		if v := resolveVersion(t, client); v != "1" {
+			t.Fatalf("Expected version 1, got %s", v)
+		}
+	}
+	if atomic.LoadInt32(&server.full) != 1 || atomic.LoadInt32(&server.cond) != 2 {
+		t.Errorf("Expected one full response and two 304s, got %d and %d", atomic.LoadInt32(&server.full), atomic.LoadInt32(&server.cond))
+	}
+
+	server.mu.Lock()
+	server.version = 2
+	server.mu.Unlock()
+	if v := resolveVersion(t, client); v != "2" {
+		t.Errorf("Expected the new version after revalidation, got %s", v)
+	}
+
+	// A deactivated DID is reported and dropped from the cache
+	server.mu.Lock()
+	server.version = -1
+	server.mu.Unlock()
+	if _, err := client.Resolve(context.Background(), "did:acc:alice"); !errors.Is(err, ErrGoneDeactivated) {
+		t.Errorf("Expected ErrGoneDeactivated, got %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Expected the entry to be removed, %d left", cache.Len())
+	}
+}
+
+func TestResolverCache_MaxAgeAndNoStore(t *testing.T) {
+	server := &cachingResolver{version: 1, cacheControl: "public, max-age=60"}
+	client, cache := newCachedResolver(t, server)
+
+	resolveVersion(t, client)
+	server.mu.Lock()
+	server.version = 2
+	server.mu.Unlock()
+	if v := resolveVersion(t, client); v != "1" || atomic.LoadInt32(&server.full)+atomic.LoadInt32(&server.cond) != 1 {
+		t.Errorf("Expected the fresh entry without a request, got version %s after %d requests", v, atomic.LoadInt32(&server.full)+atomic.LoadInt32(&server.cond))
+	}
+
+	// The versionTime is part of the key
+	if _, err := client.ResolveAt(context.Background(), "did:acc:alice", time.Now()); err != nil || atomic.LoadInt32(&server.full) != 2 {
+		t.Errorf("Expected ResolveAt to miss the cache, got %v after %d requests", err, atomic.LoadInt32(&server.full))
+	}
+
+	server.mu.Lock()
+	server.cacheControl = "no-store"
+	server.mu.Unlock()
+	cache.Delete("did:acc:alice\x00")
+	resolveVersion(t, client)
+	resolveVersion(t, client)
+	if atomic.LoadInt32(&server.full) != 4 || cache.Len() != 1 {
+		t.Errorf("Expected no-store responses to be fetched each time and not kept, got %d requests and %d entries", atomic.LoadInt32(&server.full), cache.Len())
+	}
+}
+
+func TestResolverCache_Coalescing(t *testing.T) {
+	server := &cachingResolver{version: 1, cacheControl: "no-cache", release: make(chan struct{})}
+	client, _ := newCachedResolver(t, server)
+
+	// A caller that gives up does not fail the others
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Resolve(canceled, "did:acc:alice"); err == nil {
+		t.Error("Expected the canceled caller to fail")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Resolve(context.Background(), "did:acc:alice")
+			if err == nil && res.DIDDocument.ID != "did:acc:alice" {
+				err = fmt.Errorf("unexpected document %+v", res.DIDDocument)
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(server.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if atomic.LoadInt32(&server.full) != 1 {
+		t.Errorf("Expected concurrent resolutions to share one request, got %d", atomic.LoadInt32(&server.full))
+	}
+}
+
+func TestMemoryCache_Eviction(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", CacheEntry{ETag: "1"})
+	cache.Set("b", CacheEntry{ETag: "2"})
+	cache.Get("a")
+	cache.Set("c", CacheEntry{ETag: "3"})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected the least recently used entry to be evicted")
+	}
+	if entry, ok := cache.Get("a"); !ok || entry.ETag != "1" {
+		t.Errorf("Expected a to stay, got %+v, %v", entry, ok)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestCacheLifetime(t *testing.T) {
+	tests := []struct {
+		header   string
+		lifetime time.Duration
+		storable bool
+	}{
+		{"", 0, true},
+		{"max-age=30", 30 * time.Second, true},
+		{"private, max-age=\"5\"", 5 * time.Second, true},
+		{"max-age=30, no-cache", 0, true},
+		{"no-store", 0, false},
+		{"max-age=abc", 0, true},
+	}
+	for _, tt := range tests {
+		lifetime, storable := cacheLifetime(tt.header)
+		if lifetime != tt.lifetime || storable != tt.storable {
+			t.Errorf("cacheLifetime(%q) = %v, %v; want %v, %v", tt.header, lifetime, storable, tt.lifetime, tt.storable)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration file: named profiles and the profile used
+// when none is given
+type Config struct {
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile holds the endpoints and credentials of one deployment
+type Profile struct {
+	Resolver  string `yaml:"resolver"`
+	Registrar string `yaml:"registrar"`
+	Node      string `yaml:"node"` // Accumulate JSON-RPC v3 endpoint
+
+	// APIKey authenticates to the resolver and registrar. APIKeyEnv names an
+	// environment variable holding it instead, keeping it out of the file.
+	APIKey    string `yaml:"apiKey"`
+	APIKeyEnv string `yaml:"apiKeyEnv"`
+
+	Output  string        `yaml:"output"` // table, json or yaml
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// defaultProfile points at services running locally
+var defaultProfile = Profile{
+	Resolver:  "http://localhost:8080",
+	Registrar: "http://localhost:8081",
+	Output:    "table",
+	Timeout:   30 * time.Second,
+}
+
+// defaultConfigPath is the configuration file read when neither --config
+// nor ACCDID_CONFIG is set
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".accdid", "config.yaml")
+	}
+	return filepath.Join(dir, "accdid", "config.yaml")
+}
+
+// loadProfile returns the effective settings: the defaults, overridden by
+// the selected profile, then by ACCDID_* environment variables, then by
+// the non-empty fields of flags
+func loadProfile(path, name string, flags Profile) (Profile, error) {
+	explicit := path != "" || os.Getenv("ACCDID_CONFIG") != ""
+	if path == "" {
+		path = os.Getenv("ACCDID_CONFIG")
+	}
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if name == "" {
+		name = os.Getenv("ACCDID_PROFILE")
+	}
+
+	var cfg Config
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Profile{}, fmt.Errorf("invalid configuration file %s: %w", path, err)
+		}
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+		// No file: the defaults apply
+	default:
+		return Profile{}, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	if name == "" {
+		name = cfg.Profile
+	}
+	profile := defaultProfile
+	if name != "" {
+		selected, ok := cfg.Profiles[name]
+		if !ok {
+			return Profile{}, fmt.Errorf("no profile %q in %s (profiles: %s)", name, path, strings.Join(profileNames(cfg), ", "))
+		}
+		profile = merge(profile, selected)
+	}
+
+	profile = merge(profile, Profile{
+		Resolver:  os.Getenv("ACCDID_RESOLVER_URL"),
+		Registrar: os.Getenv("ACCDID_REGISTRAR_URL"),
+		Node:      os.Getenv("ACCDID_NODE_URL"),
+		APIKey:    os.Getenv("ACCDID_API_KEY"),
+	})
+	profile = merge(profile, flags)
+
+	if profile.APIKeyEnv != "" && flags.APIKey == "" && os.Getenv("ACCDID_API_KEY") == "" {
+		profile.APIKey = os.Getenv(profile.APIKeyEnv)
+	}
+	switch profile.Output {
+	case "table", "json", "yaml":
+	default:
+		return Profile{}, fmt.Errorf("unknown output format %q: use table, json or yaml", profile.Output)
+	}
+	return profile, nil
+}
+
+// merge returns base with the non-empty fields of over
+func merge(base, over Profile) Profile {
+	for _, f := range []struct{ dst, src *string }{
+		{&base.Resolver, &over.Resolver},
+		{&base.Registrar, &over.Registrar},
+		{&base.Node, &over.Node},
+		{&base.APIKey, &over.APIKey},
+		{&base.APIKeyEnv, &over.APIKeyEnv},
+		{&base.Output, &over.Output},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	if over.Timeout > 0 {
+		base.Timeout = over.Timeout
+	}
+	return base
+}
+
+func profileNames(cfg Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
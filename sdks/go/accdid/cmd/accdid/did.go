@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid"
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// resolutionView is a resolution as printed
+type resolutionView struct {
+	DIDDocument         *accdid.Document       `json:"didDocument"`
+	ResolutionMetadata  map[string]interface{} `json:"didResolutionMetadata,omitempty"`
+	DIDDocumentMetadata map[string]interface{} `json:"didDocumentMetadata,omitempty"`
+}
+
+func (c *cli) resolve(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("resolve", flag.ContinueOnError)
+	at := fs.String("at", "", "resolve the document as it was at this RFC 3339 time")
+	direct := fs.Bool("direct", false, "read did:acc documents from the Accumulate node instead of the resolver service")
+	args, err := c.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	resolver, err := c.methodResolver(*direct)
+	if err != nil {
+		return err
+	}
+
+	var result *accdid.ResolutionResult
+	if *at != "" {
+		versionTime, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return usagef("invalid --at time %q: use RFC 3339, e.g. 2025-01-01T00:00:00Z", *at)
+		}
+		result, err = resolver.ResolveAt(ctx, args[0], versionTime)
+		if err != nil {
+			return err
+		}
+	} else if result, err = resolver.Resolve(ctx, args[0]); err != nil {
+		return err
+	}
+
+	view := resolutionView{result.DIDDocument, result.Metadata, result.DocumentMetadata}
+	return c.print(view, func(row func(...interface{})) {
+		meta := result.DocumentMetadata
+		row("DID", args[0])
+		row("VERSION", orDash(metaString(meta, "versionId")))
+		row("UPDATED", orDash(metaString(meta, "updated")))
+		row("CONTENT HASH", orDash(metaString(meta, "contentHash")))
+		documentRows(row, result.DIDDocument)
+	})
+}
+
+// documentRows lists the verification methods and services of doc
+func documentRows(row func(...interface{}), doc *accdid.Document) {
+	if doc == nil {
+		return
+	}
+	for i := range doc.VerificationMethod {
+		vm := &doc.VerificationMethod[i]
+		var rels []string
+		for _, rel := range accdid.Relationships {
+			if doc.HasRelationship(rel, vm.ID) {
+				rels = append(rels, string(rel))
+			}
+		}
+		row("METHOD", vm.ID, vm.Type, orDash(strings.Join(rels, ",")))
+	}
+	for _, svc := range doc.Service {
+		row("SERVICE", doc.AbsoluteID(svc.ID), svc.Type, fmt.Sprint(svc.ServiceEndpoint))
+	}
+}
+
+func metaString(meta map[string]interface{}, key string) string {
+	s, _ := meta[key].(string)
+	return s
+}
+
+// writeView is the outcome of a write as printed: the resolved version, or
+// with --dry-run the document and registrar request that would be sent
+type writeView struct {
+	DryRun        bool                 `json:"dryRun,omitempty"`
+	DID           string               `json:"did"`
+	TransactionID string               `json:"transactionId,omitempty"`
+	VersionID     string               `json:"versionId,omitempty"`
+	ContentHash   string               `json:"contentHash,omitempty"`
+	Updated       *time.Time           `json:"updated,omitempty"`
+	Deactivated   bool                 `json:"deactivated,omitempty"`
+	Document      *accdid.Document     `json:"didDocument,omitempty"`
+	Envelope      *accdid.PlannedWrite `json:"envelope,omitempty"`
+}
+
+func (c *cli) printWrite(meta *accdid.VersionMetadata) error {
+	view := writeView{
+		DryRun:        meta.Planned != nil,
+		DID:           meta.DID,
+		TransactionID: meta.TransactionID,
+		VersionID:     meta.VersionID,
+		ContentHash:   meta.ContentHash,
+		Deactivated:   meta.Deactivated,
+		Document:      meta.Document,
+		Envelope:      meta.Planned,
+	}
+	if !meta.Updated.IsZero() {
+		view.Updated = &meta.Updated
+	}
+	if meta.Planned != nil {
+		view.ContentHash = meta.Planned.ContentHash
+	}
+
+	return c.print(view, func(row func(...interface{})) {
+		if view.DryRun {
+			row("DRY RUN", "nothing was written")
+			row("REQUEST", "POST "+c.profile.Registrar+meta.Planned.Endpoint)
+			row("IDEMPOTENCY KEY", meta.Planned.IdempotencyKey)
+			row("BASE VERSION", orDash(meta.Planned.BaseVersionID))
+		}
+		row("DID", view.DID)
+		row("TRANSACTION", orDash(view.TransactionID))
+		row("VERSION", orDash(view.VersionID))
+		row("CONTENT HASH", orDash(view.ContentHash))
+		row("DEACTIVATED", view.Deactivated)
+		documentRows(row, view.Document)
+	})
+}
+
+// writeFlags registers the flags shared by the commands that write
+func writeFlags(fs *flag.FlagSet) (dryRun *bool, wait *time.Duration) {
+	dryRun = fs.Bool("dry-run", false, "print the document and registrar request without writing")
+	wait = fs.Duration("wait", accdid.DefaultManagerWait, "how long to wait for the resolver to show the write")
+	return dryRun, wait
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func (c *cli) create(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	docPath := fs.String("doc", "", "DID document file, or - for standard input (default: an empty document)")
+	var keyArgs, services stringList
+	fs.Var(&keyArgs, "key", "public key to add as key-N for authentication and assertions: a did:key, multibase key or key file from 'keys gen' (repeatable)")
+	fs.Var(&services, "service", "service to add, as fragment,type,endpoint (repeatable)")
+	dryRun, wait := writeFlags(fs)
+	args, err := c.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	did := args[0]
+
+	doc, err := accdid.NewDocument(did).Build()
+	if err != nil {
+		return err
+	}
+	if *docPath != "" {
+		data, err := c.readInput(*docPath)
+		if err != nil {
+			return err
+		}
+		doc = &accdid.Document{}
+		if err := json.Unmarshal(data, doc); err != nil {
+			return fmt.Errorf("invalid document %s: %w", *docPath, err)
+		}
+		if doc.ID == "" {
+			doc.ID = did
+		}
+		if doc.ID != did {
+			return fmt.Errorf("document %s is for %s, not %s", *docPath, doc.ID, did)
+		}
+	}
+
+	for _, spec := range services {
+		parts := strings.SplitN(spec, ",", 3)
+		if len(parts) != 3 {
+			return usagef("invalid --service %q: use fragment,type,endpoint", spec)
+		}
+		id := doc.AbsoluteID(parts[0])
+		if _, exists := doc.FindService(id); exists {
+			return fmt.Errorf("duplicate service %s", id)
+		}
+		doc.Service = append(doc.Service, accdid.Service{ID: id, Type: parts[1], ServiceEndpoint: parts[2]})
+	}
+
+	pubs := make([]keys.PublicKey, 0, len(keyArgs))
+	for _, arg := range keyArgs {
+		pub, err := parsePublicKey(arg)
+		if err != nil {
+			return err
+		}
+		pubs = append(pubs, pub)
+	}
+
+	m, err := c.manager(*dryRun, *wait)
+	if err != nil {
+		return err
+	}
+	meta, err := m.Create(ctx, doc, pubs...)
+	if err != nil {
+		return err
+	}
+	return c.printWrite(meta)
+}
+
+func (c *cli) update(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	patchPath := fs.String("patch", "", "JSON Patch (array) or JSON Merge Patch (object) file, or - for standard input")
+	dryRun, wait := writeFlags(fs)
+	args, err := c.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	if *patchPath == "" {
+		return usagef("--patch is required")
+	}
+	patch, err := c.readInput(*patchPath)
+	if err != nil {
+		return err
+	}
+	did := args[0]
+
+	m, err := c.manager(*dryRun, *wait)
+	if err != nil {
+		return err
+	}
+	meta, err := m.Update(ctx, did, func(doc *accdid.Document) error {
+		current, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		patched, err := applyPatch(current, patch)
+		if err != nil {
+			return err
+		}
+		var next accdid.Document
+		if err := json.Unmarshal(patched, &next); err != nil {
+			return fmt.Errorf("the patched document is invalid: %w", err)
+		}
+		if next.ID != did {
+			return fmt.Errorf("the patch must not change the document id")
+		}
+		*doc = next
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.printWrite(meta)
+}
+
+func (c *cli) deactivate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("deactivate", flag.ContinueOnError)
+	reason := fs.String("reason", "", "reason recorded with the deactivation")
+	dryRun, wait := writeFlags(fs)
+	args, err := c.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	m, err := c.manager(*dryRun, *wait)
+	if err != nil {
+		return err
+	}
+	meta, err := m.Deactivate(ctx, args[0], *reason)
+	if err != nil {
+		return err
+	}
+	return c.printWrite(meta)
+}
+
+func (c *cli) history(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	args, err := c.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	direct, err := c.directResolver()
+	if err != nil {
+		return err
+	}
+	history, err := direct.History(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	return c.print(history, func(row func(...interface{})) {
+		row("SEQUENCE", "TIME", "VERSION", "CONTENT HASH", "STATE")
+		for _, h := range history {
+			var state []string
+			if h.Current {
+				state = append(state, "current")
+			}
+			if h.Deactivated {
+				state = append(state, "deactivated")
+			}
+			if !h.Valid {
+				state = append(state, "invalid")
+			}
+			timestamp := "-"
+			if !h.Timestamp.IsZero() {
+				timestamp = h.Timestamp.Format(time.RFC3339)
+			}
+			row(h.Sequence, timestamp, orDash(h.VersionID), h.ContentHash, orDash(strings.Join(state, ",")))
+		}
+	})
+}
+
+// parsePublicKey reads a public key given as a did:key, a multibase
+// multicodec key or a key file written by 'keys gen'
+func parsePublicKey(arg string) (keys.PublicKey, error) {
+	if strings.HasPrefix(arg, keys.DIDKeyPrefix) {
+		return keys.ParseDIDKey(arg)
+	}
+	if data, err := os.ReadFile(arg); err == nil {
+		var file keyFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return keys.PublicKey{}, fmt.Errorf("invalid key file %s: %w", arg, err)
+		}
+		return keys.ParseMultibase(file.PublicKeyMultibase)
+	}
+	pub, err := keys.ParseMultibase(arg)
+	if err != nil {
+		return keys.PublicKey{}, fmt.Errorf("%q is neither a did:key, a multibase key nor a key file: %w", arg, err)
+	}
+	return pub, nil
+}
@@ -0,0 +1,12 @@
+module github.com/opendlt/accu-did/sdks/go/accdid/cmd/accdid
+
+go 1.22
+
+replace github.com/opendlt/accu-did/sdks/go/accdid => ../..
+
+require (
+	github.com/opendlt/accu-did/sdks/go/accdid v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0 // indirect
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid"
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// keyFile is a key pair as written by 'keys gen --out'
+type keyFile struct {
+	Type               keys.Type `json:"type"`
+	PublicKeyMultibase string    `json:"publicKeyMultibase"`
+	DIDKey             string    `json:"didKey"`
+	PublicKeyJwk       *keys.JWK `json:"publicKeyJwk,omitempty"`
+
+	// PrivateKey is the hex secret: an Ed25519 seed or a curve scalar
+	PrivateKey string `json:"privateKey,omitempty"`
+}
+
+// keyTypes maps the names --type accepts to key types
+var keyTypes = map[string]keys.Type{
+	"ed25519":   keys.Ed25519,
+	"secp256k1": keys.Secp256k1,
+	"p-256":     keys.P256,
+	"p256":      keys.P256,
+}
+
+func (c *cli) keysGen(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keys gen", flag.ContinueOnError)
+	typeName := fs.String("type", "ed25519", "key type: ed25519, secp256k1 or p-256")
+	out := fs.String("out", "", "write the key pair to this file (mode 0600) and print only the public key")
+	if _, err := c.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	t, ok := keyTypes[strings.ToLower(*typeName)]
+	if !ok {
+		return usagef("unknown key type %q: use ed25519, secp256k1 or p-256", *typeName)
+	}
+
+	key, err := keys.Generate(t)
+	if err != nil {
+		return err
+	}
+	file := keyFile{
+		Type:               t,
+		PublicKeyMultibase: key.Public.Multibase(),
+		DIDKey:             key.Public.DIDKey(),
+		PrivateKey:         hex.EncodeToString(key.Bytes()),
+	}
+	if file.PublicKeyJwk, err = key.Public.JWK(); err != nil {
+		return err
+	}
+
+	if *out != "" {
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return err
+		}
+		// O_EXCL keeps an existing key from being overwritten
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(append(data, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		file.PrivateKey = ""
+	}
+
+	return c.print(file, func(row func(...interface{})) {
+		row("TYPE", file.Type)
+		row("PUBLIC KEY", file.PublicKeyMultibase)
+		row("DID:KEY", file.DIDKey)
+		if file.PrivateKey != "" {
+			row("PRIVATE KEY", file.PrivateKey)
+		} else {
+			row("KEY FILE", *out)
+		}
+	})
+}
+
+func (c *cli) keypageShow(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("keypage show", flag.ContinueOnError)
+	args, err := c.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	// A DID names the first page of its ADI's key book
+	pageURL := args[0]
+	if strings.HasPrefix(pageURL, "did:") {
+		adi, _, err := accdid.ParseDID(pageURL)
+		if err != nil {
+			return err
+		}
+		pageURL = "acc://" + adi + "/book/1"
+	}
+
+	direct, err := c.directResolver()
+	if err != nil {
+		return err
+	}
+	page, err := direct.KeyPage(ctx, pageURL)
+	if err != nil {
+		return err
+	}
+
+	return c.print(page, func(row func(...interface{})) {
+		row("URL", page.URL)
+		row("VERSION", page.Version)
+		row("CREDITS", fmt.Sprintf("%d.%02d", page.CreditBalance/100, page.CreditBalance%100))
+		row("THRESHOLD", page.AcceptThreshold)
+		for _, key := range page.Keys {
+			switch {
+			case key.PublicKeyHash != "" && key.Delegate != "":
+				row("KEY", key.PublicKeyHash, "delegate "+key.Delegate)
+			case key.Delegate != "":
+				row("KEY", "-", "delegate "+key.Delegate)
+			default:
+				row("KEY", key.PublicKeyHash, "")
+			}
+		}
+	})
+}
@@ -0,0 +1,269 @@
+// Command accdid resolves, creates, updates and deactivates Accumulate DIDs
+// and inspects keys, key pages and signatures, using the Go SDK.
+//
+// Endpoints and credentials come from a profile of the configuration file,
+// overridden by ACCDID_* environment variables and then by flags:
+//
+//	accdid --profile devnet resolve did:acc:alice
+//	accdid update did:acc:alice --patch patch.json --dry-run -o yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid"
+)
+
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // the command failed, or a signature did not verify
+	exitUsage = 2
+)
+
+// command is a subcommand; name may have two words, such as "keys gen"
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *cli, ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"resolve", "<did>", "resolve a DID document", (*cli).resolve},
+	{"create", "<did>", "register a DID document", (*cli).create},
+	{"update", "<did> --patch <file>", "apply a JSON Patch or JSON Merge Patch to a DID document", (*cli).update},
+	{"deactivate", "<did>", "deactivate a DID", (*cli).deactivate},
+	{"history", "<did>", "list the entries of a DID's data account", (*cli).history},
+	{"keys gen", "", "generate a key pair", (*cli).keysGen},
+	{"keypage show", "<acc://adi/book/n | did>", "show a key page", (*cli).keypageShow},
+	{"verify", "", "verify a raw, JWS or Data Integrity signature", (*cli).verify},
+}
+
+// cli holds the streams and the global options shared by every command
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+
+	configPath  string
+	profileName string
+	overrides   Profile // from flags
+	profile     Profile // effective settings
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage(stdout)
+		return exitOK
+	}
+
+	// Global flags may also come before the command
+	global := flag.NewFlagSet("accdid", flag.ContinueOnError)
+	c.globalFlags(global)
+	global.SetOutput(stderr)
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	args = global.Args()
+
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		fmt.Fprintf(stderr, "accdid: unknown command %q\n\n", strings.Join(args[:min(len(args), 2)], " "))
+		c.usage(stderr)
+		return exitUsage
+	}
+
+	err := cmd.run(c, ctx, rest)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "accdid %s: %v\nusage: accdid %s %s [flags]\n", cmd.name, err, cmd.name, cmd.args)
+		return exitUsage
+	case errors.Is(err, errNotVerified):
+		return exitError
+	}
+	fmt.Fprintf(stderr, "accdid %s: %v\n", cmd.name, err)
+	return exitError
+}
+
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+func (c *cli) usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: accdid <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nglobal flags, accepted by every command:")
+	fs := flag.NewFlagSet("accdid", flag.ContinueOnError)
+	c.globalFlags(fs)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fmt.Fprintln(w, "\nRun 'accdid <command> -h' for the flags of a command.")
+}
+
+// usageError is a command line mistake, reported with the command's usage
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// globalFlags registers the options every command accepts; values already
+// set by flags before the command are kept
+func (c *cli) globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.configPath, "config", c.configPath, "configuration file (env: ACCDID_CONFIG; default "+defaultConfigPath()+")")
+	fs.StringVar(&c.profileName, "profile", c.profileName, "profile of the configuration file (env: ACCDID_PROFILE)")
+	fs.StringVar(&c.overrides.Resolver, "resolver", c.overrides.Resolver, "resolver service URL (env: ACCDID_RESOLVER_URL)")
+	fs.StringVar(&c.overrides.Registrar, "registrar", c.overrides.Registrar, "registrar service URL (env: ACCDID_REGISTRAR_URL)")
+	fs.StringVar(&c.overrides.Node, "node", c.overrides.Node, "Accumulate JSON-RPC v3 endpoint (env: ACCDID_NODE_URL)")
+	fs.StringVar(&c.overrides.APIKey, "api-key", c.overrides.APIKey, "API key for the services (env: ACCDID_API_KEY)")
+	fs.StringVar(&c.overrides.Output, "output", c.overrides.Output, "output format: table, json or yaml")
+	fs.StringVar(&c.overrides.Output, "o", c.overrides.Output, "shorthand for --output")
+	fs.DurationVar(&c.overrides.Timeout, "timeout", c.overrides.Timeout, "timeout of each request (default 30s)")
+}
+
+// parse parses the flags of a command, which may come before, between or
+// after its arguments, loads the effective profile and returns the
+// arguments, checking there are between minArgs and maxArgs
+func (c *cli) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	c.globalFlags(fs)
+	fs.SetOutput(c.stderr)
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usageError{err}
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) < minArgs || len(positional) > maxArgs {
+		return nil, usagef("expected %s, got %d arguments", argCount(minArgs, maxArgs), len(positional))
+	}
+
+	profile, err := loadProfile(c.configPath, c.profileName, c.overrides)
+	if err != nil {
+		return nil, err
+	}
+	c.profile = profile
+	return positional, nil
+}
+
+func argCount(minArgs, maxArgs int) string {
+	switch {
+	case minArgs == maxArgs && minArgs == 1:
+		return "1 argument"
+	case minArgs == maxArgs:
+		return fmt.Sprintf("%d arguments", minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", minArgs, maxArgs)
+}
+
+// clientOptions returns the SDK options for a service at baseURL
+func (c *cli) clientOptions(baseURL string) accdid.ClientOptions {
+	return accdid.ClientOptions{
+		BaseURL: baseURL,
+		APIKey:  c.profile.APIKey,
+		Timeout: c.profile.Timeout,
+		Retries: accdid.RetryPolicy{Max: 2, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second, Jitter: true},
+	}
+}
+
+// resolverClient returns a client of the resolver service
+func (c *cli) resolverClient() (*accdid.ResolverClient, error) {
+	return accdid.NewResolverClient(c.clientOptions(c.profile.Resolver))
+}
+
+// directResolver returns a resolver reading the Accumulate node
+func (c *cli) directResolver() (*accdid.DirectResolver, error) {
+	if c.profile.Node == "" {
+		return nil, errors.New("no Accumulate node: set node in the profile, ACCDID_NODE_URL or --node")
+	}
+	opts := c.clientOptions(c.profile.Node)
+	opts.APIKey = ""
+	return accdid.NewDirectResolver(opts)
+}
+
+// methodResolver resolves did:acc through the resolver service, or through
+// the node when direct is set, and did:key and did:web itself
+func (c *cli) methodResolver(direct bool) (*accdid.MethodResolver, error) {
+	var acc accdid.Resolver
+	var err error
+	if direct {
+		acc, err = c.directResolver()
+	} else {
+		acc, err = c.resolverClient()
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := accdid.NewMethodResolver()
+	if err := m.Register("acc", acc); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// manager returns a Manager writing through the registrar and reading
+// through the resolver service
+func (c *cli) manager(dryRun bool, wait time.Duration) (*accdid.Manager, error) {
+	registrar, err := accdid.NewRegistrarClient(c.clientOptions(c.profile.Registrar))
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := c.resolverClient()
+	if err != nil {
+		return nil, err
+	}
+	return accdid.NewManager(registrar, resolver, accdid.ManagerOptions{DryRun: dryRun, WaitTimeout: wait}), nil
+}
+
+// readInput reads a file, or standard input for "-"
+func (c *cli) readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(c.stdin)
+	}
+	return os.ReadFile(path)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// isolate points the CLI at a configuration file holding config and clears
+// the ACCDID_* environment
+func isolate(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"ACCDID_PROFILE", "ACCDID_RESOLVER_URL", "ACCDID_REGISTRAR_URL", "ACCDID_NODE_URL", "ACCDID_API_KEY"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("ACCDID_CONFIG", path)
+	return path
+}
+
+// runCLI runs the command line and returns its exit code and output
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := isolate(t, `
+profile: devnet
+profiles:
+  devnet:
+    resolver: https://resolver.devnet.example
+    node: https://devnet.example/v3
+    apiKeyEnv: DEVNET_KEY
+    output: json
+  mainnet:
+    resolver: https://resolver.example
+    timeout: 5s
+`)
+	t.Setenv("DEVNET_KEY", "secret")
+
+	// The profile overrides the defaults
+	p, err := loadProfile("", "", Profile{})
+	if err != nil {
+		t.Fatalf("loadProfile: %v", err)
+	}
+	if p.Resolver != "https://resolver.devnet.example" || p.Registrar != defaultProfile.Registrar || p.APIKey != "secret" || p.Output != "json" {
+		t.Errorf("Unexpected devnet profile %+v", p)
+	}
+
+	// The environment overrides the profile, and flags the environment
+	t.Setenv("ACCDID_PROFILE", "mainnet")
+	t.Setenv("ACCDID_RESOLVER_URL", "https://env.example")
+	p, err = loadProfile(path, "", Profile{Output: "yaml"})
+	if err != nil {
+		t.Fatalf("loadProfile: %v", err)
+	}
+	if p.Resolver != "https://env.example" || p.Timeout != 5*time.Second || p.Output != "yaml" || p.APIKey != "" {
+		t.Errorf("Unexpected mainnet profile %+v", p)
+	}
+	if p, _ := loadProfile(path, "", Profile{Resolver: "https://flag.example"}); p.Resolver != "https://flag.example" {
+		t.Errorf("Expected the flag to win, got %s", p.Resolver)
+	}
+
+	if _, err := loadProfile(path, "testnet", Profile{}); err == nil || !strings.Contains(err.Error(), "devnet, mainnet") {
+		t.Errorf("Expected an unknown profile error listing the profiles, got %v", err)
+	}
+	if _, err := loadProfile(path, "", Profile{Output: "xml"}); err == nil {
+		t.Error("Expected an unknown output format error")
+	}
+	if _, err := loadProfile(filepath.Join(t.TempDir(), "missing.yaml"), "", Profile{}); err == nil {
+		t.Error("Expected an error for a missing --config file")
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := `{"id":"did:acc:alice","alsoKnownAs":["a","b"],"service":[{"id":"#web"}]}`
+	tests := []struct {
+		name, patch, want string
+	}{
+		{"merge", `{"alsoKnownAs":null,"controller":"did:acc:bob"}`,
+			`{"controller":"did:acc:bob","id":"did:acc:alice","service":[{"id":"#web"}]}`},
+		{"add to end", `[{"op":"add","path":"/alsoKnownAs/-","value":"c"}]`,
+			`{"alsoKnownAs":["a","b","c"],"id":"did:acc:alice","service":[{"id":"#web"}]}`},
+		{"insert and remove", `[{"op":"add","path":"/alsoKnownAs/0","value":"z"},{"op":"remove","path":"/alsoKnownAs/2"}]`,
+			`{"alsoKnownAs":["z","a"],"id":"did:acc:alice","service":[{"id":"#web"}]}`},
+		{"replace and copy", `[{"op":"replace","path":"/service/0/id","value":"#home"},{"op":"copy","from":"/alsoKnownAs/0","path":"/controller"}]`,
+			`{"alsoKnownAs":["a","b"],"controller":"a","id":"did:acc:alice","service":[{"id":"#home"}]}`},
+		{"move", `[{"op":"move","from":"/alsoKnownAs","path":"/aka"}]`,
+			`{"aka":["a","b"],"id":"did:acc:alice","service":[{"id":"#web"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPatch([]byte(doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("applyPatch: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	for _, patch := range []string{
+		`[{"op":"test","path":"/id","value":"did:acc:bob"}]`,
+		`[{"op":"replace","path":"/controller","value":"x"}]`,
+		`[{"op":"remove","path":"/alsoKnownAs/2"}]`,
+		`[{"op":"add","path":"/alsoKnownAs/01","value":"x"}]`,
+		`[{"op":"move","from":"/service","path":"/service/0/x"}]`,
+		`"not a patch"`,
+	} {
+		if _, err := applyPatch([]byte(doc), []byte(patch)); err == nil {
+			t.Errorf("Expected %s to fail", patch)
+		}
+	}
+}
+
+func TestKeysGen_Resolve(t *testing.T) {
+	isolate(t, "")
+	out := filepath.Join(t.TempDir(), "alice.key")
+
+	code, stdout, stderr := runCLI(t, "", "keys", "gen", "--out", out, "-o", "json")
+	if code != exitOK {
+		t.Fatalf("keys gen exited %d: %s", code, stderr)
+	}
+	var printed keyFile
+	if err := json.Unmarshal([]byte(stdout), &printed); err != nil || printed.PrivateKey != "" || printed.Type != keys.Ed25519 {
+		t.Errorf("Expected only the public key on stdout, got %s", stdout)
+	}
+	info, err := os.Stat(out)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("Expected a 0600 key file, got %v, %v", info, err)
+	}
+	if code, _, _ := runCLI(t, "", "keys", "gen", "--out", out); code != exitError {
+		t.Errorf("Expected keys gen to refuse to overwrite a key, got exit %d", code)
+	}
+
+	// did:key resolves without any service
+	code, stdout, stderr = runCLI(t, "", "resolve", printed.DIDKey, "--output", "yaml")
+	if code != exitOK {
+		t.Fatalf("resolve exited %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout, "didDocument:\n  ") || !strings.Contains(stdout, "id: "+printed.DIDKey+"\n") {
+		t.Errorf("Expected a block-style YAML resolution, got\n%s", stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "", "resolve"); code != exitUsage || !strings.Contains(stderr, "usage: accdid resolve <did>") {
+		t.Errorf("Expected a usage error, got exit %d: %s", code, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "frobnicate"); code != exitUsage {
+		t.Errorf("Expected an unknown command to exit %d, got %d", exitUsage, code)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	isolate(t, "")
+	key, err := keys.Generate(keys.Ed25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := filepath.Join(t.TempDir(), "message.txt")
+	os.WriteFile(message, []byte("hello"), 0o600)
+	sig, err := key.Sign([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	did := key.Public.DIDKey()
+	keyID := did + "#" + strings.TrimPrefix(did, keys.DIDKeyPrefix)
+
+	code, stdout, stderr := runCLI(t, "", "verify", "--key-id", keyID, "--message", message, "--signature", hex.EncodeToString(sig))
+	if code != exitOK || !strings.Contains(stdout, "VERIFIED") || !strings.Contains(stdout, "true") {
+		t.Errorf("Expected the signature to verify, got exit %d: %s%s", code, stdout, stderr)
+	}
+
+	// A bad signature prints the result and exits 1 without an error message
+	sig[0] ^= 0xff
+	code, stdout, stderr = runCLI(t, "hello", "verify", "--key-id", keyID, "--message", "-", "--signature", hex.EncodeToString(sig), "-o", "json")
+	if code != exitError || !strings.Contains(stdout, `"verified": false`) || stderr != "" {
+		t.Errorf("Expected a failed verification, got exit %d: %s%s", code, stdout, stderr)
+	}
+
+	if code, _, _ := runCLI(t, "", "verify", "--key-id", keyID); code != exitUsage {
+		t.Errorf("Expected a usage error without a message and signature, got exit %d", code)
+	}
+}
+
+// fakeServices serves a DID document as the resolver and counts registrar
+// writes
+type fakeServices struct {
+	doc    string
+	writes int
+}
+
+func (f *fakeServices) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		f.writes++
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	fmt.Fprintf(w, `{"didDocument":%s,"didDocumentMetadata":{"versionId":"v1","contentHash":"h1"}}`, f.doc)
+}
+
+func TestUpdate_DryRun(t *testing.T) {
+	services := &fakeServices{doc: `{"@context":["https://www.w3.org/ns/did/v1"],"id":"did:acc:alice","alsoKnownAs":["https://old.example"]}`}
+	server := httptest.NewServer(services)
+	defer server.Close()
+	isolate(t, fmt.Sprintf("profiles:\n  test:\n    resolver: %s\n    registrar: %s\n", server.URL, server.URL))
+
+	patch := `[{"op":"replace","path":"/alsoKnownAs/0","value":"https://alice.example"}]`
+	code, stdout, stderr := runCLI(t, patch, "--profile", "test", "update", "did:acc:alice", "--patch", "-", "--dry-run", "-o", "json")
+	if code != exitOK {
+		t.Fatalf("update exited %d: %s", code, stderr)
+	}
+	var view struct {
+		DryRun   bool `json:"dryRun"`
+		Document struct {
+			AlsoKnownAs []string `json:"alsoKnownAs"`
+		} `json:"didDocument"`
+		Envelope struct {
+			Endpoint      string `json:"endpoint"`
+			BaseVersionID string `json:"baseVersionId"`
+		} `json:"envelope"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &view); err != nil {
+		t.Fatalf("Invalid output %s: %v", stdout, err)
+	}
+	if !view.DryRun || view.Envelope.Endpoint != "/native/update" || view.Envelope.BaseVersionID != "v1" ||
+		len(view.Document.AlsoKnownAs) != 1 || view.Document.AlsoKnownAs[0] != "https://alice.example" {
+		t.Errorf("Unexpected dry run %s", stdout)
+	}
+	if services.writes != 0 {
+		t.Errorf("A dry run must not write, got %d writes", services.writes)
+	}
+
+	// The patch must not move the document to another DID
+	code, _, stderr = runCLI(t, `{"id":"did:acc:bob"}`, "--profile", "test", "update", "did:acc:alice", "--patch", "-", "--dry-run")
+	if code != exitError || !strings.Contains(stderr, "document id") {
+		t.Errorf("Expected the id change to be refused, got exit %d: %s", code, stderr)
+	}
+}
+
+func TestKeypageShow(t *testing.T) {
+	var scope string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params struct {
+				Scope string `json:"scope"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		scope = req.Params.Scope
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"recordType":"account","account":{"type":"keyPage","url":%q,"creditBalance":2505,"acceptThreshold":1,"version":2,"keys":[{"publicKeyHash":"aa11"}]}}}`, scope)
+	}))
+	defer node.Close()
+	isolate(t, "")
+
+	// A DID shows the first page of its ADI's key book
+	code, stdout, stderr := runCLI(t, "", "keypage", "show", "did:acc:alice.acme", "--node", node.URL)
+	if code != exitOK {
+		t.Fatalf("keypage show exited %d: %s", code, stderr)
+	}
+	if scope != "acc://alice.acme/book/1" || !strings.Contains(stdout, "25.05") || !strings.Contains(stdout, "aa11") {
+		t.Errorf("Unexpected key page of %s:\n%s", scope, stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "", "keypage", "show", "acc://alice.acme/book/1"); code != exitError || !strings.Contains(stderr, "no Accumulate node") {
+		t.Errorf("Expected an error without a node, got exit %d: %s", code, stderr)
+	}
+}
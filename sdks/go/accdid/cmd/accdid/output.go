@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// print writes v in the profile's output format. JSON and YAML show v as it
+// encodes to JSON; the table shows the rows table writes, one tab-separated
+// line each.
+func (c *cli) print(v interface{}, table func(row func(cells ...interface{}))) error {
+	switch c.profile.Output {
+	case "json":
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "yaml":
+		return writeYAML(c.stdout, v)
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	table(func(cells ...interface{}) {
+		text := make([]string, len(cells))
+		for i, cell := range cells {
+			text[i] = fmt.Sprint(cell)
+		}
+		fmt.Fprintln(tw, strings.Join(text, "\t"))
+	})
+	return tw.Flush()
+}
+
+// writeYAML writes v as YAML, keeping the member order of its JSON encoding
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is YAML; decoding into a node keeps the order, and clearing the
+	// styles turns JSON's flow style into block style
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func clearStyle(node *yaml.Node) {
+	// The encoder still quotes strings that would read as another type
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// orDash returns s, or "-" when it is empty, for table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// applyPatch applies patch to the JSON document doc: a JSON array is a JSON
+// Patch (RFC 6902), an object a JSON Merge Patch (RFC 7396)
+func applyPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(patch)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var ops []patchOp
+		if err := json.Unmarshal(trimmed, &ops); err != nil {
+			return nil, fmt.Errorf("invalid JSON Patch: %w", err)
+		}
+		for i, op := range ops {
+			var err error
+			if target, err = op.apply(target); err != nil {
+				return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var merge interface{}
+		if err := json.Unmarshal(trimmed, &merge); err != nil {
+			return nil, fmt.Errorf("invalid JSON Merge Patch: %w", err)
+		}
+		target = mergePatch(target, merge)
+	default:
+		return nil, fmt.Errorf("a patch must be a JSON Patch array or a JSON Merge Patch object")
+	}
+	return json.Marshal(target)
+}
+
+// mergePatch applies a JSON Merge Patch: members of patch replace those of
+// target, recursively for objects, and null removes them
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
+
+// patchOp is one operation of a JSON Patch
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (op patchOp) apply(doc interface{}) (interface{}, error) {
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = get(doc, from); err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("cannot move %s into itself", op.From)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		return set(doc, path, value)
+	case "test":
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	}
+	return add(doc, path, value)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index token; "-" is the end of the array when
+// allowEnd is set
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("cannot descend into %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// add sets the value at path, inserting into arrays; it returns the new
+// document, since replacing the root or growing an array changes it
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		grown := append(node[:i:i], append([]interface{}{value}, node[i:]...)...)
+		return set(doc, path[:len(path)-1], grown)
+	}
+	return nil, fmt.Errorf("cannot add to %q", last)
+}
+
+// set replaces the existing value at path and returns the new document
+func set(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+		return doc, nil
+	}
+	return nil, fmt.Errorf("cannot set %q", last)
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("no member %q", last)
+		}
+		delete(node, last)
+		return doc, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		shrunk := append(node[:i:i], node[i+1:]...)
+		return set(doc, path[:len(path)-1], shrunk)
+	}
+	return nil, fmt.Errorf("cannot remove from %q", last)
+}
+
+func deepCopy(v interface{}) interface{} {
+	data, _ := json.Marshal(v)
+	var copied interface{}
+	json.Unmarshal(data, &copied)
+	return copied
+}
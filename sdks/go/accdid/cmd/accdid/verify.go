@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid"
+)
+
+// errNotVerified makes verify exit with exitError once the result is printed
+var errNotVerified = errors.New("signature not verified")
+
+// verificationView is a verification result as printed
+type verificationView struct {
+	Verified     bool       `json:"verified"`
+	Failure      string     `json:"failure,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+	Format       string     `json:"format"`
+	Algorithm    string     `json:"algorithm,omitempty"`
+	DID          string     `json:"did,omitempty"`
+	KeyID        string     `json:"keyId,omitempty"`
+	Relationship string     `json:"relationship,omitempty"`
+	VersionTime  *time.Time `json:"versionTime,omitempty"`
+	Deactivated  bool       `json:"deactivated,omitempty"`
+}
+
+func (c *cli) verify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	jws := fs.String("jws", "", "compact JWS to verify, or a file holding it; the key is its kid")
+	document := fs.String("document", "", "JSON document with an eddsa-jcs-2022 Data Integrity proof, or - for standard input")
+	keyID := fs.String("key-id", "", "key of a raw signature, a DID URL such as did:acc:alice#key-1")
+	message := fs.String("message", "", "file holding the signed message, or - for standard input; the payload of a detached JWS")
+	signature := fs.String("signature", "", "raw signature, hex or base64url")
+	relationship := fs.String("relationship", "", "verification relationship the key must be in (default assertionMethod, or the proof purpose)")
+	at := fs.String("at", "", "check the key against the document as it was at this RFC 3339 time")
+	direct := fs.Bool("direct", false, "read did:acc documents from the Accumulate node instead of the resolver service")
+	if _, err := c.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	opts := accdid.VerifyOptions{Relationship: accdid.Relationship(*relationship)}
+	if *at != "" {
+		versionTime, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return usagef("invalid --at time %q: use RFC 3339, e.g. 2025-01-01T00:00:00Z", *at)
+		}
+		opts.VersionTime = &versionTime
+	}
+
+	var payload []byte
+	if *message != "" {
+		var err error
+		if payload, err = c.readInput(*message); err != nil {
+			return err
+		}
+	}
+
+	resolver, err := c.methodResolver(*direct)
+	if err != nil {
+		return err
+	}
+	verifier := accdid.NewVerifier(resolver)
+
+	var result *accdid.VerificationResult
+	switch {
+	case *jws != "":
+		token := *jws
+		if strings.Count(token, ".") != 2 {
+			data, err := c.readInput(token)
+			if err != nil {
+				return err
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		result, err = verifier.VerifyJWS(ctx, token, payload, opts)
+	case *document != "":
+		data, readErr := c.readInput(*document)
+		if readErr != nil {
+			return readErr
+		}
+		result, err = verifier.VerifyDataIntegrity(ctx, data, opts)
+	case *keyID != "" && *signature != "" && payload != nil:
+		sig, decodeErr := decodeSignature(*signature)
+		if decodeErr != nil {
+			return usagef("invalid --signature: %v", decodeErr)
+		}
+		result, err = verifier.Verify(ctx, *keyID, payload, sig, opts)
+	default:
+		return usagef("give --jws, --document, or --key-id with --message and --signature")
+	}
+	if err != nil {
+		return err
+	}
+
+	view := verificationView{
+		Verified:     result.Verified,
+		Failure:      string(result.Failure),
+		Reason:       result.Reason,
+		Format:       string(result.Format),
+		Algorithm:    result.Algorithm,
+		DID:          result.DID,
+		KeyID:        result.KeyID,
+		Relationship: string(result.Relationship),
+		VersionTime:  result.VersionTime,
+		Deactivated:  result.Deactivated,
+	}
+	if err := c.print(view, func(row func(...interface{})) {
+		row("VERIFIED", view.Verified)
+		if !view.Verified {
+			row("FAILURE", view.Failure)
+			row("REASON", view.Reason)
+		}
+		row("FORMAT", view.Format)
+		row("ALGORITHM", orDash(view.Algorithm))
+		row("KEY", orDash(view.KeyID))
+		row("RELATIONSHIP", orDash(view.Relationship))
+	}); err != nil {
+		return err
+	}
+	if !result.Verified {
+		return errNotVerified
+	}
+	return nil
+}
+
+// decodeSignature decodes a hex or base64url signature
+func decodeSignature(s string) ([]byte, error) {
+	if sig, err := hex.DecodeString(s); err == nil {
+		return sig, nil
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,318 @@
+package accdid
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCredentials is returned when a CredentialProvider cannot authenticate a
+// request, e.g. because the token endpoint refused the client
+var ErrCredentials = errors.New("credentials unavailable")
+
+// CredentialProvider authenticates outgoing requests. Apply is called for
+// every attempt, retries included, so providers can refresh tokens or sign
+// each attempt afresh.
+type CredentialProvider interface {
+	Apply(req *http.Request) error
+}
+
+// CredentialInvalidator is implemented by providers whose credentials can go
+// stale. After a 401 response the client invalidates the credential and
+// sends the request once more.
+type CredentialInvalidator interface {
+	Invalidate()
+}
+
+// CredentialProviderFunc adapts a function to CredentialProvider
+type CredentialProviderFunc func(req *http.Request) error
+
+// Apply calls f
+func (f CredentialProviderFunc) Apply(req *http.Request) error {
+	return f(req)
+}
+
+// StaticKey sends a fixed API key as "Authorization: Bearer <key>"
+func StaticKey(key string) CredentialProvider {
+	return CredentialProviderFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+key)
+		return nil
+	})
+}
+
+// RotatingKey sends an API key read from source, e.g. a secrets manager or a
+// mounted file, and reads it again every refresh interval or after the
+// registrar rejects it
+type RotatingKey struct {
+	source  func(context.Context) (string, error)
+	refresh time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	key     string
+	fetched time.Time
+}
+
+// NewRotatingKey creates a provider reading its key from source; a refresh of
+// 0 keeps the key until it is rejected
+func NewRotatingKey(source func(context.Context) (string, error), refresh time.Duration) *RotatingKey {
+	return &RotatingKey{source: source, refresh: refresh, now: time.Now}
+}
+
+// Apply sets the current key
+func (k *RotatingKey) Apply(req *http.Request) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	if k.key == "" || (k.refresh > 0 && now.Sub(k.fetched) >= k.refresh) {
+		key, err := k.source(req.Context())
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return errors.New("key source returned an empty key")
+		}
+		k.key, k.fetched = key, now
+	}
+	req.Header.Set("Authorization", "Bearer "+k.key)
+	return nil
+}
+
+// Invalidate makes the next request read the key again
+func (k *RotatingKey) Invalidate() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.key = ""
+}
+
+// ClientCredentialsConfig configures an OAuth2 client credentials grant
+// (RFC 6749 section 4.4)
+type ClientCredentialsConfig struct {
+	// TokenURL is the authorization server's token endpoint
+	TokenURL string
+
+	// ClientID and ClientSecret authenticate the client with HTTP Basic auth
+	ClientID     string
+	ClientSecret string
+
+	// Scopes requested for the token (optional)
+	Scopes []string
+
+	// HTTP client for the token endpoint (optional, defaults to http.DefaultClient)
+	HTTP *http.Client
+}
+
+// tokenExpiryLeeway renews tokens this long before they expire
+const tokenExpiryLeeway = 30 * time.Second
+
+// ClientCredentials sends an OAuth2 access token obtained with the client
+// credentials grant, and fetches a new one shortly before it expires or after
+// it is rejected
+type ClientCredentials struct {
+	cfg ClientCredentialsConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time // zero when the server gave no lifetime
+}
+
+// NewClientCredentials creates an OAuth2 client credentials provider
+func NewClientCredentials(cfg ClientCredentialsConfig) *ClientCredentials {
+	if cfg.HTTP == nil {
+		cfg.HTTP = http.DefaultClient
+	}
+	return &ClientCredentials{cfg: cfg, now: time.Now}
+}
+
+// Apply sets a current access token
+func (c *ClientCredentials) Apply(req *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token == "" || (!c.expires.IsZero() && !c.now().Before(c.expires)) {
+		if err := c.fetch(req.Context()); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return nil
+}
+
+// Invalidate makes the next request fetch a new token
+func (c *ClientCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// fetch requests a token; the caller holds c.mu
+func (c *ClientCredentials) fetch(ctx context.Context) error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	resp, err := c.cfg.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	json.Unmarshal(body, &token)
+	if resp.StatusCode != http.StatusOK {
+		if token.Error != "" {
+			return fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+		}
+		return fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	if token.AccessToken == "" {
+		return errors.New("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+
+	c.token, c.expires = token.AccessToken, time.Time{}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		c.expires = c.now().Add(lifetime - min(tokenExpiryLeeway, lifetime/2))
+	}
+	return nil
+}
+
+// DIDAuthScheme is the Authorization scheme of DID-Auth signed requests
+const DIDAuthScheme = "DIDAuth"
+
+// DIDAuthSigner signs each request with an Ed25519 key of a DID controller,
+// using the registrar's DID-Auth scheme: a detached JWS over the body, bound
+// to the method, path and time, sent as "Authorization: DIDAuth ...". The
+// registrar accepts it for updates and deactivations in place of an API key.
+type DIDAuthSigner struct {
+	keyID string
+	key   ed25519.PrivateKey
+	now   func() time.Time
+}
+
+// NewDIDAuthSigner creates a signer. keyID is either a verification method of
+// the DID's current document (did:acc:alice.acme#key-1), or the ADI's key
+// page (acc://alice.acme/book/1) holding the key, in which case the public
+// key is sent along.
+func NewDIDAuthSigner(keyID string, key ed25519.PrivateKey) *DIDAuthSigner {
+	return &DIDAuthSigner{keyID: keyID, key: key, now: time.Now}
+}
+
+// didAuthHeader is the protected header of a DID-Auth signature
+type didAuthHeader struct {
+	Alg string      `json:"alg"`
+	Kid string      `json:"kid"`
+	JWK *didAuthJWK `json:"jwk,omitempty"`
+	Iat int64       `json:"iat"`
+	Htm string      `json:"htm"`
+	Htu string      `json:"htu"`
+	Jti string      `json:"jti"`
+}
+
+type didAuthJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// Apply signs req. A fresh jti makes every attempt's signature unique, since
+// the registrar accepts each signature once.
+func (s *DIDAuthSigner) Apply(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	header := didAuthHeader{
+		Alg: "EdDSA",
+		Kid: s.keyID,
+		Iat: s.now().Unix(),
+		Htm: req.Method,
+		Htu: req.URL.Path,
+		Jti: defaultRequestID(),
+	}
+	if strings.HasPrefix(s.keyID, "acc://") {
+		pub := s.key.Public().(ed25519.PublicKey)
+		header.JWK = &didAuthJWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub)}
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(encoded)
+	signature := ed25519.Sign(s.key, []byte(protected+"."+base64.RawURLEncoding.EncodeToString(body)))
+	req.Header.Set("Authorization", DIDAuthScheme+" "+protected+".."+base64.RawURLEncoding.EncodeToString(signature))
+	return nil
+}
+
+// credentialMiddleware applies credentials to each attempt. A 401 response
+// to a provider whose credentials can go stale invalidates them and sends
+// the request once more.
+func credentialMiddleware(provider CredentialProvider) Middleware {
+	return func(next Doer) Doer {
+		return doerFunc(func(req *http.Request) (*http.Response, error) {
+			if err := provider.Apply(req); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrCredentials, err)
+			}
+			resp, err := next.Do(req)
+			invalidator, ok := provider.(CredentialInvalidator)
+			if err != nil || !ok || resp.StatusCode != http.StatusUnauthorized {
+				return resp, err
+			}
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return resp, nil
+			}
+
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return resp, nil
+				}
+			}
+			invalidator.Invalidate()
+			if err := provider.Apply(retry); err != nil {
+				return resp, nil
+			}
+			resp.Body.Close()
+			return next.Do(retry)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package accdid
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAPIKey_SentAsBearer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Expected Authorization 'Bearer secret', got %q", got)
+		}
+		w.Write([]byte(`{"transactionId":"tx-1"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewRegistrarClient(ClientOptions{BaseURL: server.URL, APIKey: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.Deactivate(context.Background(), NativeDeactivateRequest{DID: "did:acc:alice"}); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+}
+
+func TestRotatingKey_RefreshedAfter401(t *testing.T) {
+	var current atomic.Value
+	current.Store("old")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "did:acc:alice") {
+			t.Errorf("Expected the body on every attempt, got %q", body)
+		}
+		if r.Header.Get("Authorization") != "Bearer "+current.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"transactionId":"tx-1"}`))
+	}))
+	defer server.Close()
+
+	var reads int32
+	source := func(context.Context) (string, error) {
+		atomic.AddInt32(&reads, 1)
+		if atomic.LoadInt32(&reads) == 1 {
+			return "old", nil
+		}
+		return "new", nil
+	}
+	client, err := NewRegistrarClient(ClientOptions{BaseURL: server.URL, Credentials: NewRotatingKey(source, 0)})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"}); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	current.Store("new")
+	if _, err := client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"}); err != nil {
+		t.Fatalf("Deactivate with a rotated key failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&reads); n != 2 {
+		t.Errorf("Expected the key to be read twice, got %d", n)
+	}
+
+	failing := NewRotatingKey(func(context.Context) (string, error) { return "", errors.New("vault sealed") }, 0)
+	client, _ = NewRegistrarClient(ClientOptions{BaseURL: server.URL, Credentials: failing})
+	if _, err := client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"}); !errors.Is(err, ErrCredentials) {
+		t.Errorf("Expected ErrCredentials, got %v", err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	var issued int32
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		r.ParseForm()
+		if id != "registrar-client" || secret != "s3cret" || r.Form.Get("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.Form.Get("scope") != "did:write did:read" {
+			t.Errorf("Unexpected scope %q", r.Form.Get("scope"))
+		}
+		n := atomic.AddInt32(&issued, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token-" + string(rune('0'+n)), "token_type": "Bearer", "expires_in": 3600,
+		})
+	}))
+	defer tokens.Close()
+
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"transactionId":"tx-1"}`))
+	}))
+	defer server.Close()
+
+	creds := NewClientCredentials(ClientCredentialsConfig{
+		TokenURL:     tokens.URL,
+		ClientID:     "registrar-client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"did:write", "did:read"},
+	})
+	client, err := NewRegistrarClient(ClientOptions{BaseURL: server.URL, Credentials: creds})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"}); err != nil {
+			t.Fatalf("Deactivate failed: %v", err)
+		}
+	}
+	if len(seen) != 2 || seen[0] != "Bearer token-1" || seen[1] != "Bearer token-1" {
+		t.Errorf("Expected the cached token on both requests, got %v", seen)
+	}
+
+	creds.Invalidate()
+	client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"})
+	if seen[2] != "Bearer token-2" {
+		t.Errorf("Expected a new token after Invalidate, got %s", seen[2])
+	}
+
+	bad := NewClientCredentials(ClientCredentialsConfig{TokenURL: tokens.URL, ClientID: "intruder"})
+	client, _ = NewRegistrarClient(ClientOptions{BaseURL: server.URL, Credentials: bad})
+	_, err = client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"})
+	if !errors.Is(err, ErrCredentials) || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("Expected ErrCredentials with the OAuth error, got %v", err)
+	}
+}
+
+func TestDIDAuthSigner(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var headers []didAuthHeader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		credential, ok := strings.CutPrefix(r.Header.Get("Authorization"), DIDAuthScheme+" ")
+		protected, signature, found := strings.Cut(credential, "..")
+		if !ok || !found {
+			t.Errorf("Expected a detached DIDAuth credential, got %q", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(signature)
+		if !ed25519.Verify(pub, []byte(protected+"."+base64.RawURLEncoding.EncodeToString(body)), sig) {
+			t.Errorf("Signature does not verify")
+		}
+		raw, _ := base64.RawURLEncoding.DecodeString(protected)
+		var header didAuthHeader
+		json.Unmarshal(raw, &header)
+		headers = append(headers, header)
+		w.Write([]byte(`{"transactionId":"tx-1"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client, _ := NewRegistrarClient(ClientOptions{BaseURL: server.URL, Credentials: NewDIDAuthSigner("did:acc:alice#key-1", priv)})
+	for i := 0; i < 2; i++ {
+		if _, err := client.Update(ctx, NativeUpdateRequest{DID: "did:acc:alice", Patch: json.RawMessage(`{}`)}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+	}
+	client, _ = NewRegistrarClient(ClientOptions{BaseURL: server.URL, Credentials: NewDIDAuthSigner("acc://alice/book/1", priv)})
+	if _, err := client.Deactivate(ctx, NativeDeactivateRequest{DID: "did:acc:alice"}); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+
+	first := headers[0]
+	if first.Alg != "EdDSA" || first.Kid != "did:acc:alice#key-1" || first.Htm != http.MethodPost || first.Htu != "/native/update" || first.JWK != nil {
+		t.Errorf("Unexpected header %+v", first)
+	}
+	if first.Jti == headers[1].Jti {
+		t.Error("Expected a fresh jti per request")
+	}
+	page := headers[2]
+	if page.JWK == nil || page.JWK.X != base64.RawURLEncoding.EncodeToString(pub) {
+		t.Errorf("Expected the public key with a key page kid, got %+v", page.JWK)
+	}
+}
@@ -0,0 +1,421 @@
+package accdid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/httpx"
+)
+
+// ResolveOrder selects which entry of a DID's data account is current
+type ResolveOrder string
+
+const (
+	// OrderSequence takes the entry with the highest chain sequence, then
+	// the latest timestamp, then the greatest content hash
+	OrderSequence ResolveOrder = "sequence"
+
+	// OrderTimestamp takes the latest entry, then the greatest content hash
+	OrderTimestamp ResolveOrder = "timestamp"
+)
+
+// directResolverName is reported as the resolver in resolution metadata
+const directResolverName = "accdid-direct"
+
+// DirectResolver resolves DIDs by reading their data accounts from an
+// Accumulate JSON-RPC v3 endpoint, applying the same deterministic algorithm
+// as the resolver service, so no intermediate resolver has to be deployed or
+// trusted.
+//
+// Like the resolver service, a DID whose data account cannot be read is
+// reported as not found; failures to reach the node are reported as such.
+type DirectResolver struct {
+	endpoint string
+	doer     Doer
+	logger   Logger
+	order    ResolveOrder
+	pageSize uint64
+}
+
+// NewDirectResolver creates a resolver reading from the JSON-RPC v3 endpoint
+// at opts.BaseURL, e.g. https://mainnet.accumulatenetwork.io/v3. Timeouts,
+// retries, credentials and logging apply as for the other clients.
+func NewDirectResolver(opts ClientOptions) (*DirectResolver, error) {
+	if opts.BaseURL == "" {
+		return nil, fmt.Errorf("BaseURL is required")
+	}
+
+	opts = applyDefaults(opts)
+
+	var doer Doer = opts.HTTP
+	if opts.Credentials != nil {
+		doer = credentialMiddleware(opts.Credentials)(doer)
+	}
+	doer, err := withRetries(doer, opts.Retries)
+	if err != nil {
+		return nil, err
+	}
+	doer = WithMiddleware(doer,
+		headerMiddleware(opts.IdempotencyKey, opts.RequestID),
+		timeoutMiddleware(opts.Timeout),
+	)
+
+	return &DirectResolver{
+		endpoint: opts.BaseURL,
+		doer:     doer,
+		logger:   opts.Logger,
+		order:    OrderSequence,
+		pageSize: 100,
+	}, nil
+}
+
+// WithOrder sets the entry ordering (default OrderSequence). It must match
+// the resolver service's resolve.order for both to give the same results.
+func (r *DirectResolver) WithOrder(order ResolveOrder) *DirectResolver {
+	r.order = order
+	return r
+}
+
+// Resolve resolves the current DID document
+func (r *DirectResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	return r.resolve(ctx, did, nil)
+}
+
+// ResolveAt resolves the DID document as it was at versionTime
+func (r *DirectResolver) ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error) {
+	return r.resolve(ctx, did, &versionTime)
+}
+
+// HistoryEntry is one entry of a DID's data account
+type HistoryEntry struct {
+	Sequence    uint64    `json:"sequence"`
+	Timestamp   time.Time `json:"timestamp"` // zero when the node reports none
+	ContentHash string    `json:"contentHash"`
+	VersionID   string    `json:"versionId,omitempty"`
+	Deactivated bool      `json:"deactivated,omitempty"`
+
+	// Valid is set for entries holding a JSON object; Resolve skips others
+	Valid bool `json:"valid"`
+
+	// Current marks the entry Resolve selects
+	Current bool `json:"current,omitempty"`
+}
+
+// History lists every entry of the DID's data account, oldest first
+func (r *DirectResolver) History(ctx context.Context, did string) ([]HistoryEntry, error) {
+	adi, path, err := ParseDID(did)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID: %w", err)
+	}
+	entries, err := r.dataEntries(ctx, "acc://"+adi+"/"+path)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := r.selectLatest(did, entries)
+	history := make([]HistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		h := HistoryEntry{
+			Sequence:    e.sequence,
+			Timestamp:   e.timestamp,
+			ContentHash: e.contentHash,
+			Current:     e == current,
+		}
+		var doc map[string]interface{}
+		if json.Unmarshal(e.data, &doc) == nil {
+			h.Valid = true
+			h.VersionID, _ = doc["versionId"].(string)
+			h.Deactivated, _ = doc["deactivated"].(bool)
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
+// KeyPage is the state of an Accumulate key page
+type KeyPage struct {
+	URL             string         `json:"url"`
+	Version         uint64         `json:"version"`
+	CreditBalance   uint64         `json:"creditBalance"` // credits x 100
+	AcceptThreshold uint64         `json:"acceptThreshold"`
+	Keys            []KeyPageEntry `json:"keys"`
+}
+
+// KeyPageEntry is a key of a key page. The network keeps the SHA-256 hash of
+// each key rather than the key itself.
+type KeyPageEntry struct {
+	PublicKeyHash string `json:"publicKeyHash"` // hex
+	Delegate      string `json:"delegate,omitempty"`
+	LastUsedOn    uint64 `json:"lastUsedOn,omitempty"`
+}
+
+// KeyPage reads the key page at pageURL, e.g. acc://alice.acme/book/1
+func (r *DirectResolver) KeyPage(ctx context.Context, pageURL string) (*KeyPage, error) {
+	if !strings.HasPrefix(pageURL, "acc://") {
+		return nil, fmt.Errorf("key page URL %q must start with acc://", pageURL)
+	}
+	var record struct {
+		Account struct {
+			Type string `json:"type"`
+			KeyPage
+		} `json:"account"`
+	}
+	err := r.call(ctx, "query", map[string]interface{}{
+		"scope": pageURL,
+		"query": map[string]interface{}{"queryType": "default"},
+	}, &record)
+	if err != nil {
+		return nil, err
+	}
+	if record.Account.Type != "keyPage" {
+		return nil, fmt.Errorf("%s is not a key page (type %q)", pageURL, record.Account.Type)
+	}
+	page := record.Account.KeyPage
+	return &page, nil
+}
+
+// dataEntry is one entry of a data account with its chain metadata
+type dataEntry struct {
+	data        []byte
+	sequence    uint64 // 1-based position in the data chain
+	timestamp   time.Time
+	contentHash string
+}
+
+func (r *DirectResolver) resolve(ctx context.Context, did string, versionTime *time.Time) (*ResolutionResult, error) {
+	adi, path, err := ParseDID(did)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID: %w", err)
+	}
+	account := "acc://" + adi + "/" + path
+
+	r.logger.Debugf("Resolving DID %s from %s", did, account)
+	entries, err := r.dataEntries(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries written after versionTime are dropped; entries without a
+	// timestamp are kept
+	if versionTime != nil {
+		kept := entries[:0]
+		for _, e := range entries {
+			if e.timestamp.IsZero() || !e.timestamp.After(*versionTime) {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+
+	selected, doc := r.selectLatest(did, entries)
+	if selected == nil {
+		return nil, fmt.Errorf("%w: no DID document in %s", ErrNotFound, account)
+	}
+	if deactivated, _ := doc["deactivated"].(bool); deactivated {
+		return nil, fmt.Errorf("%w: %s", ErrGoneDeactivated, did)
+	}
+
+	var document Document
+	if err := json.Unmarshal(selected.data, &document); err != nil {
+		return nil, fmt.Errorf("entry %d of %s is not a DID document: %w", selected.sequence, account, err)
+	}
+
+	// The metadata matches what the resolver service reports
+	sequence := selected.sequence
+	docMeta := map[string]interface{}{
+		"updated":     selected.timestamp.Format(time.RFC3339Nano),
+		"canonicalId": did,
+		"contentHash": selected.contentHash,
+		"sequence":    float64(sequence),
+	}
+	resMeta := map[string]interface{}{
+		"contentType": "application/did+json",
+		"retrieved":   time.Now().UTC().Format(time.RFC3339Nano),
+		"resolver":    directResolverName,
+	}
+	if versionID, _ := doc["versionId"].(string); versionID != "" {
+		docMeta["versionId"] = versionID
+		resMeta["versionId"] = versionID
+	}
+
+	r.logger.Debugf("Resolved DID %s at sequence %d", did, sequence)
+	return &ResolutionResult{DIDDocument: &document, Metadata: resMeta, DocumentMetadata: docMeta}, nil
+}
+
+// selectLatest returns the current entry among those holding a JSON object,
+// and that object
+func (r *DirectResolver) selectLatest(did string, entries []*dataEntry) (*dataEntry, map[string]interface{}) {
+	var valid []*dataEntry
+	for _, e := range entries {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(e.data, &doc); err != nil {
+			r.logger.Warnf("Skipping malformed entry %d of %s: %v", e.sequence, did, err)
+			continue
+		}
+		valid = append(valid, e)
+	}
+	if len(valid) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(valid, func(i, j int) bool {
+		return r.before(valid[i], valid[j])
+	})
+	latest := valid[len(valid)-1]
+
+	var doc map[string]interface{}
+	json.Unmarshal(latest.data, &doc)
+	return latest, doc
+}
+
+// before reports whether entry a precedes entry b in the configured order
+func (r *DirectResolver) before(a, b *dataEntry) bool {
+	if r.order == OrderSequence && a.sequence != b.sequence {
+		return a.sequence < b.sequence
+	}
+	if (r.order == OrderSequence || r.order == OrderTimestamp) && !a.timestamp.Equal(b.timestamp) {
+		return a.timestamp.Before(b.timestamp)
+	}
+	return a.contentHash < b.contentHash
+}
+
+// JSON-RPC v3 messages, reduced to the members resolution reads
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type rpcRecordRange struct {
+	Records []rpcChainEntry `json:"records"`
+	Start   uint64          `json:"start"`
+	Total   uint64          `json:"total"`
+}
+
+type rpcChainEntry struct {
+	Index         uint64     `json:"index"`
+	LastBlockTime *time.Time `json:"lastBlockTime"`
+	Value         *struct {
+		Message *struct {
+			Transaction *struct {
+				Body struct {
+					Type  string `json:"type"`
+					Entry *struct {
+						Data []string `json:"data"` // hex
+					} `json:"entry"`
+				} `json:"body"`
+			} `json:"transaction"`
+		} `json:"message"`
+	} `json:"value"`
+}
+
+// dataEntries reads every WriteData entry of account, oldest first
+func (r *DirectResolver) dataEntries(ctx context.Context, account string) ([]*dataEntry, error) {
+	var entries []*dataEntry
+	for start := uint64(0); ; start += r.pageSize {
+		var page rpcRecordRange
+		err := r.call(ctx, "query", map[string]interface{}{
+			"scope": account,
+			"query": map[string]interface{}{
+				"queryType": "data",
+				"range":     map[string]interface{}{"start": start, "count": r.pageSize, "expand": true},
+			},
+		}, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range page.Records {
+			data, ok := writeDataEntry(record)
+			if !ok {
+				continue
+			}
+			hash := sha256.Sum256(data)
+			entry := &dataEntry{
+				data:        data,
+				sequence:    record.Index + 1,
+				contentHash: hex.EncodeToString(hash[:]),
+			}
+			if record.LastBlockTime != nil {
+				entry.timestamp = record.LastBlockTime.UTC()
+			}
+			entries = append(entries, entry)
+		}
+
+		if len(page.Records) == 0 || start+uint64(len(page.Records)) >= page.Total {
+			break
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no data entries in %s", ErrNotFound, account)
+	}
+	return entries, nil
+}
+
+// writeDataEntry returns the first data item of a WriteData record
+func writeDataEntry(record rpcChainEntry) ([]byte, bool) {
+	if record.Value == nil || record.Value.Message == nil || record.Value.Message.Transaction == nil {
+		return nil, false
+	}
+	body := record.Value.Message.Transaction.Body
+	if body.Type != "writeData" || body.Entry == nil || len(body.Entry.Data) == 0 {
+		return nil, false
+	}
+	data, err := hex.DecodeString(body.Entry.Data[0])
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	return data, true
+}
+
+// call sends a JSON-RPC request and decodes its result into out. Errors
+// reported by the node mean the account cannot be read.
+func (r *DirectResolver) call(ctx context.Context, method string, params, out interface{}) error {
+	var resp rpcResponse
+	status, body, err := httpx.DoJSON(ctx, r.doer, http.MethodPost, r.endpoint, "", rpcRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  method,
+		Params:  params,
+	}, &resp)
+	if status >= 400 {
+		return decodeHTTPError(&http.Response{StatusCode: status, Status: fmt.Sprintf("%d", status)}, body)
+	}
+	if err != nil {
+		_, classified := classifyError(err)
+		return classified
+	}
+
+	if resp.Error != nil {
+		return fmt.Errorf("%w: %s (JSON-RPC error %d)", ErrNotFound, resp.Error.Message, resp.Error.Code)
+	}
+	if len(resp.Result) == 0 || bytes.Equal(resp.Result, []byte("null")) {
+		return fmt.Errorf("%w: empty %s result", ErrNotFound, method)
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package accdid
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNode answers JSON-RPC v3 data queries from a map of account entries
+type fakeNode struct {
+	accounts map[string][]string // account URL to entry data, oldest first
+	times    []time.Time         // block time of each entry index
+	states   map[string]string   // account URL to account JSON, for default queries
+	queries  int
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string `json:"method"`
+		Params struct {
+			Scope string `json:"scope"`
+			Query struct {
+				QueryType string `json:"queryType"`
+				Range     struct {
+					Start uint64 `json:"start"`
+					Count uint64 `json:"count"`
+				} `json:"range"`
+			} `json:"query"`
+		} `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	n.queries++
+
+	if state, ok := n.states[req.Params.Scope]; ok && req.Params.Query.QueryType == "default" {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"recordType":"account","account":%s}}`, state)
+		return
+	}
+
+	entries, ok := n.accounts[req.Params.Scope]
+	if req.Method != "query" || req.Params.Query.QueryType != "data" || !ok {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-33404,"message":"%s not found"}}`, req.Params.Scope)
+		return
+	}
+
+	var records []string
+	for i := req.Params.Query.Range.Start; i < uint64(len(entries)) && i < req.Params.Query.Range.Start+req.Params.Query.Range.Count; i++ {
+		var blockTime string
+		if int(i) < len(n.times) {
+			blockTime = fmt.Sprintf(`,"lastBlockTime":%q`, n.times[i].Format(time.RFC3339))
+		}
+		records = append(records, fmt.Sprintf(`{"recordType":"chainEntry","index":%d,"entry":"00"%s,"value":{"recordType":"message","message":{"type":"transaction","transaction":{"header":{"principal":%q},"body":{"type":"writeData","entry":{"type":"doubleHash","data":[%q]}}}}}}`,
+			i, blockTime, req.Params.Scope, hex.EncodeToString([]byte(entries[i]))))
+	}
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":{"recordType":"range","records":[%s],"start":%d,"total":%d}}`,
+		strings.Join(records, ","), req.Params.Query.Range.Start, len(entries))
+}
+
+func newDirectResolver(t *testing.T, node *fakeNode) *DirectResolver {
+	t.Helper()
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	r, err := NewDirectResolver(ClientOptions{BaseURL: server.URL + "/v3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.pageSize = 2
+	return r
+}
+
+func TestDirectResolver_Resolve(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	node := &fakeNode{
+		accounts: map[string][]string{
+			"acc://alice.acme/did": {
+				`{"id":"did:acc:alice.acme","versionId":"1"}`,
+				`not json`,
+				`{"id":"did:acc:alice.acme","versionId":"2","service":[{"id":"#web","type":"LinkedDomains","serviceEndpoint":"https://alice.example"}]}`,
+				`{"id":"did:acc:alice.acme","versionId":"3"}`,
+				`{broken`,
+			},
+			"acc://bob.acme/profile": {`{"id":"did:acc:bob.acme/profile"}`},
+		},
+		times: []time.Time{day(1), day(2), day(3), day(4), day(5)},
+	}
+	r := newDirectResolver(t, node)
+	ctx := context.Background()
+
+	// The latest valid entry wins, read over several pages
+	res, err := r.Resolve(ctx, "did:acc:alice.acme")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if res.DocumentMetadata["versionId"] != "3" || res.DocumentMetadata["sequence"] != float64(4) {
+		t.Errorf("Expected version 3 at sequence 4, got %v", res.DocumentMetadata)
+	}
+	if res.DocumentMetadata["updated"] != "2024-03-04T00:00:00Z" || len(res.DocumentMetadata["contentHash"].(string)) != 64 {
+		t.Errorf("Unexpected metadata %v", res.DocumentMetadata)
+	}
+	if res.DIDDocument.ID != "did:acc:alice.acme" || node.queries != 3 {
+		t.Errorf("Expected the document after 3 pages, got %+v after %d queries", res.DIDDocument, node.queries)
+	}
+
+	// Entries after versionTime are ignored
+	res, err = r.ResolveAt(ctx, "did:acc:alice.acme", day(3).Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ResolveAt: %v", err)
+	}
+	if _, ok := res.DIDDocument.FindService("web"); !ok || res.DocumentMetadata["versionId"] != "2" {
+		t.Errorf("Expected version 2, got %v", res.DocumentMetadata)
+	}
+	if _, err := r.ResolveAt(ctx, "did:acc:alice.acme", day(1).Add(-time.Hour)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected no version before the first entry, got %v", err)
+	}
+
+	// A path selects another data account
+	if res, err := r.Resolve(ctx, "did:acc:bob.acme/profile"); err != nil || res.DIDDocument.ID != "did:acc:bob.acme/profile" {
+		t.Errorf("Expected bob's profile, got %+v, %v", res, err)
+	}
+
+	if _, err := r.Resolve(ctx, "did:acc:nobody.acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := r.Resolve(ctx, "did:web:example.com"); !errors.Is(err, ErrInvalidDID) {
+		t.Errorf("Expected ErrInvalidDID, got %v", err)
+	}
+}
+
+func TestDirectResolver_Deactivated(t *testing.T) {
+	node := &fakeNode{accounts: map[string][]string{
+		"acc://carol.acme/did": {`{"id":"did:acc:carol.acme"}`, `{"id":"did:acc:carol.acme","deactivated":true}`},
+	}}
+	r := newDirectResolver(t, node)
+
+	if _, err := r.Resolve(context.Background(), "did:acc:carol.acme"); !errors.Is(err, ErrGoneDeactivated) {
+		t.Errorf("Expected ErrGoneDeactivated, got %v", err)
+	}
+}
+
+func TestDirectResolver_History(t *testing.T) {
+	node := &fakeNode{
+		accounts: map[string][]string{"acc://alice.acme/did": {
+			`{"id":"did:acc:alice.acme","versionId":"1"}`,
+			`not json`,
+			`{"id":"did:acc:alice.acme","versionId":"2","deactivated":true}`,
+		}},
+		times: []time.Time{time.Unix(100, 0), time.Unix(200, 0), time.Unix(300, 0)},
+	}
+	r := newDirectResolver(t, node)
+
+	history, err := r.History(context.Background(), "did:acc:alice.acme")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 entries, got %+v", history)
+	}
+	if h := history[0]; h.Sequence != 1 || h.VersionID != "1" || !h.Valid || h.Current || !h.Timestamp.Equal(time.Unix(100, 0)) {
+		t.Errorf("Unexpected first entry %+v", h)
+	}
+	if h := history[1]; h.Valid || h.Current {
+		t.Errorf("Expected the malformed entry to be marked invalid, got %+v", h)
+	}
+	if h := history[2]; h.VersionID != "2" || !h.Deactivated || !h.Current {
+		t.Errorf("Expected the deactivation to be current, got %+v", h)
+	}
+	if _, err := r.History(context.Background(), "did:acc:nobody.acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDirectResolver_KeyPage(t *testing.T) {
+	node := &fakeNode{states: map[string]string{
+		"acc://alice.acme/book/1": `{"type":"keyPage","url":"acc://alice.acme/book/1","creditBalance":2500,"acceptThreshold":1,"version":3,"keys":[{"publicKeyHash":"aa11","lastUsedOn":7},{"delegate":"acc://bob.acme/book"}]}`,
+		"acc://alice.acme/did":    `{"type":"dataAccount","url":"acc://alice.acme/did"}`,
+	}}
+	r := newDirectResolver(t, node)
+	ctx := context.Background()
+
+	page, err := r.KeyPage(ctx, "acc://alice.acme/book/1")
+	if err != nil {
+		t.Fatalf("KeyPage: %v", err)
+	}
+	if page.Version != 3 || page.CreditBalance != 2500 || page.AcceptThreshold != 1 || len(page.Keys) != 2 {
+		t.Errorf("Unexpected key page %+v", page)
+	}
+	if page.Keys[0].PublicKeyHash != "aa11" || page.Keys[0].LastUsedOn != 7 || page.Keys[1].Delegate != "acc://bob.acme/book" {
+		t.Errorf("Unexpected keys %+v", page.Keys)
+	}
+
+	if _, err := r.KeyPage(ctx, "acc://alice.acme/did"); err == nil {
+		t.Error("Expected a data account to be rejected")
+	}
+	if _, err := r.KeyPage(ctx, "acc://nobody.acme/book/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := r.KeyPage(ctx, "alice.acme/book/1"); err == nil {
+		t.Error("Expected a URL without acc:// to be rejected")
+	}
+}
+
+// Without sequences to tell them apart the ordering falls back to block time
+// and then the content hash, as in the resolver service
+func TestDirectResolver_Order(t *testing.T) {
+	early := &dataEntry{sequence: 2, timestamp: time.Unix(100, 0), contentHash: "bb"}
+	late := &dataEntry{sequence: 1, timestamp: time.Unix(200, 0), contentHash: "aa"}
+	same := &dataEntry{sequence: 1, timestamp: time.Unix(200, 0), contentHash: "cc"}
+
+	bySequence := &DirectResolver{order: OrderSequence}
+	if !bySequence.before(late, early) || !bySequence.before(late, same) {
+		t.Error("Sequence order should compare sequences, then content hashes")
+	}
+	byTime := &DirectResolver{order: OrderTimestamp}
+	if !byTime.before(early, late) || !byTime.before(late, same) {
+		t.Error("Timestamp order should compare times, then content hashes")
+	}
+}
+
+func TestDirectResolver_NodeUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	r, _ := NewDirectResolver(ClientOptions{BaseURL: server.URL, Retries: RetryPolicy{Max: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}})
+
+	if _, err := r.Resolve(context.Background(), "did:acc:alice.acme"); !errors.Is(err, ErrServer) {
+		t.Errorf("Expected ErrServer for an unavailable node, got %v", err)
+	}
+}
@@ -31,7 +31,7 @@
 // The SDK automatically handles:
 //   - Exponential backoff with jitter for retryable errors (429, 502, 503, 504)
 //   - Request tracking with X-Request-Id headers
-//   - Optional API key authentication
+//   - Optional authentication with API keys, OAuth2 tokens or DID-Auth signatures (CredentialProvider)
 //   - Idempotency keys for safe retries
 //   - Deterministic resolution ordering
 //   - Canonical deactivation tombstone handling (410 responses)
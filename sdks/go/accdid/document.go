@@ -0,0 +1,314 @@
+package accdid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jsonobj"
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// DIDContextV1 is the DID Core JSON-LD context
+const DIDContextV1 = "https://www.w3.org/ns/did/v1"
+
+// Relationship names a verification relationship (DID Core §5.3)
+type Relationship string
+
+// Verification relationships
+const (
+	Authentication       Relationship = "authentication"
+	AssertionMethod      Relationship = "assertionMethod"
+	KeyAgreement         Relationship = "keyAgreement"
+	CapabilityInvocation Relationship = "capabilityInvocation"
+	CapabilityDelegation Relationship = "capabilityDelegation"
+)
+
+// Relationships lists every verification relationship in document order
+var Relationships = []Relationship{Authentication, AssertionMethod, KeyAgreement, CapabilityInvocation, CapabilityDelegation}
+
+// Document is a W3C DID document. Properties it does not model are kept in
+// Extra, so decoding and encoding a document loses nothing; a controller or
+// @context read as a single string is written back as one.
+type Document struct {
+	Context            []interface{} // strings or JSON-LD context objects
+	ID                 string
+	AlsoKnownAs        []string
+	Controller         []string
+	VerificationMethod []VerificationMethod
+
+	Authentication       []VerificationMethodRef
+	AssertionMethod      []VerificationMethodRef
+	KeyAgreement         []VerificationMethodRef
+	CapabilityInvocation []VerificationMethodRef
+	CapabilityDelegation []VerificationMethodRef
+
+	Service []Service
+
+	// Extra holds the properties not modeled above, by name
+	Extra map[string]json.RawMessage
+
+	contextString    bool // @context was a single string
+	controllerString bool // controller was a single string
+}
+
+// VerificationMethod is a public key or other means to verify a proof. The
+// keys package converts between keys and verification methods.
+type VerificationMethod = keys.VerificationMethod
+
+// JWK is a public JSON Web Key (RFC 7517)
+type JWK = keys.JWK
+
+// VerificationMethodRef is an entry of a verification relationship: either a
+// reference to a verification method by ID or an embedded method
+type VerificationMethodRef struct {
+	ID     string              // set for references
+	Method *VerificationMethod // set for embedded methods
+}
+
+// Service is a service endpoint of a DID document
+type Service struct {
+	ID              string
+	Type            string
+	ServiceEndpoint interface{} // URL string, map or set of either
+
+	// Extra holds the properties not modeled above, by name
+	Extra map[string]json.RawMessage
+}
+
+// Relationship returns the entries of the verification relationship rel
+func (d *Document) Relationship(rel Relationship) []VerificationMethodRef {
+	if refs := d.relationship(rel); refs != nil {
+		return *refs
+	}
+	return nil
+}
+
+// relationship returns the field holding rel, or nil for an unknown name
+func (d *Document) relationship(rel Relationship) *[]VerificationMethodRef {
+	switch rel {
+	case Authentication:
+		return &d.Authentication
+	case AssertionMethod:
+		return &d.AssertionMethod
+	case KeyAgreement:
+		return &d.KeyAgreement
+	case CapabilityInvocation:
+		return &d.CapabilityInvocation
+	case CapabilityDelegation:
+		return &d.CapabilityDelegation
+	}
+	return nil
+}
+
+// AbsoluteID expands a fragment ("key-1" or "#key-1") or relative DID URL to
+// a DID URL of this document; absolute DID URLs are returned as is
+func (d *Document) AbsoluteID(id string) string {
+	switch {
+	case strings.HasPrefix(id, "did:"):
+		return id
+	case strings.HasPrefix(id, "#"):
+		return d.ID + id
+	default:
+		return d.ID + "#" + id
+	}
+}
+
+// FindVerificationMethod returns the verification method with the given ID
+// or fragment, whether listed under verificationMethod or embedded in a
+// verification relationship
+func (d *Document) FindVerificationMethod(id string) (*VerificationMethod, bool) {
+	want := d.AbsoluteID(id)
+	for i := range d.VerificationMethod {
+		if d.AbsoluteID(d.VerificationMethod[i].ID) == want {
+			return &d.VerificationMethod[i], true
+		}
+	}
+	for _, rel := range Relationships {
+		for _, ref := range d.Relationship(rel) {
+			if ref.Method != nil && d.AbsoluteID(ref.Method.ID) == want {
+				return ref.Method, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// VerificationMethods returns the methods of the relationship rel, resolving
+// references; references to missing methods are skipped
+func (d *Document) VerificationMethods(rel Relationship) []*VerificationMethod {
+	var methods []*VerificationMethod
+	for _, ref := range d.Relationship(rel) {
+		if ref.Method != nil {
+			methods = append(methods, ref.Method)
+		} else if vm, ok := d.FindVerificationMethod(ref.ID); ok {
+			methods = append(methods, vm)
+		}
+	}
+	return methods
+}
+
+// HasRelationship reports whether the method with the given ID or fragment
+// is in the relationship rel, by reference or embedded
+func (d *Document) HasRelationship(rel Relationship, id string) bool {
+	want := d.AbsoluteID(id)
+	for _, ref := range d.Relationship(rel) {
+		refID := ref.ID
+		if ref.Method != nil {
+			refID = ref.Method.ID
+		}
+		if d.AbsoluteID(refID) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// FindService returns the service with the given ID or fragment
+func (d *Document) FindService(id string) (*Service, bool) {
+	want := d.AbsoluteID(id)
+	for i := range d.Service {
+		if d.AbsoluteID(d.Service[i].ID) == want {
+			return &d.Service[i], true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON encodes the document, followed by its Extra properties
+func (d Document) MarshalJSON() ([]byte, error) {
+	var w jsonobj.Writer
+	if len(d.Context) == 1 && d.contextString {
+		w.Field("@context", d.Context[0])
+	} else if d.Context != nil {
+		w.Field("@context", d.Context)
+	}
+	w.Field("id", d.ID)
+	if d.AlsoKnownAs != nil {
+		w.Field("alsoKnownAs", d.AlsoKnownAs)
+	}
+	if len(d.Controller) == 1 && d.controllerString {
+		w.Field("controller", d.Controller[0])
+	} else if d.Controller != nil {
+		w.Field("controller", d.Controller)
+	}
+	if d.VerificationMethod != nil {
+		w.Field("verificationMethod", d.VerificationMethod)
+	}
+	for _, rel := range Relationships {
+		if refs := d.Relationship(rel); refs != nil {
+			w.Field(string(rel), refs)
+		}
+	}
+	if d.Service != nil {
+		w.Field("service", d.Service)
+	}
+	return w.Close(d.Extra)
+}
+
+// UnmarshalJSON decodes a document, keeping unknown properties in Extra
+func (d *Document) UnmarshalJSON(data []byte) error {
+	members, err := jsonobj.Members(data)
+	if err != nil {
+		return fmt.Errorf("DID document: %w", err)
+	}
+	*d = Document{}
+	for key, raw := range members {
+		switch key {
+		case "@context":
+			var single string
+			if json.Unmarshal(raw, &single) == nil {
+				d.Context, d.contextString = []interface{}{single}, true
+			} else if err = json.Unmarshal(raw, &d.Context); err != nil {
+				return fmt.Errorf("DID document @context: %w", err)
+			}
+		case "id":
+			err = json.Unmarshal(raw, &d.ID)
+		case "alsoKnownAs":
+			err = json.Unmarshal(raw, &d.AlsoKnownAs)
+		case "controller":
+			var single string
+			if json.Unmarshal(raw, &single) == nil {
+				d.Controller, d.controllerString = []string{single}, true
+			} else {
+				err = json.Unmarshal(raw, &d.Controller)
+			}
+		case "verificationMethod":
+			err = json.Unmarshal(raw, &d.VerificationMethod)
+		case "service":
+			err = json.Unmarshal(raw, &d.Service)
+		default:
+			if refs := d.relationship(Relationship(key)); refs != nil {
+				err = json.Unmarshal(raw, refs)
+			} else {
+				jsonobj.AddExtra(&d.Extra, key, raw)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("DID document %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes a reference as its ID and an embedded method as an
+// object
+func (r VerificationMethodRef) MarshalJSON() ([]byte, error) {
+	if r.Method != nil {
+		return json.Marshal(r.Method)
+	}
+	return json.Marshal(r.ID)
+}
+
+// UnmarshalJSON decodes a reference or an embedded method
+func (r *VerificationMethodRef) UnmarshalJSON(data []byte) error {
+	*r = VerificationMethodRef{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		r.Method = &VerificationMethod{}
+		return json.Unmarshal(data, r.Method)
+	}
+	return json.Unmarshal(data, &r.ID)
+}
+
+// MarshalJSON encodes the service, followed by its Extra properties
+func (s Service) MarshalJSON() ([]byte, error) {
+	var w jsonobj.Writer
+	w.Field("id", s.ID)
+	w.Optional("type", s.Type)
+	if s.ServiceEndpoint != nil {
+		w.Field("serviceEndpoint", s.ServiceEndpoint)
+	}
+	return w.Close(s.Extra)
+}
+
+// UnmarshalJSON decodes a service, keeping unknown properties in Extra. A
+// type given as a set of strings is kept in Extra too, leaving Type empty.
+func (s *Service) UnmarshalJSON(data []byte) error {
+	*s = Service{}
+	members, err := jsonobj.Members(data)
+	if err != nil {
+		return fmt.Errorf("service: %w", err)
+	}
+	if raw, ok := members["type"]; ok && json.Unmarshal(raw, &s.Type) != nil {
+		s.Type = ""
+		s.Extra = map[string]json.RawMessage{"type": raw}
+		delete(members, "type")
+	}
+	fields := map[string]interface{}{
+		"id":              &s.ID,
+		"type":            &s.Type,
+		"serviceEndpoint": &s.ServiceEndpoint,
+	}
+	for key, raw := range members {
+		target, known := fields[key]
+		if !known {
+			jsonobj.AddExtra(&s.Extra, key, raw)
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf("service %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package accdid
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// Ed25519VerificationKey2020 is the verification method type of Ed25519 keys
+// written by AddEd25519Key
+const Ed25519VerificationKey2020 = keys.Ed25519VerificationKey2020
+
+// Ed25519Context2020 is the JSON-LD context defining Ed25519VerificationKey2020
+const Ed25519Context2020 = "https://w3id.org/security/suites/ed25519-2020/v1"
+
+// DocumentBuilder assembles a Document. Methods record the first error,
+// which Build returns.
+type DocumentBuilder struct {
+	doc *Document
+	err error
+}
+
+// NewDocument starts a document for did with the DID Core context
+func NewDocument(did string) *DocumentBuilder {
+	b := &DocumentBuilder{doc: &Document{Context: []interface{}{DIDContextV1}, ID: did}}
+	if parts := strings.SplitN(did, ":", 3); len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		b.err = fmt.Errorf("invalid DID %q", did)
+	}
+	return b
+}
+
+// AddContext appends a JSON-LD context (a URL or an object) unless present
+func (b *DocumentBuilder) AddContext(context interface{}) *DocumentBuilder {
+	if url, ok := context.(string); ok {
+		for _, c := range b.doc.Context {
+			if c == url {
+				return b
+			}
+		}
+	}
+	b.doc.Context = append(b.doc.Context, context)
+	return b
+}
+
+// AddController adds a DID allowed to make changes to the document
+func (b *DocumentBuilder) AddController(did string) *DocumentBuilder {
+	b.doc.Controller = append(b.doc.Controller, did)
+	return b
+}
+
+// AddAlsoKnownAs adds another identifier of the subject
+func (b *DocumentBuilder) AddAlsoKnownAs(uri string) *DocumentBuilder {
+	b.doc.AlsoKnownAs = append(b.doc.AlsoKnownAs, uri)
+	return b
+}
+
+// AddVerificationMethod adds vm and references it from each of rels. A
+// fragment ID is expanded and an empty controller set to the document's DID.
+func (b *DocumentBuilder) AddVerificationMethod(vm VerificationMethod, rels ...Relationship) *DocumentBuilder {
+	if vm.ID == "" || vm.Type == "" {
+		return b.fail(errors.New("verification method needs an ID and a type"))
+	}
+	vm.ID = b.doc.AbsoluteID(vm.ID)
+	if vm.Controller == "" {
+		vm.Controller = b.doc.ID
+	}
+	if _, exists := b.doc.FindVerificationMethod(vm.ID); exists {
+		return b.fail(fmt.Errorf("duplicate verification method %s", vm.ID))
+	}
+	b.doc.VerificationMethod = append(b.doc.VerificationMethod, vm)
+	for _, rel := range rels {
+		refs := b.doc.relationship(rel)
+		if refs == nil {
+			return b.fail(fmt.Errorf("unknown verification relationship %q", rel))
+		}
+		*refs = append(*refs, VerificationMethodRef{ID: vm.ID})
+	}
+	return b
+}
+
+// AddKey adds a verification method of methodType for key under fragment,
+// referenced from rels, or from authentication and assertionMethod when none
+// are given. An empty methodType picks keys.DefaultMethodType; the JSON-LD
+// context of the type is added to the document.
+func (b *DocumentBuilder) AddKey(fragment string, key keys.PublicKey, methodType string, rels ...Relationship) *DocumentBuilder {
+	vm, err := key.VerificationMethod(fragment, "", methodType)
+	if err != nil {
+		return b.fail(fmt.Errorf("key %s: %w", fragment, err))
+	}
+	if len(rels) == 0 {
+		rels = []Relationship{Authentication, AssertionMethod}
+	}
+	if context := keys.Context(vm.Type); context != "" {
+		b.AddContext(context)
+	}
+	return b.AddVerificationMethod(vm, rels...)
+}
+
+// AddEd25519Key adds an Ed25519VerificationKey2020 method for key under
+// fragment, referenced from rels, or from authentication and assertionMethod
+// when none are given
+func (b *DocumentBuilder) AddEd25519Key(fragment string, key ed25519.PublicKey, rels ...Relationship) *DocumentBuilder {
+	pub, err := keys.NewPublicKey(keys.Ed25519, key)
+	if err != nil {
+		return b.fail(fmt.Errorf("key %s: %w", fragment, err))
+	}
+	return b.AddKey(fragment, pub, Ed25519VerificationKey2020, rels...)
+}
+
+// AddService adds a service endpoint under fragment
+func (b *DocumentBuilder) AddService(fragment, serviceType string, endpoint interface{}) *DocumentBuilder {
+	if serviceType == "" || endpoint == nil {
+		return b.fail(fmt.Errorf("service %s needs a type and an endpoint", fragment))
+	}
+	id := b.doc.AbsoluteID(fragment)
+	if _, exists := b.doc.FindService(id); exists {
+		return b.fail(fmt.Errorf("duplicate service %s", id))
+	}
+	b.doc.Service = append(b.doc.Service, Service{ID: id, Type: serviceType, ServiceEndpoint: endpoint})
+	return b
+}
+
+// Build returns the document, or the first error met while building it
+func (b *DocumentBuilder) Build() (*Document, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.doc, nil
+}
+
+func (b *DocumentBuilder) fail(err error) *DocumentBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
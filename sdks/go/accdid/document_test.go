@@ -0,0 +1,206 @@
+package accdid
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+const fullDocument = `{
+	"@context": ["https://www.w3.org/ns/did/v1", {"@vocab": "https://example.com/#"}],
+	"id": "did:acc:alice.acme",
+	"alsoKnownAs": ["https://alice.example"],
+	"controller": "did:acc:alice.acme",
+	"verificationMethod": [{
+		"id": "did:acc:alice.acme#key-1",
+		"type": "JsonWebKey2020",
+		"controller": "did:acc:alice.acme",
+		"publicKeyJwk": {"kty": "OKP", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", "use": "sig"},
+		"revoked": false
+	}],
+	"authentication": ["#key-1", {
+		"id": "did:acc:alice.acme#auth-2",
+		"type": "Ed25519VerificationKey2020",
+		"controller": "did:acc:alice.acme",
+		"publicKeyMultibase": "z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+	}],
+	"assertionMethod": [],
+	"service": [
+		{"id": "#hub", "type": "LinkedDomains", "serviceEndpoint": {"origins": ["https://alice.example"]}, "priority": 1},
+		{"id": "did:acc:alice.acme#multi", "type": ["A", "B"], "serviceEndpoint": "https://b.example"}
+	],
+	"created": "2024-01-01T00:00:00Z"
+}`
+
+func compact(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", data, err)
+	}
+	return v
+}
+
+func TestDocument_RoundTrip(t *testing.T) {
+	var doc Document
+	if err := json.Unmarshal([]byte(fullDocument), &doc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if doc.ID != "did:acc:alice.acme" || len(doc.Controller) != 1 || len(doc.Context) != 2 {
+		t.Errorf("Unexpected document %+v", doc)
+	}
+	if len(doc.Authentication) != 2 || doc.Authentication[0].ID != "#key-1" || doc.Authentication[1].Method == nil {
+		t.Errorf("Expected a reference and an embedded method, got %+v", doc.Authentication)
+	}
+	if doc.AssertionMethod == nil || len(doc.AssertionMethod) != 0 {
+		t.Errorf("Expected an empty assertionMethod, got %#v", doc.AssertionMethod)
+	}
+	if jwk := doc.VerificationMethod[0].PublicKeyJwk; jwk == nil || jwk.Crv != "Ed25519" || string(jwk.Extra["use"]) != `"sig"` {
+		t.Errorf("Unexpected JWK %+v", jwk)
+	}
+	if string(doc.Extra["created"]) != `"2024-01-01T00:00:00Z"` {
+		t.Errorf("Expected the unknown property in Extra, got %v", doc.Extra)
+	}
+
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got, want := compact(t, encoded), compact(t, []byte(fullDocument))
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("Round trip changed the document\n got: %s\nwant: %s", gotJSON, wantJSON)
+	}
+	if !strings.HasPrefix(string(encoded), `{"@context":`) {
+		t.Errorf("Expected the known properties first, got %s", encoded)
+	}
+}
+
+func TestDocument_Accessors(t *testing.T) {
+	var doc Document
+	if err := json.Unmarshal([]byte(fullDocument), &doc); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	for _, id := range []string{"key-1", "#key-1", "did:acc:alice.acme#key-1"} {
+		if vm, ok := doc.FindVerificationMethod(id); !ok || vm.Type != "JsonWebKey2020" {
+			t.Errorf("FindVerificationMethod(%q) = %v, %v", id, vm, ok)
+		}
+	}
+	if vm, ok := doc.FindVerificationMethod("auth-2"); !ok || vm.PublicKeyMultibase == "" {
+		t.Errorf("Expected the embedded method, got %v, %v", vm, ok)
+	}
+	if _, ok := doc.FindVerificationMethod("missing"); ok {
+		t.Error("Expected no method for an unknown fragment")
+	}
+
+	if methods := doc.VerificationMethods(Authentication); len(methods) != 2 {
+		t.Errorf("Expected both authentication methods, got %d", len(methods))
+	}
+	if !doc.HasRelationship(Authentication, "did:acc:alice.acme#key-1") || !doc.HasRelationship(Authentication, "auth-2") {
+		t.Error("Expected key-1 and auth-2 to authenticate")
+	}
+	if doc.HasRelationship(AssertionMethod, "key-1") {
+		t.Error("Expected key-1 not to be an assertion method")
+	}
+
+	svc, ok := doc.FindService("hub")
+	if !ok || svc.Type != "LinkedDomains" || string(svc.Extra["priority"]) != "1" {
+		t.Errorf("FindService(hub) = %+v, %v", svc, ok)
+	}
+	if svc, _ := doc.FindService("multi"); svc.Type != "" || string(svc.Extra["type"]) != `["A", "B"]` {
+		t.Errorf("Expected a type set to be kept in Extra, got %+v", svc)
+	}
+}
+
+func TestDocumentBuilder(t *testing.T) {
+	pub := ed25519.PublicKey(bytes.Repeat([]byte{1}, ed25519.PublicKeySize))
+	doc, err := NewDocument("did:acc:bob.acme").
+		AddController("did:acc:admin.acme").
+		AddAlsoKnownAs("https://bob.example").
+		AddEd25519Key("key-1", pub).
+		AddEd25519Key("#agree", pub, KeyAgreement).
+		AddService("website", "LinkedDomains", "https://bob.example").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	vm, ok := doc.FindVerificationMethod("key-1")
+	if !ok || vm.ID != "did:acc:bob.acme#key-1" || vm.Controller != "did:acc:bob.acme" || vm.Type != Ed25519VerificationKey2020 {
+		t.Fatalf("Unexpected method %+v", vm)
+	}
+	if !strings.HasPrefix(vm.PublicKeyMultibase, "z6Mk") {
+		t.Errorf("Expected a multibase Ed25519 key, got %s", vm.PublicKeyMultibase)
+	}
+	if !doc.HasRelationship(Authentication, "key-1") || !doc.HasRelationship(AssertionMethod, "key-1") || doc.HasRelationship(Authentication, "agree") {
+		t.Error("Unexpected verification relationships")
+	}
+	if len(doc.Context) != 2 || doc.Context[1] != Ed25519Context2020 {
+		t.Errorf("Expected the Ed25519 2020 context once, got %v", doc.Context)
+	}
+
+	encoded, _ := json.Marshal(doc)
+	var decoded Document
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if svc, ok := decoded.FindService("website"); !ok || svc.ServiceEndpoint != "https://bob.example" {
+		t.Errorf("Expected the service after a round trip, got %+v", svc)
+	}
+	if !strings.Contains(string(encoded), `"controller":["did:acc:admin.acme"]`) {
+		t.Errorf("Expected the built controller as a set, got %s", encoded)
+	}
+
+	tests := []struct {
+		name  string
+		build *DocumentBuilder
+	}{
+		{"invalid DID", NewDocument("alice")},
+		{"duplicate key", NewDocument("did:acc:bob").AddEd25519Key("k", pub).AddEd25519Key("#k", pub)},
+		{"short key", NewDocument("did:acc:bob").AddEd25519Key("k", pub[:8])},
+		{"duplicate service", NewDocument("did:acc:bob").AddService("s", "T", "https://a").AddService("s", "T", "https://b")},
+		{"unknown relationship", NewDocument("did:acc:bob").AddEd25519Key("k", pub, "signing")},
+	}
+	for _, tt := range tests {
+		if _, err := tt.build.Build(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestDocumentBuilder_AddKey(t *testing.T) {
+	key, err := keys.Generate(keys.Secp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := NewDocument("did:acc:carol.acme").
+		AddKey("jwk", key.Public, keys.JsonWebKey2020, CapabilityInvocation).
+		AddKey("multi", key.Public, "").
+		Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	want := []interface{}{DIDContextV1, keys.Context(keys.JsonWebKey2020), keys.Context(keys.Multikey)}
+	if len(doc.Context) != len(want) || doc.Context[1] != want[1] || doc.Context[2] != want[2] {
+		t.Errorf("Expected contexts %v, got %v", want, doc.Context)
+	}
+	vm, _ := doc.FindVerificationMethod("jwk")
+	if got, err := keys.FromVerificationMethod(vm); err != nil || !got.Equal(key.Public) {
+		t.Errorf("Expected the key back from the JWK method, got %v, %v", got, err)
+	}
+	if !doc.HasRelationship(CapabilityInvocation, "jwk") || !doc.HasRelationship(Authentication, "multi") {
+		t.Error("Unexpected verification relationships")
+	}
+
+	if _, err := NewDocument("did:acc:carol").AddKey("k", key.Public, Ed25519VerificationKey2020).Build(); err == nil {
+		t.Error("Expected Ed25519VerificationKey2020 to refuse a secp256k1 key")
+	}
+}
@@ -20,6 +20,14 @@ var (
 	ErrNetwork         = errors.New("network error")
 )
 
+// ErrInsufficientCredits is returned when the registrar's signer cannot pay
+// for an operation; the error envelope details carry the shortfall
+var ErrInsufficientCredits = errors.New("insufficient credits")
+
+// ErrTransactionFailed is returned when the network accepted a transaction
+// but did not apply it
+var ErrTransactionFailed = errors.New("transaction failed")
+
 // HTTPError represents a structured HTTP error
 type HTTPError struct {
 	StatusCode int
@@ -63,9 +71,17 @@ func decodeHTTPError(resp *http.Response, body []byte) error {
 	switch resp.StatusCode {
 	case 404:
 		httpErr.Err = ErrNotFound
+	case 402:
+		httpErr.Err = ErrInsufficientCredits
 	case 410:
 		httpErr.Err = ErrGoneDeactivated
-	case 400, 401, 403, 422, 429:
+	case 422:
+		if httpErr.Envelope != nil && httpErr.Envelope.Error == "transactionFailed" {
+			httpErr.Err = ErrTransactionFailed
+		} else {
+			httpErr.Err = ErrBadRequest
+		}
+	case 400, 401, 403, 429:
 		httpErr.Err = ErrBadRequest
 	default:
 		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
@@ -1,3 +1,5 @@
 module github.com/opendlt/accu-did/sdks/go/accdid
 
 go 1.22
+
+require github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
@@ -0,0 +1,94 @@
+package accdid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/httpx"
+)
+
+// Health and readiness report statuses
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusReady    = "ready"
+	HealthStatusNotReady = "not_ready"
+)
+
+// Readiness check statuses
+const (
+	CheckStatusPass = "pass"
+	CheckStatusWarn = "warn"
+	CheckStatusFail = "fail"
+)
+
+// HealthReport is the body of /healthz or /readyz. Liveness reports fill
+// Nodes; readiness reports fill Checks.
+type HealthReport struct {
+	Status    string        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Nodes     []NodeStatus  `json:"nodes,omitempty"`
+	Checks    []HealthCheck `json:"checks,omitempty"`
+}
+
+// NodeStatus is the state of one Accumulate node used by a service
+type NodeStatus struct {
+	URL                 string    `json:"url"`
+	State               string    `json:"state"` // up, down or recovering
+	LatencyMs           float64   `json:"latencyMs"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastChecked         time.Time `json:"lastChecked"`
+}
+
+// HealthCheck is the outcome of one readiness check
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	Message   string  `json:"message,omitempty"`
+}
+
+// Ready reports whether the service considers itself ready to serve traffic
+func (r *HealthReport) Ready() bool {
+	return r.Status == HealthStatusReady
+}
+
+// Failed returns the checks that did not pass
+func (r *HealthReport) Failed() []HealthCheck {
+	var failed []HealthCheck
+	for _, check := range r.Checks {
+		if check.Status != CheckStatusPass {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// getHealthReport fetches a health endpoint. Services that predate the JSON
+// report answer with a plain "OK"; that is reported as status ok. A 503 from
+// /readyz still carries a report, so it is returned alongside the error.
+func getHealthReport(ctx context.Context, doer Doer, baseURL, endpoint string) (*HealthReport, error) {
+	status, body, err := httpx.DoJSON(ctx, doer, "GET", baseURL, endpoint, nil, nil)
+	if err != nil {
+		_, classified := classifyError(err)
+		return nil, classified
+	}
+
+	var report HealthReport
+	if json.Unmarshal(body, &report) != nil || report.Status == "" {
+		if status >= 400 {
+			return nil, decodeHTTPError(&http.Response{StatusCode: status, Status: fmt.Sprintf("%d", status)}, body)
+		}
+		return &HealthReport{Status: HealthStatusOK}, nil
+	}
+
+	if status >= 400 {
+		return &report, decodeHTTPError(&http.Response{StatusCode: status, Status: fmt.Sprintf("%d", status)}, body)
+	}
+	return &report, nil
+}
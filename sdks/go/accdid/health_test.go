@@ -0,0 +1,116 @@
+package accdid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResolverClient_HealthDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			t.Errorf("Expected path /healthz, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"degraded","timestamp":"2025-01-01T00:00:00Z","nodes":[
+			{"url":"http://a:26660/v3","state":"up","latencyMs":12.5,"consecutiveFailures":0,"lastChecked":"2025-01-01T00:00:00Z"},
+			{"url":"http://b:26660/v3","state":"down","latencyMs":0,"consecutiveFailures":3,"lastError":"connection refused","lastChecked":"2025-01-01T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewResolverClient(ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	report, err := client.HealthDetails(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Status != HealthStatusDegraded {
+		t.Errorf("Expected status degraded, got %s", report.Status)
+	}
+	if len(report.Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(report.Nodes))
+	}
+	if report.Nodes[1].State != "down" || report.Nodes[1].LastError != "connection refused" {
+		t.Errorf("Unexpected node status: %+v", report.Nodes[1])
+	}
+}
+
+func TestResolverClient_HealthDetails_PlainOK(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	client, err := NewResolverClient(ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	report, err := client.HealthDetails(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Status != HealthStatusOK {
+		t.Errorf("Expected status ok, got %s", report.Status)
+	}
+}
+
+func TestRegistrarClient_Readiness(t *testing.T) {
+	ready := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("Expected path /readyz, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if ready {
+			w.Write([]byte(`{"status":"ready","timestamp":"2025-01-01T00:00:00Z","checks":[
+				{"name":"accumulate.nodes","status":"pass","critical":true,"latencyMs":3.2,"message":"2/2 nodes up"},
+				{"name":"accumulate.credits","status":"warn","critical":false,"latencyMs":8.1,"message":"below 100 credits"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"not_ready","timestamp":"2025-01-01T00:00:00Z","checks":[
+			{"name":"keystore","status":"fail","critical":true,"latencyMs":0.4,"message":"failed to read key store"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewRegistrarClient(ClientOptions{
+		BaseURL: server.URL,
+		Retries: RetryPolicy{Max: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	report, err := client.Readiness(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.Ready() {
+		t.Errorf("Expected ready, got %s", report.Status)
+	}
+	if len(report.Checks) != 2 {
+		t.Fatalf("Expected 2 checks, got %d", len(report.Checks))
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "accumulate.credits" {
+		t.Errorf("Expected only the credits check to be reported, got %+v", failed)
+	}
+
+	ready = false
+	report, err = client.Readiness(context.Background())
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("Expected ErrServer, got %v", err)
+	}
+	if report == nil {
+		t.Fatal("Expected the report to be returned with the error")
+	}
+	if report.Ready() || report.Checks[0].Name != "keystore" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
@@ -131,4 +131,43 @@ func ParseEndpoint(template string, params map[string]string) string {
 		result = strings.ReplaceAll(result, placeholder, url.PathEscape(v))
 	}
 	return result
-}
\ No newline at end of file
+}
+
+// GetQuery performs a GET request with query parameters and extra headers,
+// returning the response headers and the undecoded body
+func GetQuery(ctx context.Context, doer Doer, baseURL, endpoint string, params map[string]string, header http.Header) (status int, respHeader http.Header, body []byte, err error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	u.Path = path.Join(u.Path, endpoint)
+
+	if len(params) > 0 {
+		q := u.Query()
+		for k, v := range params {
+			q.Add(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doer.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
@@ -0,0 +1,61 @@
+package jcs
+
+import (
+	"math"
+	"testing"
+)
+
+// The example of RFC 8785 section 3.2.2
+func TestCanonicalize_RFC8785(t *testing.T) {
+	input := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	got, err := Canonicalize([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{1, "1"},
+		{-1.5, "-1.5"},
+		{100, "100"},
+		{1e21, "1e+21"},
+		{1e20, "100000000000000000000"},
+		{0.000001, "0.000001"},
+		{0.0000001, "1e-7"},
+		{123456789012345680000, "123456789012345680000"},
+		{9007199254740992, "9007199254740992"},
+		{1.7976931348623157e308, "1.7976931348623157e+308"},
+		{5e-324, "5e-324"},
+		{-1.25e-10, "-1.25e-10"},
+	}
+	for _, tt := range tests {
+		if got, _ := formatNumber(tt.f); got != tt.want {
+			t.Errorf("formatNumber(%v) = %s, want %s", tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalize_SortsByUTF16(t *testing.T) {
+	// U+FB33 sorts after U+1F600 in UTF-8 but before it in UTF-16
+	got, err := Canonicalize([]byte(`{"\ufb33":2,"\ud83d\ude00":1,"a":3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":3,\"\U0001F600\":1,\"\uFB33\":2}"; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+// Package jsonobj encodes and decodes JSON objects member by member, so types
+// can keep the members they do not model and write them back
+package jsonobj
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Members splits a JSON object into its members
+func Members(data []byte) (map[string]json.RawMessage, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	if members == nil {
+		return nil, fmt.Errorf("expected an object, got null")
+	}
+	return members, nil
+}
+
+// Decode decodes the members of the JSON object data into fields by name and
+// keeps the others in extra
+func Decode(data []byte, what string, fields map[string]interface{}, extra *map[string]json.RawMessage) error {
+	members, err := Members(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	for key, raw := range members {
+		target, known := fields[key]
+		if !known {
+			AddExtra(extra, key, raw)
+			continue
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf("%s %s: %w", what, key, err)
+		}
+	}
+	return nil
+}
+
+// AddExtra stores raw under key, creating the map if needed
+func AddExtra(extra *map[string]json.RawMessage, key string, raw json.RawMessage) {
+	if *extra == nil {
+		*extra = make(map[string]json.RawMessage)
+	}
+	(*extra)[key] = raw
+}
+
+// Writer writes a JSON object member by member, keeping their order
+type Writer struct {
+	buf     bytes.Buffer
+	written map[string]bool
+	err     error
+}
+
+// Field writes a member
+func (w *Writer) Field(key string, value interface{}) {
+	if w.err != nil {
+		return
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		w.err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+	w.raw(key, encoded)
+}
+
+// Optional writes a string member unless it is empty
+func (w *Writer) Optional(key, value string) {
+	if value != "" {
+		w.Field(key, value)
+	}
+}
+
+func (w *Writer) raw(key string, value []byte) {
+	if w.written == nil {
+		w.written = make(map[string]bool)
+		w.buf.WriteByte('{')
+	} else {
+		w.buf.WriteByte(',')
+	}
+	w.written[key] = true
+	name, _ := json.Marshal(key)
+	w.buf.Write(name)
+	w.buf.WriteByte(':')
+	w.buf.Write(value)
+}
+
+// Close writes the extra members not written yet, sorted by name, and ends
+// the object
+func (w *Writer) Close(extra map[string]json.RawMessage) ([]byte, error) {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if !w.written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		w.raw(key, extra[key])
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	if w.written == nil {
+		return []byte("{}"), nil
+	}
+	w.buf.WriteByte('}')
+	return w.buf.Bytes(), nil
+}
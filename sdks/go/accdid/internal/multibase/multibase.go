@@ -0,0 +1,91 @@
+// Package multibase encodes keys as base58btc multibase strings with a
+// multicodec prefix, as used by publicKeyMultibase
+package multibase
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Base58BTC is the multibase prefix of base58btc strings
+const Base58BTC = 'z'
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var decodeMap = func() [256]int8 {
+	var m [256]int8
+	for i := range m {
+		m[i] = -1
+	}
+	for i, c := range alphabet {
+		m[c] = int8(i)
+	}
+	return m
+}()
+
+// EncodeBase58 encodes b with the Bitcoin base58 alphabet
+func EncodeBase58(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		out = append(out, alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// DecodeBase58 decodes a Bitcoin base58 string
+func DecodeBase58(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i := 0; i < len(s); i++ {
+		d := decodeMap[s[i]]
+		if d < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", s[i])
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// Encode returns the base58btc multibase form of data prefixed with the
+// varint-encoded multicodec code
+func Encode(code uint64, data []byte) string {
+	return string(Base58BTC) + EncodeBase58(append(binary.AppendUvarint(nil, code), data...))
+}
+
+// Decode parses a base58btc multibase string and splits off its multicodec
+// code
+func Decode(s string) (uint64, []byte, error) {
+	if len(s) < 2 || s[0] != Base58BTC {
+		return 0, nil, errors.New("not a base58btc multibase string")
+	}
+	raw, err := DecodeBase58(s[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	code, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return 0, nil, errors.New("invalid multicodec prefix")
+	}
+	return code, raw[n:], nil
+}
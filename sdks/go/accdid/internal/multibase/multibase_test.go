@@ -0,0 +1,51 @@
+package multibase
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBase58(t *testing.T) {
+	tests := []struct {
+		hex, base58 string
+	}{
+		{"", ""},
+		{"0000", "11"},
+		{"61", "2g"},
+		{"626262", "a3gV"},
+		{"516b6fcd0f", "ABnLTmg"},
+		{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+	}
+	for _, tt := range tests {
+		raw, _ := hex.DecodeString(tt.hex)
+		if got := EncodeBase58(raw); got != tt.base58 {
+			t.Errorf("EncodeBase58(%s) = %s, want %s", tt.hex, got, tt.base58)
+		}
+		decoded, err := DecodeBase58(tt.base58)
+		if err != nil || !bytes.Equal(decoded, raw) {
+			t.Errorf("DecodeBase58(%s) = %x, %v, want %s", tt.base58, decoded, err, tt.hex)
+		}
+	}
+	if _, err := DecodeBase58("0OIl"); err == nil {
+		t.Error("Expected an error for characters outside the alphabet")
+	}
+}
+
+func TestEncode_Ed25519(t *testing.T) {
+	// did:key test vector from the did:key method specification
+	const multibase = "z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+	code, key, err := Decode(multibase)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if code != 0xed || len(key) != 32 {
+		t.Fatalf("Expected an Ed25519 key (0xed, 32 bytes), got %#x with %d bytes", code, len(key))
+	}
+	if got := Encode(code, key); got != multibase {
+		t.Errorf("Encode = %s, want %s", got, multibase)
+	}
+	if _, _, err := Decode("f0123"); err == nil {
+		t.Error("Expected an error for a non-base58btc multibase")
+	}
+}
@@ -0,0 +1,109 @@
+package keys
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jsonobj"
+)
+
+// JWK is a public JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string
+	Crv string
+	X   string
+	Y   string
+	Kid string
+
+	// Extra holds the members not modeled above, by name
+	Extra map[string]json.RawMessage
+}
+
+// MarshalJSON encodes the key, followed by its Extra members
+func (k JWK) MarshalJSON() ([]byte, error) {
+	var w jsonobj.Writer
+	w.Field("kty", k.Kty)
+	w.Optional("crv", k.Crv)
+	w.Optional("x", k.X)
+	w.Optional("y", k.Y)
+	w.Optional("kid", k.Kid)
+	return w.Close(k.Extra)
+}
+
+// UnmarshalJSON decodes a key, keeping unknown members in Extra
+func (k *JWK) UnmarshalJSON(data []byte) error {
+	*k = JWK{}
+	return jsonobj.Decode(data, "JWK", map[string]interface{}{
+		"kty": &k.Kty,
+		"crv": &k.Crv,
+		"x":   &k.X,
+		"y":   &k.Y,
+		"kid": &k.Kid,
+	}, &k.Extra)
+}
+
+// JWK returns the key as an OKP (Ed25519) or EC JWK (RFC 8037, RFC 7518)
+func (k PublicKey) JWK() (*JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k.Type {
+	case Ed25519:
+		return &JWK{Kty: "OKP", Crv: string(Ed25519), X: b64(k.Bytes)}, nil
+	case Secp256k1, P256:
+		x, y, err := k.coordinates()
+		if err != nil {
+			return nil, err
+		}
+		return &JWK{Kty: "EC", Crv: string(k.Type), X: b64(x), Y: b64(y)}, nil
+	}
+	return nil, fmt.Errorf("%w: type %q", ErrUnsupportedKey, k.Type)
+}
+
+// coordinates returns the 32-byte affine coordinates of an EC key
+func (k PublicKey) coordinates() (x, y []byte, err error) {
+	x, y = make([]byte, 32), make([]byte, 32)
+	switch k.Type {
+	case Secp256k1:
+		p, err := parseSecp256k1(k.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		uncompressed := p.SerializeUncompressed()
+		copy(x, uncompressed[1:33])
+		copy(y, uncompressed[33:])
+	case P256:
+		px, py, err := p256Point(k.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		px.FillBytes(x)
+		py.FillBytes(y)
+	}
+	return x, y, nil
+}
+
+// ParseJWK decodes a public JWK of a supported curve
+func ParseJWK(jwk *JWK) (PublicKey, error) {
+	if jwk == nil {
+		return PublicKey{}, errors.New("missing JWK")
+	}
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("JWK x: %w", err)
+	}
+	switch {
+	case jwk.Kty == "OKP" && jwk.Crv == string(Ed25519):
+		return NewPublicKey(Ed25519, x)
+	case jwk.Kty == "EC" && (jwk.Crv == string(Secp256k1) || jwk.Crv == string(P256)):
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return PublicKey{}, fmt.Errorf("JWK y: %w", err)
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return PublicKey{}, fmt.Errorf("JWK coordinates of %s must be 32 bytes", jwk.Crv)
+		}
+		return NewPublicKey(Type(jwk.Crv), append(append([]byte{4}, x...), y...))
+	}
+	return PublicKey{}, fmt.Errorf("%w: JWK kty %q crv %q", ErrUnsupportedKey, jwk.Kty, jwk.Crv)
+}
@@ -0,0 +1,346 @@
+// Package keys generates key pairs and converts public keys between the forms
+// DID documents use: multibase strings with a multicodec prefix (did:key,
+// Multikey, Ed25519VerificationKey2020), JWKs and base58.
+//
+// Ed25519, secp256k1 and P-256 keys are supported. Ed25519 and P-256 use the
+// standard library; secp256k1 uses github.com/decred/dcrd/dcrec/secp256k1.
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/multibase"
+)
+
+// Type is a key type, named as the JWK curve
+type Type string
+
+// Key types
+const (
+	Ed25519 Type = "Ed25519"
+
+	// Secp256k1 keys sign with ECDSA over SHA-256 (ES256K)
+	Secp256k1 Type = "secp256k1"
+
+	// P256 keys sign with ECDSA over SHA-256 (ES256)
+	P256 Type = "P-256"
+)
+
+// Multicodec codes of the public key types
+const (
+	CodeEd25519Pub   uint64 = 0xed
+	CodeSecp256k1Pub uint64 = 0xe7
+	CodeP256Pub      uint64 = 0x1200
+)
+
+// ErrUnsupportedKey is returned for key types, codecs and curves this package
+// does not handle
+var ErrUnsupportedKey = errors.New("unsupported key")
+
+// DIDKeyPrefix starts every did:key DID
+const DIDKeyPrefix = "did:key:"
+
+// PublicKey is a public key. Bytes holds the 32-byte Ed25519 key, or the
+// 33-byte compressed point of secp256k1 and P-256 keys.
+type PublicKey struct {
+	Type  Type
+	Bytes []byte
+}
+
+// NewPublicKey validates a raw public key. Elliptic curve points may be
+// compressed or uncompressed; they are stored compressed.
+func NewPublicKey(t Type, b []byte) (PublicKey, error) {
+	switch t {
+	case Ed25519:
+		if len(b) != ed25519.PublicKeySize {
+			return PublicKey{}, fmt.Errorf("Ed25519 key must be %d bytes, got %d", ed25519.PublicKeySize, len(b))
+		}
+		return PublicKey{Type: t, Bytes: append([]byte(nil), b...)}, nil
+	case Secp256k1:
+		p, err := parseSecp256k1(b)
+		if err != nil {
+			return PublicKey{}, err
+		}
+		return PublicKey{Type: t, Bytes: p.SerializeCompressed()}, nil
+	case P256:
+		x, y, err := p256Point(b)
+		if err != nil {
+			return PublicKey{}, err
+		}
+		return PublicKey{Type: t, Bytes: elliptic.MarshalCompressed(elliptic.P256(), x, y)}, nil
+	}
+	return PublicKey{}, fmt.Errorf("%w: type %q", ErrUnsupportedKey, t)
+}
+
+// p256Point decodes a compressed or uncompressed P-256 point
+func p256Point(b []byte) (x, y *big.Int, err error) {
+	curve := elliptic.P256()
+	switch len(b) {
+	case 33:
+		x, y = elliptic.UnmarshalCompressed(curve, b)
+	case 65:
+		// ecdh validates the point without the deprecated elliptic.Unmarshal
+		if _, err := ecdh.P256().NewPublicKey(b); err == nil {
+			x, y = new(big.Int).SetBytes(b[1:33]), new(big.Int).SetBytes(b[33:])
+		}
+	}
+	if x == nil {
+		return nil, nil, errors.New("P-256 key must be a valid 33-byte compressed or 65-byte uncompressed point")
+	}
+	return x, y, nil
+}
+
+// Code returns the multicodec code of the key type
+func (k PublicKey) Code() uint64 {
+	switch k.Type {
+	case Ed25519:
+		return CodeEd25519Pub
+	case Secp256k1:
+		return CodeSecp256k1Pub
+	case P256:
+		return CodeP256Pub
+	}
+	return 0
+}
+
+// Multibase returns the key as a base58btc multibase string with its
+// multicodec prefix, the form of publicKeyMultibase and did:key
+func (k PublicKey) Multibase() string {
+	return multibase.Encode(k.Code(), k.Bytes)
+}
+
+// ParseMultibase decodes a multicodec-prefixed multibase key
+func ParseMultibase(s string) (PublicKey, error) {
+	code, data, err := multibase.Decode(s)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	switch code {
+	case CodeEd25519Pub:
+		return NewPublicKey(Ed25519, data)
+	case CodeSecp256k1Pub:
+		return NewPublicKey(Secp256k1, data)
+	case CodeP256Pub:
+		return NewPublicKey(P256, data)
+	}
+	return PublicKey{}, fmt.Errorf("%w: multicodec 0x%x", ErrUnsupportedKey, code)
+}
+
+// Base58 returns the raw key in base58, the form of publicKeyBase58
+func (k PublicKey) Base58() string {
+	return multibase.EncodeBase58(k.Bytes)
+}
+
+// ParseBase58 decodes a raw base58 key of type t
+func ParseBase58(t Type, s string) (PublicKey, error) {
+	data, err := multibase.DecodeBase58(s)
+	if err != nil {
+		return PublicKey{}, err
+	}
+	return NewPublicKey(t, data)
+}
+
+// Fingerprint returns the did:key method-specific identifier of the key
+func (k PublicKey) Fingerprint() string {
+	return k.Multibase()
+}
+
+// DIDKey returns the did:key DID of the key
+func (k PublicKey) DIDKey() string {
+	return DIDKeyPrefix + k.Fingerprint()
+}
+
+// ParseDIDKey decodes the key of a did:key DID or DID URL
+func ParseDIDKey(did string) (PublicKey, error) {
+	fingerprint, ok := strings.CutPrefix(did, DIDKeyPrefix)
+	if !ok {
+		return PublicKey{}, fmt.Errorf("%q is not a did:key DID", did)
+	}
+	if i := strings.IndexAny(fingerprint, "#?/"); i >= 0 {
+		fingerprint = fingerprint[:i]
+	}
+	return ParseMultibase(fingerprint)
+}
+
+// Equal reports whether k and other are the same key
+func (k PublicKey) Equal(other PublicKey) bool {
+	return k.Type == other.Type && string(k.Bytes) == string(other.Bytes)
+}
+
+// CryptoPublicKey returns the key as the standard library types crypto
+// libraries take: an ed25519.PublicKey or an *ecdsa.PublicKey for P-256.
+// secp256k1 has no standard library curve and gives ErrUnsupportedKey.
+func (k PublicKey) CryptoPublicKey() (crypto.PublicKey, error) {
+	switch k.Type {
+	case Ed25519:
+		if len(k.Bytes) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("Ed25519 key must be %d bytes, got %d", ed25519.PublicKeySize, len(k.Bytes))
+		}
+		return ed25519.PublicKey(append([]byte(nil), k.Bytes...)), nil
+	case P256:
+		x, y, err := p256Point(k.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("%w: no standard library type for %q keys", ErrUnsupportedKey, k.Type)
+}
+
+// Verify checks a signature of message: a raw Ed25519 signature, or an
+// ECDSA signature over SHA-256 encoded as r || s (the JWS form)
+func (k PublicKey) Verify(message, sig []byte) bool {
+	switch k.Type {
+	case Ed25519:
+		return len(k.Bytes) == ed25519.PublicKeySize && ed25519.Verify(k.Bytes, message, sig)
+	case Secp256k1:
+		p, err := parseSecp256k1(k.Bytes)
+		digest := sha256.Sum256(message)
+		return err == nil && verifySecp256k1(p, digest[:], sig)
+	case P256:
+		x, y, err := p256Point(k.Bytes)
+		if err != nil || len(sig) != 64 {
+			return false
+		}
+		digest := sha256.Sum256(message)
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		return ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+	}
+	return false
+}
+
+// PrivateKey is a key pair. Its secret is the 32-byte Ed25519 seed or the
+// 32-byte elliptic curve scalar.
+type PrivateKey struct {
+	Public PublicKey
+	secret []byte
+}
+
+// Generate creates a key pair of type t from crypto/rand
+func Generate(t Type) (*PrivateKey, error) {
+	secret := make([]byte, 32)
+	switch t {
+	case Ed25519:
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	case Secp256k1:
+		priv, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		secret = priv.Serialize()
+	case P256:
+		priv, err := ecdh.P256().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		secret = priv.Bytes()
+	default:
+		return nil, fmt.Errorf("%w: type %q", ErrUnsupportedKey, t)
+	}
+	return NewPrivateKey(t, secret)
+}
+
+// NewPrivateKey restores a key pair from its secret
+func NewPrivateKey(t Type, secret []byte) (*PrivateKey, error) {
+	var pub []byte
+	switch t {
+	case Ed25519:
+		if len(secret) != ed25519.SeedSize {
+			return nil, fmt.Errorf("Ed25519 seed must be %d bytes, got %d", ed25519.SeedSize, len(secret))
+		}
+		pub = ed25519.NewKeyFromSeed(secret).Public().(ed25519.PublicKey)
+	case Secp256k1:
+		priv, err := secp256k1Key(secret)
+		if err != nil {
+			return nil, err
+		}
+		pub = priv.PubKey().SerializeCompressed()
+	case P256:
+		priv, err := ecdh.P256().NewPrivateKey(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid P-256 private key: %w", err)
+		}
+		pub = priv.PublicKey().Bytes()
+	default:
+		return nil, fmt.Errorf("%w: type %q", ErrUnsupportedKey, t)
+	}
+	public, err := NewPublicKey(t, pub)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{Public: public, secret: append([]byte(nil), secret...)}, nil
+}
+
+// FromEd25519 wraps an Ed25519 private key
+func FromEd25519(key ed25519.PrivateKey) (*PrivateKey, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("Ed25519 private key must be %d bytes, got %d", ed25519.PrivateKeySize, len(key))
+	}
+	return NewPrivateKey(Ed25519, key.Seed())
+}
+
+// Type returns the key type
+func (k *PrivateKey) Type() Type {
+	return k.Public.Type
+}
+
+// Bytes returns the secret, for storage
+func (k *PrivateKey) Bytes() []byte {
+	return append([]byte(nil), k.secret...)
+}
+
+// Ed25519 returns the key for use with crypto/ed25519, or nil for other types
+func (k *PrivateKey) Ed25519() ed25519.PrivateKey {
+	if k.Public.Type != Ed25519 {
+		return nil
+	}
+	return ed25519.NewKeyFromSeed(k.secret)
+}
+
+// Sign signs message: with Ed25519, or with ECDSA over SHA-256 giving r || s
+func (k *PrivateKey) Sign(message []byte) ([]byte, error) {
+	switch k.Public.Type {
+	case Ed25519:
+		return ed25519.Sign(k.Ed25519(), message), nil
+	case Secp256k1:
+		priv, err := secp256k1Key(k.secret)
+		if err != nil {
+			return nil, err
+		}
+		digest := sha256.Sum256(message)
+		return signSecp256k1(priv, digest[:]), nil
+	case P256:
+		x, y, err := p256Point(k.Public.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		priv := &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y},
+			D:         new(big.Int).SetBytes(k.secret),
+		}
+		digest := sha256.Sum256(message)
+		r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}
+	return nil, fmt.Errorf("%w: type %q", ErrUnsupportedKey, k.Public.Type)
+}
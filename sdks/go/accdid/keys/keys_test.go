@@ -0,0 +1,284 @@
+package keys
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// RFC 8032 section 7.1, test 1, and its JWK form from RFC 8037 appendix A
+func TestEd25519_RFC8032(t *testing.T) {
+	seed := mustHex(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	key, err := NewPrivateKey(Ed25519, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(key.Public.Bytes); got != "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a" {
+		t.Errorf("Unexpected public key %s", got)
+	}
+	sig, _ := key.Sign(nil)
+	want := "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b"
+	if hex.EncodeToString(sig) != want {
+		t.Errorf("Unexpected signature %x", sig)
+	}
+	if !key.Public.Verify(nil, sig) || key.Public.Verify([]byte("x"), sig) {
+		t.Error("Verify disagrees with the test vector")
+	}
+
+	jwk, _ := key.Public.JWK()
+	if jwk.Kty != "OKP" || jwk.Crv != "Ed25519" || jwk.X != "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo" {
+		t.Errorf("Unexpected JWK %+v", jwk)
+	}
+	if !strings.HasPrefix(key.Public.DIDKey(), "did:key:z6Mk") {
+		t.Errorf("Expected an Ed25519 did:key, got %s", key.Public.DIDKey())
+	}
+}
+
+// Points k·G from the SEC 2 generator, as listed in the usual secp256k1 test
+// vectors
+func TestSecp256k1_ScalarMultiples(t *testing.T) {
+	tests := []struct {
+		scalar string
+		x, y   string
+	}{
+		{"01", "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", "483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"},
+		{"02", "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5", "1ae168fea63dc339a3c58419466ceaeef7f632653266d0e1236431a950cfe52a"},
+		{"03", "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9", "388f7b0f632de8140fe337e62a37f3566500a99934c2231b6cb9fd7584b8e672"},
+		{"aa5e28d6a97a2479a65527f7290311a3624d4cc0fa1578598ee3c2613bf99522", "34f9460f0e4f08393d192b3c5133a6ba099aa0ad9fd54ebccfacdfa239ff49c6", "0b71ea9bd730fd8923f6d25a7a91e7dd7728a960686cb5a901bb419e0f2ca232"},
+	}
+	for _, tt := range tests {
+		secret := make([]byte, 32)
+		raw := mustHex(t, tt.scalar)
+		copy(secret[32-len(raw):], raw)
+		key, err := NewPrivateKey(Secp256k1, secret)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.scalar, err)
+		}
+		jwk, _ := key.Public.JWK()
+		x, y, _ := key.Public.coordinates()
+		if hex.EncodeToString(x) != tt.x || hex.EncodeToString(y) != tt.y {
+			t.Errorf("%s·G = (%x, %x), want (%s, %s)", tt.scalar, x, y, tt.x, tt.y)
+		}
+		back, err := ParseJWK(jwk)
+		if err != nil || !back.Equal(key.Public) {
+			t.Errorf("%s: JWK round trip gave %v, %v", tt.scalar, back, err)
+		}
+	}
+}
+
+func TestSecp256k1_Signatures(t *testing.T) {
+	key, err := NewPrivateKey(Secp256k1, mustHex(t, "aa5e28d6a97a2479a65527f7290311a3624d4cc0fa1578598ee3c2613bf99522"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello accumulate")
+	sig, _ := key.Sign(message)
+	again, _ := key.Sign(message)
+	if !bytes.Equal(sig, again) {
+		t.Error("Expected deterministic (RFC 6979) signatures")
+	}
+
+	// s is low; the high form of the same signature still verifies
+	n := new(big.Int).SetBytes(mustHex(t, "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"))
+	s := new(big.Int).SetBytes(sig[32:])
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		t.Error("Expected a low s")
+	}
+	high := append([]byte(nil), sig...)
+	new(big.Int).Sub(n, s).FillBytes(high[32:])
+	if !key.Public.Verify(message, high) {
+		t.Error("Expected the high s form to verify")
+	}
+
+	for _, secret := range [][]byte{make([]byte, 32), n.Bytes(), mustHex(t, "01")} {
+		if _, err := NewPrivateKey(Secp256k1, secret); err == nil {
+			t.Errorf("Expected %x to be rejected", secret)
+		}
+	}
+}
+
+// Keys from the did:key specification's test vectors
+func TestParseDIDKey_SpecVectors(t *testing.T) {
+	tests := []struct {
+		did  string
+		typ  Type
+		size int
+	}{
+		{"did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp", Ed25519, 32},
+		{"did:key:z6MkjchhfUsD6mmvni8mCdXHw216Xrm9bQe2mBH1P5RDjVJG#z6MkjchhfUsD6mmvni8mCdXHw216Xrm9bQe2mBH1P5RDjVJG", Ed25519, 32},
+		{"did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme", Secp256k1, 33},
+		{"did:key:zDnaerDaTF5BXEavCrfRZEk316dpbLsfPDZ3WJ5hRTPFU2169", P256, 33},
+	}
+	for _, tt := range tests {
+		key, err := ParseDIDKey(tt.did)
+		if err != nil {
+			t.Errorf("%s: %v", tt.did, err)
+			continue
+		}
+		if key.Type != tt.typ || len(key.Bytes) != tt.size {
+			t.Errorf("%s: got a %d-byte %s key", tt.did, len(key.Bytes), key.Type)
+		}
+		if did, _, _ := strings.Cut(tt.did, "#"); key.DIDKey() != did {
+			t.Errorf("Expected %s to round trip, got %s", did, key.DIDKey())
+		}
+	}
+
+	for _, bad := range []string{"did:acc:alice", "did:key:6Mk", "did:key:z6Mk", "did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBmf"} {
+		if _, err := ParseDIDKey(bad); err == nil {
+			t.Errorf("Expected %s to be rejected", bad)
+		}
+	}
+}
+
+// The P-256 key of RFC 7517 appendix A.1
+func TestP256_JWK(t *testing.T) {
+	jwk := &JWK{Kty: "EC", Crv: "P-256", X: "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4", Y: "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}
+	key, err := ParseJWK(jwk)
+	if err != nil {
+		t.Fatalf("ParseJWK: %v", err)
+	}
+	if len(key.Bytes) != 33 || !strings.HasPrefix(key.Fingerprint(), "zDn") {
+		t.Errorf("Expected a compressed key with a zDn fingerprint, got %s", key.Fingerprint())
+	}
+	back, _ := key.JWK()
+	if back.X != jwk.X || back.Y != jwk.Y {
+		t.Errorf("JWK round trip changed the key: %+v", back)
+	}
+
+	jwk.Y = "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFzM"
+	if _, err := ParseJWK(jwk); err == nil {
+		t.Error("Expected a point off the curve to be rejected")
+	}
+}
+
+func TestGenerate_SignVerify(t *testing.T) {
+	for _, typ := range []Type{Ed25519, Secp256k1, P256} {
+		key, err := Generate(typ)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		message := []byte("hello accumulate")
+		sig, err := key.Sign(message)
+		if err != nil {
+			t.Fatalf("%s Sign: %v", typ, err)
+		}
+		if !key.Public.Verify(message, sig) {
+			t.Errorf("%s: signature does not verify", typ)
+		}
+		if key.Public.Verify([]byte("hello accumulatE"), sig) {
+			t.Errorf("%s: signature verifies another message", typ)
+		}
+
+		restored, err := NewPrivateKey(typ, key.Bytes())
+		if err != nil || !restored.Public.Equal(key.Public) {
+			t.Errorf("%s: restoring the secret gave %v, %v", typ, restored, err)
+		}
+		parsed, err := ParseMultibase(key.Public.Multibase())
+		if err != nil || !parsed.Equal(key.Public) {
+			t.Errorf("%s: multibase round trip gave %v, %v", typ, parsed, err)
+		}
+		parsed, err = ParseBase58(typ, key.Public.Base58())
+		if err != nil || !parsed.Equal(key.Public) {
+			t.Errorf("%s: base58 round trip gave %v, %v", typ, parsed, err)
+		}
+	}
+
+	if _, err := Generate("RSA"); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Expected ErrUnsupportedKey, got %v", err)
+	}
+}
+
+func TestVerificationMethod(t *testing.T) {
+	ed, _ := ParseDIDKey("did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp")
+	k1, _ := ParseDIDKey("did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme")
+
+	tests := []struct {
+		name       string
+		key        PublicKey
+		methodType string
+		wantType   string
+		wantJSON   string
+	}{
+		{"Ed25519 default", ed, "", Ed25519VerificationKey2020, `"publicKeyMultibase":"z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"`},
+		{"Ed25519 JWK", ed, JsonWebKey2020, JsonWebKey2020, `"publicKeyJwk":{"kty":"OKP","crv":"Ed25519","x":`},
+		{"secp256k1 default", k1, "", Multikey, `"publicKeyMultibase":"zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme"`},
+		{"secp256k1 JWK", k1, JsonWebKey2020, JsonWebKey2020, `"crv":"secp256k1"`},
+	}
+	for _, tt := range tests {
+		vm, err := tt.key.VerificationMethod("did:example:123#key-1", "did:example:123", tt.methodType)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		encoded, _ := json.Marshal(vm)
+		if vm.Type != tt.wantType || !strings.Contains(string(encoded), tt.wantJSON) {
+			t.Errorf("%s: unexpected method %s", tt.name, encoded)
+		}
+		back, err := FromVerificationMethod(&vm)
+		if err != nil || !back.Equal(tt.key) {
+			t.Errorf("%s: decoding the method gave %v, %v", tt.name, back, err)
+		}
+		if Context(vm.Type) == "" {
+			t.Errorf("%s: no context for %s", tt.name, vm.Type)
+		}
+	}
+
+	if _, err := k1.VerificationMethod("did:example:123#key-1", "", Ed25519VerificationKey2020); err == nil {
+		t.Error("Expected Ed25519VerificationKey2020 to refuse a secp256k1 key")
+	}
+
+	legacy := &VerificationMethod{Type: Ed25519VerificationKey2018, PublicKeyBase58: ed.Base58()}
+	if back, err := FromVerificationMethod(legacy); err != nil || !back.Equal(ed) {
+		t.Errorf("Expected the 2018 base58 key, got %v, %v", back, err)
+	}
+	hexKey := &VerificationMethod{Type: EcdsaSecp256k1VerificationKey2019, PublicKeyHex: hex.EncodeToString(k1.Bytes)}
+	if back, err := FromVerificationMethod(hexKey); err != nil || !back.Equal(k1) {
+		t.Errorf("Expected the 2019 hex key, got %v, %v", back, err)
+	}
+}
+
+func TestCryptoPublicKey(t *testing.T) {
+	message := []byte("interop")
+	for _, typ := range []Type{Ed25519, P256} {
+		key, _ := Generate(typ)
+		sig, _ := key.Sign(message)
+		pub, err := key.Public.CryptoPublicKey()
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		switch pub := pub.(type) {
+		case ed25519.PublicKey:
+			if !ed25519.Verify(pub, message, sig) {
+				t.Errorf("%s: the standard library rejects the signature", typ)
+			}
+		case *ecdsa.PublicKey:
+			digest := sha256.Sum256(message)
+			if !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+				t.Errorf("%s: the standard library rejects the signature", typ)
+			}
+		default:
+			t.Errorf("%s: unexpected type %T", typ, pub)
+		}
+	}
+
+	key, _ := Generate(Secp256k1)
+	if _, err := key.Public.CryptoPublicKey(); !errors.Is(err, ErrUnsupportedKey) {
+		t.Errorf("Expected ErrUnsupportedKey for secp256k1, got %v", err)
+	}
+}
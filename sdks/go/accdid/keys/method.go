@@ -0,0 +1,162 @@
+package keys
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jsonobj"
+)
+
+// Verification method types
+const (
+	// Ed25519VerificationKey2020 holds an Ed25519 key in publicKeyMultibase
+	Ed25519VerificationKey2020 = "Ed25519VerificationKey2020"
+
+	// JsonWebKey2020 holds a key of any type in publicKeyJwk
+	JsonWebKey2020 = "JsonWebKey2020"
+
+	// Multikey holds a key of any type in publicKeyMultibase
+	Multikey = "Multikey"
+
+	// Ed25519VerificationKey2018 and EcdsaSecp256k1VerificationKey2019 are
+	// older types, read but not written
+	Ed25519VerificationKey2018        = "Ed25519VerificationKey2018"
+	EcdsaSecp256k1VerificationKey2019 = "EcdsaSecp256k1VerificationKey2019"
+)
+
+// contexts are the JSON-LD contexts defining the method types
+var contexts = map[string]string{
+	Ed25519VerificationKey2020:        "https://w3id.org/security/suites/ed25519-2020/v1",
+	JsonWebKey2020:                    "https://w3id.org/security/suites/jws-2020/v1",
+	Multikey:                          "https://w3id.org/security/multikey/v1",
+	Ed25519VerificationKey2018:        "https://w3id.org/security/suites/ed25519-2018/v1",
+	EcdsaSecp256k1VerificationKey2019: "https://w3id.org/security/suites/secp256k1-2019/v1",
+}
+
+// Context returns the JSON-LD context defining methodType, or "" if unknown
+func Context(methodType string) string {
+	return contexts[methodType]
+}
+
+// VerificationMethod is a public key or other means to verify a proof
+type VerificationMethod struct {
+	ID                 string
+	Type               string
+	Controller         string
+	PublicKeyJwk       *JWK
+	PublicKeyMultibase string
+	PublicKeyBase58    string
+	PublicKeyHex       string
+
+	// Extra holds the properties not modeled above, by name
+	Extra map[string]json.RawMessage
+}
+
+// MarshalJSON encodes the method, followed by its Extra properties
+func (vm VerificationMethod) MarshalJSON() ([]byte, error) {
+	var w jsonobj.Writer
+	w.Field("id", vm.ID)
+	w.Optional("type", vm.Type)
+	w.Optional("controller", vm.Controller)
+	if vm.PublicKeyJwk != nil {
+		w.Field("publicKeyJwk", vm.PublicKeyJwk)
+	}
+	w.Optional("publicKeyMultibase", vm.PublicKeyMultibase)
+	w.Optional("publicKeyBase58", vm.PublicKeyBase58)
+	w.Optional("publicKeyHex", vm.PublicKeyHex)
+	return w.Close(vm.Extra)
+}
+
+// UnmarshalJSON decodes a method, keeping unknown properties in Extra
+func (vm *VerificationMethod) UnmarshalJSON(data []byte) error {
+	*vm = VerificationMethod{}
+	return jsonobj.Decode(data, "verification method", map[string]interface{}{
+		"id":                 &vm.ID,
+		"type":               &vm.Type,
+		"controller":         &vm.Controller,
+		"publicKeyJwk":       &vm.PublicKeyJwk,
+		"publicKeyMultibase": &vm.PublicKeyMultibase,
+		"publicKeyBase58":    &vm.PublicKeyBase58,
+		"publicKeyHex":       &vm.PublicKeyHex,
+	}, &vm.Extra)
+}
+
+// DefaultMethodType returns the method type VerificationMethod uses when none
+// is given: Ed25519VerificationKey2020 for Ed25519 keys, Multikey otherwise
+func DefaultMethodType(t Type) string {
+	if t == Ed25519 {
+		return Ed25519VerificationKey2020
+	}
+	return Multikey
+}
+
+// VerificationMethod returns a verification method of methodType holding the
+// key; an empty methodType picks DefaultMethodType
+func (k PublicKey) VerificationMethod(id, controller, methodType string) (VerificationMethod, error) {
+	if methodType == "" {
+		methodType = DefaultMethodType(k.Type)
+	}
+	vm := VerificationMethod{ID: id, Type: methodType, Controller: controller}
+	switch methodType {
+	case Ed25519VerificationKey2020:
+		if k.Type != Ed25519 {
+			return VerificationMethod{}, fmt.Errorf("%s cannot hold a %s key", methodType, k.Type)
+		}
+		vm.PublicKeyMultibase = k.Multibase()
+	case Multikey:
+		if k.Code() == 0 {
+			return VerificationMethod{}, fmt.Errorf("%w: type %q", ErrUnsupportedKey, k.Type)
+		}
+		vm.PublicKeyMultibase = k.Multibase()
+	case JsonWebKey2020:
+		jwk, err := k.JWK()
+		if err != nil {
+			return VerificationMethod{}, err
+		}
+		vm.PublicKeyJwk = jwk
+	default:
+		return VerificationMethod{}, fmt.Errorf("%w: verification method type %q", ErrUnsupportedKey, methodType)
+	}
+	return vm, nil
+}
+
+// FromVerificationMethod decodes the key of a verification method, whichever
+// of publicKeyJwk, publicKeyMultibase, publicKeyBase58 or publicKeyHex holds it
+func FromVerificationMethod(vm *VerificationMethod) (PublicKey, error) {
+	switch {
+	case vm.PublicKeyJwk != nil:
+		return ParseJWK(vm.PublicKeyJwk)
+	case vm.PublicKeyMultibase != "":
+		return ParseMultibase(vm.PublicKeyMultibase)
+	case vm.PublicKeyBase58 != "":
+		t, err := legacyType(vm.Type)
+		if err != nil {
+			return PublicKey{}, err
+		}
+		return ParseBase58(t, vm.PublicKeyBase58)
+	case vm.PublicKeyHex != "":
+		t, err := legacyType(vm.Type)
+		if err != nil {
+			return PublicKey{}, err
+		}
+		data, err := hex.DecodeString(vm.PublicKeyHex)
+		if err != nil {
+			return PublicKey{}, fmt.Errorf("publicKeyHex: %w", err)
+		}
+		return NewPublicKey(t, data)
+	}
+	return PublicKey{}, errors.New("verification method has no public key")
+}
+
+// legacyType returns the key type of the method types that store raw keys
+func legacyType(methodType string) (Type, error) {
+	switch methodType {
+	case Ed25519VerificationKey2018, Ed25519VerificationKey2020:
+		return Ed25519, nil
+	case EcdsaSecp256k1VerificationKey2019:
+		return Secp256k1, nil
+	}
+	return "", fmt.Errorf("%w: raw key of verification method type %q", ErrUnsupportedKey, methodType)
+}
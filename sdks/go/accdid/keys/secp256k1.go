@@ -0,0 +1,54 @@
+package keys
+
+import (
+	"errors"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// The secp256k1 curve (SEC 2, used by Bitcoin and Ethereum) is not in the
+// standard library. Keys, signing and verification use dcrd's constant time
+// implementation; signatures use RFC 6979 deterministic nonces.
+
+// parseSecp256k1 decodes a compressed (33 bytes) or uncompressed (65 bytes)
+// SEC 1 point and checks that it is on the curve
+func parseSecp256k1(b []byte) (*secp256k1.PublicKey, error) {
+	if len(b) != 33 && len(b) != 65 {
+		return nil, errors.New("secp256k1 key must be a 33-byte compressed or 65-byte uncompressed point")
+	}
+	pub, err := secp256k1.ParsePubKey(b)
+	if err != nil {
+		return nil, errors.New("secp256k1 point is not on the curve")
+	}
+	return pub, nil
+}
+
+// secp256k1Key checks that d is a valid private key
+func secp256k1Key(d []byte) (*secp256k1.PrivateKey, error) {
+	var k secp256k1.ModNScalar
+	if len(d) != 32 || k.SetByteSlice(d) || k.IsZero() {
+		return nil, errors.New("secp256k1 private key must be a 32-byte scalar in [1, n)")
+	}
+	return secp256k1.NewPrivateKey(&k), nil
+}
+
+// signSecp256k1 returns an ECDSA signature r || s of the 32-byte digest with
+// a low s, as ES256K and Bitcoin require
+func signSecp256k1(key *secp256k1.PrivateKey, digest []byte) []byte {
+	// The compact form is a recovery byte followed by r || s
+	return ecdsa.SignCompact(key, digest, true)[1:]
+}
+
+// verifySecp256k1 checks an r || s signature of the 32-byte digest. High s
+// values are accepted, as most verifiers do.
+func verifySecp256k1(pub *secp256k1.PublicKey, digest, sig []byte) bool {
+	if len(sig) != 64 {
+		return false
+	}
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) {
+		return false
+	}
+	return ecdsa.NewSignature(&r, &s).Verify(digest, pub)
+}
@@ -0,0 +1,385 @@
+package accdid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jcs"
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// DefaultManagerWait bounds how long a Manager waits for the resolver to
+// reflect a write when ManagerOptions.WaitTimeout is not set
+const DefaultManagerWait = 2 * time.Minute
+
+// ManagerOptions configures a Manager
+type ManagerOptions struct {
+	// PollInterval between resolver reads while waiting for a write to show
+	// (default DefaultPollInterval)
+	PollInterval time.Duration
+
+	// WaitTimeout bounds the wait for a write to show (default
+	// DefaultManagerWait); the context deadline applies as well
+	WaitTimeout time.Duration
+
+	// DryRun stops each call before it writes: the result holds the write
+	// in Planned, and nothing is submitted
+	DryRun bool
+}
+
+// Manager runs DID lifecycle operations. Each call reads the current
+// document, computes the change, submits it with an idempotency key derived
+// from the change, and waits until the resolver returns the new version, so
+// the next read sees the write.
+//
+// Updates replace the whole document. A write by someone else between the
+// read and the submission is overwritten.
+type Manager struct {
+	registrar *RegistrarClient
+	resolver  Resolver
+	opts      ManagerOptions
+}
+
+// NewManager creates a manager writing through registrar and reading
+// through resolver
+func NewManager(registrar *RegistrarClient, resolver Resolver, opts ManagerOptions) *Manager {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.WaitTimeout <= 0 {
+		opts.WaitTimeout = DefaultManagerWait
+	}
+	return &Manager{registrar: registrar, resolver: resolver, opts: opts}
+}
+
+// VersionMetadata describes the document version a Manager call produced,
+// as reported by the resolver
+type VersionMetadata struct {
+	DID           string
+	TransactionID string
+	VersionID     string
+	ContentHash   string
+	Updated       time.Time
+	Deactivated   bool
+
+	// Document is the resolved document, nil once deactivated
+	Document *Document
+
+	// DocumentMetadata is the resolver's didDocumentMetadata
+	DocumentMetadata map[string]interface{}
+
+	// Planned is the write a DryRun call would have submitted
+	Planned *PlannedWrite
+}
+
+// PlannedWrite is a registrar request a Manager computed but, in DryRun
+// mode, did not submit
+type PlannedWrite struct {
+	Operation      string      `json:"operation"` // create, update or deactivate
+	Endpoint       string      `json:"endpoint"`  // registrar path
+	IdempotencyKey string      `json:"idempotencyKey"`
+	BaseVersionID  string      `json:"baseVersionId,omitempty"`
+	Request        interface{} `json:"request"`
+
+	// ContentHash is the hex SHA-256 of the canonical (JCS) document
+	ContentHash string `json:"contentHash,omitempty"`
+}
+
+// Create registers doc, adding a verification method for each of pubs that
+// it does not already hold, named key-1, key-2, ... and used for
+// authentication and assertions
+func (m *Manager) Create(ctx context.Context, doc *Document, pubs ...keys.PublicKey) (*VersionMetadata, error) {
+	if doc == nil {
+		return nil, errors.New("document is required")
+	}
+	clone, err := cloneDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	builder := &DocumentBuilder{doc: clone}
+	for _, pub := range pubs {
+		if hasKey(builder.doc, pub) {
+			continue
+		}
+		builder.AddKey(nextKeyFragment(builder.doc), pub, "")
+	}
+	created, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	key := idempotencyKeyFor("create", created.ID, "", raw)
+	req := NativeRegisterRequest{DID: created.ID, DIDDocument: raw}
+	if m.opts.DryRun {
+		return planned(created.ID, created, raw, PlannedWrite{Operation: "create", Endpoint: "/register", IdempotencyKey: key, Request: req})
+	}
+	ctx = WithIdempotencyKey(ctx, key)
+	txID, err := m.registrar.Register(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return m.waitFor(ctx, created.ID, txID, func(res *ResolutionResult) bool {
+		return sameDocument(res.DIDDocument, raw)
+	})
+}
+
+// Update applies mutate to a copy of the current document of did, submits
+// the result and waits for it to show
+func (m *Manager) Update(ctx context.Context, did string, mutate func(*Document) error) (*VersionMetadata, error) {
+	return m.update(ctx, did, "update", mutate)
+}
+
+// AddService adds svc, whose ID may be a fragment, to the document of did
+func (m *Manager) AddService(ctx context.Context, did string, svc Service) (*VersionMetadata, error) {
+	return m.update(ctx, did, "addService", func(doc *Document) error {
+		if svc.Type == "" || svc.ServiceEndpoint == nil {
+			return fmt.Errorf("service %s needs a type and an endpoint", svc.ID)
+		}
+		svc.ID = doc.AbsoluteID(svc.ID)
+		if _, exists := doc.FindService(svc.ID); exists {
+			return fmt.Errorf("service %s already exists", svc.ID)
+		}
+		doc.Service = append(doc.Service, svc)
+		return nil
+	})
+}
+
+// RemoveService removes the service with the given ID or fragment
+func (m *Manager) RemoveService(ctx context.Context, did, id string) (*VersionMetadata, error) {
+	return m.update(ctx, did, "removeService", func(doc *Document) error {
+		want := doc.AbsoluteID(id)
+		for i := range doc.Service {
+			if doc.AbsoluteID(doc.Service[i].ID) == want {
+				doc.Service = append(doc.Service[:i], doc.Service[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: service %s", ErrNotFound, want)
+	})
+}
+
+// AddVerificationMethod adds vm and references it from each of rels. A
+// fragment ID is expanded and an empty controller set to the DID.
+func (m *Manager) AddVerificationMethod(ctx context.Context, did string, vm VerificationMethod, rels ...Relationship) (*VersionMetadata, error) {
+	return m.update(ctx, did, "addVerificationMethod", func(doc *Document) error {
+		_, err := (&DocumentBuilder{doc: doc}).AddVerificationMethod(vm, rels...).Build()
+		return err
+	})
+}
+
+// RotateKey replaces the key of the verification method methodID with pub,
+// keeping its ID, type and relationships, so references to it stay valid
+// and verifiers checking older signatures resolve the document at the time
+// they were made
+func (m *Manager) RotateKey(ctx context.Context, did, methodID string, pub keys.PublicKey) (*VersionMetadata, error) {
+	return m.update(ctx, did, "rotateKey", func(doc *Document) error {
+		vm, ok := doc.FindVerificationMethod(methodID)
+		if !ok {
+			return fmt.Errorf("%w: verification method %s", ErrNotFound, doc.AbsoluteID(methodID))
+		}
+		if current, err := keys.FromVerificationMethod(vm); err == nil && current.Equal(pub) {
+			return fmt.Errorf("verification method %s already holds this key", vm.ID)
+		}
+		rotated, err := pub.VerificationMethod(vm.ID, vm.Controller, vm.Type)
+		if err != nil {
+			return fmt.Errorf("cannot rotate %s: %w", vm.ID, err)
+		}
+		rotated.Extra = vm.Extra
+		*vm = rotated
+		return nil
+	})
+}
+
+// Deactivate deactivates did and waits until the resolver reports it
+func (m *Manager) Deactivate(ctx context.Context, did, reason string) (*VersionMetadata, error) {
+	current, err := m.resolver.Resolve(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	base := metadataString(current.DocumentMetadata, "versionId")
+
+	key := idempotencyKeyFor("deactivate", did, base, []byte(reason))
+	req := NativeDeactivateRequest{DID: did, Reason: reason}
+	if m.opts.DryRun {
+		return planned(did, nil, nil, PlannedWrite{Operation: "deactivate", Endpoint: "/native/deactivate", IdempotencyKey: key, BaseVersionID: base, Request: req})
+	}
+	ctx = WithIdempotencyKey(ctx, key)
+	txID, err := m.registrar.Deactivate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return m.waitFor(ctx, did, txID, nil)
+}
+
+// update applies mutate to a copy of the current document of did, submits
+// the result and waits for it to show
+func (m *Manager) update(ctx context.Context, did, action string, mutate func(*Document) error) (*VersionMetadata, error) {
+	current, err := m.resolver.Resolve(ctx, did)
+	if err != nil {
+		return nil, err
+	}
+	if current.DIDDocument == nil {
+		return nil, fmt.Errorf("%w: %s resolved without a document", ErrNotFound, did)
+	}
+	base := metadataString(current.DocumentMetadata, "versionId")
+
+	doc, err := cloneDocument(current.DIDDocument)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", action, err)
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+
+	// The key covers the version the change is based on, so repeating the
+	// same change later is a new write
+	key := idempotencyKeyFor(action, did, base, raw)
+	req := NativeUpdateRequest{DID: did, DIDDocument: raw}
+	if m.opts.DryRun {
+		return planned(did, doc, raw, PlannedWrite{Operation: "update", Endpoint: "/native/update", IdempotencyKey: key, BaseVersionID: base, Request: req})
+	}
+	ctx = WithIdempotencyKey(ctx, key)
+	txID, err := m.registrar.Update(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return m.waitFor(ctx, did, txID, func(res *ResolutionResult) bool {
+		version := metadataString(res.DocumentMetadata, "versionId")
+		return sameDocument(res.DIDDocument, raw) || (base != "" && version != "" && version != base)
+	})
+}
+
+// waitFor polls the resolver until reflected accepts its result, or until
+// did is deactivated when reflected is nil
+func (m *Manager) waitFor(ctx context.Context, did, txID string, reflected func(*ResolutionResult) bool) (*VersionMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.WaitTimeout)
+	defer cancel()
+
+	for {
+		res, err := m.resolver.Resolve(ctx, did)
+		switch {
+		case errors.Is(err, ErrGoneDeactivated) && reflected == nil:
+			return &VersionMetadata{DID: did, TransactionID: txID, Deactivated: true}, nil
+		case err == nil && reflected == nil:
+			if deactivated, _ := res.DocumentMetadata["deactivated"].(bool); deactivated {
+				return versionMetadata(did, txID, res), nil
+			}
+		case err == nil && reflected(res):
+			return versionMetadata(did, txID, res), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: transaction %s for %s not yet visible to the resolver", ErrTimeout, txID, did)
+		case <-time.After(m.opts.PollInterval):
+		}
+	}
+}
+
+func versionMetadata(did, txID string, res *ResolutionResult) *VersionMetadata {
+	meta := &VersionMetadata{
+		DID:              did,
+		TransactionID:    txID,
+		VersionID:        metadataString(res.DocumentMetadata, "versionId"),
+		ContentHash:      metadataString(res.DocumentMetadata, "contentHash"),
+		Document:         res.DIDDocument,
+		DocumentMetadata: res.DocumentMetadata,
+	}
+	meta.Deactivated, _ = res.DocumentMetadata["deactivated"].(bool)
+	if updated, err := time.Parse(time.RFC3339, metadataString(res.DocumentMetadata, "updated")); err == nil {
+		meta.Updated = updated
+	}
+	return meta
+}
+
+// planned reports a write a DryRun call stopped before. doc is the document
+// the write would leave, encoded as raw; both are nil for a deactivation.
+func planned(did string, doc *Document, raw []byte, write PlannedWrite) (*VersionMetadata, error) {
+	if raw != nil {
+		canonical, err := jcs.Canonicalize(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize document: %w", err)
+		}
+		hash := sha256.Sum256(canonical)
+		write.ContentHash = hex.EncodeToString(hash[:])
+	}
+	return &VersionMetadata{DID: did, Document: doc, Deactivated: doc == nil, Planned: &write}, nil
+}
+
+// metadataString returns a string member of document metadata, or ""
+func metadataString(metadata map[string]interface{}, key string) string {
+	s, _ := metadata[key].(string)
+	return s
+}
+
+// idempotencyKeyFor derives the Idempotency-Key of a change from what it does
+func idempotencyKeyFor(action, did, baseVersion string, content []byte) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(action), []byte(did), []byte(baseVersion), content} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return "accdid-" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// sameDocument reports whether doc and the encoded document raw are equal
+// after canonicalization
+func sameDocument(doc *Document, raw []byte) bool {
+	if doc == nil {
+		return false
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return false
+	}
+	a, errA := jcs.Canonicalize(encoded)
+	b, errB := jcs.Canonicalize(raw)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// cloneDocument returns a deep copy of doc
+func cloneDocument(doc *Document) (*Document, error) {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	var clone Document
+	if err := json.Unmarshal(encoded, &clone); err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	return &clone, nil
+}
+
+// hasKey reports whether a verification method of doc holds pub
+func hasKey(doc *Document, pub keys.PublicKey) bool {
+	for i := range doc.VerificationMethod {
+		if key, err := keys.FromVerificationMethod(&doc.VerificationMethod[i]); err == nil && key.Equal(pub) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextKeyFragment returns the first key-N fragment not used by doc
+func nextKeyFragment(doc *Document) string {
+	for n := 1; ; n++ {
+		fragment := fmt.Sprintf("key-%d", n)
+		if _, exists := doc.FindVerificationMethod(fragment); !exists {
+			return fragment
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package accdid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// fakeDIDService is a registrar and resolver sharing one document, whose
+// resolver lags a few reads behind each write
+type fakeDIDService struct {
+	mu          sync.Mutex
+	doc, prev   json.RawMessage
+	version     int
+	deactivated bool
+	lag         int
+	failNext    bool
+	keys        []string // Idempotency-Key of each write attempt
+	resolves    int
+}
+
+func (f *fakeDIDService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method == http.MethodPost {
+		f.keys = append(f.keys, r.Header.Get("Idempotency-Key"))
+		if f.failNext {
+			f.failNext = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			DID         string          `json:"did"`
+			DIDDocument json.RawMessage `json:"didDocument"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/register", "/native/update":
+			f.prev, f.doc = f.doc, req.DIDDocument
+		case "/native/deactivate":
+			f.prev, f.deactivated = f.doc, true
+		}
+		f.version++
+		f.lag = 2
+		fmt.Fprintf(w, `{"success":true,"txid":"tx-%d","did":%q}`, f.version, req.DID)
+		return
+	}
+
+	f.resolves++
+	doc, version, deactivated := f.doc, f.version, f.deactivated
+	if f.lag > 0 {
+		f.lag--
+		doc, version, deactivated = f.prev, version-1, false
+	}
+	switch {
+	case doc == nil:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"notFound","error":"notFound","message":"not found"}`))
+	case deactivated:
+		w.WriteHeader(http.StatusGone)
+		w.Write([]byte(`{"code":"deactivated","error":"deactivated","message":"deactivated"}`))
+	default:
+		fmt.Fprintf(w, `{"didDocument":%s,"didDocumentMetadata":{"versionId":"v%d","contentHash":"h%d","updated":"2025-01-0%dT00:00:00Z"}}`, doc, version, version, version)
+	}
+}
+
+func newTestManager(t *testing.T, service *fakeDIDService, wait time.Duration) *Manager {
+	t.Helper()
+	server := httptest.NewServer(service)
+	t.Cleanup(server.Close)
+
+	opts := ClientOptions{BaseURL: server.URL, Retries: RetryPolicy{Max: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}}
+	registrar, err := NewRegistrarClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := NewResolverClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(registrar, resolver, ManagerOptions{PollInterval: time.Millisecond, WaitTimeout: wait})
+}
+
+func TestManager_Lifecycle(t *testing.T) {
+	service := &fakeDIDService{}
+	m := newTestManager(t, service, 5*time.Second)
+	ctx := context.Background()
+
+	first, _ := keys.Generate(keys.Ed25519)
+	second, _ := keys.Generate(keys.P256)
+	draft, _ := NewDocument("did:acc:alice").Build()
+	created, err := m.Create(ctx, draft, first.Public)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.VersionID != "v1" || created.TransactionID != "tx-1" || !created.Updated.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected version %+v", created)
+	}
+	if !created.Document.HasRelationship(Authentication, "key-1") {
+		t.Error("Expected the key as key-1")
+	}
+	if len(draft.VerificationMethod) != 0 {
+		t.Error("Create must not modify the caller's document")
+	}
+
+	service.failNext = true
+	resolvesBefore := service.resolves
+	added, err := m.AddService(ctx, "did:acc:alice", Service{ID: "website", Type: "LinkedDomains", ServiceEndpoint: "https://alice.example"})
+	if err != nil {
+		t.Fatalf("AddService: %v", err)
+	}
+	if added.VersionID != "v2" || added.Document == nil {
+		t.Errorf("Expected to read the new version, got %+v", added)
+	}
+	if svc, ok := added.Document.FindService("website"); !ok || svc.ID != "did:acc:alice#website" {
+		t.Errorf("Expected the service in the returned document, got %+v", added.Document.Service)
+	}
+	if service.resolves-resolvesBefore < 3 {
+		t.Errorf("Expected to wait for the lagging resolver, resolved %d times", service.resolves-resolvesBefore)
+	}
+	if n := len(service.keys); service.keys[n-1] != service.keys[n-2] || service.keys[n-1] == service.keys[0] {
+		t.Errorf("Expected the retried update to reuse its own idempotency key, got %v", service.keys)
+	}
+
+	if _, err := m.AddService(ctx, "did:acc:alice", Service{ID: "#website", Type: "LinkedDomains", ServiceEndpoint: "https://b.example"}); err == nil {
+		t.Error("Expected a duplicate service to be refused")
+	}
+	if _, err := m.RemoveService(ctx, "did:acc:alice", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	vm, _ := second.Public.VerificationMethod("agreement", "", keys.JsonWebKey2020)
+	withMethod, err := m.AddVerificationMethod(ctx, "did:acc:alice", vm, KeyAgreement)
+	if err != nil || !withMethod.Document.HasRelationship(KeyAgreement, "agreement") {
+		t.Fatalf("AddVerificationMethod = %+v, %v", withMethod, err)
+	}
+
+	rotatedKey, _ := keys.Generate(keys.Ed25519)
+	rotated, err := m.RotateKey(ctx, "did:acc:alice", "key-1", rotatedKey.Public)
+	if err != nil {
+		t.Fatalf("RotateKey: %v", err)
+	}
+	method, _ := rotated.Document.FindVerificationMethod("key-1")
+	if got, _ := keys.FromVerificationMethod(method); !got.Equal(rotatedKey.Public) || method.Type != Ed25519VerificationKey2020 {
+		t.Errorf("Expected key-1 to hold the new key, got %+v", method)
+	}
+	if !rotated.Document.HasRelationship(AssertionMethod, "key-1") {
+		t.Error("Expected the rotated key to keep its relationships")
+	}
+	if _, err := m.RotateKey(ctx, "did:acc:alice", "key-1", rotatedKey.Public); err == nil {
+		t.Error("Expected rotating to the current key to be refused")
+	}
+
+	removed, err := m.RemoveService(ctx, "did:acc:alice", "#website")
+	if err != nil || len(removed.Document.Service) != 0 {
+		t.Fatalf("RemoveService = %+v, %v", removed, err)
+	}
+
+	gone, err := m.Deactivate(ctx, "did:acc:alice", "retired")
+	if err != nil || !gone.Deactivated || gone.TransactionID == "" {
+		t.Fatalf("Deactivate = %+v, %v", gone, err)
+	}
+	if _, err := m.AddService(ctx, "did:acc:alice", Service{ID: "x", Type: "T", ServiceEndpoint: "https://x"}); !errors.Is(err, ErrGoneDeactivated) {
+		t.Errorf("Expected updates of a deactivated DID to fail, got %v", err)
+	}
+}
+
+func TestManager_WaitTimeout(t *testing.T) {
+	service := &fakeDIDService{}
+	m := newTestManager(t, service, 20*time.Millisecond)
+	m.resolver = staleResolver{}
+
+	key, _ := keys.Generate(keys.Ed25519)
+	draft, _ := NewDocument("did:acc:slow").Build()
+	_, err := m.Create(context.Background(), draft, key.Public)
+	if !errors.Is(err, ErrTimeout) || len(service.keys) != 1 {
+		t.Errorf("Expected ErrTimeout after one write, got %v (%d writes)", err, len(service.keys))
+	}
+}
+
+func TestManager_DryRun(t *testing.T) {
+	service := &fakeDIDService{}
+	live := newTestManager(t, service, 5*time.Second)
+	dry := NewManager(live.registrar, live.resolver, ManagerOptions{DryRun: true})
+	ctx := context.Background()
+
+	key, _ := keys.Generate(keys.Ed25519)
+	draft, _ := NewDocument("did:acc:alice").Build()
+	plan, err := dry.Create(ctx, draft, key.Public)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if plan.Planned == nil || plan.Planned.Endpoint != "/register" || len(plan.Planned.ContentHash) != 64 || !plan.Document.HasRelationship(Authentication, "key-1") {
+		t.Errorf("Unexpected plan %+v", plan.Planned)
+	}
+	if len(service.keys) != 0 {
+		t.Fatal("A dry run must not write")
+	}
+
+	// The planned write is the one a live call makes
+	created, err := live.Create(ctx, draft, key.Public)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if service.keys[0] != plan.Planned.IdempotencyKey || created.Planned != nil {
+		t.Errorf("Expected the live write to use the planned key %s, got %v", plan.Planned.IdempotencyKey, service.keys)
+	}
+
+	plan, err = dry.Update(ctx, "did:acc:alice", func(doc *Document) error {
+		doc.AlsoKnownAs = append(doc.AlsoKnownAs, "https://alice.example")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	req, _ := plan.Planned.Request.(NativeUpdateRequest)
+	if plan.Planned.BaseVersionID != "v1" || !strings.Contains(string(req.DIDDocument), "alice.example") || len(service.keys) != 1 {
+		t.Errorf("Unexpected update plan %+v", plan.Planned)
+	}
+
+	plan, err = dry.Deactivate(ctx, "did:acc:alice", "retired")
+	if err != nil || !plan.Deactivated || plan.Document != nil || plan.Planned.Endpoint != "/native/deactivate" || len(service.keys) != 1 {
+		t.Errorf("Unexpected deactivation plan %+v, %v", plan, err)
+	}
+}
+
+// staleResolver never finds anything
+type staleResolver struct{}
+
+func (staleResolver) Resolve(context.Context, string) (*ResolutionResult, error) {
+	return nil, &HTTPError{StatusCode: 404, Err: ErrNotFound}
+}
+
+func (staleResolver) ResolveAt(context.Context, string, time.Time) (*ResolutionResult, error) {
+	return nil, &HTTPError{StatusCode: 404, Err: ErrNotFound}
+}
@@ -0,0 +1,249 @@
+package accdid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// MethodResolver resolves DIDs of several methods, handing each DID to the
+// Resolver registered for its method. It is safe for concurrent use and is
+// itself a Resolver, so Verifier and Manager accept it.
+type MethodResolver struct {
+	mu      sync.RWMutex
+	drivers map[string]Resolver
+}
+
+// NewMethodResolver creates a registry with the did:key and did:web resolvers
+// built in. did:acc is not registered: register a ResolverClient or a
+// DirectResolver for it.
+func NewMethodResolver() *MethodResolver {
+	return &MethodResolver{drivers: map[string]Resolver{
+		"key": &KeyResolver{},
+		"web": NewWebResolver(nil),
+	}}
+}
+
+// Register sets the resolver of a method, given without the "did:" prefix,
+// replacing any registered before
+func (m *MethodResolver) Register(method string, resolver Resolver) error {
+	if !validMethodName(method) {
+		return fmt.Errorf("invalid method name %q", method)
+	}
+	if resolver == nil {
+		return fmt.Errorf("resolver for method %q is nil", method)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drivers[method] = resolver
+	return nil
+}
+
+// Methods returns the registered method names in order
+func (m *MethodResolver) Methods() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	methods := make([]string, 0, len(m.drivers))
+	for method := range m.drivers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Resolve resolves the current DID document with the resolver of its method
+func (m *MethodResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	driver, err := m.driver(did)
+	if err != nil {
+		return nil, err
+	}
+	return driver.Resolve(ctx, did)
+}
+
+// ResolveAt resolves the DID document as it was at versionTime with the
+// resolver of its method
+func (m *MethodResolver) ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error) {
+	driver, err := m.driver(did)
+	if err != nil {
+		return nil, err
+	}
+	return driver.ResolveAt(ctx, did, versionTime)
+}
+
+func (m *MethodResolver) driver(did string) (Resolver, error) {
+	method, _, err := SplitDID(did)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID: %w", err)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	driver, ok := m.drivers[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: did:%s", ErrMethodNotSupported, method)
+	}
+	return driver, nil
+}
+
+// KeyResolver resolves did:key DIDs by expanding the key they encode into a
+// document, without any request. A did:key document never changes, so
+// ResolveAt returns the same document for every versionTime.
+type KeyResolver struct {
+	// MethodType of the verification method; empty picks
+	// keys.DefaultMethodType
+	MethodType string
+}
+
+// Resolve returns the document of a did:key DID
+func (r *KeyResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	fingerprint, ok := strings.CutPrefix(did, keys.DIDKeyPrefix)
+	if !ok || fingerprint == "" || strings.ContainsAny(fingerprint, ":#?/") {
+		return nil, fmt.Errorf("%w: %q is not a did:key DID", ErrInvalidDID, did)
+	}
+	key, err := keys.ParseDIDKey(did)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDID, err)
+	}
+
+	// The key is the one verification method, in every relationship a
+	// signing key can take
+	doc, err := NewDocument(did).
+		AddKey(fingerprint, key, r.MethodType, Authentication, AssertionMethod, CapabilityInvocation, CapabilityDelegation).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDID, err)
+	}
+	return &ResolutionResult{
+		DIDDocument:      doc,
+		Metadata:         resolutionMetadata("accdid-key"),
+		DocumentMetadata: map[string]interface{}{},
+	}, nil
+}
+
+// ResolveAt returns the document of a did:key DID
+func (r *KeyResolver) ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error) {
+	return r.Resolve(ctx, did)
+}
+
+// maxWebDocumentSize bounds the did.json documents WebResolver reads
+const maxWebDocumentSize = 1 << 20
+
+// WebResolver resolves did:web DIDs by fetching did.json over HTTPS from the
+// domain they name. The requests go to arbitrary hosts, so they carry no
+// credentials and do not pass through the SDK's middleware.
+type WebResolver struct {
+	client *http.Client
+}
+
+// NewWebResolver creates a did:web resolver fetching with client; nil uses a
+// client with a 10 second timeout
+func NewWebResolver(client *http.Client) *WebResolver {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebResolver{client: client}
+}
+
+// Resolve fetches the current document of a did:web DID
+func (r *WebResolver) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	location, err := WebDocumentURL(did)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/did+json, application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		_, classified := classifyError(err)
+		return nil, classified
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxWebDocumentSize+1))
+	if err != nil {
+		_, classified := classifyError(err)
+		return nil, classified
+	}
+	if resp.StatusCode >= 400 {
+		return nil, decodeHTTPError(resp, body)
+	}
+	if len(body) > maxWebDocumentSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", location, maxWebDocumentSize)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("%s is not a DID document: %w", location, err)
+	}
+	if doc.ID != did {
+		return nil, fmt.Errorf("%s holds the document of %q, not %q", location, doc.ID, did)
+	}
+	return &ResolutionResult{
+		DIDDocument:      &doc,
+		Metadata:         resolutionMetadata("accdid-web"),
+		DocumentMetadata: map[string]interface{}{},
+	}, nil
+}
+
+// ResolveAt fails: a did:web domain serves only its current document
+func (r *WebResolver) ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error) {
+	if _, err := WebDocumentURL(did); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: did:web does not support versionTime", ErrBadRequest)
+}
+
+// WebDocumentURL returns the URL of the document of a did:web DID:
+// did:web:example.com is read from https://example.com/.well-known/did.json
+// and did:web:example.com:users:alice from
+// https://example.com/users/alice/did.json. A port is encoded as %3A.
+func WebDocumentURL(did string) (string, error) {
+	id, ok := strings.CutPrefix(did, "did:web:")
+	if !ok || id == "" || strings.ContainsAny(id, "#?/") {
+		return "", fmt.Errorf("%w: %q is not a did:web DID", ErrInvalidDID, did)
+	}
+
+	segments := strings.Split(id, ":")
+	host, err := url.PathUnescape(segments[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/@\\") {
+		return "", fmt.Errorf("%w: invalid did:web domain %q", ErrInvalidDID, segments[0])
+	}
+	if parsed, err := url.Parse("https://" + host); err != nil || parsed.Host != host {
+		return "", fmt.Errorf("%w: invalid did:web domain %q", ErrInvalidDID, host)
+	}
+
+	path := []string{".well-known"}
+	if len(segments) > 1 {
+		path = path[:0]
+		for _, segment := range segments[1:] {
+			segment, err := url.PathUnescape(segment)
+			if err != nil || segment == "" || segment == "." || segment == ".." || strings.Contains(segment, "/") {
+				return "", fmt.Errorf("%w: invalid did:web path in %q", ErrInvalidDID, did)
+			}
+			path = append(path, segment)
+		}
+	}
+	location := url.URL{Scheme: "https", Host: host, Path: "/" + strings.Join(path, "/") + "/did.json"}
+	return location.String(), nil
+}
+
+// resolutionMetadata is the resolution metadata of the built-in resolvers
+func resolutionMetadata(resolver string) map[string]interface{} {
+	return map[string]interface{}{
+		"contentType": "application/did+json",
+		"retrieved":   time.Now().UTC().Format(time.RFC3339Nano),
+		"resolver":    resolver,
+	}
+}
@@ -0,0 +1,177 @@
+package accdid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+func TestMethodResolver_Dispatch(t *testing.T) {
+	acc := &stubResolver{docs: map[string]*ResolutionResult{
+		"did:acc:alice": {DIDDocument: &Document{ID: "did:acc:alice"}},
+	}}
+	m := NewMethodResolver()
+	if err := m.Register("acc", acc); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register("did:acc", acc); err == nil {
+		t.Error("Expected a method name with a prefix to be rejected")
+	}
+	if got := strings.Join(m.Methods(), ","); got != "acc,key,web" {
+		t.Errorf("Expected acc, key and web, got %s", got)
+	}
+
+	ctx := context.Background()
+	if res, err := m.Resolve(ctx, "did:acc:alice"); err != nil || res.DIDDocument.ID != "did:acc:alice" {
+		t.Errorf("Expected did:acc to reach the registered resolver, got %+v, %v", res, err)
+	}
+	if _, err := m.Resolve(ctx, "did:example:123"); !errors.Is(err, ErrMethodNotSupported) {
+		t.Errorf("Expected ErrMethodNotSupported, got %v", err)
+	}
+	for _, did := range []string{"", "acc:alice", "did:ACC:alice", "did:acc:", "did:acc:alice#key-1"} {
+		if _, err := m.Resolve(ctx, did); !errors.Is(err, ErrInvalidDID) {
+			t.Errorf("%q: expected ErrInvalidDID, got %v", did, err)
+		}
+	}
+}
+
+func TestKeyResolver(t *testing.T) {
+	// Ed25519 vector from the did:key specification
+	did := "did:key:z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp"
+	r := &KeyResolver{}
+	res, err := r.Resolve(context.Background(), did)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	doc := res.DIDDocument
+	vm, ok := doc.FindVerificationMethod("z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp")
+	if !ok || vm.ID != did+"#z6MkiTBz1ymuepAQ4HEHYSF1H8quG5GLVVQR3djdX3mDooWp" || vm.Controller != did || vm.Type != keys.Ed25519VerificationKey2020 {
+		t.Fatalf("Unexpected verification method %+v", vm)
+	}
+	for _, rel := range []Relationship{Authentication, AssertionMethod, CapabilityInvocation, CapabilityDelegation} {
+		if !doc.HasRelationship(rel, vm.ID) {
+			t.Errorf("Expected the key in %s", rel)
+		}
+	}
+	if doc.HasRelationship(KeyAgreement, vm.ID) {
+		t.Error("A signing key must not be in keyAgreement")
+	}
+
+	multikey := &KeyResolver{MethodType: keys.Multikey}
+	if res, err := multikey.ResolveAt(context.Background(), did, time.Unix(0, 0)); err != nil || res.DIDDocument.VerificationMethod[0].Type != keys.Multikey {
+		t.Errorf("Expected a Multikey method at any versionTime, got %+v, %v", res, err)
+	}
+
+	for _, bad := range []string{"did:key:", "did:key:zInvalid", did + "#key", "did:acc:alice"} {
+		if _, err := r.Resolve(context.Background(), bad); !errors.Is(err, ErrInvalidDID) {
+			t.Errorf("%q: expected ErrInvalidDID, got %v", bad, err)
+		}
+	}
+}
+
+// A did:key signature verifies through a MethodResolver without any request
+func TestMethodResolver_VerifyDIDKey(t *testing.T) {
+	key, err := keys.Generate(keys.P256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("hello")
+	sig, _ := key.Sign(message)
+	keyID := key.Public.DIDKey() + "#" + key.Public.Fingerprint()
+
+	result, err := NewVerifier(NewMethodResolver()).Verify(context.Background(), keyID, message, sig, VerifyOptions{})
+	if err != nil || !result.Verified {
+		t.Errorf("Expected the did:key signature to verify, got %+v, %v", result, err)
+	}
+}
+
+func TestWebDocumentURL(t *testing.T) {
+	tests := []struct {
+		did string
+		url string
+	}{
+		{"did:web:example.com", "https://example.com/.well-known/did.json"},
+		{"did:web:example.com:user:alice", "https://example.com/user/alice/did.json"},
+		{"did:web:localhost%3A8443", "https://localhost:8443/.well-known/did.json"},
+		{"did:web:example.com:a%20b", "https://example.com/a%20b/did.json"},
+		{"did:web:", ""},
+		{"did:web:example.com:..", ""},
+		{"did:web:example.com::alice", ""},
+		{"did:web:example.com:a%2Fb", ""},
+		{"did:web:user%40example.com", ""},
+		{"did:web:example.com/alice", ""},
+	}
+	for _, tt := range tests {
+		got, err := WebDocumentURL(tt.did)
+		if tt.url == "" {
+			if !errors.Is(err, ErrInvalidDID) {
+				t.Errorf("%s: expected ErrInvalidDID, got %q, %v", tt.did, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.url {
+			t.Errorf("%s: got %q, %v; want %q", tt.did, got, err, tt.url)
+		}
+	}
+}
+
+func TestWebResolver(t *testing.T) {
+	var host string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := "did:web:" + strings.ReplaceAll(host, ":", "%3A")
+		switch r.URL.Path {
+		case "/.well-known/did.json":
+			fmt.Fprintf(w, `{"@context":"https://www.w3.org/ns/did/v1","id":%q,"service":[{"id":"#web","type":"LinkedDomains","serviceEndpoint":"https://example.com"}]}`, base)
+		case "/users/alice/did.json":
+			fmt.Fprintf(w, `{"id":%q}`, base+":users:alice")
+		case "/users/mallory/did.json":
+			fmt.Fprintf(w, `{"id":%q}`, base+":users:alice")
+		case "/users/big/did.json":
+			fmt.Fprintf(w, `{"id":%q,"pad":"%s"}`, base+":users:big", strings.Repeat("x", maxWebDocumentSize))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	host = u.Host
+	base := "did:web:" + strings.ReplaceAll(host, ":", "%3A")
+
+	m := NewMethodResolver()
+	if err := m.Register("web", NewWebResolver(server.Client())); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	res, err := m.Resolve(ctx, base)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if _, ok := res.DIDDocument.FindService("web"); !ok || res.Metadata["resolver"] != "accdid-web" {
+		t.Errorf("Unexpected resolution %+v", res)
+	}
+	if res, err := m.Resolve(ctx, base+":users:alice"); err != nil || res.DIDDocument.ID != base+":users:alice" {
+		t.Errorf("Expected alice's document from her path, got %+v, %v", res, err)
+	}
+
+	if _, err := m.Resolve(ctx, base+":users:mallory"); err == nil {
+		t.Error("Expected a document for another DID to be rejected")
+	}
+	if _, err := m.Resolve(ctx, base+":users:big"); err == nil {
+		t.Error("Expected an oversized document to be rejected")
+	}
+	if _, err := m.Resolve(ctx, base+":users:nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := m.ResolveAt(ctx, base, time.Now()); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected versionTime to be refused, got %v", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"net/http"
 	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/retry"
 )
 
 // Logger defines the logging interface for the SDK
@@ -37,8 +39,14 @@ type RetryPolicy struct {
 	// Jitter adds randomization to retry delays to avoid thundering herd
 	Jitter bool
 
-	// Backoff strategy: "exp" for exponential (default)
+	// Backoff strategy: "exp" for exponential (default), "decorrelated" for
+	// decorrelated jitter or "constant"
 	Backoff string
+
+	// Budget limits retries across requests; share one between clients to
+	// limit them together (default: a budget of 10 tokens per client, each
+	// success returning 0.1)
+	Budget *retry.Budget
 }
 
 // ClientOptions configures the SDK clients
@@ -46,9 +54,14 @@ type ClientOptions struct {
 	// BaseURL is the base URL of the service (required)
 	BaseURL string
 
-	// APIKey for authentication (optional)
+	// APIKey for authentication (optional), sent as a bearer token. Ignored
+	// when Credentials is set.
 	APIKey string
 
+	// Credentials authenticates each request (optional), e.g. with a
+	// RotatingKey, ClientCredentials or DIDAuthSigner
+	Credentials CredentialProvider
+
 	// Timeout for individual requests
 	Timeout time.Duration
 
@@ -61,7 +74,9 @@ type ClientOptions struct {
 	// Logger for debug output (optional)
 	Logger Logger
 
-	// IdempotencyKey for safe retries (optional)
+	// IdempotencyKey for safe retries (optional). It is sent with every
+	// request, so a client with a fixed key should perform one operation;
+	// when empty each write gets its own key.
 	IdempotencyKey string
 
 	// RequestID generator function (optional)
@@ -120,6 +135,9 @@ func applyDefaults(opts ClientOptions) ClientOptions {
 	if opts.RequestID == nil {
 		opts.RequestID = defaults.RequestID
 	}
+	if opts.Credentials == nil && opts.APIKey != "" {
+		opts.Credentials = StaticKey(opts.APIKey)
+	}
 
 	return opts
 }
\ No newline at end of file
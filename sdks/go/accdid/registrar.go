@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/opendlt/accu-did/sdks/go/accdid/httpx"
-	"github.com/opendlt/accu-did/sdks/go/accdid/retry"
 )
 
 // RegistrarClient provides DID registration and management functionality
@@ -24,23 +23,21 @@ func NewRegistrarClient(opts ClientOptions) (*RegistrarClient, error) {
 
 	opts = applyDefaults(opts)
 
-	// Build middleware chain
+	// Build middleware chain; credentials are applied to every attempt
 	var doer Doer = opts.HTTP
+	if opts.Credentials != nil {
+		doer = credentialMiddleware(opts.Credentials)(doer)
+	}
 
 	// Add retry middleware
-	doer = retry.WithRetry(doer, retry.Policy{
-		Max:       opts.Retries.Max,
-		BaseDelay: opts.Retries.BaseDelay,
-		MaxDelay:  opts.Retries.MaxDelay,
-		Jitter:    opts.Retries.Jitter,
-	}, func(err error) bool {
-		retryable, _ := classifyError(err)
-		return retryable
-	})
+	doer, err := withRetries(doer, opts.Retries)
+	if err != nil {
+		return nil, err
+	}
 
 	// Add header and timeout middleware
 	doer = WithMiddleware(doer,
-		headerMiddleware(opts.APIKey, opts.IdempotencyKey, opts.RequestID),
+		headerMiddleware(opts.IdempotencyKey, opts.RequestID),
 		timeoutMiddleware(opts.Timeout),
 	)
 
@@ -220,4 +217,18 @@ func (c *RegistrarClient) Health(ctx context.Context) error {
 
 	c.logger.Debugf("Registrar health check passed")
 	return nil
-}
\ No newline at end of file
+}
+
+// HealthDetails fetches the registrar liveness report, including the state of
+// each Accumulate node when the service runs against a node pool
+func (c *RegistrarClient) HealthDetails(ctx context.Context) (*HealthReport, error) {
+	c.logger.Debugf("Fetching registrar health report")
+	return getHealthReport(ctx, c.doer, c.baseURL, "/healthz")
+}
+
+// Readiness runs the registrar readiness checks. When the service is not ready
+// the report is returned together with an *HTTPError wrapping ErrServer.
+func (c *RegistrarClient) Readiness(ctx context.Context) (*HealthReport, error) {
+	c.logger.Debugf("Checking registrar readiness")
+	return getHealthReport(ctx, c.doer, c.baseURL, "/readyz")
+}
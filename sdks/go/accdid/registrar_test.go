@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRegistrarClient_Register(t *testing.T) {
@@ -303,4 +304,48 @@ func TestRegistrarClient_UniversalMethods(t *testing.T) {
 	if txID != "tx-universal-123" {
 		t.Errorf("Expected tx-universal-123, got %s", txID)
 	}
-}
\ No newline at end of file
+}
+
+func TestRegistrarClient_IdempotencyKeyPerWrite(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.Header().Set("Content-Type", "application/json")
+		if len(keys) == 1 {
+			// The first attempt fails and is retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"transactionId":"tx-123"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewRegistrarClient(ClientOptions{
+		BaseURL: server.URL,
+		Retries: RetryPolicy{Max: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	req := NativeRegisterRequest{
+		DID:         "did:acc:test",
+		DIDDocument: json.RawMessage(`{"@context":["https://www.w3.org/ns/did/v1"],"id":"did:acc:test"}`),
+	}
+	if _, err := client.Register(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.Register(context.Background(), req); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(keys))
+	}
+	if keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Expected a retry to reuse the key, got %q and %q", keys[0], keys[1])
+	}
+	if keys[2] == keys[0] {
+		t.Errorf("Expected a new key for the second write, got %q again", keys[2])
+	}
+}
@@ -2,18 +2,29 @@ package accdid
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/opendlt/accu-did/sdks/go/accdid/httpx"
-	"github.com/opendlt/accu-did/sdks/go/accdid/retry"
 )
 
+// Resolver resolves DIDs. ResolverClient resolves did:acc DIDs through a
+// resolver service and DirectResolver reads Accumulate itself;
+// MethodResolver dispatches DIDs of several methods.
+type Resolver interface {
+	Resolve(ctx context.Context, did string) (*ResolutionResult, error)
+	ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error)
+}
+
 // ResolverClient provides DID resolution functionality
 type ResolverClient struct {
 	baseURL string
 	doer    Doer
 	logger  Logger
+	cache   CacheStore
+	flights flightGroup
 }
 
 // NewResolverClient creates a new resolver client
@@ -24,23 +35,21 @@ func NewResolverClient(opts ClientOptions) (*ResolverClient, error) {
 
 	opts = applyDefaults(opts)
 
-	// Build middleware chain
+	// Build middleware chain; credentials are applied to every attempt
 	var doer Doer = opts.HTTP
+	if opts.Credentials != nil {
+		doer = credentialMiddleware(opts.Credentials)(doer)
+	}
 
 	// Add retry middleware
-	doer = retry.WithRetry(doer, retry.Policy{
-		Max:       opts.Retries.Max,
-		BaseDelay: opts.Retries.BaseDelay,
-		MaxDelay:  opts.Retries.MaxDelay,
-		Jitter:    opts.Retries.Jitter,
-	}, func(err error) bool {
-		retryable, _ := classifyError(err)
-		return retryable
-	})
+	doer, err := withRetries(doer, opts.Retries)
+	if err != nil {
+		return nil, err
+	}
 
 	// Add header and timeout middleware
 	doer = WithMiddleware(doer,
-		headerMiddleware(opts.APIKey, opts.IdempotencyKey, opts.RequestID),
+		headerMiddleware(opts.IdempotencyKey, opts.RequestID),
 		timeoutMiddleware(opts.Timeout),
 	)
 
@@ -51,17 +60,37 @@ func NewResolverClient(opts ClientOptions) (*ResolverClient, error) {
 	}, nil
 }
 
+// WithCache keeps resolutions in store. Fresh entries are answered without a
+// request and expired ones are revalidated with their ETag, following the
+// resolver's Cache-Control. Concurrent resolutions of the same DID share one
+// request.
+func (c *ResolverClient) WithCache(store CacheStore) *ResolverClient {
+	c.cache = store
+	return c
+}
+
 // Resolve resolves a DID to its DID document using the native resolver API
 func (c *ResolverClient) Resolve(ctx context.Context, did string) (*ResolutionResult, error) {
+	return c.resolve(ctx, did, map[string]string{"did": did})
+}
+
+// ResolveAt resolves the DID document as it was at versionTime
+func (c *ResolverClient) ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error) {
+	return c.resolve(ctx, did, map[string]string{
+		"did":         did,
+		"versionTime": versionTime.UTC().Format(time.RFC3339),
+	})
+}
+
+func (c *ResolverClient) resolve(ctx context.Context, did string, params map[string]string) (*ResolutionResult, error) {
 	if err := ValidateDID(did); err != nil {
 		return nil, fmt.Errorf("invalid DID: %w", err)
 	}
 
 	c.logger.Debugf("Resolving DID: %s", did)
 
-	// Use query parameters for the native resolver endpoint
-	params := map[string]string{
-		"did": did,
+	if c.cache != nil {
+		return c.resolveCached(ctx, did, params)
 	}
 
 	var result ResolutionResult
@@ -80,6 +109,66 @@ func (c *ResolverClient) Resolve(ctx context.Context, did string) (*ResolutionRe
 	return &result, nil
 }
 
+// resolveCached answers from the cache while the entry is fresh, and
+// otherwise fetches or revalidates it once for all concurrent callers
+func (c *ResolverClient) resolveCached(ctx context.Context, did string, params map[string]string) (*ResolutionResult, error) {
+	key := params["did"] + "\x00" + params["versionTime"]
+
+	body, err := func() ([]byte, error) {
+		if entry, ok := c.cache.Get(key); ok && time.Now().Before(entry.Expires) {
+			return entry.Body, nil
+		}
+		return c.flights.do(ctx, key, func(ctx context.Context) ([]byte, error) {
+			return c.fetchCached(ctx, key, params)
+		})
+	}()
+	if err != nil {
+		_, classified := classifyError(err)
+		return nil, classified
+	}
+
+	var result ResolutionResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	c.logger.Debugf("Successfully resolved DID: %s", did)
+	return &result, nil
+}
+
+// fetchCached requests a resolution, conditionally when an entry is held,
+// and stores the response as its Cache-Control allows
+func (c *ResolverClient) fetchCached(ctx context.Context, key string, params map[string]string) ([]byte, error) {
+	entry, cached := c.cache.Get(key)
+	header := http.Header{}
+	if cached && entry.ETag != "" {
+		header.Set("If-None-Match", entry.ETag)
+	}
+
+	status, respHeader, body, err := httpx.GetQuery(ctx, c.doer, c.baseURL, "/resolve", params, header)
+	if err != nil {
+		return nil, err
+	}
+	etag := respHeader.Get("ETag")
+	switch {
+	case status == http.StatusNotModified && cached:
+		body = entry.Body
+		if etag == "" {
+			etag = entry.ETag
+		}
+	case status >= 300:
+		c.cache.Delete(key)
+		return nil, decodeHTTPError(&http.Response{StatusCode: status, Status: fmt.Sprintf("%d", status)}, body)
+	}
+
+	lifetime, storable := cacheLifetime(respHeader.Get("Cache-Control"))
+	if storable && (etag != "" || lifetime > 0) {
+		c.cache.Set(key, CacheEntry{Body: body, ETag: etag, Expires: time.Now().Add(lifetime)})
+	} else {
+		c.cache.Delete(key)
+	}
+	return body, nil
+}
+
 // UniversalResolve resolves a DID using the Universal Resolver API format
 func (c *ResolverClient) UniversalResolve(ctx context.Context, did string) (*ResolutionResult, error) {
 	if err := ValidateDID(did); err != nil {
@@ -132,4 +221,18 @@ func (c *ResolverClient) Health(ctx context.Context) error {
 
 	c.logger.Debugf("Resolver health check passed")
 	return nil
-}
\ No newline at end of file
+}
+
+// HealthDetails fetches the resolver liveness report, including the state of
+// each Accumulate node when the service runs against a node pool
+func (c *ResolverClient) HealthDetails(ctx context.Context) (*HealthReport, error) {
+	c.logger.Debugf("Fetching resolver health report")
+	return getHealthReport(ctx, c.doer, c.baseURL, "/healthz")
+}
+
+// Readiness runs the resolver readiness checks. When the service is not ready
+// the report is returned together with an *HTTPError wrapping ErrServer.
+func (c *ResolverClient) Readiness(ctx context.Context) (*HealthReport, error) {
+	c.logger.Debugf("Checking resolver readiness")
+	return getHealthReport(ctx, c.doer, c.baseURL, "/readyz")
+}
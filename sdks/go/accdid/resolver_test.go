@@ -216,6 +216,7 @@ func TestResolverClient_ErrorMapping(t *testing.T) {
 	}{
 		{"bad request", 400, ErrBadRequest},
 		{"unauthorized", 401, ErrBadRequest},
+		{"payment required", 402, ErrInsufficientCredits},
 		{"forbidden", 403, ErrBadRequest},
 		{"not found", 404, ErrNotFound},
 		{"gone", 410, ErrGoneDeactivated},
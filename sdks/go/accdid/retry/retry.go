@@ -1,21 +1,61 @@
-// Package retry provides retry logic with exponential backoff for the Accumulate DID SDK
+// Package retry provides retry logic with backoff for the Accumulate DID SDK
 package retry
 
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// Strategy selects how the delay between attempts grows
+type Strategy string
+
+const (
+	// Exponential doubles BaseDelay with each attempt, up to MaxDelay
+	Exponential Strategy = "exp"
+
+	// DecorrelatedJitter picks each delay at random between BaseDelay and
+	// three times the previous delay, up to MaxDelay
+	DecorrelatedJitter Strategy = "decorrelated"
+
+	// Constant waits BaseDelay between attempts
+	Constant Strategy = "constant"
+)
+
+// ParseStrategy returns the strategy named s; an empty name is Exponential
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case "", Exponential:
+		return Exponential, nil
+	case DecorrelatedJitter, Constant:
+		return Strategy(s), nil
+	}
+	return "", fmt.Errorf("unknown backoff strategy %q", s)
+}
+
 // Policy defines retry behavior
 type Policy struct {
 	Max       int
 	BaseDelay time.Duration
 	MaxDelay  time.Duration
-	Jitter    bool
+
+	// Jitter adds up to 50% to Exponential and Constant delays
+	Jitter bool
+
+	// Strategy of the delays (default Exponential)
+	Strategy Strategy
+
+	// Budget limits retries across the requests sharing it (optional)
+	Budget *Budget
 }
 
 // IsRetryable determines if an error should be retried
@@ -26,7 +66,11 @@ type Doer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// WithRetry wraps a Doer with retry logic
+// WithRetry wraps a Doer with retry logic. A request is retried when it
+// fails with an error isRetryable accepts or with a 429, 502, 503 or 504
+// status, and only when repeating it is safe: its method is idempotent, or it
+// carries an Idempotency-Key. A Retry-After on a 429 or 503 sets the delay;
+// when it asks for more than MaxDelay the response is returned instead.
 func WithRetry(doer Doer, policy Policy, isRetryable IsRetryable) Doer {
 	return &retryDoer{
 		doer:        doer,
@@ -42,93 +86,114 @@ type retryDoer struct {
 }
 
 func (r *retryDoer) Do(req *http.Request) (*http.Response, error) {
-	var lastErr error
-	var resp *http.Response
-
-	for attempt := 0; attempt <= r.policy.Max; attempt++ {
-		// Clone the request for retry safety
-		reqClone := r.cloneRequest(req)
+	if !canRepeat(req) {
+		return r.doer.Do(req)
+	}
 
-		resp, lastErr = r.doer.Do(reqClone)
+	var delay time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, err := r.doer.Do(r.cloneRequest(req))
 
-		// Success case
-		if lastErr == nil && resp.StatusCode < 500 && resp.StatusCode != 429 {
-			return resp, nil
-		}
-
-		// Check if we should retry
-		shouldRetry := false
-		if lastErr != nil {
-			shouldRetry = r.isRetryable != nil && r.isRetryable(lastErr)
-		} else if resp != nil {
-			// Check for retryable status codes
-			shouldRetry = isRetryableStatus(resp.StatusCode)
-			if !shouldRetry {
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			if r.isRetryable == nil || !r.isRetryable(err) {
+				return nil, err
+			}
+		case isRetryableStatus(resp.StatusCode):
+			var ok bool
+			if retryAfter, ok = parseRetryAfter(resp, time.Now()); ok && retryAfter > r.policy.MaxDelay {
 				return resp, nil
 			}
+		default:
+			r.policy.Budget.success()
+			return resp, nil
 		}
 
-		// Don't retry if this was the last attempt or if not retryable
-		if attempt >= r.policy.Max || !shouldRetry {
-			break
+		// Each failure spends from the budget, even on the last attempt, so
+		// callers failing everywhere soon stop retrying
+		if !r.policy.Budget.failure() || attempt >= r.policy.Max {
+			return resp, err
 		}
 
-		// Calculate delay for next attempt
-		delay := r.calculateDelay(attempt)
-
-		// Wait with context cancellation support
-		select {
-		case <-time.After(delay):
-			// Continue to next attempt
-		case <-req.Context().Done():
-			return nil, req.Context().Err()
+		delay = r.nextDelay(attempt, delay)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
 		}
 
-		// Close response body if present to avoid resource leaks
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
 		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
 	}
+}
 
-	// Return the last response/error
-	if resp != nil {
-		return resp, nil
+// canRepeat reports whether req may be sent more than once: its method is
+// idempotent (RFC 9110 section 9.2.2) or it carries an Idempotency-Key, and
+// its body can be read again
+func canRepeat(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
 	}
-	return nil, lastErr
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
 }
 
 func (r *retryDoer) cloneRequest(req *http.Request) *http.Request {
-	// Clone the request
 	clone := req.Clone(req.Context())
-
-	// If there's a body, we need to handle it carefully
 	if req.Body != nil && req.GetBody != nil {
 		newBody, err := req.GetBody()
 		if err == nil {
 			clone.Body = newBody
 		}
 	}
-
 	return clone
 }
 
-func (r *retryDoer) calculateDelay(attempt int) time.Duration {
-	// Exponential backoff: baseDelay * 2^attempt
-	delay := time.Duration(float64(r.policy.BaseDelay) * math.Pow(2, float64(attempt)))
+// nextDelay returns the delay after the given attempt, the previous delay
+// being prev
+func (r *retryDoer) nextDelay(attempt int, prev time.Duration) time.Duration {
+	base, max := r.policy.BaseDelay, r.policy.MaxDelay
 
-	// Cap at max delay
-	if delay > r.policy.MaxDelay {
-		delay = r.policy.MaxDelay
+	var delay time.Duration
+	switch r.policy.Strategy {
+	case DecorrelatedJitter:
+		if prev < base {
+			prev = base
+		}
+		upper := 3 * prev
+		if upper > max {
+			upper = max
+		}
+		delay = base
+		if upper > base {
+			delay += time.Duration(rand.Int63n(int64(upper - base)))
+		}
+		return delay
+	case Constant:
+		delay = base
+	default:
+		delay = time.Duration(float64(base) * math.Pow(2, float64(attempt)))
 	}
 
-	// Add jitter if enabled
+	if delay > max {
+		delay = max
+	}
 	if r.policy.Jitter && delay > 0 {
-		// Add up to 50% jitter
-		jitterRange := float64(delay) * 0.5
-		jitter := time.Duration(rand.Float64() * jitterRange)
-		delay = delay + jitter
+		delay += time.Duration(rand.Float64() * float64(delay) * 0.5)
 	}
-
 	return delay
 }
 
@@ -142,41 +207,95 @@ func isRetryableStatus(statusCode int) bool {
 	}
 }
 
-// DefaultIsRetryable provides a reasonable default for determining retryable errors
-func DefaultIsRetryable(err error) bool {
-	if err == nil {
-		return false
+// parseRetryAfter returns the wait a 429 or 503 response asks for in its
+// Retry-After header, given in seconds or as an HTTP date
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
 	}
-
-	// Check for context cancellation (not retryable)
-	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-		return false
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
 	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > math.MaxInt64/int64(time.Second) {
+			return math.MaxInt64, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Budget limits retries across requests, so a failing service is not sent
+// several times its usual load. It holds up to maxTokens tokens; each failed
+// attempt takes one, each successful request returns ratio, and retries are
+// allowed while more than half remain. A nil Budget allows every retry.
+type Budget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
 
-	// For network errors, we should generally retry
-	// This is a simplified check - in production you might want more sophisticated logic
-	errStr := err.Error()
-	retryableErrors := []string{
-		"connection refused",
-		"connection reset",
-		"timeout",
-		"temporary failure",
-		"network unreachable",
-		"host unreachable",
+// NewBudget returns a full budget
+func NewBudget(maxTokens int, ratio float64) *Budget {
+	return &Budget{tokens: float64(maxTokens), maxTokens: float64(maxTokens), ratio: ratio}
+}
+
+// Available returns the current number of tokens
+func (b *Budget) Available() float64 {
+	if b == nil {
+		return math.Inf(1)
 	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
 
-	for _, retryableErr := range retryableErrors {
-		if contains(errStr, retryableErr) {
-			return true
-		}
+// failure takes a token and reports whether a retry is allowed
+func (b *Budget) failure() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens > 0 {
+		b.tokens--
 	}
+	return b.tokens > b.maxTokens/2
+}
 
-	return false
+func (b *Budget) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, b.maxTokens)
 }
 
-// contains checks if a string contains a substring (case-insensitive)
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) &&
-		   s[:len(substr)] == substr ||
-		   (len(s) > len(substr) && contains(s[1:], substr))
-}
\ No newline at end of file
+// DefaultIsRetryable retries transport failures: network errors other than
+// cancellation and expired deadlines, and connections closed mid-response
+func DefaultIsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
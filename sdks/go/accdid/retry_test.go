@@ -2,9 +2,16 @@ package accdid
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -231,4 +238,164 @@ func TestExponentialBackoff(t *testing.T) {
 			t.Errorf("Delay %d too long: expected <= %v, got %v", i, maxExpected, delays[i])
 		}
 	}
-}
\ No newline at end of file
+}
+func TestRetryRequiresSafeMethodOrIdempotencyKey(t *testing.T) {
+	var calls int
+	doer := retry.WithRetry(doerFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: 503, Body: http.NoBody}, nil
+	}), retry.Policy{Max: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, retry.DefaultIsRetryable)
+
+	tests := []struct {
+		method string
+		key    string
+		calls  int
+	}{
+		{http.MethodGet, "", 3},
+		{http.MethodPut, "", 3},
+		{http.MethodPost, "", 1},
+		{http.MethodPost, "op-1", 3},
+		{http.MethodPatch, "", 1},
+	}
+	for _, tt := range tests {
+		calls = 0
+		req, _ := http.NewRequest(tt.method, "http://example.com", strings.NewReader("{}"))
+		if tt.key != "" {
+			req.Header.Set("Idempotency-Key", tt.key)
+		}
+		resp, err := doer.Do(req)
+		if err != nil || resp.StatusCode != 503 {
+			t.Errorf("%s %q: got %v, %v", tt.method, tt.key, resp, err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%s %q: expected %d attempts, got %d", tt.method, tt.key, tt.calls, calls)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var attempts int32
+	retryAfter := "1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(429)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewResolverClient(ClientOptions{
+		BaseURL: server.URL,
+		Retries: RetryPolicy{Max: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := client.Health(context.Background()); err != nil {
+		t.Fatalf("Expected success after Retry-After, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected to wait the second Retry-After asked for, waited %v", elapsed)
+	}
+
+	// A wait longer than MaxDelay is not worth it
+	atomic.StoreInt32(&attempts, 0)
+	retryAfter = time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if err := client.Health(context.Background()); err == nil || atomic.LoadInt32(&attempts) != 1 {
+		t.Errorf("Expected the 429 after one attempt, got %v after %d", err, atomic.LoadInt32(&attempts))
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(503)
+	}))
+	defer server.Close()
+
+	budget := retry.NewBudget(4, 1)
+	client, err := NewResolverClient(ClientOptions{
+		BaseURL: server.URL,
+		Retries: RetryPolicy{Max: 10, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Budget: budget},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 tokens: the first failure leaves 3 and is retried, the second leaves
+	// 2, half the budget, and ends the call
+	client.Health(context.Background())
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Errorf("Expected the budget to stop after 2 attempts, got %d", n)
+	}
+
+	// With the budget spent, calls are not retried at all
+	atomic.StoreInt32(&attempts, 0)
+	client.Health(context.Background())
+	if n := atomic.LoadInt32(&attempts); n != 1 || budget.Available() != 1 {
+		t.Errorf("Expected a single attempt, got %d (%v tokens left)", n, budget.Available())
+	}
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	for _, tt := range []struct {
+		backoff  retry.Strategy
+		maxDelay time.Duration
+		min, max time.Duration
+	}{
+		// Exponential delays would reach 80ms
+		{retry.Constant, time.Second, 10 * time.Millisecond, 60 * time.Millisecond},
+		{retry.DecorrelatedJitter, 30 * time.Millisecond, 10 * time.Millisecond, 80 * time.Millisecond},
+	} {
+		var last time.Time
+		var delays []time.Duration
+		doer := retry.WithRetry(doerFunc(func(req *http.Request) (*http.Response, error) {
+			if now := time.Now(); !last.IsZero() {
+				delays = append(delays, now.Sub(last))
+			}
+			last = time.Now()
+			return &http.Response{StatusCode: 502, Body: http.NoBody}, nil
+		}), retry.Policy{Max: 4, BaseDelay: 10 * time.Millisecond, MaxDelay: tt.maxDelay, Strategy: tt.backoff}, nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		doer.Do(req)
+		if len(delays) != 4 {
+			t.Fatalf("%s: expected 4 delays, got %d", tt.backoff, len(delays))
+		}
+		for i, delay := range delays {
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("%s: delay %d is %v, want %v to %v", tt.backoff, i, delay, tt.min, tt.max)
+			}
+		}
+	}
+
+	if _, err := NewResolverClient(ClientOptions{BaseURL: "http://example.com", Retries: RetryPolicy{Max: 1, Backoff: "linear"}}); err == nil {
+		t.Error("Expected an unknown backoff strategy to be rejected")
+	}
+}
+
+func TestDefaultIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: io.ErrUnexpectedEOF}, true},
+		{&net.DNSError{Err: "no such host", Name: "x"}, true},
+		{fmt.Errorf("wrapped: %w", syscall.ECONNRESET), true},
+		{&url.Error{Op: "Get", URL: "http://x", Err: context.DeadlineExceeded}, false},
+		{context.Canceled, false},
+		{errors.New("connection refused"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := retry.DefaultIsRetryable(tt.err); got != tt.want {
+			t.Errorf("DefaultIsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
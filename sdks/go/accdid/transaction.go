@@ -0,0 +1,95 @@
+package accdid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/httpx"
+)
+
+// Transaction delivery states reported by the registrar
+const (
+	TransactionPending   = "pending"
+	TransactionDelivered = "delivered"
+	TransactionFailed    = "failed"
+)
+
+// DefaultPollInterval is how often WaitForTransaction polls when no interval
+// is given
+const DefaultPollInterval = time.Second
+
+// TransactionStatus is the body of GET /transaction
+type TransactionStatus struct {
+	TxID            string   `json:"txid"`
+	State           string   `json:"state"`
+	Error           string   `json:"error,omitempty"`
+	SignatureHashes []string `json:"signatureHashes,omitempty"`
+}
+
+// Final reports whether the transaction is delivered or failed
+func (s *TransactionStatus) Final() bool {
+	return s.State == TransactionDelivered || s.State == TransactionFailed
+}
+
+// TransactionStatus fetches the delivery state of a transaction submitted by
+// the registrar. An unknown transaction returns an error wrapping ErrNotFound.
+func (c *RegistrarClient) TransactionStatus(ctx context.Context, txID string) (*TransactionStatus, error) {
+	if txID == "" {
+		return nil, fmt.Errorf("transaction ID is required")
+	}
+
+	c.logger.Debugf("Fetching transaction status: %s", txID)
+
+	var response TransactionStatus
+	status, body, err := httpx.DoJSONQuery(ctx, c.doer, c.baseURL, "/transaction", map[string]string{"txid": txID}, &response)
+	if err != nil {
+		_, classified := classifyError(err)
+		return nil, classified
+	}
+
+	if status >= 400 {
+		httpErr := decodeHTTPError(&http.Response{StatusCode: status, Status: fmt.Sprintf("%d", status)}, body)
+		return nil, httpErr
+	}
+
+	return &response, nil
+}
+
+// WaitForTransaction polls the status of txID every interval until it is
+// delivered or failed. A failed transaction returns its status with an error
+// wrapping ErrTransactionFailed. When ctx ends first, the last status is
+// returned with an error wrapping ErrTimeout; the transaction may still be
+// delivered later.
+func (c *RegistrarClient) WaitForTransaction(ctx context.Context, txID string, interval time.Duration) (*TransactionStatus, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	last := &TransactionStatus{TxID: txID, State: TransactionPending}
+	for {
+		status, err := c.TransactionStatus(ctx, txID)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if err == nil {
+			if status.State == TransactionFailed {
+				return status, fmt.Errorf("%w: %s: %s", ErrTransactionFailed, txID, status.Error)
+			}
+			if status.Final() {
+				c.logger.Debugf("Transaction delivered: %s", txID)
+				return status, nil
+			}
+			last = status
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return last, fmt.Errorf("%w: transaction %s still %s: %v", ErrTimeout, txID, last.State, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
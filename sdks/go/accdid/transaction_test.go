@@ -0,0 +1,114 @@
+package accdid
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTransactionClient(t *testing.T, handler http.HandlerFunc) *RegistrarClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewRegistrarClient(ClientOptions{
+		BaseURL: server.URL,
+		Retries: RetryPolicy{Max: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestRegistrarClient_WaitForTransaction(t *testing.T) {
+	var calls atomic.Int32
+	client := newTransactionClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transaction" {
+			t.Errorf("Expected path /transaction, got %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("txid"); got != "acc://abc@alice/did" {
+			t.Errorf("Expected txid acc://abc@alice/did, got %s", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) < 3 {
+			w.Write([]byte(`{"txid":"acc://abc@alice/did","state":"pending"}`))
+			return
+		}
+		w.Write([]byte(`{"txid":"acc://abc@alice/did","state":"delivered","signatureHashes":["0a1b"]}`))
+	})
+
+	status, err := client.WaitForTransaction(context.Background(), "acc://abc@alice/did", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status.State != TransactionDelivered {
+		t.Errorf("Expected delivered, got %s", status.State)
+	}
+	if len(status.SignatureHashes) != 1 || status.SignatureHashes[0] != "0a1b" {
+		t.Errorf("Unexpected signature hashes: %v", status.SignatureHashes)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 polls, got %d", calls.Load())
+	}
+}
+
+func TestRegistrarClient_WaitForTransaction_Failed(t *testing.T) {
+	client := newTransactionClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"txid":"acc://abc@alice/did","state":"failed","error":"signature is invalid"}`))
+	})
+
+	status, err := client.WaitForTransaction(context.Background(), "acc://abc@alice/did", time.Millisecond)
+	if !errors.Is(err, ErrTransactionFailed) {
+		t.Fatalf("Expected ErrTransactionFailed, got %v", err)
+	}
+	if status == nil || status.Error != "signature is invalid" {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+func TestRegistrarClient_WaitForTransaction_Timeout(t *testing.T) {
+	client := newTransactionClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"txid":"acc://abc@alice/did","state":"pending"}`))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	status, err := client.WaitForTransaction(ctx, "acc://abc@alice/did", 5*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected ErrTimeout, got %v", err)
+	}
+	if status == nil || status.State != TransactionPending {
+		t.Errorf("Expected pending status, got %+v", status)
+	}
+}
+
+func TestRegistrarClient_TransactionStatus_NotFound(t *testing.T) {
+	client := newTransactionClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"notFound","message":"transaction not found"}`))
+	})
+
+	if _, err := client.TransactionStatus(context.Background(), "acc://abc@alice/did"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDecodeHTTPError_TransactionFailed(t *testing.T) {
+	err := decodeHTTPError(&http.Response{StatusCode: 422, Status: "422"}, []byte(`{"error":"transactionFailed","message":"transaction failed"}`))
+	if !errors.Is(err, ErrTransactionFailed) {
+		t.Errorf("Expected ErrTransactionFailed, got %v", err)
+	}
+
+	err = decodeHTTPError(&http.Response{StatusCode: 422, Status: "422"}, []byte(`{"error":"invalidRequest"}`))
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
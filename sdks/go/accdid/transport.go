@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/retry"
 )
 
 // Doer is the interface for HTTP request execution
@@ -29,8 +31,17 @@ func WithMiddleware(d Doer, middlewares ...Middleware) Doer {
 	return d
 }
 
+type idempotencyKeyContext struct{}
+
+// WithIdempotencyKey returns a context whose writes send key as their
+// Idempotency-Key instead of ClientOptions.IdempotencyKey or a fresh key, so
+// an operation repeated with the same key is applied once
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
 // headerMiddleware adds common headers to requests
-func headerMiddleware(apiKey, idempotencyKey string, requestIDGen func() string) Middleware {
+func headerMiddleware(idempotencyKey string, requestIDGen func() string) Middleware {
 	return func(next Doer) Doer {
 		return doerFunc(func(req *http.Request) (*http.Response, error) {
 			// Always set request ID
@@ -38,14 +49,15 @@ func headerMiddleware(apiKey, idempotencyKey string, requestIDGen func() string)
 				req.Header.Set("X-Request-Id", requestIDGen())
 			}
 
-			// Set API key if provided
-			if apiKey != "" {
-				req.Header.Set("X-API-Key", apiKey)
-			}
-
-			// Set idempotency key if provided
-			if idempotencyKey != "" {
+			// Set idempotency key if provided, otherwise a fresh key per
+			// write; retries of the call reuse it, so the registrar applies
+			// the write once
+			if key, ok := req.Context().Value(idempotencyKeyContext{}).(string); ok && key != "" {
+				req.Header.Set("Idempotency-Key", key)
+			} else if idempotencyKey != "" {
 				req.Header.Set("Idempotency-Key", idempotencyKey)
+			} else if req.Method == http.MethodPost {
+				req.Header.Set("Idempotency-Key", defaultRequestID())
 			}
 
 			// Ensure JSON content type and accept headers
@@ -75,4 +87,25 @@ func timeoutMiddleware(timeout time.Duration) Middleware {
 			return next.Do(req.WithContext(ctx))
 		})
 	}
-}
\ No newline at end of file
+}
+
+// withRetries wraps next in the retry policy. Only POSTs carrying an
+// Idempotency-Key are retried, so headerMiddleware must run first.
+func withRetries(next Doer, policy RetryPolicy) (Doer, error) {
+	strategy, err := retry.ParseStrategy(policy.Backoff)
+	if err != nil {
+		return nil, err
+	}
+	budget := policy.Budget
+	if budget == nil {
+		budget = retry.NewBudget(10, 0.1)
+	}
+	return retry.WithRetry(next, retry.Policy{
+		Max:       policy.Max,
+		BaseDelay: policy.BaseDelay,
+		MaxDelay:  policy.MaxDelay,
+		Jitter:    policy.Jitter,
+		Strategy:  strategy,
+		Budget:    budget,
+	}, retry.DefaultIsRetryable), nil
+}
@@ -4,30 +4,15 @@ import (
 	"encoding/json"
 )
 
-// DIDDocument represents a W3C DID Document
+// DIDDocument represents a W3C DID Document as untyped JSON.
+//
+// Deprecated: use Document, which keeps unknown properties too.
 type DIDDocument map[string]interface{}
 
-// Service represents a service in a DID Document
-type Service struct {
-	ID              string      `json:"id"`
-	Type            string      `json:"type"`
-	ServiceEndpoint interface{} `json:"serviceEndpoint"`
-}
-
-// VerificationMethod represents a verification method in a DID Document
-type VerificationMethod struct {
-	ID                 string      `json:"id"`
-	Type               string      `json:"type"`
-	Controller         string      `json:"controller"`
-	PublicKeyJwk       interface{} `json:"publicKeyJwk,omitempty"`
-	PublicKeyMultibase string      `json:"publicKeyMultibase,omitempty"`
-	PublicKeyBase58    string      `json:"publicKeyBase58,omitempty"`
-}
-
 // ResolutionResult represents the result of DID resolution
 type ResolutionResult struct {
 	// DIDDocument contains the resolved DID document
-	DIDDocument interface{} `json:"didDocument"`
+	DIDDocument *Document `json:"didDocument"`
 
 	// Metadata contains resolution metadata
 	Metadata map[string]interface{} `json:"resolutionMetadata,omitempty"`
@@ -50,10 +35,12 @@ type NativeRegisterRequest struct {
 	DIDDocument json.RawMessage `json:"didDocument"`
 }
 
-// NativeUpdateRequest represents a request to update an existing DID
+// NativeUpdateRequest represents a request to update an existing DID, either
+// with a patch or with the complete new DIDDocument
 type NativeUpdateRequest struct {
-	DID   string          `json:"did"`
-	Patch json.RawMessage `json:"patch"`
+	DID         string          `json:"did"`
+	Patch       json.RawMessage `json:"patch,omitempty"`
+	DIDDocument json.RawMessage `json:"didDocument,omitempty"`
 }
 
 // NativeDeactivateRequest represents a request to deactivate a DID
@@ -68,9 +55,27 @@ type RegistrarResponse struct {
 	JobID         string `json:"jobId,omitempty"`
 }
 
+// UnmarshalJSON also accepts the txid member of the native endpoints'
+// responses as the transaction ID
+func (r *RegistrarResponse) UnmarshalJSON(data []byte) error {
+	type plain RegistrarResponse
+	var v struct {
+		plain
+		TxID string `json:"txid"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = RegistrarResponse(v.plain)
+	if r.TransactionID == "" {
+		r.TransactionID = v.TxID
+	}
+	return nil
+}
+
 // UniversalResolveResponse represents Universal Resolver response format
 type UniversalResolveResponse struct {
 	DIDResolutionMetadata map[string]interface{} `json:"didResolutionMetadata,omitempty"`
-	DIDDocument           interface{}            `json:"didDocument"`
+	DIDDocument           *Document              `json:"didDocument"`
 	DIDDocumentMetadata   map[string]interface{} `json:"didDocumentMetadata,omitempty"`
 }
\ No newline at end of file
@@ -8,6 +8,10 @@ import (
 
 var (
 	ErrInvalidDID = errors.New("invalid DID")
+
+	// ErrMethodNotSupported is returned for a DID whose method has no
+	// registered resolver
+	ErrMethodNotSupported = errors.New("DID method not supported")
 )
 
 // ValidateDID validates that a DID string is properly formatted for the acc method
@@ -69,4 +73,38 @@ func ParseDID(did string) (adi string, path string, err error) {
 	}
 
 	return adi, path, nil
-}
\ No newline at end of file
+}
+
+// SplitDID checks the generic DID syntax of did, whatever its method, and
+// returns its method name and method-specific identifier
+func SplitDID(did string) (method string, id string, err error) {
+	rest, ok := strings.CutPrefix(did, "did:")
+	if !ok {
+		return "", "", fmt.Errorf("%w: missing 'did:' prefix", ErrInvalidDID)
+	}
+	method, id, _ = strings.Cut(rest, ":")
+	if !validMethodName(method) {
+		return "", "", fmt.Errorf("%w: invalid method name %q", ErrInvalidDID, method)
+	}
+	if id == "" {
+		return "", "", fmt.Errorf("%w: missing method-specific identifier", ErrInvalidDID)
+	}
+	if strings.ContainsAny(id, "#? ") {
+		return "", "", fmt.Errorf("%w: %q is a DID URL, not a DID", ErrInvalidDID, did)
+	}
+	return method, id, nil
+}
+
+// validMethodName reports whether name is a method name: lowercase letters
+// and digits
+func validMethodName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,411 @@
+package accdid
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jcs"
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jsonobj"
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/multibase"
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// SignatureFormat names the form of a signature checked by a Verifier
+type SignatureFormat string
+
+// Signature formats
+const (
+	SignatureRaw           SignatureFormat = "raw"
+	SignatureJWS           SignatureFormat = "jws"
+	SignatureDataIntegrity SignatureFormat = "eddsa-jcs-2022"
+)
+
+// VerificationFailure tells why a signature was not verified
+type VerificationFailure string
+
+// Verification failures
+const (
+	// FailureMalformed: the key reference, JWS or proof cannot be parsed or
+	// uses a feature the Verifier does not support
+	FailureMalformed VerificationFailure = "malformed"
+
+	// FailureKeyNotFound: the document has no such verification method, or
+	// its key cannot be decoded
+	FailureKeyNotFound VerificationFailure = "keyNotFound"
+
+	// FailureRelationship: the key is not in the required verification
+	// relationship
+	FailureRelationship VerificationFailure = "notInRelationship"
+
+	// FailureDeactivated: the DID is deactivated
+	FailureDeactivated VerificationFailure = "deactivated"
+
+	// FailureAlgorithm: the signature algorithm does not match the key type
+	FailureAlgorithm VerificationFailure = "algorithmMismatch"
+
+	// FailureSignature: the signature does not match the message
+	FailureSignature VerificationFailure = "invalidSignature"
+)
+
+// VerifyOptions adjusts a verification
+type VerifyOptions struct {
+	// Relationship the key must be in. Defaults to assertionMethod, or to the
+	// proofPurpose of a Data Integrity proof.
+	Relationship Relationship
+
+	// VersionTime checks the key against the document as it was then, e.g.
+	// when the signature was made. A versionTime parameter in the key's DID
+	// URL does the same.
+	VersionTime *time.Time
+}
+
+// VerificationResult reports a verification. Verified is set only when every
+// check passed; otherwise Failure and Reason say which did not.
+type VerificationResult struct {
+	Verified bool
+	Failure  VerificationFailure
+	Reason   string
+
+	Format    SignatureFormat
+	Algorithm string // JWS name: EdDSA, ES256K or ES256
+
+	DID          string
+	KeyID        string // absolute DID URL of the verification method
+	Relationship Relationship
+	VersionTime  *time.Time
+	Deactivated  bool
+
+	// Method is the verification method that was used, when found
+	Method *VerificationMethod
+
+	// DocumentMetadata of the resolved document
+	DocumentMetadata map[string]interface{}
+}
+
+func (r *VerificationResult) fail(failure VerificationFailure, format string, args ...interface{}) *VerificationResult {
+	r.Verified, r.Failure, r.Reason = false, failure, fmt.Sprintf(format, args...)
+	return r
+}
+
+// Verifier checks signatures against keys of resolved DID documents. It
+// returns an error only when resolution fails; a signature that does not
+// verify gives a result with Verified unset.
+type Verifier struct {
+	resolver Resolver
+}
+
+// NewVerifier creates a verifier resolving DIDs with resolver
+func NewVerifier(resolver Resolver) *Verifier {
+	return &Verifier{resolver: resolver}
+}
+
+// Verify checks a raw signature of message by the key keyID, a DID URL such
+// as did:acc:alice#key-1. Ed25519 signatures are 64 bytes; ECDSA signatures
+// are r || s over the SHA-256 digest of message.
+func (v *Verifier) Verify(ctx context.Context, keyID string, message, signature []byte, opts VerifyOptions) (*VerificationResult, error) {
+	result := &VerificationResult{Format: SignatureRaw}
+	key, ok, err := v.lookup(ctx, keyID, opts, AssertionMethod, result)
+	if !ok {
+		return result, err
+	}
+	result.Algorithm = jwsAlgorithm(key.Type)
+	if !key.Verify(message, signature) {
+		return result.fail(FailureSignature, "signature does not match the message"), nil
+	}
+	result.Verified = true
+	return result, nil
+}
+
+// jwsHeader holds the protected header members a Verifier reads
+type jwsHeader struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid"`
+	B64  *bool    `json:"b64"`
+	Crit []string `json:"crit"`
+}
+
+// VerifyJWS checks a compact JWS signed by the key named in its kid header.
+// For a detached JWS (empty payload section) payload holds the signed
+// content; for an attached one payload may be nil, or must match.
+func (v *Verifier) VerifyJWS(ctx context.Context, jws string, payload []byte, opts VerifyOptions) (*VerificationResult, error) {
+	result := &VerificationResult{Format: SignatureJWS}
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return result.fail(FailureMalformed, "JWS must have three parts, got %d", len(parts)), nil
+	}
+	var header jwsHeader
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err == nil {
+		err = json.Unmarshal(raw, &header)
+	}
+	if err != nil {
+		return result.fail(FailureMalformed, "invalid JWS header: %v", err), nil
+	}
+	if (header.B64 != nil && !*header.B64) || len(header.Crit) > 0 {
+		return result.fail(FailureMalformed, "unencoded payloads and critical header parameters are not supported"), nil
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return result.fail(FailureMalformed, "invalid JWS signature: %v", err), nil
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if parts[1] == "" {
+		if payload == nil {
+			return result.fail(FailureMalformed, "detached JWS needs the payload"), nil
+		}
+		signingInput += base64.RawURLEncoding.EncodeToString(payload)
+	} else if payload != nil {
+		attached, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil || !bytes.Equal(attached, payload) {
+			return result.fail(FailureSignature, "JWS payload does not match the given payload"), nil
+		}
+	}
+
+	key, ok, err := v.lookup(ctx, header.Kid, opts, AssertionMethod, result)
+	if !ok {
+		return result, err
+	}
+	result.Algorithm = jwsAlgorithm(key.Type)
+	if header.Alg != result.Algorithm {
+		return result.fail(FailureAlgorithm, "alg %q does not match the %s key", header.Alg, key.Type), nil
+	}
+	if !key.Verify([]byte(signingInput), signature) {
+		return result.fail(FailureSignature, "JWS signature does not match"), nil
+	}
+	result.Verified = true
+	return result, nil
+}
+
+// dataIntegrityProof holds the proof members a Verifier reads
+type dataIntegrityProof struct {
+	Type               string `json:"type"`
+	Cryptosuite        string `json:"cryptosuite"`
+	VerificationMethod string `json:"verificationMethod"`
+	ProofPurpose       string `json:"proofPurpose"`
+	ProofValue         string `json:"proofValue"`
+}
+
+// VerifyDataIntegrity checks the eddsa-jcs-2022 Data Integrity proof of a
+// secured JSON document, such as a verifiable credential. The key must be in
+// the relationship named by the proof's proofPurpose.
+func (v *Verifier) VerifyDataIntegrity(ctx context.Context, document []byte, opts VerifyOptions) (*VerificationResult, error) {
+	result := &VerificationResult{Format: SignatureDataIntegrity}
+	unsecured, err := jsonobj.Members(document)
+	if err != nil {
+		return result.fail(FailureMalformed, "invalid document: %v", err), nil
+	}
+	options, err := jsonobj.Members(unsecured["proof"])
+	if err != nil {
+		return result.fail(FailureMalformed, "document must have a single proof object: %v", err), nil
+	}
+	delete(unsecured, "proof")
+
+	var proof dataIntegrityProof
+	encoded, _ := json.Marshal(options)
+	if err := json.Unmarshal(encoded, &proof); err != nil {
+		return result.fail(FailureMalformed, "invalid proof: %v", err), nil
+	}
+	if proof.Type != "DataIntegrityProof" || proof.Cryptosuite != string(SignatureDataIntegrity) {
+		return result.fail(FailureMalformed, "unsupported proof %s/%s", proof.Type, proof.Cryptosuite), nil
+	}
+	signature, err := decodeProofValue(proof.ProofValue)
+	if err != nil {
+		return result.fail(FailureMalformed, "invalid proofValue: %v", err), nil
+	}
+	rel := Relationship(proof.ProofPurpose)
+	if opts.Relationship != "" && opts.Relationship != rel {
+		return result.fail(FailureRelationship, "proof purpose %s, required %s", rel, opts.Relationship), nil
+	}
+	opts.Relationship = rel
+
+	// The proof configuration is the proof without its value; a context it
+	// declares must start the document's, and replaces it for hashing
+	delete(options, "proofValue")
+	if context, ok := options["@context"]; ok {
+		if !contextHasPrefix(unsecured["@context"], context) {
+			return result.fail(FailureMalformed, "proof @context is not a prefix of the document @context"), nil
+		}
+		unsecured["@context"] = context
+	}
+	hashData, err := dataIntegrityHash(options, unsecured)
+	if err != nil {
+		return result.fail(FailureMalformed, "cannot canonicalize: %v", err), nil
+	}
+
+	key, ok, err := v.lookup(ctx, proof.VerificationMethod, opts, rel, result)
+	if !ok {
+		return result, err
+	}
+	result.Algorithm = jwsAlgorithm(key.Type)
+	if key.Type != keys.Ed25519 {
+		return result.fail(FailureAlgorithm, "eddsa-jcs-2022 needs an Ed25519 key, got %s", key.Type), nil
+	}
+	if !key.Verify(hashData, signature) {
+		return result.fail(FailureSignature, "proof does not match the document"), nil
+	}
+	result.Verified = true
+	return result, nil
+}
+
+// dataIntegrityHash returns SHA-256(JCS(proof config)) || SHA-256(JCS(document))
+func dataIntegrityHash(config, document map[string]json.RawMessage) ([]byte, error) {
+	canonicalConfig, err := jcs.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	canonicalDocument, err := jcs.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	configHash := sha256.Sum256(canonicalConfig)
+	documentHash := sha256.Sum256(canonicalDocument)
+	return append(configHash[:], documentHash[:]...), nil
+}
+
+// decodeProofValue decodes a base58btc multibase signature
+func decodeProofValue(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, string(multibase.Base58BTC)) {
+		return nil, errors.New("expected a base58btc multibase value")
+	}
+	return multibase.DecodeBase58(value[1:])
+}
+
+// contextHasPrefix reports whether the JSON-LD context prefix (a value or an
+// array) starts the context full
+func contextHasPrefix(full, prefix json.RawMessage) bool {
+	list := func(raw json.RawMessage) []string {
+		var items []json.RawMessage
+		if json.Unmarshal(raw, &items) != nil {
+			items = []json.RawMessage{raw}
+		}
+		out := make([]string, len(items))
+		for i, item := range items {
+			canonical, _ := jcs.Canonicalize(item)
+			out[i] = string(canonical)
+		}
+		return out
+	}
+	if full == nil {
+		return false
+	}
+	fullList, prefixList := list(full), list(prefix)
+	if len(prefixList) > len(fullList) {
+		return false
+	}
+	for i := range prefixList {
+		if prefixList[i] != fullList[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup resolves the DID of keyID and finds its key in the relationship
+// given by opts or defaultRel, recording each step in result. ok is false
+// when a check failed (result says which) or resolution failed (err).
+func (v *Verifier) lookup(ctx context.Context, keyID string, opts VerifyOptions, defaultRel Relationship, result *VerificationResult) (key keys.PublicKey, ok bool, err error) {
+	rel := opts.Relationship
+	if rel == "" {
+		rel = defaultRel
+	}
+	result.Relationship = rel
+
+	did, fragment, versionTime, err := parseKeyReference(keyID)
+	if err != nil {
+		result.fail(FailureMalformed, "%v", err)
+		return key, false, nil
+	}
+	if opts.VersionTime != nil {
+		versionTime = opts.VersionTime
+	}
+	result.DID, result.KeyID, result.VersionTime = did, did+"#"+fragment, versionTime
+
+	var resolved *ResolutionResult
+	if versionTime != nil {
+		resolved, err = v.resolver.ResolveAt(ctx, did, *versionTime)
+	} else {
+		resolved, err = v.resolver.Resolve(ctx, did)
+	}
+	if errors.Is(err, ErrGoneDeactivated) {
+		result.Deactivated = true
+		result.fail(FailureDeactivated, "%s is deactivated", did)
+		return key, false, nil
+	}
+	if err != nil {
+		return key, false, fmt.Errorf("failed to resolve %s: %w", did, err)
+	}
+	result.DocumentMetadata = resolved.DocumentMetadata
+	if deactivated, _ := resolved.DocumentMetadata["deactivated"].(bool); deactivated {
+		result.Deactivated = true
+		result.fail(FailureDeactivated, "%s is deactivated", did)
+		return key, false, nil
+	}
+
+	doc := resolved.DIDDocument
+	if doc == nil {
+		result.fail(FailureKeyNotFound, "%s resolved without a document", did)
+		return key, false, nil
+	}
+	vm, found := doc.FindVerificationMethod(result.KeyID)
+	if !found {
+		result.fail(FailureKeyNotFound, "%s has no verification method %s", did, result.KeyID)
+		return key, false, nil
+	}
+	result.Method = vm
+	if !doc.HasRelationship(rel, result.KeyID) {
+		result.fail(FailureRelationship, "%s is not in %s", result.KeyID, rel)
+		return key, false, nil
+	}
+	if key, err = keys.FromVerificationMethod(vm); err != nil {
+		result.fail(FailureKeyNotFound, "cannot decode the key of %s: %v", result.KeyID, err)
+		return key, false, nil
+	}
+	return key, true, nil
+}
+
+// parseKeyReference splits a DID URL naming a key into its DID, fragment and
+// optional versionTime parameter
+func parseKeyReference(keyID string) (did, fragment string, versionTime *time.Time, err error) {
+	base, fragment, found := strings.Cut(keyID, "#")
+	if !found || fragment == "" {
+		return "", "", nil, fmt.Errorf("key reference %q must be a DID URL with a fragment", keyID)
+	}
+	did, query, _ := strings.Cut(base, "?")
+	if !strings.HasPrefix(did, "did:") {
+		return "", "", nil, fmt.Errorf("key reference %q must be a DID URL with a fragment", keyID)
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid key reference query: %w", err)
+	}
+	if vt := params.Get("versionTime"); vt != "" {
+		parsed, err := time.Parse(time.RFC3339, vt)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid versionTime %q: %w", vt, err)
+		}
+		versionTime = &parsed
+	}
+	return did, fragment, versionTime, nil
+}
+
+// jwsAlgorithm returns the JWS algorithm of a key type
+func jwsAlgorithm(t keys.Type) string {
+	switch t {
+	case keys.Ed25519:
+		return "EdDSA"
+	case keys.Secp256k1:
+		return "ES256K"
+	case keys.P256:
+		return "ES256"
+	}
+	return ""
+}
@@ -0,0 +1,288 @@
+package accdid
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/jcs"
+	"github.com/opendlt/accu-did/sdks/go/accdid/internal/multibase"
+	"github.com/opendlt/accu-did/sdks/go/accdid/keys"
+)
+
+// stubResolver serves fixed documents, and the "old" document for any
+// versionTime before cutover
+type stubResolver struct {
+	docs    map[string]*ResolutionResult
+	old     map[string]*ResolutionResult
+	cutover time.Time
+	err     error
+}
+
+func (s *stubResolver) Resolve(_ context.Context, did string) (*ResolutionResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if res, ok := s.docs[did]; ok {
+		return res, nil
+	}
+	return nil, &HTTPError{StatusCode: 404, Err: ErrNotFound}
+}
+
+func (s *stubResolver) ResolveAt(ctx context.Context, did string, versionTime time.Time) (*ResolutionResult, error) {
+	if res, ok := s.old[did]; ok && versionTime.Before(s.cutover) {
+		return res, nil
+	}
+	return s.Resolve(ctx, did)
+}
+
+type verifierFixture struct {
+	resolver *stubResolver
+	ed, k1   *keys.PrivateKey
+	p256     *keys.PrivateKey
+	old      *keys.PrivateKey
+}
+
+func newVerifierFixture(t *testing.T) *verifierFixture {
+	t.Helper()
+	f := &verifierFixture{}
+	for _, k := range []struct {
+		dst **keys.PrivateKey
+		typ keys.Type
+	}{{&f.ed, keys.Ed25519}, {&f.k1, keys.Secp256k1}, {&f.p256, keys.P256}, {&f.old, keys.Ed25519}} {
+		key, err := keys.Generate(k.typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*k.dst = key
+	}
+
+	current, err := NewDocument("did:acc:alice").
+		AddKey("ed", f.ed.Public, "").
+		AddKey("k1", f.k1.Public, keys.JsonWebKey2020, AssertionMethod).
+		AddKey("p256", f.p256.Public, keys.Multikey, Authentication).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, _ := NewDocument("did:acc:alice").AddKey("ed", f.old.Public, "").Build()
+	gone, _ := NewDocument("did:acc:bob").AddKey("ed", f.ed.Public, "").Build()
+
+	f.resolver = &stubResolver{
+		docs: map[string]*ResolutionResult{
+			"did:acc:alice": {DIDDocument: current},
+			"did:acc:bob":   {DIDDocument: gone, DocumentMetadata: map[string]interface{}{"deactivated": true}},
+		},
+		old:     map[string]*ResolutionResult{"did:acc:alice": {DIDDocument: previous}},
+		cutover: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	return f
+}
+
+func mustSign(t *testing.T, key *keys.PrivateKey, message []byte) []byte {
+	t.Helper()
+	sig, err := key.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestVerifier_Raw(t *testing.T) {
+	f := newVerifierFixture(t)
+	v := NewVerifier(f.resolver)
+	ctx := context.Background()
+	message := []byte("pay 10 ACME to bob")
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		keyID   string
+		sig     []byte
+		opts    VerifyOptions
+		failure VerificationFailure
+	}{
+		{"Ed25519", "did:acc:alice#ed", mustSign(t, f.ed, message), VerifyOptions{}, ""},
+		{"secp256k1", "did:acc:alice#k1", mustSign(t, f.k1, message), VerifyOptions{}, ""},
+		{"P-256 authentication", "did:acc:alice#p256", mustSign(t, f.p256, message), VerifyOptions{Relationship: Authentication}, ""},
+		{"P-256 not an assertion method", "did:acc:alice#p256", mustSign(t, f.p256, message), VerifyOptions{}, FailureRelationship},
+		{"wrong key", "did:acc:alice#ed", mustSign(t, f.k1, message), VerifyOptions{}, FailureSignature},
+		{"unknown key", "did:acc:alice#nope", mustSign(t, f.ed, message), VerifyOptions{}, FailureKeyNotFound},
+		{"no fragment", "did:acc:alice", mustSign(t, f.ed, message), VerifyOptions{}, FailureMalformed},
+		{"deactivated", "did:acc:bob#ed", mustSign(t, f.ed, message), VerifyOptions{}, FailureDeactivated},
+		{"rotated key at versionTime", "did:acc:alice#ed", mustSign(t, f.old, message), VerifyOptions{VersionTime: &before}, ""},
+		{"rotated key now", "did:acc:alice#ed", mustSign(t, f.old, message), VerifyOptions{}, FailureSignature},
+		{"versionTime in the DID URL", "did:acc:alice?versionTime=2024-06-01T00:00:00Z#ed", mustSign(t, f.old, message), VerifyOptions{}, ""},
+	}
+	for _, tt := range tests {
+		result, err := v.Verify(ctx, tt.keyID, message, tt.sig, tt.opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if result.Verified != (tt.failure == "") || result.Failure != tt.failure {
+			t.Errorf("%s: got verified=%v failure=%q (%s), want failure %q", tt.name, result.Verified, result.Failure, result.Reason, tt.failure)
+		}
+	}
+
+	result, _ := v.Verify(ctx, "did:acc:alice#k1", message, mustSign(t, f.k1, message), VerifyOptions{})
+	if result.KeyID != "did:acc:alice#k1" || result.Algorithm != "ES256K" || result.Method == nil || result.Method.Type != keys.JsonWebKey2020 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	result, _ = v.Verify(ctx, "did:acc:bob#ed", message, nil, VerifyOptions{})
+	if !result.Deactivated {
+		t.Error("Expected the result to report the deactivation")
+	}
+
+	f.resolver.err = &HTTPError{StatusCode: 503, Err: ErrServer}
+	if _, err := v.Verify(ctx, "did:acc:alice#ed", message, nil, VerifyOptions{}); !errors.Is(err, ErrServer) {
+		t.Errorf("Expected the resolution error, got %v", err)
+	}
+	f.resolver.err = &HTTPError{StatusCode: 410, Err: ErrGoneDeactivated}
+	if result, err := v.Verify(ctx, "did:acc:alice#ed", message, nil, VerifyOptions{}); err != nil || result.Failure != FailureDeactivated {
+		t.Errorf("Expected a 410 to fail as deactivated, got %+v, %v", result, err)
+	}
+}
+
+func signJWS(t *testing.T, key *keys.PrivateKey, header map[string]interface{}, payload []byte, detached bool) string {
+	t.Helper()
+	encodedHeader, _ := json.Marshal(header)
+	protected := base64.RawURLEncoding.EncodeToString(encodedHeader)
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	sig := base64.RawURLEncoding.EncodeToString(mustSign(t, key, []byte(protected+"."+body)))
+	if detached {
+		body = ""
+	}
+	return protected + "." + body + "." + sig
+}
+
+func TestVerifier_JWS(t *testing.T) {
+	f := newVerifierFixture(t)
+	v := NewVerifier(f.resolver)
+	ctx := context.Background()
+	payload := []byte(`{"amount":10}`)
+
+	tests := []struct {
+		name    string
+		jws     string
+		payload []byte
+		failure VerificationFailure
+	}{
+		{"EdDSA compact", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA", "kid": "did:acc:alice#ed"}, payload, false), nil, ""},
+		{"ES256K compact with payload", signJWS(t, f.k1, map[string]interface{}{"alg": "ES256K", "kid": "did:acc:alice#k1"}, payload, false), payload, ""},
+		{"EdDSA detached", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA", "kid": "did:acc:alice#ed"}, payload, true), payload, ""},
+		{"detached without payload", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA", "kid": "did:acc:alice#ed"}, payload, true), nil, FailureMalformed},
+		{"detached with other payload", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA", "kid": "did:acc:alice#ed"}, payload, true), []byte(`{"amount":1000}`), FailureSignature},
+		{"attached payload mismatch", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA", "kid": "did:acc:alice#ed"}, payload, false), []byte(`{}`), FailureSignature},
+		{"alg mismatch", signJWS(t, f.k1, map[string]interface{}{"alg": "ES256", "kid": "did:acc:alice#k1"}, payload, false), nil, FailureAlgorithm},
+		{"missing kid", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA"}, payload, false), nil, FailureMalformed},
+		{"unencoded payload", signJWS(t, f.ed, map[string]interface{}{"alg": "EdDSA", "kid": "did:acc:alice#ed", "b64": false, "crit": []string{"b64"}}, payload, false), nil, FailureMalformed},
+		{"not a JWS", "abc", nil, FailureMalformed},
+	}
+	for _, tt := range tests {
+		result, err := v.VerifyJWS(ctx, tt.jws, tt.payload, VerifyOptions{})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if result.Verified != (tt.failure == "") || result.Failure != tt.failure {
+			t.Errorf("%s: got verified=%v failure=%q (%s), want failure %q", tt.name, result.Verified, result.Failure, result.Reason, tt.failure)
+		}
+	}
+}
+
+// addProof secures document with an eddsa-jcs-2022 proof, following the
+// W3C Data Integrity EdDSA Cryptosuites algorithms
+func addProof(t *testing.T, key *keys.PrivateKey, document map[string]interface{}, vm, purpose string) []byte {
+	t.Helper()
+	config := map[string]interface{}{
+		"type":               "DataIntegrityProof",
+		"cryptosuite":        "eddsa-jcs-2022",
+		"created":            "2024-06-01T00:00:00Z",
+		"verificationMethod": vm,
+		"proofPurpose":       purpose,
+		"@context":           document["@context"],
+	}
+	canonicalConfig, err := jcs.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalDocument, _ := jcs.Marshal(document)
+	configHash, documentHash := sha256.Sum256(canonicalConfig), sha256.Sum256(canonicalDocument)
+	sig := mustSign(t, key, append(configHash[:], documentHash[:]...))
+
+	config["proofValue"] = "z" + multibase.EncodeBase58(sig)
+	secured := map[string]interface{}{"proof": config}
+	for k, v := range document {
+		secured[k] = v
+	}
+	encoded, _ := json.Marshal(secured)
+	return encoded
+}
+
+func TestVerifier_DataIntegrity(t *testing.T) {
+	f := newVerifierFixture(t)
+	v := NewVerifier(f.resolver)
+	ctx := context.Background()
+	credential := map[string]interface{}{
+		"@context":          []interface{}{"https://www.w3.org/ns/credentials/v2"},
+		"type":              []interface{}{"VerifiableCredential"},
+		"issuer":            "did:acc:alice",
+		"credentialSubject": map[string]interface{}{"id": "did:acc:bob", "name": "Bob <b&b>"},
+	}
+
+	secured := addProof(t, f.ed, credential, "did:acc:alice#ed", "assertionMethod")
+	result, err := v.VerifyDataIntegrity(ctx, secured, VerifyOptions{})
+	if err != nil || !result.Verified || result.Relationship != AssertionMethod || result.Algorithm != "EdDSA" {
+		t.Fatalf("Expected the proof to verify, got %+v, %v", result, err)
+	}
+
+	var tampered map[string]interface{}
+	json.Unmarshal(secured, &tampered)
+	tampered["issuer"] = "did:acc:mallory"
+	encoded, _ := json.Marshal(tampered)
+	if result, _ := v.VerifyDataIntegrity(ctx, encoded, VerifyOptions{}); result.Failure != FailureSignature {
+		t.Errorf("Expected a tampered credential to fail, got %+v", result)
+	}
+
+	if result, _ := v.VerifyDataIntegrity(ctx, secured, VerifyOptions{Relationship: Authentication}); result.Failure != FailureRelationship {
+		t.Errorf("Expected a proof purpose mismatch, got %+v", result)
+	}
+	authProof := addProof(t, f.ed, credential, "did:acc:alice#ed", "capabilityInvocation")
+	if result, _ := v.VerifyDataIntegrity(ctx, authProof, VerifyOptions{}); result.Failure != FailureRelationship {
+		t.Errorf("Expected the key not to be a capabilityInvocation key, got %+v", result)
+	}
+	k1Proof := addProof(t, f.k1, credential, "did:acc:alice#k1", "assertionMethod")
+	if result, _ := v.VerifyDataIntegrity(ctx, k1Proof, VerifyOptions{}); result.Failure != FailureAlgorithm {
+		t.Errorf("Expected a secp256k1 key to be refused, got %+v", result)
+	}
+	if result, _ := v.VerifyDataIntegrity(ctx, []byte(`{"issuer":"did:acc:alice"}`), VerifyOptions{}); result.Failure != FailureMalformed {
+		t.Errorf("Expected a document without proof to be malformed, got %+v", result)
+	}
+}
+
+func TestResolverClient_ResolveAt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("versionTime"); got != "2024-06-01T12:00:00Z" {
+			t.Errorf("Expected versionTime in UTC, got %q", got)
+		}
+		w.Write([]byte(`{"didDocument":{"id":"did:acc:alice"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewResolverClient(ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 6, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	result, err := client.ResolveAt(context.Background(), "did:acc:alice", at)
+	if err != nil || result.DIDDocument.ID != "did:acc:alice" {
+		t.Errorf("ResolveAt = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,197 @@
+// Package config loads service configuration from YAML or TOML files and
+// environment variables. The registrar and resolver keep their own Config
+// types and bind their variables under their own prefix.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration that reads and writes strings like "15s"
+type Duration time.Duration
+
+// Std returns the value as a time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// UnmarshalText parses a Go duration string
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// LoadFile decodes a YAML (.yaml, .yml) or TOML (.toml) file over v.
+// Unknown keys are rejected so typos do not silently fall back to defaults.
+func LoadFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), v)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("failed to parse %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+
+	return nil
+}
+
+// Binding sets one configuration value from an environment variable
+type Binding struct {
+	name   string
+	global bool
+	set    func(string) error
+}
+
+// Env binds the variable named by the service prefix followed by name
+func Env(name string, set func(string) error) Binding {
+	return Binding{name: name, set: set}
+}
+
+// GlobalEnv binds a variable read without the service prefix, such as
+// LOG_LEVEL or ACC_NODE_URL
+func GlobalEnv(name string, set func(string) error) Binding {
+	return Binding{name: name, global: true, set: set}
+}
+
+// Name returns the variable read for the binding under prefix
+func (b Binding) Name(prefix string) string {
+	if b.global {
+		return b.name
+	}
+	return prefix + b.name
+}
+
+// ApplyEnv applies each binding whose variable lookup (os.LookupEnv in
+// production) finds with a non-empty value. prefix names the service, e.g.
+// "REGISTRAR_".
+func ApplyEnv(prefix string, bindings []Binding, lookup func(string) (string, bool)) error {
+	for _, b := range bindings {
+		name := b.Name(prefix)
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := b.set(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// StringVar sets *p to the value
+func StringVar(p *string) func(string) error {
+	return func(v string) error {
+		*p = v
+		return nil
+	}
+}
+
+// BoolVar parses the value into *p
+func BoolVar(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+		return nil
+	}
+}
+
+// IntVar parses the value into *p
+func IntVar(p *int) func(string) error {
+	return func(v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = i
+		return nil
+	}
+}
+
+// Uint64Var parses the value into *p
+func Uint64Var(p *uint64) func(string) error {
+	return func(v string) error {
+		u, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*p = u
+		return nil
+	}
+}
+
+// FloatVar parses the value into *p
+func FloatVar(p *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+		return nil
+	}
+}
+
+// DurationVar parses a Go duration string into *p
+func DurationVar(p *Duration) func(string) error {
+	return func(v string) error {
+		return p.UnmarshalText([]byte(v))
+	}
+}
+
+// ListVar parses a comma-separated list, trimming whitespace around items
+func ListVar(p *[]string) func(string) error {
+	return func(v string) error {
+		*p = SplitList(v)
+		return nil
+	}
+}
+
+// SplitList splits a comma-separated flag or env value, dropping empty items
+func SplitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
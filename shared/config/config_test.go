@@ -0,0 +1,96 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Name    string   `yaml:"name" toml:"name"`
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	var yamlCfg testConfig
+	if err := LoadFile(write("a.yaml", "name: a\ntimeout: 3s\n"), &yamlCfg); err != nil {
+		t.Fatal(err)
+	}
+	if yamlCfg.Name != "a" || yamlCfg.Timeout.Std() != 3*time.Second {
+		t.Errorf("yaml = %+v", yamlCfg)
+	}
+
+	var tomlCfg testConfig
+	if err := LoadFile(write("b.toml", "name = \"b\"\ntimeout = \"1m\"\n"), &tomlCfg); err != nil {
+		t.Fatal(err)
+	}
+	if tomlCfg.Name != "b" || tomlCfg.Timeout.Std() != time.Minute {
+		t.Errorf("toml = %+v", tomlCfg)
+	}
+
+	for _, path := range []string{write("c.yaml", "nmae: typo\n"), write("d.toml", "nmae = \"typo\"\n")} {
+		if err := LoadFile(path, new(testConfig)); err == nil {
+			t.Errorf("%s: unknown keys were accepted", filepath.Base(path))
+		}
+	}
+	if err := LoadFile(write("e.json", "{}"), new(testConfig)); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("json: got %v", err)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	var (
+		name    string
+		level   string
+		enabled bool
+		timeout Duration
+		list    []string
+	)
+	bindings := []Binding{
+		Env("NAME", StringVar(&name)),
+		Env("ENABLED", BoolVar(&enabled)),
+		Env("TIMEOUT", DurationVar(&timeout)),
+		Env("LIST", ListVar(&list)),
+		GlobalEnv("LOG_LEVEL", StringVar(&level)),
+	}
+	env := map[string]string{
+		"SVC_NAME":    "svc",
+		"SVC_ENABLED": "true",
+		"SVC_TIMEOUT": "",
+		"SVC_LIST":    " a, ,b ",
+		"LOG_LEVEL":   "debug",
+		"NAME":        "unprefixed",
+	}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+
+	if err := ApplyEnv("SVC_", bindings, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if name != "svc" || !enabled || level != "debug" || timeout != 0 {
+		t.Errorf("got name=%q enabled=%v level=%q timeout=%v", name, enabled, level, timeout.Std())
+	}
+	if !reflect.DeepEqual(list, []string{"a", "b"}) {
+		t.Errorf("list = %q", list)
+	}
+
+	env["SVC_ENABLED"] = "maybe"
+	err := ApplyEnv("SVC_", bindings, lookup)
+	if err == nil || !strings.Contains(err.Error(), "SVC_ENABLED") {
+		t.Errorf("got %v, want an error naming SVC_ENABLED", err)
+	}
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opendlt/accu-did/shared/nodepool"
+)
+
+// NodePool is implemented by nodepool.Pool
+type NodePool interface {
+	Probe(ctx context.Context)
+	Status() []nodepool.NodeStatus
+}
+
+// NodesCheck reports the pool's node health and fails when none is up. The
+// pool's own probes keep the status current, so nodes are only probed here
+// when a status is older than maxAge (always when maxAge is 0).
+func NodesCheck(pool NodePool, maxAge time.Duration) Check {
+	return Check{
+		Name:     "accumulate.nodes",
+		Critical: true,
+		Run: func(ctx context.Context) (string, error) {
+			status := pool.Status()
+			if stale(status, maxAge) {
+				pool.Probe(ctx)
+				status = pool.Status()
+			}
+
+			up := 0
+			for _, n := range status {
+				if n.State == nodepool.NodeUp {
+					up++
+				}
+			}
+			if up == 0 {
+				return "", fmt.Errorf("no Accumulate nodes reachable (0/%d up)", len(status))
+			}
+			return fmt.Sprintf("%d/%d nodes up", up, len(status)), nil
+		},
+	}
+}
+
+// stale reports whether any node was last checked more than maxAge ago
+func stale(status []nodepool.NodeStatus, maxAge time.Duration) bool {
+	for _, n := range status {
+		if n.LastChecked.IsZero() || time.Since(n.LastChecked) > maxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkCheck asks a node for its network ID and, when expected is set,
+// fails if it differs (e.g. a mainnet service pointed at a testnet node)
+func NetworkCheck(networkID func(ctx context.Context) (string, error), expected string) Check {
+	return Check{
+		Name:     "accumulate.network",
+		Critical: true,
+		Run: func(ctx context.Context) (string, error) {
+			network, err := networkID(ctx)
+			if err != nil {
+				return "", err
+			}
+			if expected != "" && network != expected {
+				return "", fmt.Errorf("node reports network %q, expected %q", network, expected)
+			}
+			return "network " + network, nil
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/opendlt/accu-did/shared/nodepool"
+)
+
+// HealthResponse represents the health check response
+type HealthResponse struct {
+	Status    string                `json:"status"`
+	Timestamp time.Time             `json:"timestamp"`
+	Nodes     []nodepool.NodeStatus `json:"nodes,omitempty"`
+}
+
+// NodeStatusReporter reports the health of upstream Accumulate nodes
+type NodeStatusReporter interface {
+	Status() []nodepool.NodeStatus
+}
+
+// HealthHandler serves /healthz including per-node status
+type HealthHandler struct {
+	nodes NodeStatusReporter
+}
+
+// NewHealthHandler creates a health handler; nodes may be nil in FAKE mode
+func NewHealthHandler(nodes NodeStatusReporter) *HealthHandler {
+	return &HealthHandler{nodes: nodes}
+}
+
+// Healthz handles health check requests. The service is alive while it can
+// answer, so the status code is always 200; status is "degraded" when any
+// node is not up.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{
+		Status:    "ok",
+		Timestamp: time.Now().UTC(),
+	}
+
+	if h.nodes != nil {
+		response.Nodes = h.nodes.Status()
+		for _, n := range response.Nodes {
+			if n.State != nodepool.NodeUp {
+				response.Status = "degraded"
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Healthz handles health check requests without node reporting
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	NewHealthHandler(nil).Healthz(w, r)
+}
+
+// ReadyHandler serves /readyz from a set of readiness checks
+type ReadyHandler struct {
+	checker *Checker
+}
+
+// NewReadyHandler creates a readiness handler
+func NewReadyHandler(checker *Checker) *ReadyHandler {
+	return &ReadyHandler{checker: checker}
+}
+
+// Readyz runs every check and returns the report: 200 when ready, 503 when
+// a critical check failed
+func (h *ReadyHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,127 @@
+// Package health runs readiness checks and serves /healthz and /readyz for
+// the registrar and resolver
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Check results
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn" // a non-critical check failed
+	StatusFail = "fail" // a critical check failed
+)
+
+// Overall report status
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// DefaultTimeout bounds each check when the checker has no timeout set
+const DefaultTimeout = 5 * time.Second
+
+// CheckFunc performs a check and returns a short human-readable message
+type CheckFunc func(ctx context.Context) (string, error)
+
+// Check is a named readiness check. A failing critical check makes the
+// service not ready; a failing non-critical check is reported as a warning.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      CheckFunc
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	Message   string  `json:"message,omitempty"`
+}
+
+// Report is the JSON body of /readyz
+type Report struct {
+	Status    string    `json:"status"`
+	Timestamp time.Time `json:"timestamp"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Ready reports whether no critical check failed
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Checker runs a fixed set of checks concurrently
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker creates a checker that gives each check at most timeout
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check; checks are reported in the order they were added
+func (c *Checker) Add(check Check) *Checker {
+	c.checks = append(c.checks, check)
+	return c
+}
+
+// Run executes every check and returns the report
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusReady,
+		Timestamp: time.Now().UTC(),
+		Checks:    results,
+	}
+	for _, r := range results {
+		if r.Status == StatusFail {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	message, err := check.Run(ctx)
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusPass,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Message:   message,
+	}
+	if err != nil {
+		result.Message = err.Error()
+		result.Status = StatusWarn
+		if check.Critical {
+			result.Status = StatusFail
+		}
+	}
+	return result
+}
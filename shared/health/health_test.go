@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opendlt/accu-did/shared/nodepool"
+)
+
+func ok(msg string) CheckFunc {
+	return func(ctx context.Context) (string, error) { return msg, nil }
+}
+
+func fail(msg string) CheckFunc {
+	return func(ctx context.Context) (string, error) { return "", errors.New(msg) }
+}
+
+func TestChecker_Run(t *testing.T) {
+	t.Run("all pass", func(t *testing.T) {
+		report := NewChecker(time.Second).
+			Add(Check{Name: "a", Critical: true, Run: ok("fine")}).
+			Add(Check{Name: "b", Run: ok("")}).
+			Run(context.Background())
+
+		if !report.Ready() {
+			t.Fatalf("status = %s, want ready", report.Status)
+		}
+		if len(report.Checks) != 2 {
+			t.Fatalf("got %d checks, want 2", len(report.Checks))
+		}
+		if c := report.Checks[0]; c.Name != "a" || c.Status != StatusPass || c.Message != "fine" {
+			t.Errorf("first check = %+v", c)
+		}
+	})
+
+	t.Run("non-critical failure warns", func(t *testing.T) {
+		report := NewChecker(time.Second).
+			Add(Check{Name: "a", Critical: true, Run: ok("")}).
+			Add(Check{Name: "b", Run: fail("low")}).
+			Run(context.Background())
+
+		if !report.Ready() {
+			t.Errorf("status = %s, want ready", report.Status)
+		}
+		if c := report.Checks[1]; c.Status != StatusWarn || c.Message != "low" {
+			t.Errorf("second check = %+v", c)
+		}
+	})
+
+	t.Run("critical failure is not ready", func(t *testing.T) {
+		report := NewChecker(time.Second).
+			Add(Check{Name: "a", Critical: true, Run: fail("down")}).
+			Run(context.Background())
+
+		if report.Ready() || report.Status != StatusNotReady {
+			t.Errorf("status = %s, want %s", report.Status, StatusNotReady)
+		}
+		if report.Checks[0].Status != StatusFail {
+			t.Errorf("check status = %s, want %s", report.Checks[0].Status, StatusFail)
+		}
+	})
+
+	t.Run("checks are bounded by the timeout", func(t *testing.T) {
+		slow := func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		report := NewChecker(10 * time.Millisecond).
+			Add(Check{Name: "slow", Critical: true, Run: slow}).
+			Run(context.Background())
+
+		if c := report.Checks[0]; c.Status != StatusFail || !strings.Contains(c.Message, "deadline exceeded") {
+			t.Errorf("check = %+v", c)
+		}
+	})
+}
+
+// fakePool marks every node checked now when probed
+type fakePool struct {
+	nodes  []nodepool.NodeStatus
+	probes int
+}
+
+func (p *fakePool) Probe(ctx context.Context) {
+	p.probes++
+	for i := range p.nodes {
+		p.nodes[i].LastChecked = time.Now()
+	}
+}
+
+func (p *fakePool) Status() []nodepool.NodeStatus { return p.nodes }
+
+func TestNodesCheck(t *testing.T) {
+	pool := &fakePool{nodes: []nodepool.NodeStatus{
+		{State: nodepool.NodeUp, LastChecked: time.Now()},
+		{State: nodepool.NodeDown, LastChecked: time.Now()},
+	}}
+	msg, err := NodesCheck(pool, time.Minute).Run(context.Background())
+	if err != nil || msg != "1/2 nodes up" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+	if pool.probes != 0 {
+		t.Errorf("a fresh status is reported without probing, got %d probes", pool.probes)
+	}
+
+	// A status older than maxAge is refreshed first
+	pool.nodes[1].LastChecked = time.Now().Add(-2 * time.Minute)
+	if _, err := NodesCheck(pool, time.Minute).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if pool.probes != 1 {
+		t.Errorf("got %d probes, want 1", pool.probes)
+	}
+
+	if _, err := NodesCheck(&fakePool{nodes: []nodepool.NodeStatus{{State: nodepool.NodeDown}}}, time.Minute).Run(context.Background()); err == nil {
+		t.Error("expected an error with no node up")
+	}
+}
+
+func TestNetworkCheck(t *testing.T) {
+	networkID := func(ctx context.Context) (string, error) { return "MainNet", nil }
+
+	msg, err := NetworkCheck(networkID, "MainNet").Run(context.Background())
+	if err != nil || msg != "network MainNet" {
+		t.Fatalf("got %q, %v", msg, err)
+	}
+
+	_, err = NetworkCheck(networkID, "Kermit").Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `expected "Kermit"`) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	pool := &fakePool{nodes: []nodepool.NodeStatus{{State: nodepool.NodeDown}}}
+	w := httptest.NewRecorder()
+	NewHealthHandler(pool).Healthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var health HealthResponse
+	if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || health.Status != "degraded" {
+		t.Errorf("healthz = %d %s, want 200 degraded", w.Code, health.Status)
+	}
+
+	w = httptest.NewRecorder()
+	checker := NewChecker(time.Second).Add(Check{Name: "a", Critical: true, Run: fail("down")})
+	NewReadyHandler(checker).Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q", got)
+	}
+}
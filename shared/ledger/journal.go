@@ -0,0 +1,271 @@
+package ledger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// JournalFile is the name of the journal inside a state directory
+const JournalFile = "ledger.jsonl"
+
+// journal is an append-only file of records, one JSON object per line. Any
+// number of processes may share it: writers hold an exclusive file lock while
+// they catch up and append, readers pick up complete lines past their offset.
+type journal struct {
+	file   *os.File
+	offset int64
+}
+
+// Open opens the persistent ledger in dir, creating the directory and journal
+// when they do not exist, and replays it. Ledgers opened on the same
+// directory, in this process or another, see each other's changes.
+func Open(dir string) (*Ledger, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, JournalFile), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	l := New()
+	l.journal = &journal{file: file}
+	if err := l.refresh(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Close closes the journal of a ledger opened with Open. The in-memory state
+// stays readable but no longer follows other processes.
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.journal == nil {
+		return nil
+	}
+	err := l.journal.file.Close()
+	l.journal = nil
+	return err
+}
+
+// Export writes every record as JSON lines, in the journal format. Import
+// the output into an empty ledger to reproduce this one exactly.
+func (l *Ledger) Export(w io.Writer) error {
+	if err := l.refresh(); err != nil {
+		return err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	enc := json.NewEncoder(w)
+	for _, rec := range l.records {
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("failed to export record %d: %w", rec.Tx.Height, err)
+		}
+	}
+	return nil
+}
+
+// Import replays records written by Export. The ledger must be empty; a
+// persistent ledger also appends the records to its journal.
+func (l *Ledger) Import(r io.Reader) error {
+	var records []record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("failed to parse record %d: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read records: %w", err)
+	}
+
+	// Replay into a scratch ledger first so a bad fixture leaves no trace
+	scratch := New()
+	for _, rec := range records {
+		if err := scratch.validate(rec); err != nil {
+			return err
+		}
+		scratch.apply(rec)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.journal != nil {
+		unlock, err := l.journal.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		if err := l.catchUp(); err != nil {
+			return err
+		}
+	}
+
+	if l.height != 0 {
+		return fmt.Errorf("cannot import into a ledger at height %d", l.height)
+	}
+	for _, rec := range records {
+		if l.journal != nil {
+			if err := l.journal.append(rec); err != nil {
+				return err
+			}
+		}
+		l.apply(rec)
+	}
+	return nil
+}
+
+// ExportFile writes the records to path
+func (l *Ledger) ExportFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	if err := l.Export(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ImportFile replays the records in path
+func (l *Ledger) ImportFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+	return l.Import(file)
+}
+
+// refresh applies records other processes appended to the journal. It is a
+// no-op for in-memory ledgers.
+func (l *Ledger) refresh() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.catchUp()
+}
+
+// catchUp applies complete journal lines past the last offset read. The
+// caller holds l.mu.
+func (l *Ledger) catchUp() error {
+	if l.journal == nil {
+		return nil
+	}
+
+	info, err := l.journal.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat journal: %w", err)
+	}
+	if info.Size() <= l.journal.offset {
+		return nil
+	}
+
+	buf := make([]byte, info.Size()-l.journal.offset)
+	if _, err := l.journal.file.ReadAt(buf, l.journal.offset); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	// A writer may be halfway through its line; leave it for the next call
+	end := bytes.LastIndexByte(buf, '\n')
+	if end < 0 {
+		return nil
+	}
+	for _, line := range bytes.Split(buf[:end], []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("corrupt journal record after height %d: %w", l.height, err)
+		}
+		if err := l.validate(rec); err != nil {
+			return fmt.Errorf("corrupt journal: %w", err)
+		}
+		l.apply(rec)
+	}
+	l.journal.offset += int64(end) + 1
+	return nil
+}
+
+// validate checks that rec can be applied next. Records from this process
+// are valid by construction; this guards against hand-edited or truncated
+// files. The caller holds l.mu.
+func (l *Ledger) validate(rec record) error {
+	tx := rec.Tx
+	if tx.Height != l.height+1 {
+		return fmt.Errorf("record %s has height %d, expected %d", tx.ID, tx.Height, l.height+1)
+	}
+
+	switch tx.Type {
+	case TxCreateIdentity:
+		if rec.Identity == nil || rec.KeyPage == nil {
+			return fmt.Errorf("record %d: %s needs an identity and a key page", tx.Height, tx.Type)
+		}
+	case TxCreateDataAccount:
+		if rec.DataAccount == "" {
+			return fmt.Errorf("record %d: %s needs a data account", tx.Height, tx.Type)
+		}
+	case TxWriteData:
+		if rec.Entry == nil {
+			return fmt.Errorf("record %d: %s needs an entry", tx.Height, tx.Type)
+		}
+		if _, ok := l.dataAccounts[rec.DataAccount]; !ok {
+			return fmt.Errorf("record %d: %w: %s", tx.Height, ErrNotFound, rec.DataAccount)
+		}
+	case TxUpdateKeyPage:
+		if rec.KeyPage == nil {
+			return fmt.Errorf("record %d: %s needs a key page", tx.Height, tx.Type)
+		}
+		if _, ok := l.keyPages[rec.KeyPage.URL]; !ok {
+			return fmt.Errorf("record %d: %w: %s", tx.Height, ErrNotFound, rec.KeyPage.URL)
+		}
+	case TxAddCredits:
+		if _, ok := l.keyPages[tx.Principal]; !ok {
+			return fmt.Errorf("record %d: %w: %s", tx.Height, ErrNotFound, tx.Principal)
+		}
+	default:
+		return fmt.Errorf("record %d: unknown transaction type %q", tx.Height, tx.Type)
+	}
+	return nil
+}
+
+// append writes rec as one line. The caller holds the journal lock.
+func (j *journal) append(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+	line = append(line, '\n')
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("failed to append to journal: %w", err)
+	}
+	j.offset += int64(len(line))
+	return nil
+}
+
+// lock takes the cross-process lock on the journal
+func (j *journal) lock() (func(), error) {
+	if err := lockFile(j.file); err != nil {
+		return nil, fmt.Errorf("failed to lock journal: %w", err)
+	}
+	return func() { unlockFile(j.file) }, nil
+}
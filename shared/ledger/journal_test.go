@@ -0,0 +1,181 @@
+package ledger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func populate(t *testing.T, l *Ledger) {
+	t.Helper()
+	if _, err := l.CreateIdentity("acc://alice.acme", "", Key{PublicKey: "ed25519:aa", KeyType: "ed25519"}); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	if _, err := l.CreateDataAccount("acc://alice.acme/did", "acc://alice.acme/book/1"); err != nil {
+		t.Fatalf("CreateDataAccount: %v", err)
+	}
+	if _, _, err := l.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte(`{"v":1}`)); err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+	if _, err := l.UpdateKeyPage("acc://alice.acme/book/1", []KeyOperation{{Type: "add", PublicKey: "ed25519:bb", KeyType: "ed25519"}}); err != nil {
+		t.Fatalf("UpdateKeyPage: %v", err)
+	}
+}
+
+func assertSameState(t *testing.T, want, got *Ledger) {
+	t.Helper()
+	if want.Height() != got.Height() {
+		t.Fatalf("Expected height %d, got %d", want.Height(), got.Height())
+	}
+
+	wantPage, _ := want.KeyPage("acc://alice.acme/book/1")
+	gotPage, err := got.KeyPage("acc://alice.acme/book/1")
+	if err != nil {
+		t.Fatalf("KeyPage: %v", err)
+	}
+	if gotPage.Version != wantPage.Version || gotPage.CreditBalance != wantPage.CreditBalance || len(gotPage.Keys) != len(wantPage.Keys) {
+		t.Errorf("Expected key page %+v, got %+v", wantPage, gotPage)
+	}
+
+	wantEntry, _ := want.Latest("acc://alice.acme/did")
+	gotEntry, err := got.Latest("acc://alice.acme/did")
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if gotEntry.TxID != wantEntry.TxID || !gotEntry.Timestamp.Equal(wantEntry.Timestamp) || string(gotEntry.Data) != string(wantEntry.Data) {
+		t.Errorf("Expected entry %+v, got %+v", wantEntry, gotEntry)
+	}
+}
+
+func TestOpen_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	populate(t, l)
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reopened.Close()
+	assertSameState(t, l, reopened)
+
+	// Writes continue from the replayed height
+	tx, _, err := reopened.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte(`{"v":2}`))
+	if err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+	if tx.Height != l.Height()+1 {
+		t.Errorf("Expected height %d, got %d", l.Height()+1, tx.Height)
+	}
+}
+
+func TestOpen_SharedDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	writer, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer writer.Close()
+	reader, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reader.Close()
+
+	populate(t, writer)
+	assertSameState(t, writer, reader)
+
+	// Both sides write; neither loses the other's records
+	if _, _, err := reader.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte(`{"v":2}`)); err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+	if _, _, err := writer.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte(`{"v":3}`)); err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+	entries, err := reader.Entries("acc://alice.acme/did")
+	if err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(entries) != 3 || entries[2].Sequence != 3 || string(entries[2].Data) != `{"v":3}` {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+}
+
+func TestOpen_CorruptJournal(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, JournalFile), []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); err == nil {
+		t.Error("Expected error for a corrupt journal")
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	source := New()
+	populate(t, source)
+
+	var buf bytes.Buffer
+	if err := source.Export(&buf); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != int(source.Height()) {
+		t.Errorf("Expected %d lines, got %d", source.Height(), lines)
+	}
+
+	imported := New()
+	if err := imported.Import(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	assertSameState(t, source, imported)
+
+	// Importing into a persistent ledger writes the journal
+	dir := t.TempDir()
+	persistent, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := persistent.Import(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	persistent.Close()
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reopened.Close()
+	assertSameState(t, source, reopened)
+}
+
+func TestImport_NonEmpty(t *testing.T) {
+	source := New()
+	populate(t, source)
+	var buf bytes.Buffer
+	if err := source.Export(&buf); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	target := New()
+	populate(t, target)
+	if err := target.Import(&buf); err == nil {
+		t.Error("Expected error importing into a non-empty ledger")
+	}
+}
+
+func TestImport_Invalid(t *testing.T) {
+	// A write to an account that was never created
+	fixture := `{"tx":{"id":"x","type":"writeData","principal":"acc://a/did","height":1},"dataAccount":"acc://a/did","entry":{"data":"e30=","sequence":1}}` + "\n"
+	if err := New().Import(strings.NewReader(fixture)); err == nil {
+		t.Error("Expected error for a record that does not apply")
+	}
+}
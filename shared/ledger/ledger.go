@@ -0,0 +1,327 @@
+// Package ledger is an in-process simulation of the parts of an Accumulate
+// network the DID services use: ADIs, key books and pages, data accounts with
+// ordered entries, and credit balances. The resolver and registrar fakes share
+// a Ledger so that documents written in FAKE mode resolve immediately.
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/accumulatenetwork/accumulate/protocol"
+)
+
+// Errors returned by ledger operations; match with errors.Is
+var (
+	ErrNotFound            = errors.New("account not found")
+	ErrExists              = errors.New("account already exists")
+	ErrInsufficientCredits = errors.New("insufficient credits")
+)
+
+// Transaction types
+const (
+	TxCreateIdentity    = "createIdentity"
+	TxCreateDataAccount = "createDataAccount"
+	TxWriteData         = "writeData"
+	TxUpdateKeyPage     = "updateKeyPage"
+	TxAddCredits        = "addCredits"
+)
+
+// Fees in credits x protocol.CreditPrecision, charged to the signing key page.
+// They follow the Accumulate fee schedule for the operations the services use.
+const (
+	FeeCreateIdentity    uint64 = 500 * protocol.CreditPrecision
+	FeeCreateDataAccount uint64 = 25 * protocol.CreditPrecision
+	FeeUpdateKeyPage     uint64 = 3 * protocol.CreditPrecision
+	FeeWriteData         uint64 = protocol.CreditPrecision / 10 // per started 256 bytes
+)
+
+// DefaultInitialCredits is the balance of a key page created by CreateIdentity
+const DefaultInitialCredits uint64 = 1000 * protocol.CreditPrecision
+
+// Key is a key entry on a key page
+type Key struct {
+	PublicKey string `json:"publicKey"`
+	KeyType   string `json:"keyType"`
+}
+
+// KeyOperation changes the keys of a key page
+type KeyOperation struct {
+	Type      string `json:"type"` // "add" or "remove"
+	PublicKey string `json:"publicKey"`
+	KeyType   string `json:"keyType"`
+}
+
+// KeyPage is the state of a key page
+type KeyPage struct {
+	URL           string `json:"url"`
+	KeyBook       string `json:"keyBook"`
+	Threshold     int    `json:"threshold"`
+	Keys          []Key  `json:"keys"`
+	Version       uint64 `json:"version"`
+	CreditBalance uint64 `json:"creditBalance"` // in credits x protocol.CreditPrecision
+}
+
+// Identity is an ADI and the key book that governs it
+type Identity struct {
+	URL     string `json:"url"`
+	KeyBook string `json:"keyBook"`
+}
+
+// Entry is one entry of a data account. Sequence numbers start at 1 and
+// follow write order.
+type Entry struct {
+	Data      []byte    `json:"data"`
+	Sequence  uint64    `json:"sequence"`
+	Timestamp time.Time `json:"timestamp"`
+	TxID      string    `json:"txid"`
+	Signer    string    `json:"signer"`
+}
+
+// Transaction records an operation applied to the ledger
+type Transaction struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Principal string    `json:"principal"`
+	Signer    string    `json:"signer,omitempty"`
+	Height    uint64    `json:"height"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type dataAccount struct {
+	url       string
+	authority string
+	entries   []Entry
+}
+
+// Ledger holds the simulated network state. It is safe for concurrent use.
+// Every change is a record; a ledger opened with Open also appends records to
+// a journal file and picks up records appended by other processes.
+type Ledger struct {
+	mu             sync.RWMutex
+	now            func() time.Time
+	initialCredits uint64
+	height         uint64
+	last           time.Time
+	records        []record
+	journal        *journal
+
+	identities   map[string]*Identity
+	keyPages     map[string]*KeyPage
+	dataAccounts map[string]*dataAccount
+	transactions map[string]*Transaction
+}
+
+// New creates an empty ledger
+func New() *Ledger {
+	return &Ledger{
+		now:            time.Now,
+		initialCredits: DefaultInitialCredits,
+		identities:     make(map[string]*Identity),
+		keyPages:       make(map[string]*KeyPage),
+		dataAccounts:   make(map[string]*dataAccount),
+		transactions:   make(map[string]*Transaction),
+	}
+}
+
+// WithClock sets the clock used to timestamp transactions. Timestamps are
+// still forced to increase strictly so entries always have a total order.
+func (l *Ledger) WithClock(now func() time.Time) *Ledger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+	return l
+}
+
+// WithInitialCredits sets the balance of key pages created by CreateIdentity
+func (l *Ledger) WithInitialCredits(credits uint64) *Ledger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.initialCredits = credits
+	return l
+}
+
+// Normalize returns the canonical form of an account URL: lower case, with
+// the acc:// scheme and without a trailing slash. Accumulate URLs are case
+// insensitive.
+func Normalize(accountURL string) string {
+	u := strings.ToLower(strings.TrimSpace(accountURL))
+	u = strings.TrimPrefix(u, "acc://")
+	return "acc://" + strings.TrimRight(u, "/")
+}
+
+// authority returns the ADI URL of an account URL
+func authority(accountURL string) string {
+	rest := strings.TrimPrefix(accountURL, "acc://")
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest = rest[:i]
+	}
+	return "acc://" + rest
+}
+
+// parent returns the URL one path segment up
+func parent(accountURL string) string {
+	if i := strings.LastIndexByte(accountURL, '/'); i > len("acc://") {
+		return accountURL[:i]
+	}
+	return accountURL
+}
+
+// Identity returns an ADI
+func (l *Ledger) Identity(adiURL string) (Identity, error) {
+	if err := l.refresh(); err != nil {
+		return Identity{}, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	adi, ok := l.identities[Normalize(adiURL)]
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: %s", ErrNotFound, Normalize(adiURL))
+	}
+	return *adi, nil
+}
+
+// KeyPage returns a copy of a key page
+func (l *Ledger) KeyPage(keyPageURL string) (KeyPage, error) {
+	if err := l.refresh(); err != nil {
+		return KeyPage{}, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	page, ok := l.keyPages[Normalize(keyPageURL)]
+	if !ok {
+		return KeyPage{}, fmt.Errorf("%w: %s", ErrNotFound, Normalize(keyPageURL))
+	}
+	copied := *page
+	copied.Keys = append([]Key(nil), page.Keys...)
+	return copied, nil
+}
+
+// Entries returns the entries of a data account in write order
+func (l *Ledger) Entries(dataAccountURL string) ([]Entry, error) {
+	if err := l.refresh(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	account, ok := l.dataAccounts[Normalize(dataAccountURL)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, Normalize(dataAccountURL))
+	}
+	return append([]Entry(nil), account.entries...), nil
+}
+
+// Latest returns the most recent entry of a data account
+func (l *Ledger) Latest(dataAccountURL string) (Entry, error) {
+	entries, err := l.Entries(dataAccountURL)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, fmt.Errorf("%w: %s has no entries", ErrNotFound, Normalize(dataAccountURL))
+	}
+	return entries[len(entries)-1], nil
+}
+
+// EntryAt returns the most recent entry written at or before t
+func (l *Ledger) EntryAt(dataAccountURL string, t time.Time) (Entry, error) {
+	entries, err := l.Entries(dataAccountURL)
+	if err != nil {
+		return Entry{}, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Timestamp.After(t) {
+			return entries[i], nil
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %s has no entries at %s", ErrNotFound, Normalize(dataAccountURL), t.Format(time.RFC3339))
+}
+
+// Height returns the height of the last transaction; 0 for an empty ledger
+func (l *Ledger) Height() uint64 {
+	l.refresh() // on error, report what is known
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.height
+}
+
+// Transaction returns a transaction by ID
+func (l *Ledger) Transaction(txID string) (Transaction, error) {
+	if err := l.refresh(); err != nil {
+		return Transaction{}, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	tx, ok := l.transactions[txID]
+	if !ok {
+		return Transaction{}, fmt.Errorf("transaction not found: %s", txID)
+	}
+	return *tx, nil
+}
+
+// Transactions returns every transaction in the order it was applied
+func (l *Ledger) Transactions() []Transaction {
+	l.refresh() // on error, report what is known
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	txs := make([]Transaction, 0, len(l.transactions))
+	for _, tx := range l.transactions {
+		txs = append(txs, *tx)
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Height < txs[j].Height })
+	return txs
+}
+
+// nextTx builds the transaction at the next height without applying it. The
+// caller holds l.mu.
+func (l *Ledger) nextTx(txType, principal, signer string, payload []byte) Transaction {
+	height := l.height + 1
+
+	timestamp := l.now().UTC()
+	if !timestamp.After(l.last) {
+		timestamp = l.last.Add(time.Nanosecond)
+	}
+
+	// The ID commits to the height so identical payloads get distinct IDs
+	h := sha256.New()
+	var heightBytes [8]byte
+	binary.BigEndian.PutUint64(heightBytes[:], height)
+	h.Write(heightBytes[:])
+	h.Write([]byte(txType))
+	h.Write([]byte(principal))
+	h.Write([]byte(signer))
+	h.Write(payload)
+
+	return Transaction{
+		ID:        hex.EncodeToString(h.Sum(nil)),
+		Type:      txType,
+		Principal: principal,
+		Signer:    signer,
+		Height:    height,
+		Timestamp: timestamp,
+	}
+}
+
+// checkFee verifies the signing key page can pay fee. The caller holds l.mu.
+func (l *Ledger) checkFee(keyPageURL string, fee uint64) error {
+	page, ok := l.keyPages[keyPageURL]
+	if !ok {
+		return fmt.Errorf("%w: signer %s", ErrNotFound, keyPageURL)
+	}
+	if page.CreditBalance < fee {
+		return fmt.Errorf("%w: %s has %d, needs %d", ErrInsufficientCredits, keyPageURL, page.CreditBalance, fee)
+	}
+	return nil
+}
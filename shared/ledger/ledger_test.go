@@ -0,0 +1,221 @@
+package ledger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLedger(t *testing.T) *Ledger {
+	t.Helper()
+	l := New()
+	if _, err := l.CreateIdentity("acc://alice.acme", "", Key{PublicKey: "ed25519:aa", KeyType: "ed25519"}); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	if _, err := l.CreateDataAccount("acc://alice.acme/did", "acc://alice.acme/book/1"); err != nil {
+		t.Fatalf("CreateDataAccount: %v", err)
+	}
+	return l
+}
+
+func TestCreateIdentity(t *testing.T) {
+	l := newTestLedger(t)
+
+	adi, err := l.Identity("acc://Alice.acme")
+	if err != nil {
+		t.Fatalf("Identity: %v", err)
+	}
+	if adi.KeyBook != "acc://alice.acme/book" {
+		t.Errorf("Expected key book acc://alice.acme/book, got %s", adi.KeyBook)
+	}
+
+	page, err := l.KeyPage("acc://alice.acme/book/1")
+	if err != nil {
+		t.Fatalf("KeyPage: %v", err)
+	}
+	if page.Threshold != 1 || page.Version != 1 || len(page.Keys) != 1 {
+		t.Errorf("Unexpected key page: %+v", page)
+	}
+	if want := DefaultInitialCredits - FeeCreateDataAccount; page.CreditBalance != want {
+		t.Errorf("Expected balance %d, got %d", want, page.CreditBalance)
+	}
+
+	if _, err := l.CreateIdentity("acc://alice.acme", ""); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+	if _, err := l.CreateIdentity("acc://bob.acme", "acc://alice.acme/book/1"); err == nil {
+		t.Error("Expected an error for a key page outside the ADI")
+	}
+}
+
+func TestCreateDataAccount(t *testing.T) {
+	l := newTestLedger(t)
+
+	if _, err := l.CreateDataAccount("acc://alice.acme/did", "acc://alice.acme/book/1"); !errors.Is(err, ErrExists) {
+		t.Errorf("Expected ErrExists, got %v", err)
+	}
+	if _, err := l.CreateDataAccount("acc://bob.acme/did", "acc://alice.acme/book/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing ADI, got %v", err)
+	}
+	if _, err := l.CreateDataAccount("acc://alice.acme/other", "acc://alice.acme/book/2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing signer, got %v", err)
+	}
+}
+
+func TestWriteData(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	l := newTestLedger(t).WithClock(func() time.Time { return clock })
+
+	tx1, e1, err := l.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte(`{"v":1}`))
+	if err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+	// Same clock reading: the second entry must still be strictly later
+	tx2, e2, err := l.WriteData("acc://ALICE.acme/did/", "acc://alice.acme/book/1", []byte(`{"v":1}`))
+	if err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+
+	if e1.Sequence != 1 || e2.Sequence != 2 {
+		t.Errorf("Expected sequences 1 and 2, got %d and %d", e1.Sequence, e2.Sequence)
+	}
+	if !e2.Timestamp.After(e1.Timestamp) {
+		t.Errorf("Expected strictly increasing timestamps, got %s and %s", e1.Timestamp, e2.Timestamp)
+	}
+	if tx1.ID == tx2.ID || len(tx1.ID) != 64 {
+		t.Errorf("Expected distinct 32-byte hex IDs, got %s and %s", tx1.ID, tx2.ID)
+	}
+
+	latest, err := l.Latest("acc://alice.acme/did")
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest.TxID != tx2.ID {
+		t.Errorf("Expected latest entry from %s, got %s", tx2.ID, latest.TxID)
+	}
+
+	at, err := l.EntryAt("acc://alice.acme/did", e1.Timestamp)
+	if err != nil {
+		t.Fatalf("EntryAt: %v", err)
+	}
+	if at.Sequence != 1 {
+		t.Errorf("Expected entry 1 at its own timestamp, got %d", at.Sequence)
+	}
+	if _, err := l.EntryAt("acc://alice.acme/did", start.Add(-time.Hour)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound before the first entry, got %v", err)
+	}
+
+	if _, _, err := l.WriteData("acc://alice.acme/missing", "acc://alice.acme/book/1", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestWriteData_Credits(t *testing.T) {
+	l := New().WithInitialCredits(FeeCreateDataAccount + FeeWriteData)
+	if _, err := l.CreateIdentity("acc://alice.acme", ""); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	if _, err := l.CreateDataAccount("acc://alice.acme/did", "acc://alice.acme/book/1"); err != nil {
+		t.Fatalf("CreateDataAccount: %v", err)
+	}
+
+	if _, _, err := l.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", make([]byte, 256)); err != nil {
+		t.Fatalf("WriteData: %v", err)
+	}
+	_, _, err := l.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte("x"))
+	if !errors.Is(err, ErrInsufficientCredits) {
+		t.Errorf("Expected ErrInsufficientCredits, got %v", err)
+	}
+
+	entries, _ := l.Entries("acc://alice.acme/did")
+	if len(entries) != 1 {
+		t.Errorf("Expected the failed write to leave 1 entry, got %d", len(entries))
+	}
+}
+
+func TestUpdateKeyPage(t *testing.T) {
+	l := newTestLedger(t)
+
+	_, err := l.UpdateKeyPage("acc://alice.acme/book/1", []KeyOperation{
+		{Type: "add", PublicKey: "ed25519:bb", KeyType: "ed25519"},
+		{Type: "remove", PublicKey: "ed25519:aa"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateKeyPage: %v", err)
+	}
+
+	page, _ := l.KeyPage("acc://alice.acme/book/1")
+	if page.Version != 2 || len(page.Keys) != 1 || page.Keys[0].PublicKey != "ed25519:bb" {
+		t.Errorf("Unexpected key page: %+v", page)
+	}
+
+	_, err = l.UpdateKeyPage("acc://alice.acme/book/1", []KeyOperation{{Type: "remove", PublicKey: "ed25519:zz"}})
+	if err == nil {
+		t.Error("Expected an error removing a missing key")
+	}
+	page, _ = l.KeyPage("acc://alice.acme/book/1")
+	if page.Version != 2 {
+		t.Errorf("Expected a rejected update to leave version 2, got %d", page.Version)
+	}
+}
+
+func TestTransactions_Ordered(t *testing.T) {
+	l := newTestLedger(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.WriteData("acc://alice.acme/did", "acc://alice.acme/book/1", []byte("{}"))
+		}()
+	}
+	wg.Wait()
+
+	txs := l.Transactions()
+	if len(txs) != 22 {
+		t.Fatalf("Expected 22 transactions, got %d", len(txs))
+	}
+	for i, tx := range txs {
+		if tx.Height != uint64(i)+1 {
+			t.Fatalf("Expected height %d, got %d", i+1, tx.Height)
+		}
+	}
+
+	entries, _ := l.Entries("acc://alice.acme/did")
+	for i, entry := range entries {
+		if entry.Sequence != uint64(i)+1 {
+			t.Fatalf("Expected sequence %d, got %d", i+1, entry.Sequence)
+		}
+	}
+}
+
+func TestAddCredits(t *testing.T) {
+	l := newTestLedger(t).WithInitialCredits(0)
+	if _, err := l.CreateIdentity("acc://bob.acme", ""); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	if _, err := l.CreateDataAccount("acc://bob.acme/did", "acc://bob.acme/book/1"); !errors.Is(err, ErrInsufficientCredits) {
+		t.Fatalf("Expected ErrInsufficientCredits, got %v", err)
+	}
+
+	tx, err := l.AddCredits("acc://bob.acme/book/1", "acc://lite/ACME", FeeCreateDataAccount)
+	if err != nil {
+		t.Fatalf("AddCredits: %v", err)
+	}
+	if tx.Type != TxAddCredits || tx.Signer != "acc://lite/ACME" {
+		t.Errorf("Unexpected transaction: %+v", tx)
+	}
+	if _, err := l.CreateDataAccount("acc://bob.acme/did", "acc://bob.acme/book/1"); err != nil {
+		t.Fatalf("CreateDataAccount after AddCredits: %v", err)
+	}
+	if page, _ := l.KeyPage("acc://bob.acme/book/1"); page.CreditBalance != 0 {
+		t.Errorf("Expected balance 0, got %d", page.CreditBalance)
+	}
+
+	if _, err := l.AddCredits("acc://nobody.acme/book/1", "acc://lite/ACME", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package ledger
+
+import "os"
+
+// Without flock, processes sharing a state directory are not serialised;
+// run a single writer on these platforms.
+
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
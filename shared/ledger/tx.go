@@ -0,0 +1,287 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// record is one state change: the transaction plus everything needed to
+// apply it again without re-running validation. Records are what the journal
+// stores and what Export writes.
+type record struct {
+	Tx          Transaction `json:"tx"`
+	Identity    *Identity   `json:"identity,omitempty"`
+	KeyPage     *KeyPage    `json:"keyPage,omitempty"`
+	DataAccount string      `json:"dataAccount,omitempty"`
+	Entry       *Entry      `json:"entry,omitempty"`
+	Fee         uint64      `json:"fee,omitempty"`     // charged to Tx.Signer
+	Credits     uint64      `json:"credits,omitempty"` // added to Tx.Principal
+}
+
+// apply changes the state according to rec. The caller holds l.mu.
+func (l *Ledger) apply(rec record) {
+	tx := rec.Tx
+	l.height = tx.Height
+	l.last = tx.Timestamp
+	l.transactions[tx.ID] = &tx
+	l.records = append(l.records, rec)
+
+	if rec.Fee > 0 {
+		if page, ok := l.keyPages[tx.Signer]; ok {
+			page.CreditBalance -= rec.Fee
+		}
+	}
+
+	switch tx.Type {
+	case TxCreateIdentity:
+		adi := *rec.Identity
+		page := *rec.KeyPage
+		page.Keys = append([]Key{}, page.Keys...)
+		l.identities[adi.URL] = &adi
+		l.keyPages[page.URL] = &page
+
+	case TxCreateDataAccount:
+		l.dataAccounts[rec.DataAccount] = &dataAccount{url: rec.DataAccount, authority: authority(rec.DataAccount)}
+
+	case TxWriteData:
+		account := l.dataAccounts[rec.DataAccount]
+		account.entries = append(account.entries, *rec.Entry)
+
+	case TxUpdateKeyPage:
+		page := l.keyPages[rec.KeyPage.URL]
+		page.Keys = append([]Key{}, rec.KeyPage.Keys...)
+		page.Version = rec.KeyPage.Version
+
+	case TxAddCredits:
+		l.keyPages[tx.Principal].CreditBalance += rec.Credits
+	}
+}
+
+// commit validates and applies one change. build runs under the ledger lock,
+// after the journal (if any) has been locked and caught up, so it sees the
+// latest state from every process.
+func (l *Ledger) commit(build func() (record, error)) (record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.journal != nil {
+		unlock, err := l.journal.lock()
+		if err != nil {
+			return record{}, err
+		}
+		defer unlock()
+		if err := l.catchUp(); err != nil {
+			return record{}, err
+		}
+	}
+
+	rec, err := build()
+	if err != nil {
+		return record{}, err
+	}
+
+	if l.journal != nil {
+		if err := l.journal.append(rec); err != nil {
+			return record{}, err
+		}
+	}
+	l.apply(rec)
+	return rec, nil
+}
+
+// CreateIdentity creates an ADI with a key book and its first key page. The
+// book is the parent of keyPageURL (acc://<adi>/book/1 when empty). The new
+// page accepts one signature, holds keys and is funded with the ledger's
+// initial credits.
+func (l *Ledger) CreateIdentity(adiURL, keyPageURL string, keys ...Key) (*Transaction, error) {
+	adiURL = Normalize(adiURL)
+	if keyPageURL == "" {
+		keyPageURL = adiURL + "/book/1"
+	}
+	keyPageURL = Normalize(keyPageURL)
+	if authority(keyPageURL) != adiURL {
+		return nil, fmt.Errorf("key page %s does not belong to %s", keyPageURL, adiURL)
+	}
+
+	rec, err := l.commit(func() (record, error) {
+		if _, exists := l.identities[adiURL]; exists {
+			return record{}, fmt.Errorf("%w: %s", ErrExists, adiURL)
+		}
+
+		keyBook := parent(keyPageURL)
+		return record{
+			Tx:       l.nextTx(TxCreateIdentity, adiURL, "", []byte(keyPageURL)),
+			Identity: &Identity{URL: adiURL, KeyBook: keyBook},
+			KeyPage: &KeyPage{
+				URL:           keyPageURL,
+				KeyBook:       keyBook,
+				Threshold:     1,
+				Keys:          append([]Key{}, keys...),
+				Version:       1,
+				CreditBalance: l.initialCredits,
+			},
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Tx, nil
+}
+
+// CreateDataAccount creates a data account under an existing ADI, signed and
+// paid for by keyPageURL
+func (l *Ledger) CreateDataAccount(dataAccountURL, keyPageURL string) (*Transaction, error) {
+	dataAccountURL = Normalize(dataAccountURL)
+	keyPageURL = Normalize(keyPageURL)
+	adiURL := authority(dataAccountURL)
+	if adiURL == dataAccountURL {
+		return nil, fmt.Errorf("data account URL %s has no path", dataAccountURL)
+	}
+
+	rec, err := l.commit(func() (record, error) {
+		if _, ok := l.identities[adiURL]; !ok {
+			return record{}, fmt.Errorf("%w: %s", ErrNotFound, adiURL)
+		}
+		if _, exists := l.dataAccounts[dataAccountURL]; exists {
+			return record{}, fmt.Errorf("%w: %s", ErrExists, dataAccountURL)
+		}
+		if err := l.checkFee(keyPageURL, FeeCreateDataAccount); err != nil {
+			return record{}, err
+		}
+
+		return record{
+			Tx:          l.nextTx(TxCreateDataAccount, dataAccountURL, keyPageURL, nil),
+			DataAccount: dataAccountURL,
+			Fee:         FeeCreateDataAccount,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Tx, nil
+}
+
+// WriteData appends an entry to a data account, signed and paid for by
+// keyPageURL
+func (l *Ledger) WriteData(dataAccountURL, keyPageURL string, data []byte) (*Transaction, Entry, error) {
+	dataAccountURL = Normalize(dataAccountURL)
+	keyPageURL = Normalize(keyPageURL)
+
+	rec, err := l.commit(func() (record, error) {
+		account, ok := l.dataAccounts[dataAccountURL]
+		if !ok {
+			return record{}, fmt.Errorf("%w: %s", ErrNotFound, dataAccountURL)
+		}
+		fee := WriteDataFee(len(data))
+		if err := l.checkFee(keyPageURL, fee); err != nil {
+			return record{}, err
+		}
+
+		tx := l.nextTx(TxWriteData, dataAccountURL, keyPageURL, data)
+		return record{
+			Tx:          tx,
+			DataAccount: dataAccountURL,
+			Fee:         fee,
+			Entry: &Entry{
+				Data:      append([]byte(nil), data...),
+				Sequence:  uint64(len(account.entries)) + 1,
+				Timestamp: tx.Timestamp,
+				TxID:      tx.ID,
+				Signer:    keyPageURL,
+			},
+		}, nil
+	})
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	return &rec.Tx, *rec.Entry, nil
+}
+
+// UpdateKeyPage applies key operations to a key page, signed and paid for by
+// the page itself. The page version is bumped once per transaction.
+func (l *Ledger) UpdateKeyPage(keyPageURL string, operations []KeyOperation) (*Transaction, error) {
+	keyPageURL = Normalize(keyPageURL)
+
+	rec, err := l.commit(func() (record, error) {
+		page, ok := l.keyPages[keyPageURL]
+		if !ok {
+			return record{}, fmt.Errorf("%w: %s", ErrNotFound, keyPageURL)
+		}
+
+		keys := append([]Key(nil), page.Keys...)
+		for _, op := range operations {
+			switch op.Type {
+			case "add":
+				keys = append(keys, Key{PublicKey: op.PublicKey, KeyType: op.KeyType})
+			case "remove":
+				i := indexOfKey(keys, op.PublicKey)
+				if i < 0 {
+					return record{}, fmt.Errorf("key %s is not on %s", op.PublicKey, keyPageURL)
+				}
+				keys = append(keys[:i], keys[i+1:]...)
+			default:
+				return record{}, fmt.Errorf("unsupported key page operation %q", op.Type)
+			}
+		}
+		if err := l.checkFee(keyPageURL, FeeUpdateKeyPage); err != nil {
+			return record{}, err
+		}
+
+		var payload strings.Builder
+		for _, op := range operations {
+			payload.WriteString(op.Type + ":" + op.PublicKey + ";")
+		}
+		return record{
+			Tx:      l.nextTx(TxUpdateKeyPage, keyPageURL, keyPageURL, []byte(payload.String())),
+			KeyPage: &KeyPage{URL: keyPageURL, Keys: keys, Version: page.Version + 1},
+			Fee:     FeeUpdateKeyPage,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Tx, nil
+}
+
+// AddCredits credits a key page, as buying credits with ACME from source (a
+// lite token account) does on a real network. The ledger does not model
+// token balances, so the purchase always succeeds.
+func (l *Ledger) AddCredits(keyPageURL, source string, credits uint64) (*Transaction, error) {
+	keyPageURL = Normalize(keyPageURL)
+	if credits == 0 {
+		return nil, fmt.Errorf("credits must be positive")
+	}
+
+	rec, err := l.commit(func() (record, error) {
+		if _, ok := l.keyPages[keyPageURL]; !ok {
+			return record{}, fmt.Errorf("%w: %s", ErrNotFound, keyPageURL)
+		}
+		return record{
+			Tx:      l.nextTx(TxAddCredits, keyPageURL, source, binary.BigEndian.AppendUint64(nil, credits)),
+			Credits: credits,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rec.Tx, nil
+}
+
+func indexOfKey(keys []Key, publicKey string) int {
+	for i, key := range keys {
+		if key.PublicKey == publicKey {
+			return i
+		}
+	}
+	return -1
+}
+
+// WriteDataFee returns the fee for writing an entry of size bytes
+func WriteDataFee(size int) uint64 {
+	chunks := uint64(size+255) / 256
+	if chunks == 0 {
+		chunks = 1
+	}
+	return chunks * FeeWriteData
+}
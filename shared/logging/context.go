@@ -0,0 +1,37 @@
+package logging
+
+import "context"
+
+// requestIDKey is the context key for the request ID. It is unexported so
+// only this package can set or read the value.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" if none
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// clientIPKey is the context key for the resolved client IP
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the client IP, as resolved from
+// the peer address and any trusted forwarding headers
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the client IP stored in ctx, or "" if none
+func ClientIPFromContext(ctx context.Context) string {
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+// Package logging provides structured slog logging with request correlation
+// and optional redaction for the resolver and registrar
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys shared by all log lines
+const (
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+	KeyDID       = "did"
+	KeyClientIP  = "client_ip"
+)
+
+// Config holds logging configuration
+type Config struct {
+	Level      string // debug, info, warn or error
+	Format     string // json or text
+	RedactDIDs bool   // replace DIDs with a stable hash
+	RedactIPs  bool   // truncate client IPs to their network prefix
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		Level:  "info",
+		Format: "json",
+	}
+}
+
+// ParseLevel converts a level name to a slog.Level
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+	return l, nil
+}
+
+// New creates a logger writing to w. Every record logged with a context
+// carries the request ID and trace/span IDs found in that context.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	return NewWithLevelVar(w, cfg, new(slog.LevelVar))
+}
+
+// NewWithLevelVar is like New but takes the level from level, which is set
+// from cfg.Level and can be changed later (e.g. on config reload)
+func NewWithLevelVar(w io.Writer, cfg Config, level *slog.LevelVar) (*slog.Logger, error) {
+	l, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	level.Set(l)
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: newRedactor(cfg.RedactDIDs, cfg.RedactIPs),
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "json", "":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be json or text", cfg.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// DID returns the attribute used to log a DID, so redaction can find it
+func DID(did string) slog.Attr {
+	return slog.String(KeyDID, did)
+}
+
+// ClientIP returns the attribute used to log a client IP address
+func ClientIP(ip string) slog.Attr {
+	return slog.String(KeyClientIP, ip)
+}
+
+// contextHandler adds correlation IDs from the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(
+			slog.String(KeyTraceID, sc.TraceID().String()),
+			slog.String(KeySpanID, sc.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("Invalid log line %q: %v", buf.String(), err)
+	}
+	return line
+}
+
+func newTestLogger(t *testing.T, buf *bytes.Buffer, cfg Config) *slog.Logger {
+	t.Helper()
+	logger, err := New(buf, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return logger
+}
+
+func TestNew_AttachesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf, DefaultConfig())
+
+	ctx := WithRequestID(context.Background(), "req_123")
+	logger.InfoContext(ctx, "DID resolved", DID("did:acc:alice"))
+
+	line := decodeLine(t, &buf)
+	if line[KeyRequestID] != "req_123" || line[KeyDID] != "did:acc:alice" || line["level"] != "INFO" {
+		t.Errorf("Unexpected log line %v", line)
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Level = "warn"
+	logger := newTestLogger(t, &buf, cfg)
+
+	logger.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("Expected info to be dropped at warn, got %q", buf.String())
+	}
+
+	cfg.Level = "verbose"
+	if _, err := New(&buf, cfg); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestNew_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.RedactDIDs = true
+	cfg.RedactIPs = true
+	logger := newTestLogger(t, &buf, cfg)
+
+	logger.Warn("lookup for did:acc:alice failed",
+		DID("did:acc:alice"),
+		ClientIP("203.0.113.42"),
+		"error", errors.New("DID not found: did:acc:alice"),
+	)
+
+	line := decodeLine(t, &buf)
+	redacted := RedactDID("did:acc:alice")
+	if !regexp.MustCompile(`^did:acc:redacted-[0-9a-f]{12}$`).MatchString(redacted) {
+		t.Errorf("Unexpected redacted DID %s", redacted)
+	}
+	if line[KeyDID] != redacted || line["msg"] != "lookup for "+redacted+" failed" || line["error"] != "DID not found: "+redacted {
+		t.Errorf("Expected every DID to be redacted, got %v", line)
+	}
+	if line[KeyClientIP] != "203.0.113.0" || strings.Contains(buf.String(), "alice") {
+		t.Errorf("Expected the IP to be truncated and no DID left, got %s", buf.String())
+	}
+}
+
+func TestRedactIP(t *testing.T) {
+	for ip, want := range map[string]string{
+		"203.0.113.42":      "203.0.113.0",
+		"203.0.113.42:8080": "203.0.113.0:8080",
+		"2001:db8:1:2::1":   "2001:db8:1::",
+		"not-an-ip":         "not-an-ip",
+	} {
+		if got := RedactIP(ip); got != want {
+			t.Errorf("RedactIP(%q) = %q, expected %q", ip, got, want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf, DefaultConfig())
+
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/register", nil)
+	req = req.WithContext(WithRequestID(req.Context(), "req_456"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := decodeLine(t, &buf)
+	if line["msg"] != "request completed" || line["level"] != slog.LevelWarn.String() || line[KeyRequestID] != "req_456" {
+		t.Errorf("Unexpected log line %v", line)
+	}
+	if line["status"] != float64(http.StatusNotFound) || line[KeyClientIP] != "192.0.2.1" {
+		t.Errorf("Expected status 404 from 192.0.2.1, got %v", line)
+	}
+
+	// A client IP resolved by the security middleware wins over the peer
+	buf.Reset()
+	req = req.WithContext(WithClientIP(req.Context(), "198.51.100.7"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if line := decodeLine(t, &buf); line[KeyClientIP] != "198.51.100.7" {
+		t.Errorf("Expected the resolved client IP, got %v", line[KeyClientIP])
+	}
+}
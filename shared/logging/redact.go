@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"regexp"
+)
+
+// didPattern matches DIDs embedded in log messages, paths and error strings
+var didPattern = regexp.MustCompile(`did:[a-z0-9]+:[A-Za-z0-9._%\-:/]+`)
+
+// RedactDID replaces every DID in s with its method and a short hash of the
+// full identifier, so log lines stay correlatable without exposing the DID
+func RedactDID(s string) string {
+	return didPattern.ReplaceAllStringFunc(s, func(did string) string {
+		sum := sha256.Sum256([]byte(did))
+		method := did[len("did:"):]
+		for i := range method {
+			if method[i] == ':' {
+				method = method[:i]
+				break
+			}
+		}
+		return "did:" + method + ":redacted-" + hex.EncodeToString(sum[:6])
+	})
+}
+
+// RedactIP truncates an IPv4 address to its /24 and an IPv6 address to its /48.
+// Values that are not IP addresses are returned unchanged.
+func RedactIP(s string) string {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		host, port = s, ""
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return s
+	}
+
+	var masked string
+	if v4 := ip.To4(); v4 != nil {
+		masked = v4.Mask(net.CIDRMask(24, 32)).String()
+	} else {
+		masked = ip.Mask(net.CIDRMask(48, 128)).String()
+	}
+
+	if port != "" {
+		return net.JoinHostPort(masked, port)
+	}
+	return masked
+}
+
+// newRedactor returns a slog ReplaceAttr function applying the enabled redactions
+func newRedactor(dids, ips bool) func(groups []string, a slog.Attr) slog.Attr {
+	if !dids && !ips {
+		return nil
+	}
+
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if ips && a.Key == KeyClientIP {
+			return slog.String(a.Key, RedactIP(a.Value.String()))
+		}
+
+		if dids {
+			switch a.Value.Kind() {
+			case slog.KindString:
+				return slog.String(a.Key, RedactDID(a.Value.String()))
+			case slog.KindAny:
+				// Errors frequently quote the DID that failed
+				if err, ok := a.Value.Any().(error); ok {
+					return slog.String(a.Key, RedactDID(err.Error()))
+				}
+			}
+		}
+
+		return a
+	}
+}
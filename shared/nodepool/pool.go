@@ -0,0 +1,453 @@
+// Package nodepool spreads Accumulate JSON-RPC calls over several nodes with
+// health probes, circuit breaking and failover. The resolver and registrar
+// share it.
+package nodepool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrNoAvailableNodes is returned when every node's circuit is open
+var ErrNoAvailableNodes = errors.New("no available Accumulate nodes")
+
+// Node states reported by Pool.Status
+const (
+	NodeUp         = "up"         // circuit closed
+	NodeDown       = "down"       // circuit open, requests are not sent
+	NodeRecovering = "recovering" // cooldown elapsed, a trial request is allowed
+)
+
+// idempotentMethods are JSON-RPC methods that can be safely retried on
+// another node. Anything else (submit, faucet, batches) is sent at most once.
+var idempotentMethods = map[string]bool{
+	"query":            true,
+	"node-info":        true,
+	"network-status":   true,
+	"consensus-status": true,
+	"find-service":     true,
+	"metrics":          true,
+}
+
+// Config tunes health probing, circuit breaking and retries
+type Config struct {
+	ProbeInterval    time.Duration // 0 disables background probes
+	ProbeTimeout     time.Duration
+	FailureThreshold int           // consecutive failures that open a node's circuit
+	Cooldown         time.Duration // time a circuit stays open before a trial request
+	Retries          int           // extra attempts on other nodes for idempotent queries
+}
+
+// DefaultConfig returns the default pool settings
+func DefaultConfig() Config {
+	return Config{
+		ProbeInterval:    10 * time.Second,
+		ProbeTimeout:     5 * time.Second,
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+		Retries:          2,
+	}
+}
+
+// NodeStatus is the health of one node as reported in /healthz
+type NodeStatus struct {
+	URL                 string    `json:"url"`
+	State               string    `json:"state"`
+	LatencyMs           float64   `json:"latencyMs"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastChecked         time.Time `json:"lastChecked"`
+}
+
+// defaultLatency is assumed for nodes that have not answered yet
+const defaultLatency = 100 * time.Millisecond
+
+type poolNode struct {
+	raw       string
+	url       *url.URL
+	transport http.RoundTripper
+
+	state       string
+	failures    int
+	openedAt    time.Time
+	trial       bool          // a half-open trial request is in flight
+	latency     time.Duration // moving average of successful calls
+	lastErr     string
+	lastChecked time.Time
+}
+
+// Pool spreads JSON-RPC calls over several Accumulate nodes. It is an
+// http.RoundTripper: a jsonrpc.Client pointed at URL() with the pool as its
+// transport has each call routed to a node chosen by latency, skipping nodes
+// whose circuit is open. Idempotent queries are retried on other nodes;
+// submissions are only retried when the connection failed before anything
+// was sent, so a transaction is never submitted twice.
+type Pool struct {
+	cfg   Config
+	nodes []*poolNode
+
+	mu   sync.Mutex
+	now  func() time.Time
+	rand func() float64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New creates a pool over nodeURLs. transport returns the HTTP transport
+// for a node (http.DefaultTransport when transport or its result is nil), so
+// per-node instrumentation can be layered underneath.
+func New(nodeURLs []string, cfg Config, transport func(nodeURL string) http.RoundTripper) (*Pool, error) {
+	if len(nodeURLs) == 0 {
+		return nil, errors.New("at least one node URL is required")
+	}
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+
+	p := &Pool{
+		cfg:  cfg,
+		now:  time.Now,
+		rand: rand.Float64,
+		stop: make(chan struct{}),
+	}
+	for _, raw := range nodeURLs {
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid node URL %q", raw)
+		}
+
+		var rt http.RoundTripper
+		if transport != nil {
+			rt = transport(raw)
+		}
+		if rt == nil {
+			rt = http.DefaultTransport
+		}
+
+		p.nodes = append(p.nodes, &poolNode{raw: raw, url: u, transport: rt, state: NodeUp})
+	}
+
+	return p, nil
+}
+
+// URL returns the endpoint to configure on the JSON-RPC client. The pool
+// rewrites every request to the node it selects.
+func (p *Pool) URL() string {
+	return p.nodes[0].raw
+}
+
+// Start probes every node now and then every ProbeInterval until Close
+func (p *Pool) Start() {
+	p.Probe(context.Background())
+	if p.cfg.ProbeInterval <= 0 {
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.cfg.ProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.Probe(context.Background())
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops background probing
+func (p *Pool) Close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+	p.wg.Wait()
+}
+
+// Status returns a snapshot of every node's health
+func (p *Pool) Status() []NodeStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	status := make([]NodeStatus, len(p.nodes))
+	for i, n := range p.nodes {
+		state := n.state
+		if state == NodeDown && now.Sub(n.openedAt) >= p.cfg.Cooldown {
+			state = NodeRecovering
+		}
+		status[i] = NodeStatus{
+			URL:                 n.raw,
+			State:               state,
+			LatencyMs:           float64(n.latency) / float64(time.Millisecond),
+			ConsecutiveFailures: n.failures,
+			LastError:           n.lastErr,
+			LastChecked:         n.lastChecked,
+		}
+	}
+	return status
+}
+
+// Probe sends a node-info call to every node and records the outcome. A
+// successful probe closes a node's circuit immediately.
+func (p *Pool) Probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, n := range p.nodes {
+		wg.Add(1)
+		go func(n *poolNode) {
+			defer wg.Done()
+			p.probe(ctx, n)
+		}(n)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) probe(ctx context.Context, n *poolNode) {
+	if p.cfg.ProbeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.cfg.ProbeTimeout)
+		defer cancel()
+	}
+
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"node-info","params":{}}`)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.raw, bytes.NewReader(body))
+	if err != nil {
+		p.recordFailure(n, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := p.now()
+	resp, err := n.transport.RoundTrip(req)
+	if err != nil {
+		p.recordFailure(n, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		p.recordFailure(n, fmt.Errorf("probe returned HTTP %d", resp.StatusCode))
+		return
+	}
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		p.recordFailure(n, fmt.Errorf("probe returned invalid JSON-RPC: %w", err))
+		return
+	}
+	if len(result.Error) > 0 && string(result.Error) != "null" {
+		p.recordFailure(n, fmt.Errorf("probe returned error: %s", result.Error))
+		return
+	}
+
+	p.recordSuccess(n, p.now().Sub(start))
+}
+
+// RoundTrip sends req to a selected node, failing over as described on Pool
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	idempotent := idempotentMethods[rpcMethod(body)]
+	attempts := 1
+	if idempotent {
+		attempts += p.cfg.Retries
+	}
+
+	// The last failed attempt is kept until another node is actually tried,
+	// so the caller sees the real upstream answer when nodes run out
+	tried := make(map[*poolNode]bool)
+	var lastResp *http.Response
+	var lastErr error
+	for {
+		n := p.pick(tried)
+		if n == nil {
+			break
+		}
+		tried[n] = true
+		if lastResp != nil {
+			lastResp.Body.Close()
+			lastResp = nil
+		}
+
+		start := p.now()
+		resp, err := n.transport.RoundTrip(rewrite(req, n.url, body))
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			p.recordSuccess(n, p.now().Sub(start))
+			return resp, nil
+		}
+
+		// The caller gave up; that says nothing about the node
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			p.release(n)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if err == nil {
+				err = ctxErr
+			}
+			return nil, err
+		}
+
+		failure := err
+		if failure == nil {
+			failure = fmt.Errorf("node %s returned HTTP %d", n.url.Host, resp.StatusCode)
+		}
+		p.recordFailure(n, failure)
+
+		// A request that never left the client can go anywhere; otherwise
+		// only idempotent queries get another node
+		if !notSent(failure) && !(idempotent && len(tried) < attempts) {
+			return resp, err
+		}
+		lastResp, lastErr = resp, err
+	}
+
+	switch {
+	case lastResp != nil:
+		return lastResp, nil
+	case lastErr != nil:
+		return nil, lastErr
+	default:
+		return nil, ErrNoAvailableNodes
+	}
+}
+
+// pick chooses an untried node, weighting by inverse latency. A node whose
+// cooldown has elapsed is allowed one trial request at a time.
+func (p *Pool) pick(tried map[*poolNode]bool) *poolNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var candidates []*poolNode
+	var weights []float64
+	var total float64
+	for _, n := range p.nodes {
+		if tried[n] {
+			continue
+		}
+		if n.state == NodeDown && (n.trial || now.Sub(n.openedAt) < p.cfg.Cooldown) {
+			continue
+		}
+
+		latency := n.latency
+		if latency <= 0 {
+			latency = defaultLatency
+		}
+		w := 1 / latency.Seconds()
+		candidates = append(candidates, n)
+		weights = append(weights, w)
+		total += w
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	chosen := candidates[len(candidates)-1]
+	r := p.rand() * total
+	for i, w := range weights {
+		if r < w {
+			chosen = candidates[i]
+			break
+		}
+		r -= w
+	}
+
+	if chosen.state == NodeDown {
+		chosen.trial = true
+	}
+	return chosen
+}
+
+func (p *Pool) recordSuccess(n *poolNode, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n.state = NodeUp
+	n.failures = 0
+	n.trial = false
+	n.lastErr = ""
+	n.lastChecked = p.now()
+	if n.latency == 0 {
+		n.latency = latency
+	} else {
+		n.latency = (4*n.latency + latency) / 5
+	}
+}
+
+func (p *Pool) recordFailure(n *poolNode, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n.failures++
+	n.lastErr = err.Error()
+	n.lastChecked = p.now()
+	if n.trial || n.failures >= p.cfg.FailureThreshold {
+		n.state = NodeDown
+		n.openedAt = p.now()
+	}
+	n.trial = false
+}
+
+// release gives back a trial slot without judging the node
+func (p *Pool) release(n *poolNode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.trial = false
+}
+
+// rewrite clones req for the given node with a fresh copy of body
+func rewrite(req *http.Request, node *url.URL, body []byte) *http.Request {
+	out := req.Clone(req.Context())
+	u := *node
+	out.URL = &u
+	out.Host = ""
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	out.ContentLength = int64(len(body))
+	return out
+}
+
+// notSent reports whether err happened while connecting, i.e. before any
+// part of the request reached the node
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// rpcMethod extracts the method name from a JSON-RPC request. Batches and
+// unparsable bodies return "" and are treated as non-idempotent.
+func rpcMethod(body []byte) string {
+	var req struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.Method
+}
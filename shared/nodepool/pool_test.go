@@ -0,0 +1,220 @@
+package nodepool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rpcNode is an httptest JSON-RPC stand-in that counts calls per method
+type rpcNode struct {
+	*httptest.Server
+	status atomic.Int32 // HTTP status to answer with
+	calls  map[string]*atomic.Int32
+}
+
+func newRPCNode(t *testing.T) *rpcNode {
+	n := &rpcNode{calls: map[string]*atomic.Int32{
+		"node-info": {}, "query": {}, "submit": {},
+	}}
+	n.status.Store(http.StatusOK)
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if c, ok := n.calls[req.Method]; ok {
+			c.Add(1)
+		}
+
+		status := int(n.status.Load())
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{}})
+		}
+	}))
+	t.Cleanup(n.Close)
+	return n
+}
+
+// rpcCall sends a JSON-RPC call through p and closes the response body
+func rpcCall(t *testing.T, p *Pool, method string) (int, error) {
+	t.Helper()
+	body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":{}}`
+	req, err := http.NewRequest(http.MethodPost, p.URL(), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := p.RoundTrip(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func newTestPool(t *testing.T, nodeURLs []string, configure func(*Config)) *Pool {
+	t.Helper()
+	cfg := Config{
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+		Retries:          2,
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	pool, err := New(nodeURLs, cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	pool.rand = func() float64 { return 0 } // always prefer the first candidate
+	return pool
+}
+
+func TestPool_QueryFailsOverToHealthyNode(t *testing.T) {
+	bad, good := newRPCNode(t), newRPCNode(t)
+	bad.status.Store(http.StatusBadGateway)
+	pool := newTestPool(t, []string{bad.URL, good.URL}, nil)
+
+	status, err := rpcCall(t, pool, "query")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Expected 200 from the healthy node, got %d, %v", status, err)
+	}
+	if bad.calls["query"].Load() != 1 || good.calls["query"].Load() != 1 {
+		t.Errorf("Expected one query per node, got %d and %d", bad.calls["query"].Load(), good.calls["query"].Load())
+	}
+}
+
+func TestPool_SubmitIsNeverResent(t *testing.T) {
+	bad, good := newRPCNode(t), newRPCNode(t)
+	bad.status.Store(http.StatusBadGateway)
+	pool := newTestPool(t, []string{bad.URL, good.URL}, nil)
+
+	// The node's answer is returned as is
+	status, err := rpcCall(t, pool, "submit")
+	if err != nil || status != http.StatusBadGateway {
+		t.Fatalf("Expected the node's 502, got %d, %v", status, err)
+	}
+	// A submission that reached a node must not be sent again
+	if bad.calls["submit"].Load() != 1 || good.calls["submit"].Load() != 0 {
+		t.Errorf("Expected a single submission, got %d and %d", bad.calls["submit"].Load(), good.calls["submit"].Load())
+	}
+}
+
+func TestPool_SubmitFailsOverWhenNothingWasSent(t *testing.T) {
+	good := newRPCNode(t)
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close() // connections are refused
+	pool := newTestPool(t, []string{downURL, good.URL}, nil)
+
+	if _, err := rpcCall(t, pool, "submit"); err != nil {
+		t.Fatalf("Expected the submission to reach the healthy node: %v", err)
+	}
+	if good.calls["submit"].Load() != 1 {
+		t.Errorf("Expected 1 submission, got %d", good.calls["submit"].Load())
+	}
+}
+
+func TestPool_CircuitOpensAndRecovers(t *testing.T) {
+	bad, good := newRPCNode(t), newRPCNode(t)
+	bad.status.Store(http.StatusInternalServerError)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool := newTestPool(t, []string{bad.URL, good.URL}, func(cfg *Config) { cfg.Retries = 0 })
+	pool.now = func() time.Time { return now }
+
+	// Two consecutive failures open the circuit
+	for i := 0; i < 2; i++ {
+		if _, err := rpcCall(t, pool, "query"); err != nil {
+			t.Fatalf("query: %v", err)
+		}
+	}
+	if s := pool.Status()[0]; s.State != NodeDown || s.ConsecutiveFailures != 2 {
+		t.Errorf("Expected the node down after 2 failures, got %+v", s)
+	}
+
+	// While open, the node is skipped
+	if _, err := rpcCall(t, pool, "query"); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if bad.calls["query"].Load() != 2 || good.calls["query"].Load() != 1 {
+		t.Errorf("Expected the open node to be skipped, got %d and %d", bad.calls["query"].Load(), good.calls["query"].Load())
+	}
+
+	// After the cooldown one trial request is let through and closes the circuit
+	now = now.Add(time.Minute)
+	bad.status.Store(http.StatusOK)
+	if s := pool.Status()[0]; s.State != NodeRecovering {
+		t.Errorf("Expected the node to be recovering, got %s", s.State)
+	}
+	if _, err := rpcCall(t, pool, "query"); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if bad.calls["query"].Load() != 3 || pool.Status()[0].State != NodeUp {
+		t.Errorf("Expected the trial to close the circuit, got %d calls, state %s", bad.calls["query"].Load(), pool.Status()[0].State)
+	}
+}
+
+func TestPool_ProbeUpdatesStatus(t *testing.T) {
+	bad, good := newRPCNode(t), newRPCNode(t)
+	bad.status.Store(http.StatusServiceUnavailable)
+	pool := newTestPool(t, []string{bad.URL, good.URL}, func(cfg *Config) { cfg.FailureThreshold = 1 })
+
+	pool.Probe(context.Background())
+	status := pool.Status()
+	if len(status) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(status))
+	}
+	if status[0].State != NodeDown || !strings.Contains(status[0].LastError, "HTTP 503") {
+		t.Errorf("Expected the first node down with HTTP 503, got %+v", status[0])
+	}
+	if status[1].State != NodeUp || good.calls["node-info"].Load() != 1 {
+		t.Errorf("Expected the second node up after one probe, got %+v", status[1])
+	}
+
+	// A successful probe closes the circuit without waiting for the cooldown
+	bad.status.Store(http.StatusOK)
+	pool.Probe(context.Background())
+	if pool.Status()[0].State != NodeUp {
+		t.Errorf("Expected the first node up, got %s", pool.Status()[0].State)
+	}
+}
+
+func TestPool_PrefersLowLatencyNodes(t *testing.T) {
+	slow, fast := newRPCNode(t), newRPCNode(t)
+	pool := newTestPool(t, []string{slow.URL, fast.URL}, nil)
+	pool.nodes[0].latency = 900 * time.Millisecond
+	pool.nodes[1].latency = 100 * time.Millisecond
+
+	// Weights are 1/0.9 and 1/0.1, so a draw of 0.2 lands on the fast node
+	pool.rand = func() float64 { return 0.2 }
+	if _, err := rpcCall(t, pool, "query"); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if slow.calls["query"].Load() != 0 || fast.calls["query"].Load() != 1 {
+		t.Errorf("Expected the fast node, got %d and %d", slow.calls["query"].Load(), fast.calls["query"].Load())
+	}
+}
+
+func TestPool_AllNodesDown(t *testing.T) {
+	bad := newRPCNode(t)
+	bad.status.Store(http.StatusBadGateway)
+	pool := newTestPool(t, []string{bad.URL}, func(cfg *Config) { cfg.FailureThreshold = 1 })
+
+	if _, err := rpcCall(t, pool, "query"); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if _, err := rpcCall(t, pool, "query"); !errors.Is(err, ErrNoAvailableNodes) {
+		t.Errorf("Expected ErrNoAvailableNodes, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Namespace prefixes every accu-did metric name
+const Namespace = "accdid"
+
+// HTTPMetrics holds the collectors both services share: served requests,
+// rate limit rejections and Accumulate JSON-RPC calls. A nil *HTTPMetrics is
+// valid and records nothing.
+type HTTPMetrics struct {
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	rateLimited      prometheus.Counter
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+}
+
+// NewHTTPMetrics creates the shared collectors under subsystem and registers
+// them
+func NewHTTPMetrics(reg prometheus.Registerer, subsystem string) *HTTPMetrics {
+	m := &HTTPMetrics{
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		rateLimited: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Requests rejected by the rate limiter.",
+		}),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "upstream_requests_total",
+			Help:      "Accumulate JSON-RPC calls by node, method and result.",
+		}, []string{"node", "method", "result"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: subsystem,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Accumulate JSON-RPC call latency by node and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"node", "method"}),
+	}
+
+	reg.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.rateLimited,
+		m.upstreamRequests,
+		m.upstreamDuration,
+	)
+
+	return m
+}
+
+// ObserveHTTP records a completed HTTP request
+func (m *HTTPMetrics) ObserveHTTP(route, method, code string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method).Observe(d.Seconds())
+}
+
+// ObserveRateLimited records a request rejected by the rate limiter
+func (m *HTTPMetrics) ObserveRateLimited() {
+	if m == nil {
+		return
+	}
+	m.rateLimited.Inc()
+}
+
+// ObserveUpstream records an Accumulate JSON-RPC call. result is "ok" or an
+// error class ("transport", "http", "rpc").
+func (m *HTTPMetrics) ObserveUpstream(node, method, result string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.upstreamRequests.WithLabelValues(node, method, result).Inc()
+	m.upstreamDuration.WithLabelValues(node, method).Observe(d.Seconds())
+}
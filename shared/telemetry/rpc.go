@@ -0,0 +1,146 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Upstream results used as the "result" label
+const (
+	UpstreamOK        = "ok"
+	UpstreamTransport = "transport"
+	UpstreamHTTP      = "http"
+	UpstreamRPC       = "rpc"
+)
+
+// Transport wraps base (http.DefaultTransport when nil) so that every
+// Accumulate JSON-RPC call gets a client span and upstream metrics
+func (t *Telemetry) Transport(nodeURL string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	node := nodeURL
+	if u, err := url.Parse(nodeURL); err == nil && u.Host != "" {
+		node = u.Host
+	}
+
+	return &rpcTransport{
+		base:    base,
+		node:    node,
+		tracer:  t.Tracer(),
+		metrics: t.HTTP,
+	}
+}
+
+type rpcTransport struct {
+	base    http.RoundTripper
+	node    string
+	tracer  trace.Tracer
+	metrics *HTTPMetrics
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	// Peek at the request body to learn the JSON-RPC method
+	method := "unknown"
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			method = rpcMethod(data)
+		}
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), "accumulate.rpc "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", method),
+			attribute.String("server.address", t.node),
+		),
+	)
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		t.metrics.ObserveUpstream(t.node, method, UpstreamTransport, time.Since(start))
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	result := UpstreamOK
+	if resp.StatusCode >= 400 {
+		result = UpstreamHTTP
+	} else if resp.Body != nil {
+		// JSON-RPC errors arrive with HTTP 200, so the body has to be inspected
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr != nil {
+			result = UpstreamTransport
+		} else if rpcFailed(data) {
+			result = UpstreamRPC
+		}
+	}
+
+	if result != UpstreamOK {
+		span.SetStatus(codes.Error, "upstream "+result+" error (status "+strconv.Itoa(resp.StatusCode)+")")
+	}
+	t.metrics.ObserveUpstream(t.node, method, result, time.Since(start))
+
+	return resp, nil
+}
+
+// rpcMethod extracts the method name from a JSON-RPC request or batch
+func rpcMethod(body []byte) string {
+	var single struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &single); err == nil && single.Method != "" {
+		return single.Method
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err == nil && len(batch) > 0 {
+		return "batch"
+	}
+
+	return "unknown"
+}
+
+// rpcFailed reports whether a JSON-RPC response (or any batch member) carries an error
+func rpcFailed(body []byte) bool {
+	var single struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &single); err == nil {
+		return len(single.Error) > 0 && string(single.Error) != "null"
+	}
+
+	var batch []struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &batch); err == nil {
+		for _, item := range batch {
+			if len(item.Error) > 0 && string(item.Error) != "null" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
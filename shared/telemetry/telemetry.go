@@ -0,0 +1,135 @@
+// Package telemetry provides OpenTelemetry tracing and Prometheus metrics for
+// the resolver and registrar. It covers the HTTP server, rate limiting and
+// Accumulate JSON-RPC calls; each service registers its own metrics on
+// Registry.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Config holds telemetry configuration
+type Config struct {
+	ServiceName    string  // service.name resource attribute
+	ServiceVersion string  // service.version resource attribute
+	TracerName     string  // instrumentation scope of the service's spans
+	Subsystem      string  // metric name prefix after "accdid_", e.g. "resolver"
+	OTLPEndpoint   string  // OTLP/HTTP collector endpoint (host:port), empty disables trace export
+	OTLPInsecure   bool    // use plain HTTP to reach the collector
+	SampleRatio    float64 // fraction of new traces to sample (parent-based)
+}
+
+// DefaultConfig returns default configuration
+func DefaultConfig() Config {
+	return Config{
+		SampleRatio: 1.0,
+	}
+}
+
+// Telemetry bundles the tracer provider and the Prometheus registry
+type Telemetry struct {
+	tracerProvider trace.TracerProvider
+	tracerName     string
+	shutdown       func(context.Context) error
+	registry       *prometheus.Registry
+	HTTP           *HTTPMetrics
+}
+
+// New configures tracing and metrics. When no OTLP endpoint is configured
+// tracing is a no-op, metrics are always collected.
+func New(ctx context.Context, cfg Config) (*Telemetry, error) {
+	if cfg.OTLPEndpoint == "" {
+		return newTelemetry(cfg, noop.NewTracerProvider(), func(context.Context) error { return nil }), nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	tp := newTracerProvider(cfg, sdktrace.WithBatcher(exporter))
+	return newTelemetry(cfg, tp, tp.Shutdown), nil
+}
+
+// NewWithExporter configures telemetry with a caller-supplied span exporter.
+// Spans are exported synchronously, which makes this suitable for tests with
+// an in-memory exporter (tracetest.NewInMemoryExporter).
+func NewWithExporter(cfg Config, exporter sdktrace.SpanExporter) *Telemetry {
+	tp := newTracerProvider(cfg, sdktrace.WithSyncer(exporter))
+	return newTelemetry(cfg, tp, tp.Shutdown)
+}
+
+func newTracerProvider(cfg Config, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	)
+
+	opts = append(opts,
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+func newTelemetry(cfg Config, tp trace.TracerProvider, shutdown func(context.Context) error) *Telemetry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	// Register globally so instrumented packages can use otel.Tracer
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return &Telemetry{
+		tracerProvider: tp,
+		tracerName:     cfg.TracerName,
+		shutdown:       shutdown,
+		registry:       registry,
+		HTTP:           NewHTTPMetrics(registry, cfg.Subsystem),
+	}
+}
+
+// Tracer returns the service tracer
+func (t *Telemetry) Tracer() trace.Tracer {
+	return t.tracerProvider.Tracer(t.tracerName)
+}
+
+// Registry returns the Prometheus registry backing /metrics
+func (t *Telemetry) Registry() *prometheus.Registry {
+	return t.registry
+}
+
+// MetricsHandler serves the Prometheus exposition format
+func (t *Telemetry) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(t.registry, promhttp.HandlerOpts{})
+}
+
+// Shutdown flushes pending spans and stops the exporter
+func (t *Telemetry) Shutdown(ctx context.Context) error {
+	return t.shutdown(ctx)
+}
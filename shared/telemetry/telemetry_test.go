@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTelemetry(t *testing.T) (*Telemetry, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	cfg := DefaultConfig()
+	cfg.ServiceName = "accu-did-test"
+	cfg.Subsystem = "test"
+	tel := NewWithExporter(cfg, exporter)
+	t.Cleanup(func() { _ = tel.Shutdown(context.Background()) })
+	return tel, exporter
+}
+
+func TestMiddleware_SpanAndMetrics(t *testing.T) {
+	tel, exporter := newTestTelemetry(t)
+
+	r := chi.NewRouter()
+	r.Use(tel.Middleware())
+	r.Get("/1.0/identifiers/{did}", func(w http.ResponseWriter, r *http.Request) {
+		// Handler spans must be children of the server span
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Error("Expected a server span in the handler context")
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/1.0/identifiers/did:acc:alice", nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "GET /1.0/identifiers/{did}" || spans[0].SpanKind != trace.SpanKindServer {
+		t.Errorf("Unexpected span %s (%s)", spans[0].Name, spans[0].SpanKind)
+	}
+
+	count := testutil.ToFloat64(tel.HTTP.httpRequests.WithLabelValues("/1.0/identifiers/{did}", "GET", "404"))
+	if count != 1 {
+		t.Errorf("Expected 1 request counted, got %v", count)
+	}
+	// A 429 from elsewhere is not a rate limiter rejection
+	if count := testutil.ToFloat64(tel.HTTP.rateLimited); count != 0 {
+		t.Errorf("Expected no rate limit rejections, got %v", count)
+	}
+}
+
+func TestMiddleware_PropagatesTraceContext(t *testing.T) {
+	tel, exporter := newTestTelemetry(t)
+
+	r := chi.NewRouter()
+	r.Use(tel.Middleware())
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the incoming trace ID, got %s", got)
+	}
+	if got := spans[0].Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("Expected the incoming parent span, got %s", got)
+	}
+}
+
+func TestTransport_ClassifiesUpstreamResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+	}{
+		{"ok", http.StatusOK, `{"jsonrpc":"2.0","id":1,"result":{}}`, UpstreamOK},
+		{"rpc error", http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601}}`, UpstreamRPC},
+		{"http error", http.StatusBadGateway, `bad gateway`, UpstreamHTTP},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tel, exporter := newTestTelemetry(t)
+
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer node.Close()
+
+			client := &http.Client{Transport: tel.Transport(node.URL, nil)}
+			resp, err := client.Post(node.URL, "application/json",
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"query"}`))
+			if err != nil {
+				t.Fatalf("Post: %v", err)
+			}
+
+			// The body must still be readable after inspection
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil || string(body) != tt.body {
+				t.Errorf("Expected body %q, got %q (%v)", tt.body, body, err)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 || spans[0].Name != "accumulate.rpc query" {
+				t.Fatalf("Expected one accumulate.rpc query span, got %v", spans)
+			}
+
+			host := strings.TrimPrefix(node.URL, "http://")
+			count := testutil.ToFloat64(tel.HTTP.upstreamRequests.WithLabelValues(host, "query", tt.expected))
+			if count != 1 {
+				t.Errorf("Expected 1 %s upstream call, got %v", tt.expected, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Package tlsutil serves TLS from certificate files that may be rotated on
+// disk, optionally verifying client certificates (mTLS) against a CA bundle.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Client certificate modes
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional" // verify a certificate when the client sends one
+	ClientAuthRequire  = "require"
+)
+
+// DefaultCheckInterval is how often the files are checked for changes
+const DefaultCheckInterval = 10 * time.Second
+
+// Options locates the server certificate and client CA bundle
+type Options struct {
+	CertFile      string
+	KeyFile       string
+	ClientCAFile  string        // PEM bundle of CAs client certificates must chain to
+	ClientAuth    string        // none, optional or require; empty is require with a CA bundle, none without
+	CheckInterval time.Duration // 0 uses DefaultCheckInterval
+}
+
+// ParseClientAuth maps a client certificate mode to its tls setting. An
+// empty mode requires certificates when a CA bundle is set.
+func ParseClientAuth(mode string, hasCA bool) (tls.ClientAuthType, error) {
+	switch mode {
+	case "":
+		if hasCA {
+			return tls.RequireAndVerifyClientCert, nil
+		}
+		return tls.NoClientCert, nil
+	case ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthOptional, ClientAuthRequire:
+		if !hasCA {
+			return 0, fmt.Errorf("client auth %q needs a client CA bundle", mode)
+		}
+		if mode == ClientAuthOptional {
+			return tls.VerifyClientCertIfGiven, nil
+		}
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("client auth must be %q, %q or %q, got %q", ClientAuthNone, ClientAuthOptional, ClientAuthRequire, mode)
+	}
+}
+
+// Reloader holds the server certificate and client CA pool loaded from
+// Options. Handshakes pick up rotated files within the check interval; a
+// file that fails to load keeps the previous material in use.
+type Reloader struct {
+	opts       Options
+	clientAuth tls.ClientAuthType
+	now        func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+	checked   time.Time
+}
+
+// New loads the files named by opts
+func New(opts Options) (*Reloader, error) {
+	clientAuth, err := ParseClientAuth(opts.ClientAuth, opts.ClientCAFile != "")
+	if err != nil {
+		return nil, err
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = DefaultCheckInterval
+	}
+	r := &Reloader{opts: opts, clientAuth: clientAuth, now: time.Now}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the certificate, key and CA bundle
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load()
+}
+
+// load reads the files; the caller holds r.mu
+func (r *Reloader) load() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("failed to parse TLS certificate: %w", err)
+		}
+	}
+	var clientCAs *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA bundle: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("client CA bundle holds no PEM certificates")
+		}
+	}
+
+	r.cert, r.clientCAs, r.modTimes = &cert, clientCAs, modTimes
+	r.checked = r.now()
+	return nil
+}
+
+// stat returns the modification times of the configured files
+func (r *Reloader) stat() ([]time.Time, error) {
+	var modTimes []time.Time
+	for _, path := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// current returns the loaded material, first reloading it when a file
+// changed since the last check
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.checked) < r.opts.CheckInterval {
+		return r.cert, r.clientCAs
+	}
+	r.checked = now
+	modTimes, err := r.stat()
+	if err == nil && !changed(r.modTimes, modTimes) {
+		return r.cert, r.clientCAs
+	}
+	if err == nil {
+		err = r.load()
+	}
+	if err != nil {
+		slog.Warn("TLS reload failed, keeping current certificate", "error", err)
+	} else {
+		slog.Info("TLS certificate reloaded", "cert_file", r.opts.CertFile, "not_after", r.cert.Leaf.NotAfter)
+	}
+	return r.cert, r.clientCAs
+}
+
+func changed(old, cur []time.Time) bool {
+	if len(old) != len(cur) {
+		return true
+	}
+	for i := range old {
+		if !old[i].Equal(cur[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Certificate returns the certificate currently served
+func (r *Reloader) Certificate() *tls.Certificate {
+	cert, _ := r.current()
+	return cert
+}
+
+// TLSConfig returns a server config serving the current certificate and
+// verifying client certificates as configured. Pass it as
+// http.Server.TLSConfig and call ListenAndServeTLS with empty file names.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    clientCAs,
+			}, nil
+		},
+	}
+}
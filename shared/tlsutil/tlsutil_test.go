@@ -0,0 +1,183 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issue creates a certificate for cn signed by parent, or self-signed when
+// parent is nil, and returns it with its key
+func issue(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Acme"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return cert, key
+}
+
+// writePair writes cert and key as PEM files in dir
+func writePair(t *testing.T, dir string, cert *x509.Certificate, key *ecdsa.PrivateKey) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestParseClientAuth(t *testing.T) {
+	tests := []struct {
+		mode    string
+		hasCA   bool
+		want    tls.ClientAuthType
+		wantErr bool
+	}{
+		{"", false, tls.NoClientCert, false},
+		{"", true, tls.RequireAndVerifyClientCert, false},
+		{ClientAuthNone, true, tls.NoClientCert, false},
+		{ClientAuthOptional, true, tls.VerifyClientCertIfGiven, false},
+		{ClientAuthRequire, false, 0, true},
+		{"sometimes", true, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseClientAuth(tt.mode, tt.hasCA)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseClientAuth(%q, %v) error = %v, wantErr %v", tt.mode, tt.hasCA, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseClientAuth(%q, %v) = %v, want %v", tt.mode, tt.hasCA, got, tt.want)
+		}
+	}
+}
+
+func TestReloader_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	first, key := issue(t, "first", false, nil, nil)
+	certFile, keyFile := writePair(t, dir, first, key)
+
+	r, err := New(Options{CertFile: certFile, KeyFile: keyFile, CheckInterval: time.Minute})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	second, key := issue(t, "second", false, nil, nil)
+	writePair(t, dir, second, key)
+	later := time.Now().Add(time.Second)
+	os.Chtimes(certFile, later, later)
+
+	if cn := r.Certificate().Leaf.Subject.CommonName; cn != "first" {
+		t.Fatalf("Expected the first certificate before the check interval, got %s", cn)
+	}
+	now = now.Add(time.Minute)
+	if cn := r.Certificate().Leaf.Subject.CommonName; cn != "second" {
+		t.Fatalf("Expected the rotated certificate, got %s", cn)
+	}
+
+	// A broken file keeps the current certificate
+	os.WriteFile(certFile, []byte("not a certificate"), 0o600)
+	os.Chtimes(certFile, later.Add(time.Second), later.Add(time.Second))
+	now = now.Add(time.Minute)
+	if cn := r.Certificate().Leaf.Subject.CommonName; cn != "second" {
+		t.Fatalf("Expected the last good certificate, got %s", cn)
+	}
+	if err := r.Reload(); err == nil {
+		t.Fatal("Expected Reload to report the broken file")
+	}
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := issue(t, "test-ca", true, nil, nil)
+	server, serverKey := issue(t, "localhost", false, ca, caKey)
+	client, clientKey := issue(t, "ci-deployer", false, ca, caKey)
+	certFile, keyFile := writePair(t, dir, server, serverKey)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := New(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, req.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}))
+	srv.TLS = r.TLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	call := func(certs []tls.Certificate) (string, error) {
+		c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	got, err := call([]tls.Certificate{{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}})
+	if err != nil {
+		t.Fatalf("Request with a client certificate failed: %v", err)
+	}
+	if got != "ci-deployer" {
+		t.Fatalf("Expected the client subject, got %q", got)
+	}
+	if _, err := call(nil); err == nil {
+		t.Fatal("Expected a request without a client certificate to fail")
+	}
+}